@@ -39,6 +39,20 @@ type Config struct {
 	// or decrypted block data. Default: "encrypted".
 	BlockCacheMode Param[string]
 
+	// WriteBack makes ProtonFS spool writes on local disk and upload
+	// them in the background after close. Default: false.
+	WriteBack Param[bool]
+
+	// ShowVirtualDirs makes ProtonFS list the virtual .revisions and
+	// .Trash directories in directory listings. They can be looked up
+	// by name either way. Default: false.
+	ShowVirtualDirs Param[bool]
+
+	// Accounts lists the accounts proton-fuse mounts, each under
+	// fs/<account>/drive. The default account is added when missing.
+	// Default: none, mounting the default account only.
+	Accounts Param[[]string]
+
 	// Shares is keyed by Proton share ID.
 	Shares map[string]api.ShareConfig
 
@@ -71,6 +85,9 @@ type configYAML struct {
 	MemoryCacheWatermark *string                    `yaml:"memory_cache_watermark,omitempty"`
 	PrefetchBlocks       *int                       `yaml:"prefetch_blocks,omitempty"`
 	BlockCacheMode       *string                    `yaml:"block_cache_mode,omitempty"`
+	WriteBack            *bool                      `yaml:"write_back,omitempty"`
+	ShowVirtualDirs      *bool                      `yaml:"show_virtual_dirs,omitempty"`
+	Accounts             []string                   `yaml:"accounts,omitempty"`
 	Shares               map[string]api.ShareConfig `yaml:"shares,omitempty"`
 	Subsystems           map[string]coreConfigYAML  `yaml:"subsystems,omitempty"`
 }
@@ -95,6 +112,17 @@ func (c *Config) MarshalYAML() (interface{}, error) {
 		v := c.BlockCacheMode.Value()
 		y.BlockCacheMode = &v
 	}
+	if c.WriteBack.Source() == File {
+		v := c.WriteBack.Value()
+		y.WriteBack = &v
+	}
+	if c.ShowVirtualDirs.Source() == File {
+		v := c.ShowVirtualDirs.Value()
+		y.ShowVirtualDirs = &v
+	}
+	if c.Accounts.Source() == File {
+		y.Accounts = c.Accounts.Value()
+	}
 	for id, sc := range c.Shares {
 		y.Shares[id] = sc
 	}
@@ -134,6 +162,18 @@ func (c *Config) UnmarshalYAML(value *yaml.Node) error {
 	if y.BlockCacheMode != nil {
 		c.BlockCacheMode.SetFile(*y.BlockCacheMode)
 	}
+	if y.WriteBack != nil {
+		c.WriteBack.SetFile(*y.WriteBack)
+	}
+	if y.ShowVirtualDirs != nil {
+		c.ShowVirtualDirs.SetFile(*y.ShowVirtualDirs)
+	}
+	if y.Accounts != nil {
+		if err := ValidateAccounts(y.Accounts); err != nil {
+			return fmt.Errorf("accounts: %w", err)
+		}
+		c.Accounts.SetFile(y.Accounts)
+	}
 	if y.Shares != nil {
 		c.Shares = y.Shares
 	}
@@ -215,6 +255,9 @@ func DefaultConfig() *Config {
 		MemoryCacheWatermark: NewParam([2]int64{0, 0}),
 		PrefetchBlocks:       NewParam(1),
 		BlockCacheMode:       NewParam("encrypted"),
+		WriteBack:            NewParam(false),
+		ShowVirtualDirs:      NewParam(false),
+		Accounts:             NewParam([]string(nil)),
 		Shares:               make(map[string]api.ShareConfig),
 		Subsystems:           make(map[string]*CoreConfig),
 	}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -219,6 +220,15 @@ func TestConfigRoundTrip_Property(t *testing.T) {
 			mode := rapid.SampledFrom([]string{"encrypted", "decrypted"}).Draw(t, "blockCacheMode")
 			cfg.BlockCacheMode.SetFile(mode)
 		}
+		if rapid.Bool().Draw(t, "setWriteBack") {
+			cfg.WriteBack.SetFile(rapid.Bool().Draw(t, "writeBack"))
+		}
+		if rapid.Bool().Draw(t, "setShowVirtualDirs") {
+			cfg.ShowVirtualDirs.SetFile(rapid.Bool().Draw(t, "showVirtualDirs"))
+		}
+		if rapid.Bool().Draw(t, "setAccounts") {
+			cfg.Accounts.SetFile(rapid.SliceOfNDistinct(rapid.StringMatching(`[a-c][a-z]{2,7}`), 1, 3, rapid.ID[string]).Draw(t, "accounts"))
+		}
 
 		// Random shares.
 		nShares := rapid.IntRange(0, 5).Draw(t, "nShares")
@@ -269,6 +279,11 @@ func TestConfigRoundTrip_Property(t *testing.T) {
 		assertParamEqualArr(t, "MemoryCacheWatermark", cfg.MemoryCacheWatermark, loaded.MemoryCacheWatermark)
 		assertParamEqual(t, "PrefetchBlocks", cfg.PrefetchBlocks, loaded.PrefetchBlocks)
 		assertParamEqual(t, "BlockCacheMode", cfg.BlockCacheMode, loaded.BlockCacheMode)
+		assertParamEqual(t, "WriteBack", cfg.WriteBack, loaded.WriteBack)
+		assertParamEqual(t, "ShowVirtualDirs", cfg.ShowVirtualDirs, loaded.ShowVirtualDirs)
+		if cfg.Accounts.IsSet() != loaded.Accounts.IsSet() || !slices.Equal(cfg.Accounts.Value(), loaded.Accounts.Value()) {
+			t.Fatalf("Accounts: got %v, want %v", loaded.Accounts.Value(), cfg.Accounts.Value())
+		}
 
 		// Verify shares.
 		if len(cfg.Shares) != len(loaded.Shares) {
@@ -573,3 +588,149 @@ func TestBlockCacheMode_LoadFromYAML(t *testing.T) {
 		t.Fatalf("BlockCacheMode source: got %v, want File", loaded.BlockCacheMode.Source())
 	}
 }
+
+// --- WriteBack unit tests ---
+
+func TestWriteBack_Default(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.WriteBack.Value() {
+		t.Fatal("default WriteBack: got true, want false")
+	}
+	if cfg.WriteBack.IsSet() {
+		t.Fatal("WriteBack should not be set by default")
+	}
+}
+
+func TestWriteBack_RejectsInvalid(t *testing.T) {
+	cfg := DefaultConfig()
+	sel, _ := Parse("protonfs.write_back")
+
+	for _, bad := range []string{"", "yes", "1", "TRUE", "on"} {
+		err := Set(cfg, sel, bad)
+		if err == nil {
+			t.Fatalf("Set(%q): expected error", bad)
+		}
+		if !strings.Contains(err.Error(), `value must be "true" or "false"`) {
+			t.Fatalf("Set(%q): unexpected error message: %v", bad, err)
+		}
+	}
+}
+
+func TestWriteBack_YAMLRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	cfg := DefaultConfig()
+	sel, _ := Parse("protonfs.write_back")
+	if err := Set(cfg, sel, "true"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !loaded.WriteBack.Value() || loaded.WriteBack.Source() != File {
+		t.Fatalf("WriteBack: got %v (%v), want true (File)", loaded.WriteBack.Value(), loaded.WriteBack.Source())
+	}
+
+	if err := UnsetField(loaded, sel); err != nil {
+		t.Fatalf("UnsetField: %v", err)
+	}
+	if loaded.WriteBack.Value() || loaded.WriteBack.IsSet() {
+		t.Fatal("WriteBack should revert to unset false after unset")
+	}
+}
+
+// --- ShowVirtualDirs unit tests ---
+
+func TestShowVirtualDirs_YAMLRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	cfg := DefaultConfig()
+	if cfg.ShowVirtualDirs.Value() || cfg.ShowVirtualDirs.IsSet() {
+		t.Fatal("default ShowVirtualDirs: want unset false")
+	}
+	sel, _ := Parse("protonfs.show_virtual_dirs")
+	if err := Set(cfg, sel, "true"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !loaded.ShowVirtualDirs.Value() || loaded.ShowVirtualDirs.Source() != File {
+		t.Fatalf("ShowVirtualDirs: got %v (%v), want true (File)", loaded.ShowVirtualDirs.Value(), loaded.ShowVirtualDirs.Source())
+	}
+
+	if err := UnsetField(loaded, sel); err != nil {
+		t.Fatalf("UnsetField: %v", err)
+	}
+	if loaded.ShowVirtualDirs.Value() || loaded.ShowVirtualDirs.IsSet() {
+		t.Fatal("ShowVirtualDirs should revert to unset false after unset")
+	}
+}
+
+// --- Accounts unit tests ---
+
+func TestAccounts_YAMLRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	cfg := DefaultConfig()
+	if cfg.Accounts.Value() != nil || cfg.Accounts.IsSet() {
+		t.Fatal("default Accounts: want unset nil")
+	}
+	sel, _ := Parse("protonfs.accounts")
+	if err := Set(cfg, sel, "personal, work"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if got := loaded.Accounts.Value(); !slices.Equal(got, []string{"personal", "work"}) || loaded.Accounts.Source() != File {
+		t.Fatalf("Accounts: got %v (%v), want [personal work] (File)", got, loaded.Accounts.Source())
+	}
+	if got, _ := Get(loaded, sel); got != "personal,work" {
+		t.Fatalf("Get = %q, want %q", got, "personal,work")
+	}
+
+	if err := UnsetField(loaded, sel); err != nil {
+		t.Fatalf("UnsetField: %v", err)
+	}
+	if loaded.Accounts.Value() != nil || loaded.Accounts.IsSet() {
+		t.Fatal("Accounts should revert to unset nil after unset")
+	}
+}
+
+func TestAccounts_Invalid(t *testing.T) {
+	sel, _ := Parse("protonfs.accounts")
+	for _, value := range []string{"", "a,,b", "a/b", "..", "drive", "work,work"} {
+		t.Run(value, func(t *testing.T) {
+			if err := Set(DefaultConfig(), sel, value); err == nil {
+				t.Errorf("Set(%q) succeeded", value)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("accounts: [work, ../x]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig accepted an invalid account name")
+	}
+}
@@ -167,6 +167,33 @@ func List(cfg *Config) []Entry {
 		})
 	}
 
+	// Core-only: write_back.
+	if cfg.WriteBack.Source() == File {
+		entries = append(entries, Entry{
+			Selector: "protonfs.write_back",
+			Value:    formatBool(cfg.WriteBack.Value()),
+			Source:   File,
+		})
+	}
+
+	// Core-only: show_virtual_dirs.
+	if cfg.ShowVirtualDirs.Source() == File {
+		entries = append(entries, Entry{
+			Selector: "protonfs.show_virtual_dirs",
+			Value:    formatBool(cfg.ShowVirtualDirs.Value()),
+			Source:   File,
+		})
+	}
+
+	// Core-only: accounts.
+	if cfg.Accounts.Source() == File {
+		entries = append(entries, Entry{
+			Selector: "protonfs.accounts",
+			Value:    formatAccounts(cfg.Accounts.Value()),
+			Source:   File,
+		})
+	}
+
 	// Subsystem overrides.
 	for _, svc := range sortedKeys(cfg.Subsystems) {
 		sub := cfg.Subsystems[svc]
@@ -246,6 +273,30 @@ func Show(cfg *Config) []Entry {
 		Source:   bcmInfo.Source,
 	})
 
+	// Core-only: write_back.
+	wbInfo := cfg.WriteBack.Info(formatBool)
+	entries = append(entries, Entry{
+		Selector: "protonfs.write_back",
+		Value:    wbInfo.Value,
+		Source:   wbInfo.Source,
+	})
+
+	// Core-only: show_virtual_dirs.
+	svdInfo := cfg.ShowVirtualDirs.Info(formatBool)
+	entries = append(entries, Entry{
+		Selector: "protonfs.show_virtual_dirs",
+		Value:    svdInfo.Value,
+		Source:   svdInfo.Source,
+	})
+
+	// Core-only: accounts.
+	accInfo := cfg.Accounts.Info(formatAccounts)
+	entries = append(entries, Entry{
+		Selector: "protonfs.accounts",
+		Value:    accInfo.Value,
+		Source:   accInfo.Source,
+	})
+
 	// Subsystem overrides.
 	for _, svc := range sortedKeys(cfg.Subsystems) {
 		sub := cfg.Subsystems[svc]
@@ -523,8 +574,11 @@ func unsetSubsystemField(cfg *Config, sel Selector) error {
 
 // protonfsFields maps field names in the "protonfs" namespace.
 var protonfsFields = map[string]bool{
-	"prefetch_blocks":  true,
-	"block_cache_mode": true,
+	"prefetch_blocks":   true,
+	"block_cache_mode":  true,
+	"write_back":        true,
+	"show_virtual_dirs": true,
+	"accounts":          true,
 }
 
 func getProtonFSField(cfg *Config, sel Selector) (string, error) {
@@ -534,6 +588,12 @@ func getProtonFSField(cfg *Config, sel Selector) (string, error) {
 		return formatInt(cfg.PrefetchBlocks.Value()), nil
 	case "block_cache_mode":
 		return cfg.BlockCacheMode.Value(), nil
+	case "write_back":
+		return formatBool(cfg.WriteBack.Value()), nil
+	case "show_virtual_dirs":
+		return formatBool(cfg.ShowVirtualDirs.Value()), nil
+	case "accounts":
+		return formatAccounts(cfg.Accounts.Value()), nil
 	default:
 		return "", unknownFieldError("protonfs", fieldName)
 	}
@@ -555,6 +615,27 @@ func setProtonFSField(cfg *Config, sel Selector, value string) error {
 		}
 		cfg.BlockCacheMode.SetFile(value)
 		return nil
+	case "write_back":
+		v, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		cfg.WriteBack.SetFile(v.(bool))
+		return nil
+	case "show_virtual_dirs":
+		v, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		cfg.ShowVirtualDirs.SetFile(v.(bool))
+		return nil
+	case "accounts":
+		v, err := parseAccounts(value)
+		if err != nil {
+			return err
+		}
+		cfg.Accounts.SetFile(v.([]string))
+		return nil
 	default:
 		return unknownFieldError("protonfs", fieldName)
 	}
@@ -569,6 +650,15 @@ func unsetProtonFSField(cfg *Config, sel Selector) error {
 	case "block_cache_mode":
 		cfg.BlockCacheMode.Reset()
 		return nil
+	case "write_back":
+		cfg.WriteBack.Reset()
+		return nil
+	case "show_virtual_dirs":
+		cfg.ShowVirtualDirs.Reset()
+		return nil
+	case "accounts":
+		cfg.Accounts.Reset()
+		return nil
 	default:
 		return unknownFieldError("protonfs", fieldName)
 	}
@@ -592,6 +682,16 @@ func parseNonNegativeInt(s string) (any, error) {
 	return n, nil
 }
 
+func parseBool(s string) (any, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return nil, fmt.Errorf("config: value must be \"true\" or \"false\", got %q", s)
+}
+
 func parseNonEmptyString(s string) (any, error) {
 	if s == "" {
 		return nil, fmt.Errorf("config: value must be non-empty")
@@ -621,6 +721,36 @@ func parseWatermark(s string) (any, error) {
 	return [2]int64{lo, hi}, nil
 }
 
+func parseAccounts(s string) (any, error) {
+	var names []string
+	for name := range strings.SplitSeq(s, ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	if err := ValidateAccounts(names); err != nil {
+		return nil, fmt.Errorf("config: accounts: %w", err)
+	}
+	return names, nil
+}
+
+// ValidateAccounts checks that names can be mounted side by side as
+// directories of ProtonFS: each is a non-empty path component, unique,
+// and does not shadow the drive namespace.
+func ValidateAccounts(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		switch {
+		case name == "" || name == "." || name == ".." || strings.Contains(name, "/"):
+			return fmt.Errorf("invalid account name %q", name)
+		case name == "drive":
+			return fmt.Errorf("account name %q clashes with the drive namespace", name)
+		case seen[name]:
+			return fmt.Errorf("account %q listed twice", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
 func parseMemoryCacheLevel(s string) (any, error) {
 	switch s {
 	case "disabled":
@@ -647,8 +777,10 @@ func parseDiskCacheLevel(s string) (any, error) {
 
 // --- Format functions ---
 
-func formatInt(v any) string    { return strconv.Itoa(v.(int)) }
-func formatString(v any) string { return v.(string) }
+func formatInt(v any) string      { return strconv.Itoa(v.(int)) }
+func formatString(v any) string   { return v.(string) }
+func formatBool(v any) string     { return strconv.FormatBool(v.(bool)) }
+func formatAccounts(v any) string { return strings.Join(v.([]string), ",") }
 func formatWatermark(wm [2]int64) string {
 	return fmt.Sprintf("%d:%d", wm[0], wm[1])
 }
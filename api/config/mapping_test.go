@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/major0/proton-utils/api"
@@ -98,11 +99,20 @@ func genValidSelectorAndValue(t *rapid.T) (string, string) {
 			return "share[id=" + id + "].disk_cache", v
 		}
 	default: // protonfs
-		field := rapid.IntRange(0, 1).Draw(t, "protonfsField")
+		field := rapid.IntRange(0, 4).Draw(t, "protonfsField")
 		switch field {
 		case 0:
 			v := rapid.IntRange(0, 64).Draw(t, "prefetchBlocks")
 			return "protonfs.prefetch_blocks", formatInt(v)
+		case 1:
+			v := rapid.SampledFrom([]string{"true", "false"}).Draw(t, "writeBack")
+			return "protonfs.write_back", v
+		case 2:
+			v := rapid.SampledFrom([]string{"true", "false"}).Draw(t, "showVirtualDirs")
+			return "protonfs.show_virtual_dirs", v
+		case 3:
+			v := rapid.SliceOfNDistinct(rapid.StringMatching(`[a-c][a-z]{2,7}`), 1, 3, rapid.ID[string]).Draw(t, "accounts")
+			return "protonfs.accounts", strings.Join(v, ",")
 		default:
 			v := rapid.SampledFrom([]string{"encrypted", "decrypted"}).Draw(t, "blockCacheMode")
 			return "protonfs.block_cache_mode", v
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/ProtonMail/go-proton-api"
@@ -49,6 +50,9 @@ type httpBlockStore struct {
 	session  *api.Session
 	cache    *api.ObjectCache // nil when disk caching disabled
 	bufCache *bufferCache     // nil when buffer caching disabled
+
+	downloaded atomic.Int64 // block bytes fetched over HTTP
+	uploaded   atomic.Int64 // block bytes uploaded
 }
 
 // newBlockStore creates a blockStore backed by the session's HTTP transport.
@@ -127,6 +131,7 @@ func (s *httpBlockStore) fetchBlock(ctx context.Context, linkID string, index in
 	if err != nil {
 		return nil, fmt.Errorf("blockstore.GetBlock %s block %d: read: %w", linkID, index, err)
 	}
+	s.downloaded.Add(int64(len(data)))
 	slog.Debug("blockstore.GetBlock HTTP", "linkID", linkID, "block", index, "size", len(data), "elapsed", time.Since(t0))
 
 	// Populate disk cache (best-effort).
@@ -152,6 +157,7 @@ func (s *httpBlockStore) UploadBlock(ctx context.Context, linkID string, index i
 	if err := s.session.Client.UploadBlock(ctx, bareURL, token, stream); err != nil {
 		return fmt.Errorf("blockstore.UploadBlock %s block %d: %w", linkID, index, err)
 	}
+	s.uploaded.Add(int64(len(data)))
 
 	// Populate disk cache only (always encrypted).
 	// NOTE: bufCache.Put removed — avoids mode-dependent corruption.
@@ -38,6 +38,10 @@ type bufferCache struct {
 	slots map[cacheKey]*cacheSlot
 	lru   *list.List // *cacheSlot elements, most-recent at front
 	cap   int        // max slots
+
+	hits      int64 // Get calls answered with data
+	misses    int64 // slots reserved for a fetch
+	evictions int64 // clean slots evicted for space
 }
 
 // newBufferCache creates a buffer cache with the given capacity.
@@ -68,6 +72,7 @@ func (bc *bufferCache) Get(linkID string, index int) ([]byte, error) {
 	case slotClean:
 		// Touch: move to front of LRU.
 		bc.lru.MoveToFront(slot.elem)
+		bc.hits++
 		data, err := slot.data, slot.err
 		bc.mu.Unlock()
 		return data, err
@@ -88,6 +93,7 @@ func (bc *bufferCache) Get(linkID string, index int) ([]byte, error) {
 		}
 		if slot.state == slotClean {
 			bc.lru.MoveToFront(slot.elem)
+			bc.hits++
 		}
 		data, err := slot.data, slot.err
 		bc.mu.Unlock()
@@ -202,6 +208,7 @@ func (bc *bufferCache) Reserve(linkID string, index int) bool {
 	}
 	// Fetching slots are NOT in the LRU list — they can't be evicted.
 	bc.slots[k] = slot
+	bc.misses++
 	return true
 }
 
@@ -243,5 +250,35 @@ func (bc *bufferCache) evictLocked() bool {
 	slot := e.Value.(*cacheSlot)
 	bc.lru.Remove(e)
 	delete(bc.slots, slot.key)
+	bc.evictions++
 	return true
 }
+
+// usage returns the number of clean slots and the bytes they hold.
+func (bc *bufferCache) usage() (int, int64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	var n int64
+	for e := bc.lru.Front(); e != nil; e = e.Next() {
+		n += int64(len(e.Value.(*cacheSlot).data))
+	}
+	return bc.lru.Len(), n
+}
+
+// counters returns the hit, miss and eviction counts.
+func (bc *bufferCache) counters() (hits, misses, evictions int64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return bc.hits, bc.misses, bc.evictions
+}
+
+// dropClean removes all clean slots. Fetching slots are kept so their
+// waiters are still woken by Put or PutError.
+func (bc *bufferCache) dropClean() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	for e := bc.lru.Front(); e != nil; e = e.Next() {
+		delete(bc.slots, e.Value.(*cacheSlot).key)
+	}
+	bc.lru.Init()
+}
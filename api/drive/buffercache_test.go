@@ -427,3 +427,52 @@ func TestBufferCacheReserveFailsWhenAllFetching(t *testing.T) {
 		t.Fatal("Reserve should fail when all slots are fetching")
 	}
 }
+
+// TestBufferCacheDropClean verifies that dropping the cache keeps
+// fetching slots, so their waiters are still woken.
+func TestBufferCacheDropClean(t *testing.T) {
+	bc := newBufferCache(64)
+	bc.Put("link-a", 0, []byte{1, 2, 3})
+	bc.Put("link-a", 1, []byte{4})
+	if !bc.Reserve("link-b", 0) {
+		t.Fatal("Reserve failed")
+	}
+
+	if n, size := bc.usage(); n != 2 || size != 4 {
+		t.Fatalf("usage = %d slots, %d bytes; want 2, 4", n, size)
+	}
+
+	bc.dropClean()
+	if n, size := bc.usage(); n != 0 || size != 0 {
+		t.Fatalf("usage after dropClean = %d slots, %d bytes; want 0, 0", n, size)
+	}
+	if got, _ := bc.Get("link-a", 0); got != nil {
+		t.Fatal("clean slot present after dropClean")
+	}
+
+	bc.Put("link-b", 0, []byte{5})
+	if got, _ := bc.Get("link-b", 0); len(got) != 1 {
+		t.Fatal("fetching slot lost by dropClean")
+	}
+}
+
+// TestBufferCacheCounters verifies the hit, miss and eviction counts.
+func TestBufferCacheCounters(t *testing.T) {
+	bc := newBufferCache(2)
+
+	bc.Get("link", 0) // absent: neither a hit nor a fetch
+	if !bc.Reserve("link", 0) {
+		t.Fatal("Reserve failed")
+	}
+	bc.Put("link", 0, []byte{1})
+	bc.Get("link", 0)
+	bc.Get("link", 0)
+	bc.Reserve("link", 0) // already cached: no fetch
+	bc.Put("link", 1, []byte{2})
+	bc.Put("link", 2, []byte{3}) // evicts block 0
+
+	hits, misses, evictions := bc.counters()
+	if hits != 2 || misses != 1 || evictions != 1 {
+		t.Fatalf("counters = %d hits, %d misses, %d evictions; want 2, 1, 1", hits, misses, evictions)
+	}
+}
@@ -0,0 +1,77 @@
+package drive
+
+// CacheUsage reports what a Client holds in its caches.
+type CacheUsage struct {
+	Links      int   // entries in the link table
+	Objects    int   // API objects in the on-disk object cache
+	Blocks     int   // blocks in the in-memory buffer cache
+	BlockBytes int64 // bytes held by those blocks
+}
+
+// CacheUsage returns the current cache usage. Counting the object cache
+// walks its directory.
+func (c *Client) CacheUsage() CacheUsage {
+	var u CacheUsage
+	c.tableMu.RLock()
+	u.Links = len(c.linkTable)
+	c.tableMu.RUnlock()
+
+	for range c.objectCache.Keys(nil) {
+		u.Objects++
+	}
+	if c.blockStore != nil {
+		if bc := c.blockStore.getBufCache(); bc != nil {
+			u.Blocks, u.BlockBytes = bc.usage()
+		}
+	}
+	return u
+}
+
+// DropCaches empties the link table, the object cache and the buffer
+// cache, so that links and blocks are fetched from the API again. The
+// pin cache and the write-back spool are kept. Share roots stay
+// reachable through their shares.
+func (c *Client) DropCaches() error {
+	c.clearLinks()
+	if c.blockStore != nil {
+		if bc := c.blockStore.getBufCache(); bc != nil {
+			bc.dropClean()
+		}
+	}
+	return c.objectCache.EraseAll()
+}
+
+// InvalidateBlocks drops the cached blocks of a file from the buffer
+// cache and the object cache, so that reading it fetches them from the
+// server again.
+func (c *Client) InvalidateBlocks(link *Link) {
+	if c.blockStore != nil {
+		c.blockStore.Invalidate(link.LinkID(), BlockCount(link.Size()))
+	}
+}
+
+// Stats counts buffer cache activity and block transfers since the
+// Client was created.
+type Stats struct {
+	BufferHits      int64 // block reads answered by the buffer cache
+	BufferMisses    int64 // block reads that fetched into the buffer cache
+	BufferEvictions int64 // blocks evicted from the buffer cache for space
+	Downloaded      int64 // block bytes fetched over HTTP
+	Uploaded        int64 // block bytes uploaded
+}
+
+// Stats returns a point-in-time snapshot of the counters.
+func (c *Client) Stats() Stats {
+	var s Stats
+	if c.blockStore == nil {
+		return s
+	}
+	if bc := c.blockStore.getBufCache(); bc != nil {
+		s.BufferHits, s.BufferMisses, s.BufferEvictions = bc.counters()
+	}
+	if hs, ok := c.blockStore.(*httpBlockStore); ok {
+		s.Downloaded = hs.downloaded.Load()
+		s.Uploaded = hs.uploaded.Load()
+	}
+	return s
+}
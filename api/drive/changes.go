@@ -0,0 +1,119 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+// LinkChange is the result of checking a link against the server.
+type LinkChange int
+
+const (
+	// LinkUnchanged means the server's copy matches the link.
+	LinkUnchanged LinkChange = iota
+	// LinkModified means the content or metadata changed in place.
+	LinkModified
+	// LinkRemoved means the link is no longer at its name in its parent:
+	// it was deleted, trashed, renamed or moved.
+	LinkRemoved
+)
+
+// compareLinks classifies how cur, the server's copy of a link, differs
+// from old, the copy the link was built from.
+func compareLinks(old, cur *proton.Link) LinkChange {
+	if cur.State != proton.LinkStateActive {
+		return LinkRemoved
+	}
+	if cur.ParentLinkID != old.ParentLinkID || cur.Hash != old.Hash {
+		return LinkRemoved
+	}
+	if old.FileProperties != nil && cur.FileProperties != nil &&
+		cur.FileProperties.ActiveRevision.ID != old.FileProperties.ActiveRevision.ID {
+		return LinkModified
+	}
+	if cur.ModifyTime != old.ModifyTime {
+		return LinkModified
+	}
+	return LinkUnchanged
+}
+
+// CheckLink fetches link from the server, bypassing the link table and
+// object cache, and reports whether it changed since the link table's
+// copy was fetched. A modified link is replaced in the table so later
+// lookups see the new revision, and a modified folder has its children
+// invalidated. A removed link is dropped from the table and its
+// parent's children are invalidated.
+func (c *Client) CheckLink(ctx context.Context, link *Link) (LinkChange, error) {
+	shareID := link.Share().ProtonShare().ShareID
+	pLink, err := c.Session.Client.GetLink(ctx, shareID, link.LinkID())
+	c.noteAPIError(err)
+	if isGone(err) {
+		c.dropChangedLink(link)
+		return LinkRemoved, nil
+	}
+	if err != nil {
+		return LinkUnchanged, fmt.Errorf("check %s: %w", link.LinkID(), err)
+	}
+
+	// The table's copy may be newer than the caller's, e.g. after a
+	// local write or an earlier check.
+	current := link
+	if l := c.GetLink(link.LinkID()); l != nil {
+		current = l
+	}
+
+	change := compareLinks(current.ProtonLink(), &pLink)
+	if change == LinkModified && c.takeCommit(&pLink) {
+		// The revision was committed here, and the caches were updated
+		// then: refresh the table's copy only.
+		c.putLink(link.LinkID(), NewLink(&pLink, link.ParentLink(), link.Share(), c))
+		return LinkUnchanged, nil
+	}
+	switch change {
+	case LinkRemoved:
+		c.dropChangedLink(link)
+	case LinkModified:
+		_ = c.objectCache.Erase(SanitizeLinkID(link.LinkID()))
+		c.putLink(link.LinkID(), NewLink(&pLink, link.ParentLink(), link.Share(), c))
+		if link.IsDir() {
+			link.InvalidateChildren()
+			current.InvalidateChildren()
+		}
+	}
+	return change, nil
+}
+
+// noteCommit records that this client committed revisionID of the file
+// linkID.
+func (c *Client) noteCommit(linkID, revisionID string) {
+	c.tableMu.Lock()
+	defer c.tableMu.Unlock()
+	if c.commits == nil {
+		c.commits = make(map[string]string)
+	}
+	c.commits[linkID] = revisionID
+}
+
+// takeCommit reports whether the active revision of pLink is the last
+// one this client committed of it, and forgets the commit.
+func (c *Client) takeCommit(pLink *proton.Link) bool {
+	c.tableMu.Lock()
+	defer c.tableMu.Unlock()
+	rev, ok := c.commits[pLink.LinkID]
+	if !ok {
+		return false
+	}
+	delete(c.commits, pLink.LinkID)
+	return pLink.FileProperties != nil && pLink.FileProperties.ActiveRevision.ID == rev
+}
+
+// dropChangedLink removes a link that is gone from its parent.
+func (c *Client) dropChangedLink(link *Link) {
+	c.deleteLink(link.LinkID())
+	_ = c.objectCache.Erase(SanitizeLinkID(link.LinkID()))
+	if parent := link.ParentLink(); parent != nil {
+		parent.InvalidateChildren()
+	}
+}
@@ -0,0 +1,76 @@
+package drive
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+func TestCompareLinks(t *testing.T) {
+	file := func(revID string) proton.Link {
+		return proton.Link{
+			LinkID:       "file",
+			ParentLinkID: "parent",
+			Hash:         "hash",
+			State:        proton.LinkStateActive,
+			ModifyTime:   100,
+			FileProperties: &proton.FileProperties{
+				ActiveRevision: proton.RevisionMetadata{ID: revID},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*proton.Link)
+		want   LinkChange
+	}{
+		{"unchanged", func(*proton.Link) {}, LinkUnchanged},
+		{"new revision", func(l *proton.Link) { l.FileProperties.ActiveRevision.ID = "rev-2" }, LinkModified},
+		{"modify time", func(l *proton.Link) { l.ModifyTime = 200 }, LinkModified},
+		{"trashed", func(l *proton.Link) { l.State = proton.LinkStateTrashed }, LinkRemoved},
+		{"renamed", func(l *proton.Link) { l.Hash = "other" }, LinkRemoved},
+		{"moved", func(l *proton.Link) { l.ParentLinkID = "other" }, LinkRemoved},
+		{"moved and modified", func(l *proton.Link) {
+			l.ParentLinkID = "other"
+			l.FileProperties.ActiveRevision.ID = "rev-2"
+		}, LinkRemoved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := file("rev-1")
+			cur := file("rev-1")
+			tt.modify(&cur)
+			if got := compareLinks(&old, &cur); got != tt.want {
+				t.Errorf("compareLinks = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTakeCommit(t *testing.T) {
+	c := &Client{}
+	link := func(revID string) *proton.Link {
+		return &proton.Link{
+			LinkID:         "file",
+			FileProperties: &proton.FileProperties{ActiveRevision: proton.RevisionMetadata{ID: revID}},
+		}
+	}
+
+	if c.takeCommit(link("rev-2")) {
+		t.Fatal("takeCommit without a commit = true")
+	}
+	c.noteCommit("file", "rev-2")
+	if !c.takeCommit(link("rev-2")) {
+		t.Fatal("takeCommit of the committed revision = false")
+	}
+	if c.takeCommit(link("rev-2")) {
+		t.Error("takeCommit twice = true, want the commit forgotten")
+	}
+
+	// A later revision from elsewhere is a remote change.
+	c.noteCommit("file", "rev-2")
+	if c.takeCommit(link("rev-3")) {
+		t.Error("takeCommit of another revision = true")
+	}
+}
@@ -6,18 +6,37 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"time"
 )
 
 // Chmod updates the Unix permission bits for a file by creating a new
 // revision with the same content but updated XAttr. This requires a
 // full re-upload because the Proton Drive API only allows XAttr changes
-// on draft revisions (not active ones).
+// on draft revisions (not active ones). The modification time, symlink
+// target, and extended attributes are kept.
 //
 // mode should contain only permission bits (lower 12 bits: 0o7777).
 // Returns an error if the link is not a file or has no active revision.
 func (c *Client) Chmod(ctx context.Context, share *Share, link *Link, mode uint32) error {
+	c.FetchRevisionXAttr(ctx, link)
+	_, ux := link.unixAttrs()
+	if err := c.rewriteRevision(ctx, share, link, "drive.Chmod", mode&0o7777, time.Time{}, ux); err != nil {
+		return err
+	}
+	slog.Debug("drive.Chmod: done",
+		"linkID", link.LinkID(), "mode", fmt.Sprintf("%04o", mode))
+	return nil
+}
+
+// rewriteRevision re-uploads the content of link as a new revision
+// whose XAttr carries mode, modTime and the Unix extensions ux. A zero
+// modTime keeps the modification time of the current revision. The
+// caller's op name prefixes errors. On success the link's cached
+// attributes are updated and the link table entries for the link and
+// its parent are invalidated.
+func (c *Client) rewriteRevision(ctx context.Context, share *Share, link *Link, op string, mode uint32, modTime time.Time, ux *unixXAttr) error {
 	if !link.HasActiveRevision() {
-		return fmt.Errorf("drive.Chmod %s: no active revision", link.LinkID())
+		return fmt.Errorf("%s %s: no active revision", op, link.LinkID())
 	}
 
 	// Read the entire file into memory first. We must close the reader
@@ -25,7 +44,7 @@ func (c *Client) Chmod(ctx context.Context, share *Share, link *Link, mode uint3
 	// blocks for this link.
 	reader, err := c.OpenFD(ctx, link)
 	if err != nil {
-		return fmt.Errorf("drive.Chmod %s: open for read: %w", link.LinkID(), err)
+		return fmt.Errorf("%s %s: open for read: %w", op, link.LinkID(), err)
 	}
 
 	// Use ReadAt with the FD's known file size to avoid io.ReadAll's
@@ -34,41 +53,50 @@ func (c *Client) Chmod(ctx context.Context, share *Share, link *Link, mode uint3
 	fileSize, err := reader.Seek(0, io.SeekEnd)
 	if err != nil {
 		_ = reader.Close()
-		return fmt.Errorf("drive.Chmod %s: seek: %w", link.LinkID(), err)
+		return fmt.Errorf("%s %s: seek: %w", op, link.LinkID(), err)
 	}
 	content := make([]byte, fileSize)
 	n, err := reader.ReadAt(content, 0)
+	if modTime.IsZero() {
+		modTime = reader.ModTime()
+	}
 	_ = reader.Close()
 	if err != nil && !errors.Is(err, io.EOF) {
-		return fmt.Errorf("drive.Chmod %s: read: %w", link.LinkID(), err)
+		return fmt.Errorf("%s %s: read: %w", op, link.LinkID(), err)
 	}
 	content = content[:n]
 
-	slog.Debug("drive.Chmod: read complete",
-		"linkID", link.LinkID(), "bytes", n, "mode", fmt.Sprintf("%04o", mode))
+	slog.Debug("rewriteRevision: read complete",
+		"op", op, "linkID", link.LinkID(), "bytes", n)
 
 	// Create a new revision for writing.
 	writer, err := c.OverwriteFD(ctx, share, link)
 	if err != nil {
-		return fmt.Errorf("drive.Chmod %s: open for write: %w", link.LinkID(), err)
+		return fmt.Errorf("%s %s: open for write: %w", op, link.LinkID(), err)
 	}
 
-	// Set the new mode before writing (stored in XAttr on commit).
-	writer.SetMode(mode & 0o7777)
+	// Set the attributes before writing (stored in XAttr on commit).
+	writer.SetMode(mode)
+	writer.SetModTime(modTime)
+	writer.SetCommitEmpty(true)
+	if ux != nil {
+		writer.SetSymlink(ux.Symlink)
+		writer.SetXAttrs(ux.XAttrs)
+	}
 
 	// Write all content to the new revision.
 	if _, err := writer.Write(content); err != nil {
 		_ = writer.Close()
-		return fmt.Errorf("drive.Chmod %s: write: %w", link.LinkID(), err)
+		return fmt.Errorf("%s %s: write: %w", op, link.LinkID(), err)
 	}
 
 	// Close the writer to commit the new revision with updated XAttr.
 	if err := writer.Close(); err != nil {
-		return fmt.Errorf("drive.Chmod %s: commit: %w", link.LinkID(), err)
+		return fmt.Errorf("%s %s: commit: %w", op, link.LinkID(), err)
 	}
 
-	// Update the in-memory cached mode.
-	link.SetCachedMode(mode & 0o7777)
+	// Update the in-memory cached attributes.
+	link.setCachedUnixAttrs(mode, modTime, ux)
 
 	// Invalidate stale link from the link table and on-disk cache so
 	// subsequent operations re-fetch fresh state from the API.
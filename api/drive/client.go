@@ -7,7 +7,9 @@ import (
 	"encoding/gob"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
@@ -21,6 +23,9 @@ type Client struct {
 	Config          *api.SessionConfig // loaded config for cache policy lookup; may be nil
 	PrefetchBlocks  int                // number of blocks to prefetch ahead on read (0 = disabled)
 	BlockCacheMode  string             // "encrypted" or "decrypted"; controls buffer cache content type
+	Spool           *Spool             // write-back spool for ProtonFS; nil writes through
+	Pins            *PinStore          // pinned links kept for offline use; may be nil
+	ShowVirtualDirs bool               // list ProtonFS .revisions and .Trash directories
 	addresses       map[string]proton.Address
 	addressKeyRings map[string]*crypto.KeyRing
 
@@ -30,6 +35,17 @@ type Client struct {
 	linkTable map[string]*Link
 	tableMu   sync.RWMutex
 
+	// commits maps the files this client committed a revision of to
+	// that revision, so that CheckLink does not report its own commits
+	// as remote changes. Protected by tableMu.
+	commits map[string]string
+
+	// publicURLs caches the public URL of each standard share, keyed by
+	// ShareID, for lookups that must not wait on the API. Filled by
+	// RefreshPublicURLs. Protected by urlMu.
+	publicURLs map[string]string
+	urlMu      sync.RWMutex
+
 	// objectCache is the on-disk cache for encrypted API objects backed
 	// by api.ObjectCache. Nil when disk_cache is disabled or
 	// $XDG_RUNTIME_DIR is unset. Callers must handle nil gracefully
@@ -39,6 +55,10 @@ type Client struct {
 	// blockStore is the shared block store for all block I/O. Created
 	// lazily after InitObjectCache so the disk cache is wired up.
 	blockStore blockStore
+
+	// offlineUntil is the UnixNano time until which the API is
+	// considered unreachable; zero when online. See Offline.
+	offlineUntil atomic.Int64
 }
 
 // Verify Client implements LinkResolver at compile time.
@@ -65,9 +85,33 @@ func NewClient(ctx context.Context, session *api.Session) (*Client, error) {
 	}, nil
 }
 
-// ListLinkChildren fetches raw child links from the API.
+// ListLinkChildren fetches raw child links from the API. While the
+// client is offline a pinned listing is returned instead.
 func (c *Client) ListLinkChildren(ctx context.Context, shareID, linkID string, all bool) ([]proton.Link, error) {
-	return c.Session.Client.ListChildren(ctx, shareID, linkID, all)
+	if c.Offline() {
+		if children, ok := c.pinnedChildren(linkID, all); ok {
+			return children, nil
+		}
+	}
+	children, err := c.Session.Client.ListChildren(ctx, shareID, linkID, all)
+	if c.noteAPIError(err) {
+		if pinned, ok := c.pinnedChildren(linkID, all); ok {
+			return pinned, nil
+		}
+	}
+	return children, err
+}
+
+// pinnedChildren returns the pinned listing of linkID. Unless all is
+// set, only active children are returned, as the API does.
+func (c *Client) pinnedChildren(linkID string, all bool) ([]proton.Link, bool) {
+	children, ok := c.Pins.children(linkID)
+	if !ok || all {
+		return children, ok
+	}
+	return slices.DeleteFunc(children, func(l proton.Link) bool {
+		return l.State != proton.LinkStateActive
+	}), true
 }
 
 // NewChildLink constructs a child Link from a raw proton.Link. If the
@@ -165,6 +209,8 @@ func (c *Client) getLink(linkID string) *Link {
 // faithful struct round-trip. When the cache is nil (disabled or
 // XDG_RUNTIME_DIR unset), falls straight through to the API.
 //
+// While the API is unreachable, pinned metadata is returned instead.
+//
 // Note: GetShare bypasses this and calls the API directly — share root
 // links have a cache interaction issue that needs further investigation.
 func (c *Client) GetCachedLink(ctx context.Context, shareID, linkID string) (proton.Link, error) {
@@ -182,8 +228,20 @@ func (c *Client) GetCachedLink(ctx context.Context, shareID, linkID string) (pro
 		}
 	}
 
+	// Pinned metadata while offline, without waiting for the API.
+	if c.Offline() {
+		if pLink, ok := c.Pins.link(linkID); ok {
+			return pLink, nil
+		}
+	}
+
 	// API fetch.
 	pLink, err := c.Session.Client.GetLink(ctx, shareID, linkID)
+	if c.noteAPIError(err) {
+		if pinned, ok := c.Pins.link(linkID); ok {
+			return pinned, nil
+		}
+	}
 	if err != nil {
 		return proton.Link{}, err
 	}
@@ -226,6 +226,55 @@ func (c *Client) DecryptShareURLPassword(_ context.Context, share *Share, shareU
 	return password, nil
 }
 
+// RefreshPublicURLs looks up the public URL of each of shares and
+// replaces the set returned by PublicURL. The password is never part of
+// the cached URL. A share whose URLs cannot be listed keeps its previous
+// entry; the first such error is returned.
+func (c *Client) RefreshPublicURLs(ctx context.Context, shares []*Share) error {
+	c.urlMu.RLock()
+	old := c.publicURLs
+	c.urlMu.RUnlock()
+
+	var firstErr error
+	urls := make(map[string]string, len(shares))
+	for _, share := range shares {
+		shareID := share.Metadata().ShareID
+		list, err := c.ListShareURLs(ctx, shareID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			if url, ok := old[shareID]; ok {
+				urls[shareID] = url
+			}
+			continue
+		}
+		if len(list) > 0 && list[0].PublicURL != "" {
+			urls[shareID] = list[0].PublicURL
+		}
+	}
+	c.SetPublicURLs(urls)
+	return firstErr
+}
+
+// PublicURL returns the public URL of the share with ID shareID as of
+// the last RefreshPublicURLs, without its password, or "" when it has
+// none. It never calls the API.
+func (c *Client) PublicURL(shareID string) string {
+	c.urlMu.RLock()
+	defer c.urlMu.RUnlock()
+	return c.publicURLs[shareID]
+}
+
+// SetPublicURLs replaces the cached public URLs, keyed by ShareID. This
+// is exported for testing (simulating a refresh without a real API
+// client).
+func (c *Client) SetPublicURLs(urls map[string]string) {
+	c.urlMu.Lock()
+	c.publicURLs = urls
+	c.urlMu.Unlock()
+}
+
 // shareSessionKey extracts the session key from the share's encrypted
 // passphrase. The share passphrase is a PGP message encrypted to the
 // address keyring — we split it into key packet + data and decrypt the
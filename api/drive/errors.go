@@ -22,4 +22,9 @@ var (
 	ErrFileNameExist = errors.New("drive: file name exists")
 	// ErrDraftExist indicates that a draft revision already exists.
 	ErrDraftExist = errors.New("drive: draft exists")
+	// ErrNotASymlink indicates that the link is not a symbolic link.
+	ErrNotASymlink = errors.New("drive: not a symbolic link")
+	// ErrWriteConflict indicates that a file changed on the server since
+	// the revision a write was based on.
+	ErrWriteConflict = errors.New("drive: file changed since it was written")
 )
@@ -2,7 +2,7 @@ package drive
 
 import (
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -21,6 +21,10 @@ type fdMode int
 const (
 	fdRead fdMode = iota
 	fdWrite
+	// fdSpoolRead is a read-only FD of a file with a queued write-back
+	// job: it reads the job's blocks over those of its base revision,
+	// through the write-mode read path (see Spool.OpenFD).
+	fdSpoolRead
 )
 
 // FileDescriptor is an active handle to an open Proton Drive file.
@@ -28,13 +32,19 @@ const (
 // delegates block I/O to a blockStore. Implements io.Reader,
 // io.ReaderAt, io.Seeker, io.Writer, io.WriterAt, and io.Closer.
 //
-// Concurrency: fd.mu protects offset, closed, curBlock, curIdx, and
-// fileSize. Read/ReadAt release the lock before calling store.GetBlock
-// so block I/O never holds the FD mutex. Write/WriteAt hold the lock
-// during buffer accumulation but flushBlock only copies data and spawns
-// a goroutine — the actual encrypt+upload runs outside the lock.
-// Close sets closed=true under lock, then flushes/waits outside it;
-// concurrent ops see os.ErrClosed on their next lock acquisition.
+// Write-mode FDs are copy-on-write: modified blocks are held in memory
+// and re-encrypted into a new revision, and on commit the untouched
+// blocks are carried over from the base revision (see fd_modify.go).
+//
+// Concurrency: fd.mu protects offset, closed, fileSize and the write
+// state. Read/ReadAt on a read-mode FD release the lock before calling
+// store.GetBlock so block I/O never holds the FD mutex. Write-mode FDs
+// hold the lock across a read-modify-write, including the fetch of the
+// base block being patched, so a block never changes between fetch and
+// patch; flushBlock only copies data and spawns a goroutine — the
+// encrypt+upload runs outside the lock. Close sets closed=true under
+// lock, then flushes; concurrent ops see os.ErrClosed on their next
+// lock acquisition.
 type FileDescriptor struct {
 	// Identity
 	linkID     string
@@ -46,7 +56,8 @@ type FileDescriptor struct {
 	nodeKR     *crypto.KeyRing
 	addrKR     *crypto.KeyRing
 
-	// Block metadata (for reads — block URLs/tokens from revision)
+	// Block metadata (for reads — block URLs/tokens from revision).
+	// For write-mode FDs these are the blocks of the base revision.
 	blocks []proton.Block
 
 	// State
@@ -62,13 +73,33 @@ type FileDescriptor struct {
 	ctx context.Context
 
 	// Write-side fields (only populated for fdWrite mode)
-	curBlock []byte                // current block accumulator (up to BlockSize)
-	curIdx   int                   // current block index being filled
+	dirty    map[int][]byte        // modified blocks not yet uploaded, keyed by block index
+	uploaded map[int]bool          // blocks uploaded (or uploading) to the draft revision
+	modified bool                  // the file changed since the last commit
+	slots    chan struct{}         // bounds in-flight uploads
 	inflight sync.WaitGroup        // tracks in-flight upload goroutines
 	tokens   map[int]uploadedBlock // collected after upload, keyed by block index
 	tokensMu sync.Mutex            // protects tokens and firstErr
 	firstErr error                 // first upload error
 
+	// newRevision creates the draft revision that writes go to. Write
+	// FDs of existing files call it on the first modification, and
+	// after each commit, so that opening a file read-write leaves no
+	// draft behind until it is written.
+	newRevision func(ctx context.Context) (*FileHandle, error)
+
+	// baseRevision is the revision fd.blocks belongs to. After a commit
+	// the committed revision becomes the base and fd.blocks is
+	// reloaded on first use (baseStale).
+	baseRevision string
+	baseStale    bool
+
+	// spool is the write-back spool job of the FD. When set, blocks
+	// leaving memory and Flush go to the local spool instead of the
+	// draft revision, and Close queues the job for upload (see
+	// spool.go). Nil for write-through FDs.
+	spool *spoolJob
+
 	// Upload metadata (write-only, from FileHandle)
 	volumeID   string
 	addressID  string
@@ -97,6 +128,23 @@ type FileDescriptor struct {
 	// unixMode holds Unix permission bits (lower 12: 0o7777) to store
 	// in the revision XAttr on commit. Zero means "don't store" (omitempty).
 	unixMode uint32
+
+	// symlink is the link target stored in the revision XAttr on
+	// commit. Empty for regular files.
+	symlink string
+
+	// xattrs holds extended attributes stored in the revision XAttr
+	// on commit. Nil means none.
+	xattrs map[string][]byte
+
+	// modTime is the modification time. For read-mode FDs it is taken
+	// from the revision XAttr; for write-mode FDs it is stored in the
+	// revision XAttr on commit. Zero means unknown / "now".
+	modTime time.Time
+
+	// commitEmpty makes Flush commit a revision even when no data was
+	// written, producing an empty file.
+	commitEmpty bool
 }
 
 // Compile-time interface checks.
@@ -112,24 +160,18 @@ var (
 // OpenFD opens a Proton Drive file for reading and returns a
 // FileDescriptor. It wraps Client.OpenFile to fetch revision metadata
 // and derive the session key, then constructs a read-mode FD backed
-// by a blockStore.
+// by a blockStore. The blocks of a pinned revision are read from the
+// pin cache.
 func (c *Client) OpenFD(ctx context.Context, link *Link) (*FileDescriptor, error) {
 	fh, err := c.OpenFile(ctx, link)
 	if err != nil {
 		return nil, fmt.Errorf("OpenFD: %w", err)
 	}
+	return c.readFD(ctx, fh, link), nil
+}
 
-	store := c.blockStore
-
-	// Select read strategy based on BlockCacheMode. Default to encrypted
-	// for any value other than "decrypted" (defensive).
-	var strategy readStrategy
-	if c.BlockCacheMode == "decrypted" {
-		strategy = decryptedReadStrategy{}
-	} else {
-		strategy = encryptedReadStrategy{}
-	}
-
+// readFD constructs a read-mode FD for an opened revision.
+func (c *Client) readFD(ctx context.Context, fh *FileHandle, link *Link) *FileDescriptor {
 	return &FileDescriptor{
 		linkID:         fh.LinkID,
 		revisionID:     fh.RevisionID,
@@ -139,11 +181,22 @@ func (c *Client) OpenFD(ctx context.Context, link *Link) (*FileDescriptor, error
 		fileSize:       fh.FileSize,
 		mode:           fdRead,
 		ctx:            ctx,
-		store:          store,
-		reader:         strategy,
+		store:          c.Pins.blockStore(c.blockStore, fh.LinkID, fh.RevisionID),
+		reader:         c.readStrategy(),
 		prefetchBlocks: c.PrefetchBlocks,
 		link:           link,
-	}, nil
+		modTime:        fh.ModTime,
+	}
+}
+
+// readStrategy returns the read strategy for the client's
+// BlockCacheMode. Default to encrypted for any value other than
+// "decrypted" (defensive).
+func (c *Client) readStrategy() readStrategy {
+	if c.BlockCacheMode == "decrypted" {
+		return decryptedReadStrategy{}
+	}
+	return encryptedReadStrategy{}
 }
 
 // decryptBlock decrypts an encrypted block using the FD's session key.
@@ -168,6 +221,15 @@ func (fd *FileDescriptor) Read(p []byte) (int, error) {
 		fd.mu.Unlock()
 		return 0, os.ErrClosed
 	}
+	if fd.mode != fdRead {
+		defer fd.mu.Unlock()
+		n, err := fd.readAtLocked(p, fd.offset)
+		fd.offset += int64(n)
+		if n > 0 && errors.Is(err, io.EOF) {
+			err = nil
+		}
+		return n, err
+	}
 	offset := fd.offset
 	fileSize := fd.fileSize
 	fd.mu.Unlock()
@@ -223,6 +285,10 @@ func (fd *FileDescriptor) ReadAt(p []byte, off int64) (int, error) {
 		fd.mu.Unlock()
 		return 0, os.ErrClosed
 	}
+	if fd.mode != fdRead {
+		defer fd.mu.Unlock()
+		return fd.readAtLocked(p, off)
+	}
 	fileSize := fd.fileSize
 	fd.mu.Unlock()
 
@@ -473,7 +539,8 @@ func (fd *FileDescriptor) Seek(offset int64, whence int) (int64, error) {
 
 // Close implements io.Closer. For read-mode FDs, it marks the FD as
 // closed. For write-mode FDs, it flushes any pending data and commits
-// the revision. Idempotent — second Close is a no-op.
+// the revision, or for a write-back FD queues the spool job for upload.
+// Idempotent — second Close is a no-op.
 func (fd *FileDescriptor) Close() error {
 	fd.mu.Lock()
 	if fd.closed {
@@ -486,69 +553,55 @@ func (fd *FileDescriptor) Close() error {
 	// Delegate to Flush for the actual commit work. If Flush was already
 	// called (e.g. by the FUSE Flush handler), this is a no-op because
 	// there are no pending blocks or tokens.
-	return fd.Flush()
+	err := fd.Flush()
+
+	// A write-back FD hands its spool job to the uploader; a reader of
+	// a queued job lets go of it.
+	fd.mu.Lock()
+	job := fd.spool
+	fd.spool = nil
+	mode := fd.mode
+	fd.mu.Unlock()
+	switch {
+	case job == nil:
+	case mode == fdWrite:
+		job.release()
+	default:
+		job.spool.unread(job)
+	}
+	return err
 }
 
 // Flush commits any pending write data without closing the FD. For
-// write-mode FDs with buffered blocks or uploaded tokens, it flushes
-// the partial block, waits for in-flight uploads, commits the revision,
-// and invalidates the parent's cached children so subsequent directory
-// listings reflect the new file.
+// write-mode FDs that were modified, it uploads the modified blocks,
+// carries over the untouched ones, waits for the uploads, commits the
+// revision, and invalidates the parent's cached children so subsequent
+// directory listings reflect the new file. Later writes go to a new
+// revision based on the committed one. A write-back FD saves the
+// modified blocks to its spool job instead, without network I/O.
 //
 // Flush is idempotent — calling it when no data is pending is a no-op.
 // It is safe to call concurrently with Close (Close delegates to Flush).
 func (fd *FileDescriptor) Flush() error {
 	fd.mu.Lock()
-	mode := fd.mode
-	hasCurBlock := len(fd.curBlock) > 0
-	if hasCurBlock {
-		fd.flushBlock(fd.curIdx, fd.curBlock)
-		fd.curBlock = fd.curBlock[:0]
-	}
-	fd.mu.Unlock()
+	defer fd.mu.Unlock()
 
-	if mode != fdWrite {
+	if fd.mode != fdWrite {
 		return nil
 	}
 
-	// Wait for all in-flight block uploads to complete.
-	fd.inflight.Wait()
-
-	fd.tokensMu.Lock()
-	err := fd.firstErr
-	nTokens := len(fd.tokens)
-	fd.tokensMu.Unlock()
-
-	if err != nil {
-		return err
-	}
-
 	// Nothing to commit — either no data was written or a prior
 	// Flush/Sync already committed everything.
-	if nTokens == 0 && !hasCurBlock {
+	if !fd.modified && !fd.commitEmpty {
 		return nil
 	}
-
-	if err := fd.commitRevision(); err != nil {
-		return err
-	}
-
-	// Clear tokens so a subsequent Flush/Close is a no-op.
-	fd.tokensMu.Lock()
-	fd.tokens = make(map[int]uploadedBlock)
-	fd.tokensMu.Unlock()
-
-	// Revision committed — the file transitioned from Draft to Active
-	// on the server. Delete the stale link from the table and
-	// invalidate the parent's cached children so the next access
-	// re-fetches fresh state (Active, with FileProperties) from the API.
-	if fd.client != nil {
-		fd.client.deleteLink(fd.linkID)
+	if fd.spool != nil {
+		return fd.spool.save(fd)
 	}
-	if fd.link != nil && fd.link.ParentLink() != nil {
-		fd.link.ParentLink().InvalidateChildren()
+	if err := fd.commitLocked(); err != nil {
+		return err
 	}
-
+	fd.commitEmpty = false
 	return nil
 }
 
@@ -565,6 +618,47 @@ func (fd *FileDescriptor) SetMode(mode uint32) {
 	fd.unixMode = mode
 }
 
+// SetModTime sets the modification time to store in the revision
+// XAttr. Must be called before Close(). Zero means the commit time.
+func (fd *FileDescriptor) SetModTime(t time.Time) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.modTime = t
+}
+
+// ModTime returns the modification time recorded in the revision
+// XAttr, or the zero time when the revision carries none.
+func (fd *FileDescriptor) ModTime() time.Time {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return fd.modTime
+}
+
+// SetCommitEmpty makes Close commit the revision even when nothing was
+// written, creating an empty file. Without it an FD closed before any
+// Write leaves its draft uncommitted. Must be called before Close().
+func (fd *FileDescriptor) SetCommitEmpty(v bool) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.commitEmpty = v
+}
+
+// SetSymlink marks the revision as a symbolic link to target. Must be
+// called before Close().
+func (fd *FileDescriptor) SetSymlink(target string) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.symlink = target
+}
+
+// SetXAttrs sets the extended attributes to store in the revision
+// XAttr. Must be called before Close(). The map is not copied.
+func (fd *FileDescriptor) SetXAttrs(xattrs map[string][]byte) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.xattrs = xattrs
+}
+
 // Link returns the Link associated with this FD. For write-mode FDs
 // created via CreateFD, this is the newly created file's link. For
 // read-mode FDs, this is the opened file's link.
@@ -591,7 +685,8 @@ func newWriteFD(ctx context.Context, fh *FileHandle, store blockStore, session *
 		mode:       fdWrite,
 		ctx:        ctx,
 		store:      store,
-		curBlock:   make([]byte, 0, BlockSize),
+		dirty:      make(map[int][]byte),
+		uploaded:   make(map[int]bool),
 		tokens:     make(map[int]uploadedBlock),
 		volumeID:   fh.VolumeID,
 		addressID:  fh.AddressID,
@@ -606,7 +701,12 @@ func newWriteFD(ctx context.Context, fh *FileHandle, store blockStore, session *
 // then fetches the new file's Link so the FD carries a valid *Link for
 // consumers (e.g. FUSE FileNode construction).
 func (c *Client) CreateFD(ctx context.Context, share *Share, parent *Link, name string) (*FileDescriptor, error) {
-	fh, err := c.CreateFile(ctx, share, parent, name)
+	return c.createFD(ctx, share, parent, name, detectMIMEType(name))
+}
+
+// createFD is CreateFD with an explicit MIME type.
+func (c *Client) createFD(ctx context.Context, share *Share, parent *Link, name, mimeType string) (*FileDescriptor, error) {
+	fh, err := c.createFile(ctx, share, parent, name, mimeType)
 	if err != nil {
 		return nil, fmt.Errorf("CreateFD: %w", err)
 	}
@@ -622,152 +722,87 @@ func (c *Client) CreateFD(ctx context.Context, share *Share, parent *Link, name
 		return nil, fmt.Errorf("CreateFD: stat new link: %w", err)
 	}
 
-	store := c.blockStore
-	fd := newWriteFD(ctx, fh, store, c.Session)
-	fd.link = newLink
-	fd.client = c
-	return fd, nil
+	return c.writeFD(ctx, fh, share, newLink), nil
 }
 
 // OverwriteFD creates a new revision on an existing file and returns a
-// write-mode FileDescriptor. It wraps Client.OverwriteFile.
+// write-mode FileDescriptor for replacing its content. It wraps
+// Client.OverwriteFile. The FD starts empty; use ModifyFD to change
+// part of a file.
 func (c *Client) OverwriteFD(ctx context.Context, share *Share, link *Link) (*FileDescriptor, error) {
 	fh, err := c.OverwriteFile(ctx, share, link)
 	if err != nil {
 		return nil, fmt.Errorf("OverwriteFD: %w", err)
 	}
 
-	store := c.blockStore
-
 	// Invalidate stale cached blocks from the previous revision before
 	// uploading new blocks. Use ceiling division to cover partial tail.
 	oldSize := link.Size()
 	if oldSize > 0 {
 		oldBlockCount := int((oldSize + BlockSize - 1) / BlockSize)
-		store.Invalidate(link.LinkID(), oldBlockCount)
+		c.blockStore.Invalidate(link.LinkID(), oldBlockCount)
 	}
 
-	fd := newWriteFD(ctx, fh, store, c.Session)
+	return c.writeFD(ctx, fh, share, link), nil
+}
+
+// writeFD constructs a write-mode FileDescriptor for link on the
+// draft revision in fh. Revisions after the first are created with
+// OverwriteFile.
+func (c *Client) writeFD(ctx context.Context, fh *FileHandle, share *Share, link *Link) *FileDescriptor {
+	fd := newWriteFD(ctx, fh, c.blockStore, c.Session)
 	fd.link = link
 	fd.client = c
-	return fd, nil
+	fd.reader = c.readStrategy()
+	fd.newRevision = func(ctx context.Context) (*FileHandle, error) {
+		return c.OverwriteFile(ctx, share, link)
+	}
+	return fd
 }
 
-// Write implements io.Writer. It buffers data into the current block
-// and flushes full blocks for encrypt+upload. Returns syscall.EBADF
-// if the FD is read-only.
+// Write implements io.Writer. It writes at the current offset and
+// advances it. Returns syscall.EBADF if the FD is read-only.
 func (fd *FileDescriptor) Write(p []byte) (int, error) {
 	if len(p) == 0 {
 		return 0, nil
 	}
 
 	fd.mu.Lock()
+	defer fd.mu.Unlock()
 	if fd.closed {
-		fd.mu.Unlock()
 		return 0, os.ErrClosed
 	}
 	if fd.mode != fdWrite {
-		fd.mu.Unlock()
 		return 0, syscall.EBADF
 	}
 
-	total := 0
-	for len(p) > 0 {
-		space := int(BlockSize) - len(fd.curBlock)
-		chunk := len(p)
-		if chunk > space {
-			chunk = space
-		}
-		fd.curBlock = append(fd.curBlock, p[:chunk]...)
-		p = p[chunk:]
-		total += chunk
-		fd.fileSize += int64(chunk)
-
-		if len(fd.curBlock) == int(BlockSize) {
-			fd.flushBlock(fd.curIdx, fd.curBlock)
-			fd.curBlock = make([]byte, 0, BlockSize)
-			fd.curIdx++
-		}
-	}
-	fd.mu.Unlock()
-
-	return total, nil
+	n, err := fd.writeAtLocked(p, fd.offset)
+	fd.offset += int64(n)
+	return n, err
 }
 
 // WriteAt implements io.WriterAt. It writes data at the given offset
-// without modifying the FD's current offset. Returns syscall.EBADF
+// without modifying the FD's current offset. Writing past the end of
+// the file extends it; the gap reads as zeros. Returns syscall.EBADF
 // if the FD is read-only.
 func (fd *FileDescriptor) WriteAt(p []byte, off int64) (int, error) {
 	if len(p) == 0 {
 		return 0, nil
 	}
+	if off < 0 {
+		return 0, os.ErrInvalid
+	}
 
 	fd.mu.Lock()
+	defer fd.mu.Unlock()
 	if fd.closed {
-		fd.mu.Unlock()
 		return 0, os.ErrClosed
 	}
 	if fd.mode != fdWrite {
-		fd.mu.Unlock()
 		return 0, syscall.EBADF
 	}
 
-	total := 0
-	for len(p) > 0 {
-		targetIdx := int(off / BlockSize)
-		blockOff := int(off % BlockSize)
-
-		// If targeting a different block, flush the current one first.
-		if targetIdx != fd.curIdx {
-			if len(fd.curBlock) > 0 {
-				fd.flushBlock(fd.curIdx, fd.curBlock)
-			}
-			fd.curBlock = make([]byte, 0, BlockSize)
-			fd.curIdx = targetIdx
-		}
-
-		// Extend curBlock with zeros if the write offset is beyond
-		// the current accumulator length.
-		if blockOff > len(fd.curBlock) {
-			fd.curBlock = append(fd.curBlock, make([]byte, blockOff-len(fd.curBlock))...)
-		}
-
-		space := int(BlockSize) - blockOff
-		chunk := len(p)
-		if chunk > space {
-			chunk = space
-		}
-
-		// Overwrite or extend within the block.
-		end := blockOff + chunk
-		if end <= len(fd.curBlock) {
-			copy(fd.curBlock[blockOff:end], p[:chunk])
-		} else {
-			// Partially overwrite existing, then append the rest.
-			if blockOff < len(fd.curBlock) {
-				copy(fd.curBlock[blockOff:], p[:len(fd.curBlock)-blockOff])
-			}
-			fd.curBlock = append(fd.curBlock, p[len(fd.curBlock)-blockOff:chunk]...)
-		}
-
-		p = p[chunk:]
-		off += int64(chunk)
-		total += chunk
-
-		// Track file size growth.
-		if off > fd.fileSize {
-			fd.fileSize = off
-		}
-
-		if len(fd.curBlock) == int(BlockSize) {
-			fd.flushBlock(fd.curIdx, fd.curBlock)
-			fd.curBlock = make([]byte, 0, BlockSize)
-			fd.curIdx++
-		}
-	}
-	fd.mu.Unlock()
-
-	return total, nil
+	return fd.writeAtLocked(p, off)
 }
 
 // uploadParams returns an uploadParams populated from the FD's crypto
@@ -785,26 +820,37 @@ func (fd *FileDescriptor) uploadParams() uploadParams {
 		revisionID: fd.revisionID,
 		sigAddr:    fd.sigAddr,
 		unixMode:   fd.unixMode,
+		symlink:    fd.symlink,
+		xattrs:     fd.xattrs,
+		modTime:    fd.modTime,
 	}
 }
 
 // flushBlock submits a block for encrypt+upload in a background
-// goroutine. The result is collected in the tokens map.
+// goroutine. The result is collected in the tokens map. At most
+// maxInflightBlocks uploads run at once; beyond that flushBlock waits
+// for a slot. fd.mu must be held.
 func (fd *FileDescriptor) flushBlock(index int, data []byte) {
 	// Make a copy so the caller can reuse the slice.
 	block := make([]byte, len(data))
 	copy(block, data)
 
 	apiIndex := index + 1 // Proton API uses 1-based block indices
+	params := fd.uploadParams()
 
+	if fd.slots == nil {
+		fd.slots = make(chan struct{}, maxInflightBlocks)
+	}
+	fd.slots <- struct{}{}
 	fd.inflight.Add(1)
 	go func() {
 		defer fd.inflight.Done()
+		defer func() { <-fd.slots }()
 
 		ctx, cancel := context.WithTimeout(fd.ctx, 60*time.Second)
 		defer cancel()
 
-		ub, err := encryptAndUploadBlock(ctx, fd.uploadParams(), fd.store, apiIndex, block)
+		ub, err := encryptAndUploadBlock(ctx, params, fd.store, apiIndex, block)
 		if err != nil {
 			fd.setFirstErr(err)
 			return
@@ -826,83 +872,43 @@ func (fd *FileDescriptor) setFirstErr(err error) {
 	}
 }
 
-// Sync flushes any partial block, waits for in-flight uploads, commits
-// the current revision, and creates a new revision for subsequent
-// writes. Returns syscall.EBADF if the FD is read-only.
+// Sync commits the modified file as a new revision, like Flush, and
+// keeps the FD open for further writes, which go to another revision.
+// On a write-back FD it uploads the spooled blocks too, so the content
+// is on the server when Sync returns. Returns syscall.EBADF if the FD
+// is read-only.
 func (fd *FileDescriptor) Sync() error {
 	fd.mu.Lock()
+	defer fd.mu.Unlock()
 	if fd.closed {
-		fd.mu.Unlock()
 		return os.ErrClosed
 	}
 	if fd.mode != fdWrite {
-		fd.mu.Unlock()
 		return syscall.EBADF
 	}
 
-	// Flush partial block.
-	if len(fd.curBlock) > 0 {
-		fd.flushBlock(fd.curIdx, fd.curBlock)
-		fd.curBlock = fd.curBlock[:0]
-	}
-	fd.mu.Unlock()
-
-	fd.inflight.Wait()
-
-	fd.tokensMu.Lock()
-	err := fd.firstErr
-	nTokens := len(fd.tokens)
-	fd.tokensMu.Unlock()
-
-	if err != nil {
-		return err
-	}
-
-	// No-op if nothing was written since last Sync.
-	if nTokens == 0 {
+	// No-op if nothing was written since the last commit.
+	if !fd.modified && (fd.spool == nil || !fd.spool.pending()) {
 		return nil
 	}
 
-	if err := fd.commitRevision(); err != nil {
-		return err
+	// A write-back FD bypasses the upload queue: the spooled and
+	// modified blocks are committed here, and the spool job is
+	// emptied.
+	if err := fd.commitLocked(); err != nil {
+		return fmt.Errorf("fd.Sync: %w", err)
 	}
-
-	// Create a new revision for subsequent writes.
-	ctx, cancel := context.WithTimeout(fd.ctx, 30*time.Second)
-	defer cancel()
-
-	res, err := fd.session.Client.CreateRevision(ctx, fd.shareID, fd.linkID)
-	if err != nil {
-		return fmt.Errorf("fd.Sync: create new revision: %w", err)
-	}
-
-	// Fetch new verification code.
-	vd, err := fd.session.Client.GetVerificationData(ctx, fd.shareID, fd.linkID, res.ID)
-	if err != nil {
-		return fmt.Errorf("fd.Sync: verification data: %w", err)
-	}
-	verifyCode, err := base64.StdEncoding.DecodeString(vd.VerificationCode)
-	if err != nil {
-		return fmt.Errorf("fd.Sync: decode verification code: %w", err)
+	if fd.spool != nil {
+		if err := fd.spool.reset(fd.baseRevision); err != nil {
+			return fmt.Errorf("fd.Sync: %w", err)
+		}
 	}
-
-	fd.mu.Lock()
-	fd.revisionID = res.ID
-	fd.verifyCode = verifyCode
-	fd.curIdx = 0
-	fd.curBlock = make([]byte, 0, BlockSize)
-	fd.mu.Unlock()
-
-	fd.tokensMu.Lock()
-	fd.tokens = make(map[int]uploadedBlock)
-	fd.firstErr = nil
-	fd.tokensMu.Unlock()
-
 	return nil
 }
 
-// Truncate adjusts the file size. Full block discard is deferred —
-// for now it only updates the fileSize field.
+// Truncate changes the file size. Shrinking discards the blocks past
+// the new end and cuts the last block; growing extends the file with
+// zeros. Like writes, the change is committed by Flush, Sync or Close.
 func (fd *FileDescriptor) Truncate(size int64) error {
 	fd.mu.Lock()
 	defer fd.mu.Unlock()
@@ -913,14 +919,19 @@ func (fd *FileDescriptor) Truncate(size int64) error {
 	if fd.mode != fdWrite {
 		return syscall.EBADF
 	}
-
-	fd.fileSize = size
-	return nil
+	if size < 0 {
+		return os.ErrInvalid
+	}
+	if size == fd.fileSize {
+		return nil
+	}
+	return fd.truncateLocked(size)
 }
 
 // commitRevision copies the collected tokens and delegates to
 // commitRevisionFromTokens to build the manifest, sign it, encrypt
-// XAttr, and call UpdateRevision.
+// XAttr, and call UpdateRevision. With no tokens it commits an empty
+// file; Flush and Sync only call it when that is wanted.
 func (fd *FileDescriptor) commitRevision() error {
 	fd.tokensMu.Lock()
 	nBlocks := len(fd.tokens)
@@ -931,9 +942,5 @@ func (fd *FileDescriptor) commitRevision() error {
 	}
 	fd.tokensMu.Unlock()
 
-	if nBlocks == 0 {
-		return nil
-	}
-
 	return commitRevisionFromTokens(fd.ctx, fd.session, fd.uploadParams(), tokensCopy)
 }
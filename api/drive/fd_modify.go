@@ -0,0 +1,416 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// Write-mode FDs are copy-on-write. The Drive API cannot patch a
+// revision or reuse the blocks of another, so a change to a file is a
+// new revision holding every block:
+//
+//   - A modified block is fetched from the base revision (the one the
+//     FD was opened on, or last committed), patched in memory, and
+//     re-encrypted on upload.
+//   - Untouched blocks are carried over from the base revision on
+//     commit. They are re-encrypted too, as block signatures must come
+//     from the revision's signer.
+//   - Blocks past the end of the base revision read as zeros, so
+//     writing past the end or truncating upwards leaves a zero-filled
+//     gap, and truncating downwards cuts the last block.
+//
+// Full blocks written past the end of the base are uploaded as soon as
+// they fill, so sequential writes stream. Other modified blocks stay in
+// memory until commit, up to maxDirtyBlocks, past which the lowest full
+// ones are uploaded early. Touching a block that was already uploaded
+// commits the revision and continues on a new one based on it.
+//
+// A write-back FD sends blocks leaving memory to its spool job instead
+// of the draft revision, and creates no revision until Sync or the
+// uploader replays the job.
+
+// maxDirtyBlocks is the number of modified blocks a write-mode FD keeps
+// in memory before uploading some early.
+const maxDirtyBlocks = 16
+
+// maxInflightBlocks bounds the concurrent block uploads of one FD.
+const maxInflightBlocks = 8
+
+// ModifyFD opens an existing file for reading and writing anywhere in
+// it, and returns a write-mode FileDescriptor. Reads see the current
+// content. The first modification creates a new revision, which Flush,
+// Sync or Close commits; the mode, symlink target and extended
+// attributes of the file are kept. A file without an active revision
+// is opened as by OverwriteFD.
+func (c *Client) ModifyFD(ctx context.Context, share *Share, link *Link) (*FileDescriptor, error) {
+	if !link.HasActiveRevision() {
+		return c.OverwriteFD(ctx, share, link)
+	}
+
+	fh, err := c.OpenFile(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("ModifyFD: %w", err)
+	}
+	c.FetchRevisionXAttr(ctx, link)
+	mode, ux := link.unixAttrs()
+
+	fd := c.writeFD(ctx, &FileHandle{
+		LinkID:     fh.LinkID,
+		ShareID:    share.ProtonShare().ShareID,
+		SessionKey: fh.SessionKey,
+	}, share, link)
+	fd.store = c.Pins.blockStore(fd.store, fh.LinkID, fh.RevisionID)
+	fd.blocks = fh.Blocks
+	fd.baseRevision = fh.RevisionID
+	fd.fileSize = fh.FileSize
+	fd.unixMode = mode
+	if ux != nil {
+		fd.symlink = ux.Symlink
+		fd.xattrs = ux.XAttrs
+	}
+	return fd, nil
+}
+
+// beginRevision creates the draft revision that writes go to, unless
+// the FD already has one. fd.mu must be held.
+func (fd *FileDescriptor) beginRevision() error {
+	if fd.revisionID != "" {
+		return nil
+	}
+	if fd.newRevision == nil {
+		return fmt.Errorf("fd %s: no revision to write to", fd.linkID)
+	}
+	fh, err := fd.newRevision(fd.ctx)
+	if err != nil {
+		return fmt.Errorf("fd %s: new revision: %w", fd.linkID, err)
+	}
+	fd.revisionID = fh.RevisionID
+	fd.shareID = fh.ShareID
+	fd.sessionKey = fh.SessionKey
+	fd.nodeKR = fh.NodeKR
+	fd.addrKR = fh.AddrKR
+	fd.volumeID = fh.VolumeID
+	fd.addressID = fh.AddressID
+	fd.sigAddr = fh.SigAddr
+	fd.verifyCode = fh.VerificationCode
+	return nil
+}
+
+// loadBase reloads the block list of the base revision after a commit
+// made it stale. fd.mu must be held.
+func (fd *FileDescriptor) loadBase() error {
+	if !fd.baseStale {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(fd.ctx, 30*time.Second)
+	defer cancel()
+	rev, err := fd.session.Client.GetRevisionAllBlocks(ctx, fd.shareID, fd.linkID, fd.baseRevision)
+	if err != nil {
+		return fmt.Errorf("fd %s: get revision: %w", fd.linkID, err)
+	}
+	fd.blocks = rev.Blocks
+	fd.baseStale = false
+	return nil
+}
+
+// blockLen returns the length of block idx in a file of the FD's size.
+func (fd *FileDescriptor) blockLen(idx int) int {
+	n := fd.fileSize - int64(idx)*BlockSize
+	return int(max(0, min(n, BlockSize)))
+}
+
+// padBlock returns the first n bytes of b, extended with zeros if b is
+// shorter. The result never shares b's backing array past len(b).
+func padBlock(b []byte, n int) []byte {
+	if len(b) >= n {
+		return b[:n]
+	}
+	return append(b[:len(b):len(b)], make([]byte, n-len(b))...)
+}
+
+// blockContent returns the current content of block idx, blockLen(idx)
+// bytes long. A block already uploaded to the draft revision is read
+// back by committing the revision first. fd.mu must be held. The
+// result may be shared with a cache and must not be modified.
+func (fd *FileDescriptor) blockContent(idx int) ([]byte, error) {
+	n := fd.blockLen(idx)
+	if b, ok := fd.dirty[idx]; ok {
+		return padBlock(b, n), nil
+	}
+	if fd.spool != nil && fd.spool.has(idx) {
+		b, err := fd.spool.readBlock(fd, idx)
+		if err != nil {
+			return nil, err
+		}
+		return padBlock(b, n), nil
+	}
+	if fd.uploaded[idx] {
+		if err := fd.commitLocked(); err != nil {
+			return nil, err
+		}
+	}
+	if err := fd.loadBase(); err != nil {
+		return nil, err
+	}
+	if idx >= len(fd.blocks) {
+		return make([]byte, n), nil
+	}
+	plain, err := fd.readBlock(idx)
+	if err != nil {
+		return nil, fmt.Errorf("fd %s: read block %d: %w", fd.linkID, idx, err)
+	}
+	return padBlock(plain, n), nil
+}
+
+// readAtLocked is ReadAt for a write-mode FD. fd.mu must be held.
+func (fd *FileDescriptor) readAtLocked(p []byte, off int64) (int, error) {
+	total := 0
+	for len(p) > 0 && off < fd.fileSize {
+		idx := int(off / BlockSize)
+		block, err := fd.blockContent(idx)
+		if err != nil {
+			return total, err
+		}
+		n := copy(p, block[off%BlockSize:])
+		p = p[n:]
+		off += int64(n)
+		total += n
+	}
+	if len(p) > 0 {
+		return total, io.EOF
+	}
+	return total, nil
+}
+
+// writeAtLocked patches p into the blocks it covers at off, extending
+// the file as needed. fd.mu must be held.
+func (fd *FileDescriptor) writeAtLocked(p []byte, off int64) (int, error) {
+	if fd.spool == nil {
+		if err := fd.beginRevision(); err != nil {
+			return 0, err
+		}
+	}
+
+	total := 0
+	idx := 0
+	for len(p) > 0 {
+		idx = int(off / BlockSize)
+		blockOff := int(off % BlockSize)
+		chunk := min(len(p), int(BlockSize)-blockOff)
+
+		block, ok := fd.dirty[idx]
+		if !ok {
+			content, err := fd.blockContent(idx)
+			if err != nil {
+				return total, err
+			}
+			block = make([]byte, len(content), BlockSize)
+			copy(block, content)
+		}
+		if end := blockOff + chunk; end > len(block) {
+			block = padBlock(block, end)
+		}
+		copy(block[blockOff:], p[:chunk])
+		fd.dirty[idx] = block
+
+		p = p[chunk:]
+		off += int64(chunk)
+		total += chunk
+		fd.fileSize = max(fd.fileSize, off)
+		fd.modified = true
+
+		// A block filled up past the end of the base revision is new
+		// data written in order: upload it now so that copying a large
+		// file does not hold it in memory.
+		if len(block) == int(BlockSize) && blockOff+chunk == int(BlockSize) && idx >= len(fd.blocks) {
+			if err := fd.putBlock(idx, block); err != nil {
+				return total, err
+			}
+		}
+	}
+
+	return total, fd.evict(idx)
+}
+
+// putBlock moves dirty block idx out of memory: to the spool job of a
+// write-back FD, to the draft revision otherwise. fd.mu must be held.
+func (fd *FileDescriptor) putBlock(idx int, data []byte) error {
+	if fd.spool == nil {
+		fd.upload(idx, data)
+		return nil
+	}
+	if err := fd.spool.writeBlock(fd, idx, data); err != nil {
+		return err
+	}
+	delete(fd.dirty, idx)
+	return nil
+}
+
+// upload moves block idx from the dirty set to the draft revision.
+// fd.mu must be held.
+func (fd *FileDescriptor) upload(idx int, data []byte) {
+	fd.flushBlock(idx, data)
+	delete(fd.dirty, idx)
+	fd.uploaded[idx] = true
+}
+
+// evict moves full dirty blocks out of memory, lowest first, until at
+// most maxDirtyBlocks remain. Block keep, the one last written, stays.
+// fd.mu must be held.
+func (fd *FileDescriptor) evict(keep int) error {
+	if len(fd.dirty) <= maxDirtyBlocks {
+		return nil
+	}
+	var full []int
+	for idx, b := range fd.dirty {
+		if idx != keep && len(b) == int(BlockSize) {
+			full = append(full, idx)
+		}
+	}
+	slices.Sort(full)
+	for _, idx := range full {
+		if len(fd.dirty) <= maxDirtyBlocks {
+			return nil
+		}
+		if err := fd.putBlock(idx, fd.dirty[idx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncateLocked sets the file size to size. fd.mu must be held.
+func (fd *FileDescriptor) truncateLocked(size int64) error {
+	fd.modified = true
+	if size > fd.fileSize {
+		// The old last block is padded with zeros when read or
+		// uploaded; blocks past it read as zeros.
+		fd.fileSize = size
+		return nil
+	}
+
+	keep := BlockCount(size)
+	tail := int(size % BlockSize)
+
+	// Read the block the new end falls in before discarding anything:
+	// reading it may commit the revision, which needs the blocks past
+	// the new end.
+	var last []byte
+	if tail != 0 {
+		content, err := fd.blockContent(keep - 1)
+		if err != nil {
+			return err
+		}
+		last = make([]byte, tail, BlockSize)
+		copy(last, content)
+	}
+
+	// Settle the uploads so that no token for a discarded block
+	// arrives later.
+	fd.inflight.Wait()
+	fd.tokensMu.Lock()
+	for idx := range fd.tokens {
+		if idx >= keep {
+			delete(fd.tokens, idx)
+		}
+	}
+	fd.tokensMu.Unlock()
+	for idx := range fd.uploaded {
+		if idx >= keep {
+			delete(fd.uploaded, idx)
+		}
+	}
+	for idx := range fd.dirty {
+		if idx >= keep {
+			delete(fd.dirty, idx)
+		}
+	}
+	if fd.spool != nil {
+		fd.spool.truncate(keep)
+	}
+	if err := fd.loadBase(); err != nil {
+		return err
+	}
+	if len(fd.blocks) > keep {
+		fd.blocks = fd.blocks[:keep]
+	}
+
+	if tail != 0 {
+		delete(fd.uploaded, keep-1)
+		fd.tokensMu.Lock()
+		delete(fd.tokens, keep-1)
+		fd.tokensMu.Unlock()
+		fd.dirty[keep-1] = last
+	}
+	fd.fileSize = size
+	return nil
+}
+
+// commitLocked commits the draft revision: it uploads every block the
+// revision does not have yet, the modified ones and those carried over
+// from the base revision, waits for the uploads, and commits. The
+// committed revision becomes the base for later writes, which go to a
+// new draft. fd.mu must be held.
+func (fd *FileDescriptor) commitLocked() error {
+	if err := fd.beginRevision(); err != nil {
+		return err
+	}
+
+	nBlocks := BlockCount(fd.fileSize)
+	for idx := range nBlocks {
+		if fd.uploaded[idx] {
+			continue
+		}
+		data, err := fd.blockContent(idx)
+		if err != nil {
+			return err
+		}
+		fd.upload(idx, data)
+	}
+
+	fd.inflight.Wait()
+
+	fd.tokensMu.Lock()
+	err := fd.firstErr
+	fd.tokensMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := fd.commitRevision(); err != nil {
+		return err
+	}
+	if fd.client != nil {
+		fd.client.noteCommit(fd.linkID, fd.revisionID)
+	}
+
+	// Blocks of the old base and of the committed revision share cache
+	// keys; drop them all so later reads fetch the committed blocks.
+	fd.store.Invalidate(fd.linkID, max(nBlocks, len(fd.blocks)))
+
+	fd.baseRevision = fd.revisionID
+	fd.baseStale = true
+	fd.blocks = nil
+	fd.revisionID = ""
+	fd.modified = false
+	fd.dirty = make(map[int][]byte)
+	fd.uploaded = make(map[int]bool)
+	fd.tokensMu.Lock()
+	fd.tokens = make(map[int]uploadedBlock)
+	fd.tokensMu.Unlock()
+
+	// Revision committed — the file transitioned from Draft to Active
+	// on the server. Delete the stale link from the table and
+	// invalidate the parent's cached children so the next access
+	// re-fetches fresh state (Active, with FileProperties) from the API.
+	if fd.client != nil {
+		fd.client.deleteLink(fd.linkID)
+	}
+	if fd.link != nil && fd.link.ParentLink() != nil {
+		fd.link.ParentLink().InvalidateChildren()
+	}
+	return nil
+}
@@ -436,7 +436,8 @@ func newWriteTestFD(t testing.TB) (*FileDescriptor, *writeMemBlockStore) { //nol
 		// nodeKR and addrKR are nil — tests must not trigger flushBlock crypto
 		mode:       fdWrite,
 		store:      store,
-		curBlock:   make([]byte, 0, BlockSize),
+		dirty:      make(map[int][]byte),
+		uploaded:   make(map[int]bool),
 		tokens:     make(map[int]uploadedBlock),
 		verifyCode: make([]byte, 32),
 	}
@@ -448,7 +449,7 @@ func newWriteTestFD(t testing.TB) (*FileDescriptor, *writeMemBlockStore) { //nol
 // ---------------------------------------------------------------------------
 
 // TestFDPropertyWriteBlockBoundaries verifies that after writing N bytes,
-// curBlock holds N%BlockSize bytes and curIdx equals ⌊N/BlockSize⌋.
+// the only dirty block is block ⌊N/BlockSize⌋ holding N%BlockSize bytes.
 // This tests the accumulation logic without triggering actual crypto in
 // flushBlock (which would need real PGP keyrings). We cap writes below
 // BlockSize so flushBlock is never called.
@@ -476,21 +477,18 @@ func TestFDPropertyWriteBlockBoundaries(t *testing.T) {
 		}
 
 		fd.mu.Lock()
-		gotCurBlockLen := len(fd.curBlock)
-		gotCurIdx := fd.curIdx
+		gotDirty := len(fd.dirty)
+		gotBlockLen := len(fd.dirty[0])
 		gotFileSize := fd.fileSize
 		fd.mu.Unlock()
 
-		// All data fits in one block, so curIdx should be 0 and
-		// curBlock should hold all written bytes.
-		wantCurBlockLen := totalSize
-		wantCurIdx := 0
-
-		if gotCurBlockLen != wantCurBlockLen {
-			rt.Fatalf("curBlock len = %d, want %d (wrote %d bytes)", gotCurBlockLen, wantCurBlockLen, totalSize)
+		// All data fits in one block, so block 0 should be the only
+		// dirty block and hold all written bytes.
+		if gotDirty != 1 {
+			rt.Fatalf("%d dirty blocks, want 1", gotDirty)
 		}
-		if gotCurIdx != wantCurIdx {
-			rt.Fatalf("curIdx = %d, want %d", gotCurIdx, wantCurIdx)
+		if gotBlockLen != totalSize {
+			rt.Fatalf("block 0 len = %d, want %d (wrote %d bytes)", gotBlockLen, totalSize, totalSize)
 		}
 		if gotFileSize != int64(totalSize) {
 			rt.Fatalf("fileSize = %d, want %d", gotFileSize, totalSize)
@@ -555,7 +553,7 @@ func TestFDPropertyReadOnlyRejectsWrites(t *testing.T) {
 // Unit tests — write path (Task 7.4)
 // ---------------------------------------------------------------------------
 
-// TestFDWriteAccumulation writes small chunks and verifies curBlock grows.
+// TestFDWriteAccumulation writes small chunks and verifies the dirty block grows.
 func TestFDWriteAccumulation(t *testing.T) {
 	fd, _ := newWriteTestFD(t)
 
@@ -572,16 +570,16 @@ func TestFDWriteAccumulation(t *testing.T) {
 	}
 
 	fd.mu.Lock()
-	gotLen := len(fd.curBlock)
-	gotIdx := fd.curIdx
+	gotLen := len(fd.dirty[0])
+	gotDirty := len(fd.dirty)
 	gotSize := fd.fileSize
 	fd.mu.Unlock()
 
 	if gotLen != 300 {
-		t.Fatalf("curBlock len = %d, want 300", gotLen)
+		t.Fatalf("block 0 len = %d, want 300", gotLen)
 	}
-	if gotIdx != 0 {
-		t.Fatalf("curIdx = %d, want 0", gotIdx)
+	if gotDirty != 1 {
+		t.Fatalf("%d dirty blocks, want 1", gotDirty)
 	}
 	if gotSize != 300 {
 		t.Fatalf("fileSize = %d, want 300", gotSize)
@@ -809,7 +807,7 @@ func TestFDConcurrentWrite(t *testing.T) {
 	// Verify total bytes accumulated.
 	fd.mu.Lock()
 	totalWritten = fd.fileSize
-	gotLen := len(fd.curBlock)
+	gotLen := len(fd.dirty[0])
 	fd.mu.Unlock()
 
 	wantTotal := int64(goroutines * writesPerGoroutine * chunkSize)
@@ -817,7 +815,7 @@ func TestFDConcurrentWrite(t *testing.T) {
 		t.Fatalf("fileSize = %d, want %d", totalWritten, wantTotal)
 	}
 	if int64(gotLen) != wantTotal {
-		t.Fatalf("curBlock len = %d, want %d", gotLen, wantTotal)
+		t.Fatalf("block 0 len = %d, want %d", gotLen, wantTotal)
 	}
 }
 
@@ -930,3 +928,129 @@ func TestFDConcurrentCloseWithWrite(t *testing.T) {
 	wg.Wait()
 	<-sawClosed
 }
+
+// ---------------------------------------------------------------------------
+// Copy-on-write writes
+// ---------------------------------------------------------------------------
+
+// newModifyTestFD creates a write-mode FD opened on the given content,
+// as ModifyFD would, with a draft revision already begun. Tests must
+// not fill a block past the end of the base or commit, as that would
+// upload with nil keyrings.
+func newModifyTestFD(t testing.TB, base []byte) *FileDescriptor {
+	t.Helper()
+	fd := newTestFD(t, base)
+	fd.mode = fdWrite
+	fd.revisionID = "modify-test-rev"
+	fd.baseRevision = "modify-test-base"
+	fd.dirty = make(map[int][]byte)
+	fd.uploaded = make(map[int]bool)
+	fd.tokens = make(map[int]uploadedBlock)
+	return fd
+}
+
+// readAll reads the whole content of fd with ReadAt.
+func readAll(t testing.TB, fd *FileDescriptor) []byte {
+	t.Helper()
+	fd.mu.Lock()
+	size := fd.fileSize
+	fd.mu.Unlock()
+	buf := make([]byte, size)
+	if n, err := fd.ReadAt(buf, 0); err != nil && !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadAt: %v", err)
+	} else if int64(n) != size {
+		t.Fatalf("ReadAt read %d bytes, want %d", n, size)
+	}
+	return buf
+}
+
+// TestFDModifyWriteAtPatchesBase patches a range spanning two blocks of
+// an existing file and verifies the rest of the content is kept.
+func TestFDModifyWriteAtPatchesBase(t *testing.T) {
+	base := make([]byte, BlockSize+4096)
+	for i := range base {
+		base[i] = byte(i % 251)
+	}
+	fd := newModifyTestFD(t, base)
+
+	patch := bytes.Repeat([]byte{0xAA}, 2048)
+	off := int64(BlockSize - 1024)
+	if n, err := fd.WriteAt(patch, off); err != nil || n != len(patch) {
+		t.Fatalf("WriteAt = %d, %v", n, err)
+	}
+
+	want := bytes.Clone(base)
+	copy(want[off:], patch)
+	if got := readAll(t, fd); !bytes.Equal(got, want) {
+		t.Fatal("content after WriteAt does not match")
+	}
+	if len(fd.dirty) != 2 {
+		t.Errorf("%d dirty blocks, want 2", len(fd.dirty))
+	}
+}
+
+// TestFDModifyTruncate shrinks a file into its first block, extends it
+// again, and verifies the extension reads as zeros.
+func TestFDModifyTruncate(t *testing.T) {
+	base := bytes.Repeat([]byte{0x55}, int(BlockSize+100))
+	fd := newModifyTestFD(t, base)
+
+	if err := fd.Truncate(300); err != nil {
+		t.Fatalf("Truncate(300): %v", err)
+	}
+	if len(fd.blocks) != 1 {
+		t.Errorf("%d base blocks after shrink, want 1", len(fd.blocks))
+	}
+	if err := fd.Truncate(1000); err != nil {
+		t.Fatalf("Truncate(1000): %v", err)
+	}
+
+	want := make([]byte, 1000)
+	copy(want, base[:300])
+	if got := readAll(t, fd); !bytes.Equal(got, want) {
+		t.Fatalf("content after truncate = %x..., want %x...", got[295:305], want[295:305])
+	}
+}
+
+// TestFDModifyReadWrite_Property verifies that a sequence of WriteAt and
+// Truncate calls on an existing file reads back like the same sequence
+// applied to a byte slice.
+func TestFDModifyReadWrite_Property(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		size := rapid.IntRange(1, 8192).Draw(rt, "size")
+		model := rapid.SliceOfN(rapid.Byte(), size, size).Draw(rt, "base")
+		fd := newModifyTestFD(t, model)
+		model = bytes.Clone(model)
+
+		nOps := rapid.IntRange(1, 10).Draw(rt, "ops")
+		for range nOps {
+			if rapid.Bool().Draw(rt, "truncate") {
+				// Sizes of at least 1 keep the base block, so that
+				// writing block 0 never uploads.
+				n := rapid.IntRange(1, 16384).Draw(rt, "size")
+				if err := fd.Truncate(int64(n)); err != nil {
+					rt.Fatalf("Truncate(%d): %v", n, err)
+				}
+				if n < len(model) {
+					model = model[:n]
+				} else {
+					model = append(model, make([]byte, n-len(model))...)
+				}
+				continue
+			}
+			off := rapid.IntRange(0, 16384).Draw(rt, "off")
+			data := rapid.SliceOfN(rapid.Byte(), 1, 1024).Draw(rt, "data")
+			if _, err := fd.WriteAt(data, int64(off)); err != nil {
+				rt.Fatalf("WriteAt(%d): %v", off, err)
+			}
+			if end := off + len(data); end > len(model) {
+				model = append(model, make([]byte, end-len(model))...)
+			}
+			copy(model[off:], data)
+		}
+
+		if got := readAll(t, fd); !bytes.Equal(got, model) {
+			rt.Fatalf("content mismatch: got %d bytes, want %d", len(got), len(model))
+		}
+	})
+}
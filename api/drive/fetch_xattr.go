@@ -34,7 +34,7 @@ func (c *Client) FetchRevisionXAttr(ctx context.Context, link *Link) {
 	}
 
 	shareID := link.Share().ProtonShare().ShareID
-	fullRev, err := c.Session.Client.GetRevisionAllBlocks(ctx, shareID, pLink.LinkID, rev.ID)
+	fullRev, err := c.getRevision(ctx, shareID, pLink.LinkID, rev.ID)
 	if err != nil {
 		slog.Debug("FetchRevisionXAttr: failed",
 			"linkID", pLink.LinkID, "error", err)
@@ -42,7 +42,7 @@ func (c *Client) FetchRevisionXAttr(ctx context.Context, link *Link) {
 	}
 
 	// Populate the XAttr on the in-memory proton.Link so that
-	// Link.Mode() and Link.decryptMode() can decrypt it.
+	// Link.Mode() and Link.SymlinkTarget() can decrypt it.
 	rev.XAttr = fullRev.XAttr
 
 	// Also update Size from the revision (the listing Size may be stale).
@@ -39,8 +39,11 @@ type FileHandle struct {
 // FileHandle with the RevisionID and SessionKey needed for upload.
 // The caller uses these to populate a CopyEndpoint destination.
 func (c *Client) CreateFile(ctx context.Context, share *Share, parentLink *Link, name string) (*FileHandle, error) {
-	mimeType := detectMIMEType(name)
+	return c.createFile(ctx, share, parentLink, name, detectMIMEType(name))
+}
 
+// createFile is CreateFile with an explicit MIME type.
+func (c *Client) createFile(ctx context.Context, share *Share, parentLink *Link, name, mimeType string) (*FileHandle, error) {
 	parentKR, err := parentLink.KeyRing()
 	if err != nil {
 		return nil, fmt.Errorf("CreateFile: parent keyring: %w", err)
@@ -145,29 +148,36 @@ func (c *Client) OpenFile(ctx context.Context, link *Link) (*FileHandle, error)
 		return nil, fmt.Errorf("OpenFile: %s: no file properties", link.LinkID())
 	}
 
+	active := pLink.FileProperties.ActiveRevision
+	return c.openRevision(ctx, "OpenFile", link, active.ID, active.Size)
+}
+
+// openRevision fetches the block list of a revision of a file and
+// derives the session key. op names the caller in errors and logs.
+func (c *Client) openRevision(ctx context.Context, op string, link *Link, revisionID string, fileSize int64) (*FileHandle, error) {
+	pLink := link.ProtonLink()
 	shareID := link.Share().ProtonShare().ShareID
-	revisionID := pLink.FileProperties.ActiveRevision.ID
 
 	t0 := time.Now()
-	revision, err := c.Session.Client.GetRevisionAllBlocks(ctx, shareID, link.LinkID(), revisionID)
+	revision, err := c.getRevision(ctx, shareID, link.LinkID(), revisionID)
 	if err != nil {
-		return nil, fmt.Errorf("OpenFile: %s: get revision: %w", link.LinkID(), err)
+		return nil, fmt.Errorf("%s: %s: get revision: %w", op, link.LinkID(), err)
 	}
-	slog.Debug("OpenFile: GetRevisionAllBlocks", "linkID", link.LinkID(), "elapsed", time.Since(t0))
+	slog.Debug(op+": GetRevisionAllBlocks", "linkID", link.LinkID(), "elapsed", time.Since(t0))
 
 	t1 := time.Now()
 	nodeKR, err := link.KeyRing()
 	if err != nil {
-		return nil, fmt.Errorf("OpenFile: %s: keyring: %w", link.LinkID(), err)
+		return nil, fmt.Errorf("%s: %s: keyring: %w", op, link.LinkID(), err)
 	}
-	slog.Debug("OpenFile: KeyRing", "linkID", link.LinkID(), "elapsed", time.Since(t1))
+	slog.Debug(op+": KeyRing", "linkID", link.LinkID(), "elapsed", time.Since(t1))
 
 	t2 := time.Now()
 	sessionKey, err := pLink.GetSessionKey(nodeKR)
 	if err != nil {
-		return nil, fmt.Errorf("OpenFile: %s: session key: %w", link.LinkID(), err)
+		return nil, fmt.Errorf("%s: %s: session key: %w", op, link.LinkID(), err)
 	}
-	slog.Debug("OpenFile: GetSessionKey", "linkID", link.LinkID(), "elapsed", time.Since(t2))
+	slog.Debug(op+": GetSessionKey", "linkID", link.LinkID(), "elapsed", time.Since(t2))
 
 	// Extract mtime from revision XAttr if available.
 	var modTime time.Time
@@ -176,15 +186,13 @@ func (c *Client) OpenFile(ctx context.Context, link *Link) (*FileHandle, error)
 		xattr, xErr := revision.GetDecXAttrString(addrKR, nodeKR)
 		if xErr == nil && xattr != nil {
 			if xattr.ModificationTime != "" {
-				if mt, tErr := time.Parse(time.RFC3339, xattr.ModificationTime); tErr == nil {
+				if mt, ok := parseXAttrTime(xattr.ModificationTime); ok {
 					modTime = mt
 				}
 			}
 		}
 	}
 
-	fileSize := pLink.FileProperties.ActiveRevision.Size
-
 	return &FileHandle{
 		Link:       link,
 		Share:      link.Share(),
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
@@ -53,10 +55,20 @@ type Link struct {
 	// share's MemoryCacheLevel is >= CacheMetadata.
 	cachedChildIDs []string
 
-	// cachedMode stores the decoded Mode from the XAttr. 0 means either
-	// "not cached" or "not set" — disambiguated by cachedModeValid.
+	// cachedMode, cachedModTime and cachedUnix store the decoded Mode,
+	// ModificationTime and Unix extensions from the XAttr. Zero values
+	// mean either "not cached" or "not set" — disambiguated by
+	// cachedModeValid.
 	cachedMode      uint32
+	cachedModTime   time.Time
+	cachedUnix      *unixXAttr
 	cachedModeValid bool
+
+	// xattrModTime caches the ModificationTime from the XAttr whatever
+	// the MemoryCacheLevel, as ModifyTime is read for every listed
+	// entry; xattrModTimeValid marks it set.
+	xattrModTime      time.Time
+	xattrModTimeValid bool
 }
 
 // Type returns the link type (file or folder) without decryption.
@@ -84,9 +96,15 @@ func (l *Link) IsDraft() bool { return l.protonLink.State == proton.LinkStateDra
 func (l *Link) CreateTime() int64 { return l.protonLink.CreateTime }
 
 // ModifyTime returns the modification timestamp. For files with an active
-// revision, returns the revision's create time (which is the upload time).
+// revision, returns the ModificationTime recorded in the revision XAttr
+// (set by touch, cp --preserve=timestamps, and other uploads that carry
+// the source's time), falling back to the revision's create time (the
+// upload time) when the XAttr is absent or carries none.
 func (l *Link) ModifyTime() int64 {
 	if l.protonLink.Type == proton.LinkTypeFile && l.protonLink.FileProperties != nil {
+		if t := l.modTimeAttr(); !t.IsZero() {
+			return t.Unix()
+		}
 		return l.protonLink.FileProperties.ActiveRevision.CreateTime
 	}
 	return l.protonLink.ModifyTime
@@ -242,73 +260,183 @@ func (l *Link) KeyRing() (*crypto.KeyRing, error) {
 // Lazily decrypts the XAttr on first call and caches the result when
 // MemoryCacheLevel >= CacheMetadata (same gating as KeyRing/Stat).
 func (l *Link) Mode() uint32 {
+	mode, _ := l.unixAttrs()
+	return mode
+}
+
+// IsSymlink reports whether the link is a file created as a symbolic
+// link. Uses the MIME type only — no decryption.
+func (l *Link) IsSymlink() bool {
+	return l.protonLink.Type == proton.LinkTypeFile && l.protonLink.MIMEType == SymlinkMIMEType
+}
+
+// SymlinkTarget returns the symlink target stored in the revision
+// XAttr, or "" when the link is not a symlink or the XAttr has not been
+// fetched. Decrypted and cached together with Mode.
+func (l *Link) SymlinkTarget() string {
+	if _, ux := l.unixAttrs(); ux != nil {
+		return ux.Symlink
+	}
+	return ""
+}
+
+// XAttrs returns a copy of the extended attributes stored in the
+// revision XAttr. Returns nil when none are set or the XAttr has not
+// been fetched. Decrypted and cached together with Mode.
+func (l *Link) XAttrs() map[string][]byte {
+	_, ux := l.unixAttrs()
+	if ux == nil || len(ux.XAttrs) == 0 {
+		return nil
+	}
+	out := make(map[string][]byte, len(ux.XAttrs))
+	for k, v := range ux.XAttrs {
+		out[k] = append([]byte(nil), v...)
+	}
+	return out
+}
+
+// Digests returns the content digests stored in the revision XAttr,
+// keyed by algorithm (e.g. "SHA1", hex-encoded). Returns nil when none
+// are set or the XAttr has not been fetched. Decrypted on each call.
+func (l *Link) Digests() map[string]string {
 	if l.protonLink.Type != proton.LinkTypeFile {
-		return 0 // folders have no XAttr
+		return nil
+	}
+	xattr := l.decryptRevisionXAttr()
+	if xattr == nil || len(xattr.Common.Digests) == 0 {
+		return nil
+	}
+	return maps.Clone(xattr.Common.Digests)
+}
+
+// SetCachedMode updates the in-memory cached mode. Used after a
+// successful Chmod to reflect the change without re-decrypting XAttr.
+func (l *Link) SetCachedMode(mode uint32) {
+	_, mtime, ux := l.xattrAttrs()
+	l.setCachedUnixAttrs(mode, mtime, ux)
+}
+
+// setCachedUnixAttrs stores mode, modification time and Unix extensions
+// in the cache regardless of MemoryCacheLevel. Used after a commit
+// whose XAttr is known locally but not yet visible in the listing.
+func (l *Link) setCachedUnixAttrs(mode uint32, mtime time.Time, ux *unixXAttr) {
+	l.cacheMu.Lock()
+	defer l.cacheMu.Unlock()
+	l.cachedMode = mode
+	l.cachedModTime = mtime
+	l.cachedUnix = ux
+	l.cachedModeValid = true
+}
+
+// unixAttrs returns the Mode and Unix extensions from the revision
+// XAttr, decrypting on first call. The returned *unixXAttr is shared
+// and must not be modified.
+func (l *Link) unixAttrs() (uint32, *unixXAttr) {
+	mode, _, ux := l.xattrAttrs()
+	return mode, ux
+}
+
+// xattrAttrs returns the Mode, ModificationTime and Unix extensions
+// from the revision XAttr, decrypting on first call.
+func (l *Link) xattrAttrs() (uint32, time.Time, *unixXAttr) {
+	if l.protonLink.Type != proton.LinkTypeFile {
+		return 0, time.Time{}, nil // folders have no XAttr
 	}
 
 	l.cacheMu.RLock()
 	if l.cachedModeValid {
 		defer l.cacheMu.RUnlock()
-		return l.cachedMode
+		return l.cachedMode, l.cachedModTime, l.cachedUnix
 	}
 	l.cacheMu.RUnlock()
 
 	l.cacheMu.Lock()
 	defer l.cacheMu.Unlock()
 	if l.cachedModeValid {
-		return l.cachedMode
+		return l.cachedMode, l.cachedModTime, l.cachedUnix
 	}
 
-	mode := l.decryptMode()
-	if l.share != nil && l.share.MemoryCacheLevel >= api.CacheMetadata {
+	// Don't cache a missing XAttr — FetchRevisionXAttr may fill it in.
+	mode, mtime, ux := l.decryptUnixAttrs()
+	if l.hasXAttr() {
+		l.xattrModTime = mtime
+		l.xattrModTimeValid = true
+	}
+	if l.hasXAttr() && l.share != nil && l.share.MemoryCacheLevel >= api.CacheMetadata {
 		l.cachedMode = mode
+		l.cachedModTime = mtime
+		l.cachedUnix = ux
 		l.cachedModeValid = true
 	}
-	return mode
+	return mode, mtime, ux
 }
 
-// SetCachedMode updates the in-memory cached mode. Used after a
-// successful Chmod to reflect the change without re-decrypting XAttr.
-func (l *Link) SetCachedMode(mode uint32) {
-	l.cacheMu.Lock()
-	defer l.cacheMu.Unlock()
-	l.cachedMode = mode
-	l.cachedModeValid = true
+// modTimeAttr returns the ModificationTime from the revision XAttr,
+// decrypting it only the first time.
+func (l *Link) modTimeAttr() time.Time {
+	l.cacheMu.RLock()
+	switch {
+	case l.cachedModeValid:
+		defer l.cacheMu.RUnlock()
+		return l.cachedModTime
+	case l.xattrModTimeValid:
+		defer l.cacheMu.RUnlock()
+		return l.xattrModTime
+	}
+	l.cacheMu.RUnlock()
+
+	_, mtime, _ := l.xattrAttrs()
+	return mtime
+}
+
+// hasXAttr reports whether the active revision carries an XAttr.
+func (l *Link) hasXAttr() bool {
+	fp := l.protonLink.FileProperties
+	return fp != nil && fp.ActiveRevision.XAttr != ""
 }
 
-// decryptMode decrypts the XAttr and extracts the Mode field.
-// Returns 0 on any error (non-fatal — use default permissions).
-func (l *Link) decryptMode() uint32 {
-	if l.protonLink.FileProperties == nil {
-		return 0
+// decryptUnixAttrs decrypts the XAttr and extracts the Mode and
+// ModificationTime fields and Unix extensions. Returns zero values on
+// any error (non-fatal — use default permissions and upload time).
+func (l *Link) decryptUnixAttrs() (uint32, time.Time, *unixXAttr) {
+	xattr := l.decryptRevisionXAttr()
+	if xattr == nil {
+		return 0, time.Time{}, nil
 	}
-	rev := &l.protonLink.FileProperties.ActiveRevision
-	if rev.XAttr == "" {
-		return 0
+	mtime, _ := parseXAttrTime(xattr.Common.ModificationTime)
+	return xattr.Common.Mode, mtime, xattr.Unix
+}
+
+// decryptRevisionXAttr decrypts the XAttr of the active revision and
+// verifies its signature. Returns nil when there is no XAttr or on any
+// error.
+func (l *Link) decryptRevisionXAttr() *revisionXAttr {
+	if !l.hasXAttr() {
+		return nil
 	}
+	rev := &l.protonLink.FileProperties.ActiveRevision
 
 	nodeKR, err := l.KeyRing()
 	if err != nil {
-		return 0
+		return nil
 	}
 
 	// Get address keyring for signature verification.
 	email := rev.SignatureEmail
 	addr, ok := l.resolver.AddressForEmail(email)
 	if !ok {
-		return 0
+		return nil
 	}
 	addrKR, ok := l.resolver.AddressKeyRing(addr.ID)
 	if !ok {
-		return 0
+		return nil
 	}
 
-	xattr, err := rev.GetDecXAttrString(addrKR, nodeKR)
-	if err != nil || xattr == nil {
-		return 0
+	xattr, err := decryptXAttr(rev.XAttr, addrKR, nodeKR)
+	if err != nil {
+		return nil
 	}
-
-	return xattr.Mode
+	return xattr
 }
 
 // getParentKeyRing returns the parent's keyring for decryption.
@@ -0,0 +1,79 @@
+package drive
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+// offlineRetry is how long the client stays offline after a network
+// error before mutations try the API again.
+const offlineRetry = 30 * time.Second
+
+// IsNetworkError reports whether err means the API could not be reached,
+// as opposed to an error returned by the API. A canceled or expired
+// context is the caller's, not the network's, although
+// context.DeadlineExceeded is a net.Error.
+func IsNetworkError(err error) bool {
+	if isContextError(err) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isContextError reports whether err comes from a canceled or expired
+// context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Offline reports whether the API was unreachable within the last
+// offlineRetry. While offline, reads fall back to pinned data without
+// trying the API first, and callers should fail mutations instead of
+// waiting for a network timeout.
+func (c *Client) Offline() bool {
+	until := c.offlineUntil.Load()
+	return until != 0 && time.Now().UnixNano() < until
+}
+
+// noteAPIError records the outcome of an API call: a network error
+// takes the client offline, a context error tells nothing, anything
+// else brings it back online. Returns whether err is a network error.
+func (c *Client) noteAPIError(err error) bool {
+	if isContextError(err) {
+		return false
+	}
+	if err != nil && IsNetworkError(err) {
+		if !c.Offline() {
+			slog.Warn("drive: API unreachable, using pinned data", "error", err)
+		}
+		c.offlineUntil.Store(time.Now().Add(offlineRetry).UnixNano())
+		return true
+	}
+	if c.offlineUntil.Swap(0) != 0 {
+		slog.Info("drive: API reachable again")
+	}
+	return false
+}
+
+// getRevision fetches a revision with its full block list. While the
+// client is offline a pinned revision is returned instead.
+func (c *Client) getRevision(ctx context.Context, shareID, linkID, revisionID string) (proton.Revision, error) {
+	if c.Offline() {
+		if rev, ok := c.Pins.revision(linkID, revisionID); ok {
+			return rev, nil
+		}
+	}
+	rev, err := c.Session.Client.GetRevisionAllBlocks(ctx, shareID, linkID, revisionID)
+	if c.noteAPIError(err) {
+		if pinned, ok := c.Pins.revision(linkID, revisionID); ok {
+			return pinned, nil
+		}
+	}
+	return rev, err
+}
@@ -1,6 +1,10 @@
 package drive
 
-import "strings"
+import (
+	"path"
+	"strings"
+	"time"
+)
 
 // NormalizePath normalizes a drive path: collapses "." and ".." segments,
 // removes consecutive "/" separators, and trims leading "/".
@@ -31,3 +35,17 @@ func NormalizePath(raw string) (string, error) {
 	}
 	return result, nil
 }
+
+// ConflictName returns the name under which a conflicting version of
+// the file name, made at t, is saved beside it: the stem, a timestamp,
+// then the original extension, so "notes.txt" becomes
+// "notes.conflict-20060102-150405.txt".
+func ConflictName(name string, t time.Time) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	if stem == "" {
+		// Dotfile such as ".bashrc": treat the whole name as the stem.
+		stem, ext = name, ""
+	}
+	return stem + ".conflict-" + t.Format("20060102-150405") + ext
+}
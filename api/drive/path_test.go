@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"pgregory.net/rapid"
 )
@@ -128,3 +129,20 @@ func TestNormalizePath_Basic(t *testing.T) {
 		}
 	}
 }
+
+func TestConflictName(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		in, want string
+	}{
+		{"notes.txt", "notes.conflict-20260304-050607.txt"},
+		{"archive.tar.gz", "archive.tar.conflict-20260304-050607.gz"},
+		{"README", "README.conflict-20260304-050607"},
+		{".bashrc", ".bashrc.conflict-20260304-050607"},
+	}
+	for _, tt := range tests {
+		if got := ConflictName(tt.in, ts); got != tt.want {
+			t.Errorf("ConflictName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
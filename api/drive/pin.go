@@ -0,0 +1,442 @@
+package drive
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api"
+)
+
+// Pinning. A pinned file or folder is kept in the pin cache, an
+// ObjectCache of its own next to the pin list, so that ProtonFS can
+// serve it while the API is unreachable. The pin cache holds what the
+// API returns — link metadata, folder listings, revisions and blocks —
+// still encrypted. Unlike the object cache it is not touched by
+// Invalidate or Clear: an entry is only erased once no pin references
+// it. Blocks are keyed by revision, so a cached block is never stale.
+//
+// SyncPin fetches a pin's subtree along with the listings of its
+// ancestors, so that the path to it can be walked offline.
+
+// pinListName is the file name of the pin list.
+const pinListName = "pins.json"
+
+// Pin is a pinned file or folder.
+type Pin struct {
+	ShareID string `json:"share_id"`
+	LinkID  string `json:"link_id"`
+	// Path is the path the link was pinned by, for display.
+	Path   string    `json:"path"`
+	Synced time.Time `json:"synced,omitzero"`
+	Files  int       `json:"files"`
+	Bytes  int64     `json:"bytes"`
+	// Keys lists the pin cache entries of the pin as of its last sync.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// PinStore is the pin list of an account and the cache holding the
+// pinned data. The list is a file shared by every process of the
+// account, replaced atomically on each change.
+type PinStore struct {
+	dir   string
+	cache *api.ObjectCache
+	mu    sync.Mutex
+}
+
+// OpenPinStore opens the pin store in dir, creating it if needed.
+func OpenPinStore(dir string) (*PinStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("pins: %w", err)
+	}
+	return &PinStore{dir: dir, cache: api.NewObjectCache(filepath.Join(dir, "objects"))}, nil
+}
+
+// List returns the pins, sorted by path.
+func (p *PinStore) List() ([]Pin, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.load()
+}
+
+// Add records pin. A pin of the same link is replaced. Its data is
+// fetched by SyncPin.
+func (p *PinStore) Add(pin Pin) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pins, err := p.load()
+	if err != nil {
+		return err
+	}
+	if i := pinIndex(pins, pin.LinkID); i >= 0 {
+		pins[i] = pin
+	} else {
+		pins = append(pins, pin)
+	}
+	return p.save(pins)
+}
+
+// Remove removes the pin of linkID and erases the cached data no other
+// pin references. Returns false if linkID is not pinned.
+func (p *PinStore) Remove(linkID string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pins, err := p.load()
+	if err != nil {
+		return false, err
+	}
+	i := pinIndex(pins, linkID)
+	if i < 0 {
+		return false, nil
+	}
+	pins = slices.Delete(pins, i, i+1)
+	if err := p.save(pins); err != nil {
+		return false, err
+	}
+	p.gc(pins)
+	return true, nil
+}
+
+// update replaces a synced pin in the list and erases the entries its
+// previous sync referenced and no pin does any more. A pin removed
+// while it was synced stays removed.
+func (p *PinStore) update(pin Pin) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pins, err := p.load()
+	if err != nil {
+		return err
+	}
+	if i := pinIndex(pins, pin.LinkID); i >= 0 {
+		pins[i] = pin
+		if err := p.save(pins); err != nil {
+			return err
+		}
+	}
+	p.gc(pins)
+	return nil
+}
+
+// pinIndex returns the index of the pin of linkID, or -1.
+func pinIndex(pins []Pin, linkID string) int {
+	return slices.IndexFunc(pins, func(pin Pin) bool { return pin.LinkID == linkID })
+}
+
+// load reads the pin list. p.mu must be held.
+func (p *PinStore) load() ([]Pin, error) {
+	data, err := os.ReadFile(filepath.Join(p.dir, pinListName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pins: %w", err)
+	}
+	var pins []Pin
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("pins: %s: %w", pinListName, err)
+	}
+	return pins, nil
+}
+
+// save replaces the pin list. p.mu must be held.
+func (p *PinStore) save(pins []Pin) error {
+	slices.SortFunc(pins, func(a, b Pin) int { return strings.Compare(a.Path, b.Path) })
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("pins: %w", err)
+	}
+	if err := writeFileAtomic(p.dir, pinListName, data); err != nil {
+		return fmt.Errorf("pins: %w", err)
+	}
+	return nil
+}
+
+// gc erases the cache entries no pin references. p.mu must be held.
+func (p *PinStore) gc(pins []Pin) {
+	live := make(map[string]bool)
+	for _, pin := range pins {
+		for _, key := range pin.Keys {
+			live[key] = true
+		}
+	}
+	var dead []string
+	for key := range p.cache.Keys(nil) {
+		if !live[key] {
+			dead = append(dead, key)
+		}
+	}
+	for _, key := range dead {
+		_ = p.cache.Erase(key)
+	}
+}
+
+// Pin cache keys. Link and listing keys follow the object cache.
+func pinLinkKey(linkID string) string     { return SanitizeLinkID(linkID) }
+func pinChildrenKey(linkID string) string { return SanitizeLinkID(linkID) + ".children" }
+func pinRevisionKey(linkID string) string { return SanitizeLinkID(linkID) + ".revision" }
+func pinBlockKey(linkID, revisionID string, index int) string {
+	return fmt.Sprintf("%s.%s.block.%d", SanitizeLinkID(linkID), SanitizeLinkID(revisionID), index)
+}
+
+// get decodes the gob entry key into v. Returns false on a miss, on a
+// nil receiver, or when the entry does not decode.
+func (p *PinStore) get(key string, v any) bool {
+	if p == nil {
+		return false
+	}
+	data, _ := p.cache.Read(key)
+	if data == nil {
+		return false
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v) == nil
+}
+
+// link returns the pinned metadata of linkID.
+func (p *PinStore) link(linkID string) (proton.Link, bool) {
+	var pLink proton.Link
+	ok := p.get(pinLinkKey(linkID), &pLink)
+	return pLink, ok
+}
+
+// children returns the pinned listing of the folder linkID.
+func (p *PinStore) children(linkID string) ([]proton.Link, bool) {
+	var children []proton.Link
+	ok := p.get(pinChildrenKey(linkID), &children)
+	return children, ok
+}
+
+// revision returns the pinned revision of linkID if it is revisionID.
+func (p *PinStore) revision(linkID, revisionID string) (proton.Revision, bool) {
+	var rev proton.Revision
+	if !p.get(pinRevisionKey(linkID), &rev) || rev.ID != revisionID {
+		return proton.Revision{}, false
+	}
+	return rev, true
+}
+
+// blockStore returns a blockStore that serves the blocks of revision
+// revisionID of linkID from the pin cache, and everything else from
+// inner. Returns inner when the revision is not pinned.
+func (p *PinStore) blockStore(inner blockStore, linkID, revisionID string) blockStore {
+	if _, ok := p.revision(linkID, revisionID); !ok {
+		return inner
+	}
+	return &pinnedBlockStore{blockStore: inner, cache: p.cache, revisionID: revisionID}
+}
+
+// pinnedBlockStore reads the blocks of a pinned revision from the pin
+// cache before falling back to the wrapped blockStore.
+type pinnedBlockStore struct {
+	blockStore
+	cache      *api.ObjectCache
+	revisionID string
+}
+
+// GetBlock returns the pinned block, or fetches it from the wrapped store.
+func (s *pinnedBlockStore) GetBlock(ctx context.Context, linkID string, index int, bareURL, token string) ([]byte, error) {
+	if data, _ := s.cache.Read(pinBlockKey(linkID, s.revisionID, index)); data != nil {
+		return data, nil
+	}
+	return s.blockStore.GetBlock(ctx, linkID, index, bareURL, token)
+}
+
+// fetchBlock returns the pinned block, or fetches it from the wrapped store.
+func (s *pinnedBlockStore) fetchBlock(ctx context.Context, linkID string, index int, bareURL, token string) ([]byte, error) {
+	if data, _ := s.cache.Read(pinBlockKey(linkID, s.revisionID, index)); data != nil {
+		return data, nil
+	}
+	return s.blockStore.fetchBlock(ctx, linkID, index, bareURL, token)
+}
+
+// SyncPin fetches the data of pin into the pin cache and records the
+// entries it references; entries of a previous sync that are no longer
+// referenced are erased. Blocks already cached are not fetched again.
+// The pin must have been added to c.Pins.
+func (c *Client) SyncPin(ctx context.Context, pin Pin) (Pin, error) {
+	if c.Pins == nil {
+		return pin, errors.New("pins: no pin store")
+	}
+	s := &pinSync{c: c, shareID: pin.ShareID, keys: make(map[string]bool)}
+
+	pLink, err := s.getLink(ctx, pin.LinkID)
+	if err != nil {
+		return pin, err
+	}
+	if pLink.State != proton.LinkStateActive {
+		return pin, fmt.Errorf("pin %s: %w", pin.Path, ErrFileNotFound)
+	}
+	for id := pLink.ParentLinkID; id != ""; {
+		parent, err := s.getLink(ctx, id)
+		if err != nil {
+			return pin, err
+		}
+		if _, err := s.listChildren(ctx, id); err != nil {
+			return pin, err
+		}
+		id = parent.ParentLinkID
+	}
+	if err := s.walk(ctx, pLink); err != nil {
+		return pin, err
+	}
+
+	pin.Keys = slices.Sorted(maps.Keys(s.keys))
+	pin.Files, pin.Bytes = s.files, s.bytes
+	pin.Synced = time.Now()
+	return pin, c.Pins.update(pin)
+}
+
+// SyncPins syncs every pin, logging the pins that fail. Returns the
+// first error.
+func (c *Client) SyncPins(ctx context.Context) error {
+	if c.Pins == nil {
+		return nil
+	}
+	pins, err := c.Pins.List()
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, pin := range pins {
+		if _, err := c.SyncPin(ctx, pin); err != nil {
+			slog.Warn("pins: sync failed", "path", pin.Path, "linkID", pin.LinkID, "error", err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// pinSync is the state of one SyncPin.
+type pinSync struct {
+	c       *Client
+	shareID string
+	keys    map[string]bool
+	files   int
+	bytes   int64
+}
+
+// put stores v as the gob entry key and marks the entry as referenced.
+func (s *pinSync) put(key string, v any) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return fmt.Errorf("pin: encode %s: %w", key, err)
+	}
+	if err := s.c.Pins.cache.Write(key, buf.Bytes()); err != nil {
+		return fmt.Errorf("pin: %w", err)
+	}
+	s.keys[key] = true
+	return nil
+}
+
+// getLink fetches and stores the metadata of linkID.
+func (s *pinSync) getLink(ctx context.Context, linkID string) (proton.Link, error) {
+	pLink, err := s.c.Session.Client.GetLink(ctx, s.shareID, linkID)
+	if err != nil {
+		return proton.Link{}, fmt.Errorf("pin: stat %s: %w", linkID, err)
+	}
+	return pLink, s.put(pinLinkKey(linkID), pLink)
+}
+
+// listChildren fetches and stores the listing of the folder linkID and
+// the metadata of its children.
+func (s *pinSync) listChildren(ctx context.Context, linkID string) ([]proton.Link, error) {
+	children, err := s.c.Session.Client.ListChildren(ctx, s.shareID, linkID, true)
+	if err != nil {
+		return nil, fmt.Errorf("pin: list %s: %w", linkID, err)
+	}
+	if err := s.put(pinChildrenKey(linkID), children); err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := s.put(pinLinkKey(children[i].LinkID), children[i]); err != nil {
+			return nil, err
+		}
+	}
+	return children, nil
+}
+
+// walk stores the subtree of pLink, whose metadata is already stored.
+func (s *pinSync) walk(ctx context.Context, pLink proton.Link) error {
+	switch pLink.Type {
+	case proton.LinkTypeFolder:
+		children, err := s.listChildren(ctx, pLink.LinkID)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if child.State != proton.LinkStateActive {
+				continue
+			}
+			if err := s.walk(ctx, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case proton.LinkTypeFile:
+		return s.file(ctx, pLink)
+	default:
+		return nil
+	}
+}
+
+// file stores the active revision of a file and its blocks.
+func (s *pinSync) file(ctx context.Context, pLink proton.Link) error {
+	if pLink.FileProperties == nil || pLink.FileProperties.ActiveRevision.ID == "" {
+		return nil
+	}
+	revID := pLink.FileProperties.ActiveRevision.ID
+	rev, ok := s.c.Pins.revision(pLink.LinkID, revID)
+	if !ok {
+		var err error
+		if rev, err = s.c.Session.Client.GetRevisionAllBlocks(ctx, s.shareID, pLink.LinkID, revID); err != nil {
+			return fmt.Errorf("pin: %s: get revision: %w", pLink.LinkID, err)
+		}
+	}
+
+	for i, block := range rev.Blocks {
+		key := pinBlockKey(pLink.LinkID, revID, i+1)
+		s.keys[key] = true
+		if s.c.Pins.cache.Has(key) {
+			continue
+		}
+		data, err := s.fetchBlock(ctx, block.BareURL, block.Token)
+		if err != nil {
+			return fmt.Errorf("pin: %s block %d: %w", pLink.LinkID, i+1, err)
+		}
+		if err := s.c.Pins.cache.Write(key, data); err != nil {
+			return fmt.Errorf("pin: %w", err)
+		}
+	}
+
+	// The revision goes last, so that it is found with all its blocks.
+	if err := s.put(pinRevisionKey(pLink.LinkID), rev); err != nil {
+		return err
+	}
+	s.files++
+	s.bytes += pLink.FileProperties.ActiveRevision.Size
+	return nil
+}
+
+// fetchBlock downloads an encrypted block.
+func (s *pinSync) fetchBlock(ctx context.Context, bareURL, token string) ([]byte, error) {
+	rc, err := s.c.Session.Client.GetBlock(ctx, bareURL, token)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
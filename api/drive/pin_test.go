@@ -0,0 +1,160 @@
+package drive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+// TestPinStoreAddRemove verifies that pins are kept sorted by path, that
+// adding a pinned link replaces it, and that removing a pin erases the
+// cache entries no other pin references.
+func TestPinStoreAddRemove(t *testing.T) {
+	p, err := OpenPinStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenPinStore: %v", err)
+	}
+	for _, pin := range []Pin{
+		{ShareID: "share", LinkID: "link-b", Path: "proton://b"},
+		{ShareID: "share", LinkID: "link-a", Path: "proton://a"},
+		{ShareID: "share", LinkID: "link-b", Path: "proton://b2"},
+	} {
+		if err := p.Add(pin); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	pins, err := p.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var paths []string
+	for _, pin := range pins {
+		paths = append(paths, pin.Path)
+	}
+	if want := []string{"proton://a", "proton://b2"}; !slices.Equal(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+
+	for _, key := range []string{"shared", "only-a", "only-b", "stray"} {
+		if err := p.cache.Write(key, []byte(key)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := p.update(Pin{ShareID: "share", LinkID: "link-a", Path: "proton://a", Keys: []string{"shared", "only-a"}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := p.update(Pin{ShareID: "share", LinkID: "link-b", Path: "proton://b2", Keys: []string{"shared", "only-b"}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if p.cache.Has("stray") {
+		t.Error("unreferenced entry survived update")
+	}
+
+	removed, err := p.Remove("link-a")
+	if err != nil || !removed {
+		t.Fatalf("Remove = %v, %v; want true", removed, err)
+	}
+	if p.cache.Has("only-a") {
+		t.Error("entry of removed pin survived")
+	}
+	if !p.cache.Has("shared") || !p.cache.Has("only-b") {
+		t.Error("entry of remaining pin erased")
+	}
+	if removed, _ := p.Remove("link-a"); removed {
+		t.Error("Remove of unpinned link reported true")
+	}
+
+	// A pin removed during its sync stays removed.
+	if err := p.update(Pin{LinkID: "link-a", Path: "proton://a"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if pins, _ := p.List(); len(pins) != 1 || pins[0].LinkID != "link-b" {
+		t.Errorf("pins after update of removed pin = %+v", pins)
+	}
+}
+
+// TestPinnedBlockStore verifies that the blocks of a pinned revision are
+// served from the pin cache and other blocks from the wrapped store.
+func TestPinnedBlockStore(t *testing.T) {
+	p, err := OpenPinStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenPinStore: %v", err)
+	}
+	s := &pinSync{c: &Client{Pins: p}, keys: make(map[string]bool)}
+	rev := proton.Revision{RevisionMetadata: proton.RevisionMetadata{ID: "rev-1"}}
+	if err := s.put(pinRevisionKey("link"), rev); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	pinned := []byte("pinned block")
+	if err := p.cache.Write(pinBlockKey("link", "rev-1", 1), pinned); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	inner := newMockStore("link", map[int][]byte{1: []byte("remote 1"), 2: []byte("remote 2")})
+
+	if bs := p.blockStore(inner, "link", "rev-0"); bs != blockStore(inner) {
+		t.Error("blockStore of an unpinned revision is not the wrapped store")
+	}
+	var nilStore *PinStore
+	if bs := nilStore.blockStore(inner, "link", "rev-1"); bs != blockStore(inner) {
+		t.Error("blockStore of a nil PinStore is not the wrapped store")
+	}
+
+	bs := p.blockStore(inner, "link", "rev-1")
+	ctx := context.Background()
+	tests := []struct {
+		index int
+		want  []byte
+	}{
+		{1, pinned},
+		{2, []byte("remote 2")},
+	}
+	for _, tt := range tests {
+		got, err := bs.GetBlock(ctx, "link", tt.index, "", "")
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("GetBlock(%d) = %q, %v; want %q", tt.index, got, err, tt.want)
+		}
+		got, err = bs.fetchBlock(ctx, "link", tt.index, "", "")
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("fetchBlock(%d) = %q, %v; want %q", tt.index, got, err, tt.want)
+		}
+	}
+}
+
+// TestClientOffline verifies that network errors take the client
+// offline and that any other outcome brings it back.
+func TestClientOffline(t *testing.T) {
+	netErr := fmt.Errorf("list children: %w", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})
+	tests := []struct {
+		name        string
+		err         error
+		wantNetwork bool
+		wantOffline bool
+	}{
+		{"network error", netErr, true, true},
+		{"API error", errors.New("422 unprocessable"), false, false},
+		{"network error again", netErr, true, true},
+		{"caller deadline", fmt.Errorf("get link: %w", context.DeadlineExceeded), false, true},
+		{"caller canceled", context.Canceled, false, true},
+		{"success", nil, false, false},
+	}
+
+	c := &Client{}
+	if c.Offline() {
+		t.Fatal("new client is offline")
+	}
+	for _, tt := range tests {
+		if got := c.noteAPIError(tt.err); got != tt.wantNetwork {
+			t.Errorf("%s: noteAPIError = %v, want %v", tt.name, got, tt.wantNetwork)
+		}
+		if got := c.Offline(); got != tt.wantOffline {
+			t.Errorf("%s: Offline = %v, want %v", tt.name, got, tt.wantOffline)
+		}
+	}
+}
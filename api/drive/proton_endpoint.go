@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
@@ -116,6 +117,8 @@ type ProtonWriter struct {
 	totalSize int64
 	closed    bool // prevents double-commit
 	unixMode  uint32
+	modTime   time.Time
+	xattrs    map[string][]byte
 }
 
 // uploadedBlock holds the result of a single block upload.
@@ -173,6 +176,8 @@ func (w *ProtonWriter) uploadParams() uploadParams {
 		revisionID: w.revisionID,
 		sigAddr:    w.sigAddr,
 		unixMode:   w.unixMode,
+		modTime:    w.modTime,
+		xattrs:     w.xattrs,
 	}
 }
 
@@ -202,6 +207,22 @@ func (w *ProtonWriter) SetMode(mode uint32) {
 	w.unixMode = mode
 }
 
+// SetModTime sets the modification time to store in the revision XAttr.
+// Must be called before Close(). Zero means "use the commit time".
+func (w *ProtonWriter) SetModTime(t time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.modTime = t
+}
+
+// SetXAttrs sets the extended attributes to store in the revision
+// XAttr. Must be called before Close(). The map is not copied.
+func (w *ProtonWriter) SetXAttrs(xattrs map[string][]byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.xattrs = xattrs
+}
+
 // Close commits the revision by signing the manifest and calling
 // UpdateRevision with block tokens, XAttr, and manifest signature.
 func (w *ProtonWriter) Close() error {
@@ -213,6 +234,10 @@ func (w *ProtonWriter) Close() error {
 	w.closed = true
 	w.mu.Unlock()
 
+	if len(w.uploaded) == 0 {
+		return nil
+	}
+
 	// Use context.Background() to ensure commit completes even after
 	// pipeline context cancellation.
 	return commitRevisionFromTokens(context.Background(), w.session, w.uploadParams(), w.uploaded)
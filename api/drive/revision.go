@@ -0,0 +1,44 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+// Revisions returns the committed revisions of a file, the active one
+// included, in the order the API lists them. Draft revisions are
+// skipped.
+func (c *Client) Revisions(ctx context.Context, link *Link) ([]proton.RevisionMetadata, error) {
+	if link.Type() != proton.LinkTypeFile {
+		return nil, fmt.Errorf("Revisions: %s: not a file", link.LinkID())
+	}
+	shareID := link.Share().ProtonShare().ShareID
+	revs, err := c.Session.Client.ListRevisions(ctx, shareID, link.LinkID())
+	c.noteAPIError(err)
+	if err != nil {
+		return nil, fmt.Errorf("Revisions: %s: %w", link.LinkID(), err)
+	}
+	out := revs[:0]
+	for _, rev := range revs {
+		if rev.State != proton.RevisionStateDraft {
+			out = append(out, rev)
+		}
+	}
+	return out, nil
+}
+
+// OpenRevisionFD opens a revision of a Proton Drive file for reading,
+// as OpenFD does for the active revision. rev is one of the revisions
+// returned by Revisions.
+func (c *Client) OpenRevisionFD(ctx context.Context, link *Link, rev proton.RevisionMetadata) (*FileDescriptor, error) {
+	if link.Type() != proton.LinkTypeFile {
+		return nil, fmt.Errorf("OpenRevisionFD: %s: not a file", link.LinkID())
+	}
+	fh, err := c.openRevision(ctx, "OpenRevisionFD", link, rev.ID, rev.Size)
+	if err != nil {
+		return nil, err
+	}
+	return c.readFD(ctx, fh, link), nil
+}
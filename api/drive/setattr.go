@@ -0,0 +1,73 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// SetAttrOpts selects the revision attributes SetAttr changes. Zero
+// fields leave the current value in place.
+type SetAttrOpts struct {
+	Mode         *uint32           // permission bits (0o7777)
+	ModTime      time.Time         // modification time
+	XAttrs       map[string][]byte // extended attributes to add or replace
+	RemoveXAttrs []string          // extended attributes to delete
+}
+
+// SetAttr updates the mode, modification time, and extended attributes
+// of a file in a single new revision. Like Chmod, this re-uploads the
+// content because the API only accepts XAttr on draft revisions. The
+// symlink target of a symlink file is kept.
+func (c *Client) SetAttr(ctx context.Context, share *Share, link *Link, opts SetAttrOpts) error {
+	c.FetchRevisionXAttr(ctx, link)
+	mode, old := link.unixAttrs()
+	if opts.Mode != nil {
+		mode = *opts.Mode & 0o7777
+	}
+
+	var symlink string
+	xattrs := make(map[string][]byte)
+	if old != nil {
+		symlink = old.Symlink
+		maps.Copy(xattrs, old.XAttrs)
+	}
+	maps.Copy(xattrs, opts.XAttrs)
+	for _, name := range opts.RemoveXAttrs {
+		delete(xattrs, name)
+	}
+
+	return c.rewriteRevision(ctx, share, link, "drive.SetAttr", mode, opts.ModTime, newUnixXAttr(symlink, xattrs))
+}
+
+// Chtimes sets the modification time stored in a file's revision
+// XAttr, re-uploading the content as a new revision. A zero mtime
+// means now.
+func (c *Client) Chtimes(ctx context.Context, share *Share, link *Link, mtime time.Time) error {
+	if mtime.IsZero() {
+		mtime = time.Now()
+	}
+	c.FetchRevisionXAttr(ctx, link)
+	mode, ux := link.unixAttrs()
+	return c.rewriteRevision(ctx, share, link, "drive.Chtimes", mode, mtime, ux)
+}
+
+// CreateEmpty creates an empty file named name under parent, with the
+// given mode and modification time (zero means now). Unlike an FD
+// closed without writes, which leaves a draft, the file is committed.
+func (c *Client) CreateEmpty(ctx context.Context, share *Share, parent *Link, name string, mode uint32, mtime time.Time) (*Link, error) {
+	fd, err := c.CreateFD(ctx, share, parent, name)
+	if err != nil {
+		return nil, fmt.Errorf("drive.CreateEmpty %s: %w", name, err)
+	}
+	fd.SetMode(mode)
+	fd.SetModTime(mtime)
+	fd.SetCommitEmpty(true)
+	if err := fd.Close(); err != nil {
+		return fd.Link(), fmt.Errorf("drive.CreateEmpty %s: commit: %w", name, err)
+	}
+	link := fd.Link()
+	link.setCachedUnixAttrs(mode, mtime, nil)
+	return link, nil
+}
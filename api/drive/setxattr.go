@@ -0,0 +1,20 @@
+package drive
+
+import (
+	"context"
+	"time"
+)
+
+// SetXAttrs replaces the extended attributes of a file by committing a
+// new revision with the same content, mode, and symlink target. Like
+// Chmod, this re-uploads the file. A nil or empty map removes all
+// extended attributes.
+func (c *Client) SetXAttrs(ctx context.Context, share *Share, link *Link, xattrs map[string][]byte) error {
+	c.FetchRevisionXAttr(ctx, link)
+	mode, old := link.unixAttrs()
+	var symlink string
+	if old != nil {
+		symlink = old.Symlink
+	}
+	return c.rewriteRevision(ctx, share, link, "drive.SetXAttrs", mode, time.Time{}, newUnixXAttr(symlink, xattrs))
+}
@@ -0,0 +1,873 @@
+package drive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+)
+
+// Write-back spooling. A write-back FD keeps the blocks it modifies in
+// a spool job on local disk instead of uploading them, so that closing
+// it needs no network I/O. Close queues the job, and the Spool's
+// uploader replays it later as a copy-on-write commit onto the file's
+// current revision, retrying until it succeeds.
+//
+// A job is only replayed onto the revision it was written against. If
+// the file changed on the server in the meantime, or was trashed, the
+// job's content is saved as a new file next to it instead, named by
+// ConflictName, and the conflict is reported by Flush. A
+// job whose file can no longer be read at all stays in the spool
+// directory, out of the queue, until the next start retries it.
+//
+// A job is a directory under the spool directory holding one file per
+// saved block and a manifest. Blocks are encrypted with the file's
+// content session key, as in a revision, so the spool holds neither
+// plaintext nor keys. Flush rewrites the manifest atomically after the
+// blocks it lists are on disk; block files not in the manifest are
+// discarded on recovery, so a restarted daemon uploads each file as it
+// was at its last close(2) or flush.
+
+// manifestName is the file name of a spool job's manifest.
+const manifestName = "manifest.json"
+
+// Uploader retry backoff bounds.
+const (
+	spoolMinBackoff = 5 * time.Second
+	spoolMaxBackoff = 5 * time.Minute
+)
+
+// spoolManifest is the on-disk description of a spool job.
+type spoolManifest struct {
+	ShareID string `json:"share_id"`
+	// Path holds the link IDs from the share root (excluded) down to
+	// the file, so the uploader can rebuild the key chain.
+	Path []string `json:"path"`
+	// Base is the revision the job's blocks were written against.
+	Base string `json:"base_revision,omitempty"`
+	Size int64  `json:"size"`
+	// Blocks maps block indices to the files holding them.
+	Blocks      map[int]string `json:"blocks"`
+	CommitEmpty bool           `json:"commit_empty,omitempty"`
+	Attempts    int            `json:"attempts,omitempty"`
+}
+
+// linkID returns the ID of the file the job writes.
+func (m *spoolManifest) linkID() string {
+	if len(m.Path) == 0 {
+		return ""
+	}
+	return m.Path[len(m.Path)-1]
+}
+
+// spoolJob is one file's pending write-back. It is owned by its FD,
+// under fd.mu, until Close releases it to the Spool.
+type spoolJob struct {
+	spool    *Spool
+	id       string
+	dir      string
+	manifest spoolManifest  // as last saved
+	files    map[int]string // current block files, saved or not
+	seq      int            // suffix of the next block file
+	saved    bool           // the manifest is on disk
+
+	// Uploader state, under spool.mu.
+	notBefore time.Time
+	readers   int  // open FDs reading the queued job
+	finished  bool // uploaded; removed once the last reader closes
+}
+
+// has reports whether the job holds block idx.
+func (j *spoolJob) has(idx int) bool {
+	_, ok := j.files[idx]
+	return ok
+}
+
+// pending reports whether the job holds data not yet uploaded.
+func (j *spoolJob) pending() bool {
+	return j.saved || len(j.files) > 0
+}
+
+// writeBlock encrypts data with the FD's session key and stores it as
+// block idx.
+func (j *spoolJob) writeBlock(fd *FileDescriptor, idx int, data []byte) error {
+	enc, err := fd.sessionKey.Encrypt(crypto.NewPlainMessage(data))
+	if err != nil {
+		return fmt.Errorf("spool %s: encrypt block %d: %w", j.id, idx, err)
+	}
+	name := strconv.Itoa(idx) + "." + strconv.Itoa(j.seq)
+	j.seq++
+	if err := writeFileSync(filepath.Join(j.dir, name), enc); err != nil {
+		return fmt.Errorf("spool %s: %w", j.id, err)
+	}
+	if old, ok := j.files[idx]; ok && !j.isSaved(idx, old) {
+		_ = os.Remove(filepath.Join(j.dir, old))
+	}
+	j.files[idx] = name
+	return nil
+}
+
+// readBlock returns the plaintext of block idx.
+func (j *spoolJob) readBlock(fd *FileDescriptor, idx int) ([]byte, error) {
+	enc, err := os.ReadFile(filepath.Join(j.dir, j.files[idx]))
+	if err != nil {
+		return nil, fmt.Errorf("spool %s: %w", j.id, err)
+	}
+	plain, err := fd.decryptBlock(enc)
+	if err != nil {
+		return nil, fmt.Errorf("spool %s: decrypt block %d: %w", j.id, idx, err)
+	}
+	return plain, nil
+}
+
+// isSaved reports whether name is the saved file of block idx.
+func (j *spoolJob) isSaved(idx int, name string) bool {
+	return j.manifest.Blocks[idx] == name
+}
+
+// truncate drops the blocks at and past index keep. Saved block files
+// stay until the next save replaces the manifest.
+func (j *spoolJob) truncate(keep int) {
+	for idx, name := range j.files {
+		if idx < keep {
+			continue
+		}
+		if !j.isSaved(idx, name) {
+			_ = os.Remove(filepath.Join(j.dir, name))
+		}
+		delete(j.files, idx)
+	}
+}
+
+// save writes the FD's dirty blocks to the job and records the file's
+// state in the manifest. fd.mu must be held.
+func (j *spoolJob) save(fd *FileDescriptor) error {
+	for _, idx := range slices.Sorted(maps.Keys(fd.dirty)) {
+		if err := j.writeBlock(fd, idx, padBlock(fd.dirty[idx], fd.blockLen(idx))); err != nil {
+			return err
+		}
+		delete(fd.dirty, idx)
+	}
+
+	m := j.manifest
+	m.Size = fd.fileSize
+	m.CommitEmpty = m.CommitEmpty || fd.commitEmpty
+	m.Blocks = maps.Clone(j.files)
+	if err := writeManifest(j.dir, &m); err != nil {
+		return fmt.Errorf("spool %s: %w", j.id, err)
+	}
+
+	// Files of the previous manifest that were replaced are garbage.
+	for idx, name := range j.manifest.Blocks {
+		if m.Blocks[idx] != name {
+			_ = os.Remove(filepath.Join(j.dir, name))
+		}
+	}
+	j.manifest = m
+	j.saved = true
+	fd.modified = false
+	fd.commitEmpty = false
+	return nil
+}
+
+// reset empties the job after its content was committed by other
+// means (Sync) as revision base, which later writes are based on. Its
+// directory is kept for them.
+func (j *spoolJob) reset(base string) error {
+	if err := os.Remove(filepath.Join(j.dir, manifestName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("spool %s: %w", j.id, err)
+	}
+	for _, name := range j.files {
+		_ = os.Remove(filepath.Join(j.dir, name))
+	}
+	for _, name := range j.manifest.Blocks {
+		_ = os.Remove(filepath.Join(j.dir, name))
+	}
+	j.files = make(map[int]string)
+	j.manifest.Blocks = nil
+	j.manifest.Base = base
+	j.manifest.CommitEmpty = false
+	j.saved = false
+	return nil
+}
+
+// release hands the job over to the uploader once its FD is closed. A
+// job with nothing saved is discarded.
+func (j *spoolJob) release() {
+	if !j.saved {
+		_ = os.RemoveAll(j.dir)
+		return
+	}
+	// Unsaved block files were written after the last flush; the saved
+	// state is what gets uploaded.
+	for idx, name := range j.files {
+		if !j.isSaved(idx, name) {
+			_ = os.Remove(filepath.Join(j.dir, name))
+		}
+	}
+	j.files = maps.Clone(j.manifest.Blocks)
+	j.spool.enqueue(j)
+}
+
+// Spool is the write-back queue of a Client: it creates the spool jobs
+// of write-back FDs and uploads them in the background. Jobs persist
+// in a directory and are recovered by NewSpool after a restart.
+type Spool struct {
+	dir    string
+	client *Client
+
+	mu      sync.Mutex
+	queue   []*spoolJob
+	pending map[string]*spoolLink // keyed by link ID
+	wake    chan struct{}
+	seq     int
+	failed  []error // jobs not written back since the last Flush
+}
+
+// spoolLink tracks the queued jobs of one file.
+type spoolLink struct {
+	jobs int
+	size int64         // size of the file after its last queued job
+	done chan struct{} // closed when the last job is uploaded
+}
+
+// NewSpool opens the spool in dir, creating it if needed, and queues
+// the jobs left there by a previous run. Jobs that were never flushed
+// are removed. Call Run to start uploading.
+func NewSpool(dir string, client *Client) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	s := &Spool{
+		dir:     dir,
+		client:  client,
+		pending: make(map[string]*spoolLink),
+		wake:    make(chan struct{}, 1),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	// Job IDs start with their creation time, so name order is queue
+	// order.
+	sort.Slice(entries, func(i, k int) bool { return entries[i].Name() < entries[k].Name() })
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		j, err := s.recover(e.Name())
+		if err != nil {
+			slog.Warn("spool: discarding job", "job", e.Name(), "error", err)
+			_ = os.RemoveAll(filepath.Join(dir, e.Name()))
+			continue
+		}
+		if j == nil {
+			_ = os.RemoveAll(filepath.Join(dir, e.Name()))
+			continue
+		}
+		slog.Info("spool: recovered job", "job", j.id, "linkID", j.manifest.linkID())
+		s.enqueue(j)
+	}
+	return s, nil
+}
+
+// recover loads the job in directory id, removing block files its
+// manifest does not list. Returns nil for a job without a manifest.
+func (s *Spool) recover(id string) (*spoolJob, error) {
+	dir := filepath.Join(s.dir, id)
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m spoolManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	if m.ShareID == "" || m.linkID() == "" {
+		return nil, errors.New("manifest: no file")
+	}
+
+	listed := make(map[string]bool, len(m.Blocks))
+	for _, name := range m.Blocks {
+		listed[name] = true
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if name := e.Name(); name != manifestName && !listed[name] {
+			_ = os.Remove(filepath.Join(dir, name))
+		}
+	}
+	if m.Blocks == nil {
+		m.Blocks = make(map[int]string)
+	}
+	return &spoolJob{spool: s, id: id, dir: dir, manifest: m, files: maps.Clone(m.Blocks), saved: true}, nil
+}
+
+// Attach makes fd a write-back FD: later block evictions, Flush and
+// Close go to a new spool job instead of the server. fd must be a
+// write-mode FD with no pending writes.
+func (s *Spool) Attach(fd *FileDescriptor) error {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if fd.mode != fdWrite {
+		return fmt.Errorf("spool: attach %s: not a write FD", fd.linkID)
+	}
+	if fd.link == nil {
+		return fmt.Errorf("spool: attach %s: no link", fd.linkID)
+	}
+
+	var path []string
+	for l := fd.link; l != nil && l.ParentLink() != nil; l = l.ParentLink() {
+		path = append(path, l.LinkID())
+	}
+	slices.Reverse(path)
+
+	s.mu.Lock()
+	s.seq++
+	id := fmt.Sprintf("%016x-%04x", time.Now().UnixNano(), s.seq&0xffff)
+	s.mu.Unlock()
+
+	dir := filepath.Join(s.dir, id)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	fd.spool = &spoolJob{
+		spool: s,
+		id:    id,
+		dir:   dir,
+		manifest: spoolManifest{
+			ShareID: fd.shareID,
+			Path:    path,
+			Base:    fd.baseRevision,
+		},
+		files: make(map[int]string),
+	}
+	return nil
+}
+
+// enqueue adds a released or recovered job to the upload queue.
+func (s *Spool) enqueue(j *spoolJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, j)
+	id := j.manifest.linkID()
+	pl := s.pending[id]
+	if pl == nil {
+		pl = &spoolLink{done: make(chan struct{})}
+		s.pending[id] = pl
+	}
+	pl.jobs++
+	pl.size = j.manifest.Size
+	s.signal()
+}
+
+// signal wakes the uploader. s.mu must be held.
+func (s *Spool) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns the size the file with the given link ID has once
+// its queued jobs are uploaded, and whether it has any.
+func (s *Spool) Pending(linkID string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pl := s.pending[linkID]; pl != nil {
+		return pl.size, true
+	}
+	return 0, false
+}
+
+// Wait blocks until the file with the given link ID has no queued
+// jobs, so that opening it sees its last written content.
+func (s *Spool) Wait(ctx context.Context, linkID string) error {
+	s.mu.Lock()
+	pl := s.pending[linkID]
+	s.mu.Unlock()
+	if pl == nil {
+		return nil
+	}
+	select {
+	case <-pl.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OpenFD opens a file with queued write-back jobs for reading, without
+// waiting for their upload: it reads as the first queued job leaves it,
+// the spooled blocks over those of the job's base revision. It returns
+// nil if the file has no queued job, or if the file moved on from the
+// job's base, so that the job will not be written to it.
+func (s *Spool) OpenFD(ctx context.Context, link *Link) (*FileDescriptor, error) {
+	s.mu.Lock()
+	var j *spoolJob
+	for _, q := range s.queue {
+		if q.manifest.linkID() == link.LinkID() {
+			j = q
+			j.readers++
+			break
+		}
+	}
+	s.mu.Unlock()
+	if j == nil {
+		return nil, nil
+	}
+	if base := j.manifest.Base; base != "" && link.RevisionID() != base {
+		s.unread(j)
+		return nil, nil
+	}
+
+	fd, err := s.baseFD(ctx, j, link)
+	if err != nil {
+		s.unread(j)
+		return nil, fmt.Errorf("spool: open %s: %w", link.LinkID(), err)
+	}
+	// A queued job's block files no longer change, so the FD reads
+	// them without holding s.mu.
+	fd.mode = fdSpoolRead
+	fd.spool = j
+	fd.fileSize = j.manifest.Size
+	return fd, nil
+}
+
+// unread lets go of a job opened by OpenFD, removing it if it was
+// uploaded in the meantime.
+func (s *Spool) unread(j *spoolJob) {
+	s.mu.Lock()
+	j.readers--
+	remove := j.finished && j.readers == 0
+	s.mu.Unlock()
+	if remove {
+		s.remove(j)
+	}
+}
+
+// Len returns the number of queued jobs.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Flush makes the jobs waiting for a retry due now, wakes the uploader
+// and waits until the jobs queued when it was called are uploaded or
+// set aside. It returns the errors of the jobs that were not written
+// back to their file since the last Flush. Run must be running for
+// Flush to return before ctx ends.
+func (s *Spool) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	done := make([]chan struct{}, 0, len(s.pending))
+	for _, pl := range s.pending {
+		done = append(done, pl.done)
+	}
+	for _, j := range s.queue {
+		j.notBefore = time.Time{}
+	}
+	s.signal()
+	s.mu.Unlock()
+
+	for _, ch := range done {
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := errors.Join(s.failed...)
+	s.failed = nil
+	return err
+}
+
+// Run uploads queued jobs until ctx is canceled. Jobs of one file are
+// uploaded in order; a failed job is retried with exponential backoff
+// and holds back the later jobs of its file only. Jobs still queued
+// when Run returns stay on disk for the next NewSpool.
+func (s *Spool) Run(ctx context.Context) {
+	for {
+		j, wait := s.next(time.Now())
+		if j == nil {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-s.wake:
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+
+		err := s.upload(ctx, j)
+		if ctx.Err() != nil {
+			return
+		}
+		var conflict *conflictError
+		switch {
+		case err == nil:
+			slog.Debug("spool: uploaded", "job", j.id, "linkID", j.manifest.linkID())
+			s.finish(j)
+		case errors.As(err, &conflict):
+			s.fail(j, err)
+			s.finish(j)
+		case isGone(err):
+			s.park(j, err)
+		default:
+			s.retry(j, err)
+		}
+	}
+}
+
+// next returns the first job that is due and not behind another job
+// of its file, or how long to wait for one.
+func (s *Spool) next(now time.Time) (*spoolJob, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := spoolMaxBackoff
+	blocked := make(map[string]bool)
+	for _, j := range s.queue {
+		id := j.manifest.linkID()
+		if blocked[id] {
+			continue
+		}
+		blocked[id] = true
+		if d := j.notBefore.Sub(now); d > 0 {
+			wait = min(wait, d)
+			continue
+		}
+		return j, 0
+	}
+	return nil, wait
+}
+
+// finish removes an uploaded job, once no FD reads it.
+func (s *Spool) finish(j *spoolJob) {
+	s.mu.Lock()
+	j.finished = true
+	remove := j.readers == 0
+	s.mu.Unlock()
+	if remove {
+		s.remove(j)
+	}
+	s.dequeue(j)
+}
+
+// remove deletes the directory of a job.
+func (s *Spool) remove(j *spoolJob) {
+	if err := os.RemoveAll(j.dir); err != nil {
+		slog.Warn("spool: remove job", "job", j.id, "error", err)
+	}
+}
+
+// park takes a job that cannot be uploaded out of the queue and keeps
+// it on disk, where the next NewSpool finds it again.
+func (s *Spool) park(j *spoolJob, err error) {
+	s.fail(j, fmt.Errorf("%w (kept in %s)", err, j.dir))
+	s.dequeue(j)
+}
+
+// fail records and logs the error of a job that was not written back.
+func (s *Spool) fail(j *spoolJob, err error) {
+	err = fmt.Errorf("spool: job %s: %w", j.id, err)
+	slog.Error("spool: not written back", "job", j.id, "linkID", j.manifest.linkID(), "error", err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, err)
+}
+
+// dequeue removes a job from the queue and wakes the waiters of its
+// file if it was the last one.
+func (s *Spool) dequeue(j *spoolJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = slices.DeleteFunc(s.queue, func(q *spoolJob) bool { return q == j })
+	id := j.manifest.linkID()
+	if pl := s.pending[id]; pl != nil {
+		pl.jobs--
+		if pl.jobs == 0 {
+			close(pl.done)
+			delete(s.pending, id)
+		}
+	}
+}
+
+// retry records a failed attempt and schedules the next one.
+func (s *Spool) retry(j *spoolJob, err error) {
+	j.manifest.Attempts++
+	backoff := spoolMinBackoff << min(j.manifest.Attempts-1, 10)
+	backoff = min(backoff, spoolMaxBackoff)
+	slog.Warn("spool: upload failed, will retry",
+		"job", j.id, "linkID", j.manifest.linkID(),
+		"attempts", j.manifest.Attempts, "backoff", backoff, "error", err)
+	if werr := writeManifest(j.dir, &j.manifest); werr != nil {
+		slog.Warn("spool: save manifest", "job", j.id, "error", werr)
+	}
+	s.mu.Lock()
+	j.notBefore = time.Now().Add(backoff)
+	s.mu.Unlock()
+}
+
+// conflictError reports a job saved as a copy of its file instead of
+// being written back to it.
+type conflictError struct {
+	err  error
+	name string // of the copy
+}
+
+func (e *conflictError) Error() string {
+	return fmt.Sprintf("%v: saved as %q", e.err, e.name)
+}
+
+func (e *conflictError) Unwrap() error { return e.err }
+
+// upload commits a job onto the current revision of its file: the
+// spooled blocks replace theirs, the others are carried over. A job
+// whose file was trashed or has moved on from the job's base revision
+// is saved as a conflict copy instead, and a *conflictError returned.
+func (s *Spool) upload(ctx context.Context, j *spoolJob) error {
+	share, link, err := s.resolve(ctx, &j.manifest)
+	if err != nil {
+		return err
+	}
+
+	var conflict error
+	switch base := j.manifest.Base; {
+	case link.State() != proton.LinkStateActive && link.State() != proton.LinkStateDraft:
+		conflict = fmt.Errorf("%s: %w", link.LinkID(), ErrFileNotFound)
+	case base != "" && link.RevisionID() != base:
+		conflict = fmt.Errorf("%s: %w (written against revision %s, now %s)",
+			link.LinkID(), ErrWriteConflict, base, link.RevisionID())
+	}
+	if conflict != nil {
+		name, err := s.saveCopy(ctx, j, share, link)
+		if err != nil {
+			return fmt.Errorf("%v; saving a conflict copy: %w", conflict, err)
+		}
+		return &conflictError{err: conflict, name: name}
+	}
+
+	fd, err := s.client.ModifyFD(ctx, share, link)
+	if err != nil {
+		return err
+	}
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.spool = j
+	defer func() { fd.spool = nil }()
+
+	if j.manifest.Size != fd.fileSize {
+		if err := fd.truncateLocked(j.manifest.Size); err != nil {
+			return err
+		}
+	}
+	fd.modified = true
+	return fd.commitLocked()
+}
+
+// saveCopy commits the content of a job, its spooled blocks over
+// those of its base revision, as a new file in the folder of link named
+// after it with a conflict suffix. It returns the name of the copy.
+func (s *Spool) saveCopy(ctx context.Context, j *spoolJob, share *Share, link *Link) (string, error) {
+	parent := link.ParentLink()
+	if parent == nil {
+		return "", fmt.Errorf("%s: no parent folder: %w", link.LinkID(), ErrFileNotFound)
+	}
+	name, err := link.Name()
+	if err != nil {
+		return "", err
+	}
+
+	// The spooled blocks are encrypted with the content session key of
+	// link, which a read FD of the base revision holds.
+	src, err := s.baseFD(ctx, j, link)
+	if err != nil {
+		return "", err
+	}
+	// The block cache is keyed by link and index, not revision: keep
+	// the current revision's blocks out of the base, and the base's out
+	// of the cache.
+	nBlocks := max(BlockCount(j.manifest.Size), len(src.blocks))
+	src.store.Invalidate(link.LinkID(), nBlocks)
+	defer src.store.Invalidate(link.LinkID(), nBlocks)
+
+	name = ConflictName(name, time.Now())
+	dst, err := s.client.CreateFD(ctx, share, parent, name)
+	if err != nil {
+		return "", err
+	}
+	dst.SetCommitEmpty(true)
+	if err := copySpooled(dst, src, j); err != nil {
+		// Do not Close — that would commit the partial content.
+		if rerr := s.client.Remove(ctx, share, dst.Link(), RemoveOpts{Permanent: true}); rerr != nil {
+			slog.Warn("spool: remove incomplete copy", "job", j.id, "name", name, "error", rerr)
+		}
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// baseFD returns a read FD of the base revision of a job. For a job
+// without a base it holds the session key only, and no blocks.
+func (s *Spool) baseFD(ctx context.Context, j *spoolJob, link *Link) (*FileDescriptor, error) {
+	if base := j.manifest.Base; base != "" {
+		fh, err := s.client.openRevision(ctx, "spool", link, base, j.manifest.Size)
+		if err != nil {
+			return nil, err
+		}
+		return s.client.readFD(ctx, fh, link), nil
+	}
+	nodeKR, err := link.KeyRing()
+	if err != nil {
+		return nil, fmt.Errorf("spool: %s: keyring: %w", link.LinkID(), err)
+	}
+	sessionKey, err := link.ProtonLink().GetSessionKey(nodeKR)
+	if err != nil {
+		return nil, fmt.Errorf("spool: %s: session key: %w", link.LinkID(), err)
+	}
+	return &FileDescriptor{
+		linkID:     link.LinkID(),
+		sessionKey: sessionKey,
+		mode:       fdRead,
+		ctx:        ctx,
+		store:      s.client.blockStore,
+		link:       link,
+	}, nil
+}
+
+// copySpooled writes the content of job j to w: each block from the
+// job if it holds it, from src, the FD of its base revision, otherwise.
+func copySpooled(w io.Writer, src *FileDescriptor, j *spoolJob) error {
+	size := j.manifest.Size
+	for idx := range BlockCount(size) {
+		var (
+			data []byte
+			err  error
+		)
+		switch {
+		case j.has(idx):
+			data, err = j.readBlock(src, idx)
+		case idx < len(src.blocks):
+			data, err = src.readBlock(idx)
+		}
+		if err != nil {
+			return err
+		}
+		n := int(min(size-int64(idx)*BlockSize, BlockSize))
+		if _, err := w.Write(padBlock(data, n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve looks up the share and file of a job, in whatever state the
+// file is. The file's link is fetched from the API rather than a cache,
+// so that the commit is based on its current revision.
+func (s *Spool) resolve(ctx context.Context, m *spoolManifest) (*Share, *Link, error) {
+	share, err := s.client.GetShare(ctx, m.ShareID)
+	if err != nil {
+		return nil, nil, err
+	}
+	parent := share.Link
+	for _, id := range m.Path[:len(m.Path)-1] {
+		if parent, err = s.client.StatLink(ctx, share, parent, id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	id := m.linkID()
+	pLink, err := s.client.Session.Client.GetLink(ctx, m.ShareID, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat %s: %w", id, err)
+	}
+	link := NewLink(&pLink, parent, share, s.client)
+	if link.State() == proton.LinkStateActive || link.State() == proton.LinkStateDraft {
+		s.client.putLink(id, link)
+	}
+	return share, link, nil
+}
+
+// isGone reports whether err means the file of a job no longer exists,
+// so that retrying its upload cannot succeed.
+func isGone(err error) bool {
+	if errors.Is(err, ErrFileNotFound) {
+		return true
+	}
+	var apiErr *proton.APIError
+	return errors.As(err, &apiErr) && (apiErr.Status == http.StatusNotFound || apiErr.Code == 2501)
+}
+
+// writeManifest atomically replaces the manifest in dir.
+func writeManifest(dir string, m *spoolManifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dir, manifestName, data)
+}
+
+// writeFileAtomic replaces the file name in dir with data through a
+// synced temporary file and a rename. The temporary file name is unique
+// to the process, as other processes may replace the same file.
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp := filepath.Join(dir, name+"."+strconv.Itoa(os.Getpid())+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	d, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = d.Close() }()
+	return d.Sync()
+}
+
+// writeFileSync writes data to a new file at path and syncs it to disk.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
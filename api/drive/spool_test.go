@@ -0,0 +1,405 @@
+package drive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// attachTestJob makes fd a write-back FD of s, as Attach does for an FD
+// with a link. The job writes the file "spool-test-link".
+func attachTestJob(t testing.TB, s *Spool, fd *FileDescriptor, id string) *spoolJob {
+	t.Helper()
+	dir := filepath.Join(s.dir, id)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	fd.spool = &spoolJob{
+		spool: s,
+		id:    id,
+		dir:   dir,
+		manifest: spoolManifest{
+			ShareID: "spool-test-share",
+			Path:    []string{"spool-test-dir", "spool-test-link"},
+			Base:    fd.baseRevision,
+		},
+		files: make(map[int]string),
+	}
+	return fd.spool
+}
+
+// queueTestJob adds a saved job for linkID to the queue of s.
+func queueTestJob(s *Spool, id, linkID string, size int64) *spoolJob {
+	j := &spoolJob{
+		spool:    s,
+		id:       id,
+		dir:      filepath.Join(s.dir, id),
+		manifest: spoolManifest{ShareID: "spool-test-share", Path: []string{linkID}, Size: size},
+		files:    make(map[int]string),
+		saved:    true,
+	}
+	s.enqueue(j)
+	return j
+}
+
+// dirNames returns the sorted names in dir.
+func dirNames(t testing.TB, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// TestSpoolFlushAndRecover writes to a write-back FD, flushes, writes
+// again without flushing, and verifies that a restarted spool recovers
+// the flushed state only.
+func TestSpoolFlushAndRecover(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+
+	base := bytes.Repeat([]byte{0x11}, int(BlockSize+512))
+	fd := newModifyTestFD(t, base)
+	job := attachTestJob(t, s, fd, "0000000000000001-0001")
+
+	if _, err := fd.WriteAt([]byte("hello"), BlockSize+10); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	if err := fd.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(fd.dirty) != 0 || fd.modified {
+		t.Fatalf("after Flush: %d dirty blocks, modified %v", len(fd.dirty), fd.modified)
+	}
+
+	// Flushed blocks read back from the spool.
+	want := bytes.Clone(base)
+	copy(want[BlockSize+10:], "hello")
+	if got := readAll(t, fd); !bytes.Equal(got, want) {
+		t.Fatal("content after Flush does not match")
+	}
+
+	// A later write that is evicted but never flushed.
+	fd.mu.Lock()
+	err = fd.putBlock(1, bytes.Repeat([]byte{0x22}, 512))
+	fd.mu.Unlock()
+	if err != nil {
+		t.Fatalf("putBlock: %v", err)
+	}
+	if n := len(dirNames(t, job.dir)); n != 3 {
+		t.Fatalf("job holds %d files, want 3 (manifest, saved and unsaved block)", n)
+	}
+
+	// A job that was never flushed.
+	if err := os.Mkdir(filepath.Join(dir, "0000000000000002-0002"), 0700); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	r, err := NewSpool(dir, nil)
+	if err != nil {
+		t.Fatalf("NewSpool (recover): %v", err)
+	}
+	if r.Len() != 1 {
+		t.Fatalf("recovered %d jobs, want 1", r.Len())
+	}
+	if names := dirNames(t, dir); len(names) != 1 || names[0] != job.id {
+		t.Fatalf("spool directory holds %v, want [%s]", names, job.id)
+	}
+	got := r.queue[0]
+	if got.manifest.Size != int64(len(base)) || got.manifest.linkID() != "spool-test-link" {
+		t.Errorf("recovered manifest = %+v", got.manifest)
+	}
+	if len(got.files) != 1 || got.files[1] != job.manifest.Blocks[1] {
+		t.Errorf("recovered blocks = %v, want %v", got.files, job.manifest.Blocks)
+	}
+	if n := len(dirNames(t, job.dir)); n != 2 {
+		t.Errorf("recovered job holds %d files, want 2", n)
+	}
+	if size, ok := r.Pending("spool-test-link"); !ok || size != int64(len(base)) {
+		t.Errorf("Pending = %d, %v", size, ok)
+	}
+}
+
+// TestSpoolReleaseUnsaved verifies that closing a write-back FD that
+// never flushed anything leaves no job behind.
+func TestSpoolReleaseUnsaved(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+	fd := newModifyTestFD(t, []byte("unchanged"))
+	attachTestJob(t, s, fd, "0000000000000001-0001")
+
+	if err := fd.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if names := dirNames(t, dir); len(names) != 0 {
+		t.Errorf("spool directory holds %v, want nothing", names)
+	}
+	if s.Len() != 0 {
+		t.Errorf("%d jobs queued, want 0", s.Len())
+	}
+}
+
+// TestSpoolNextOrder verifies that jobs of one file are uploaded in
+// order, and that a job waiting for a retry holds back its file only.
+func TestSpoolNextOrder(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+	a1 := queueTestJob(s, "a1", "link-a", 10)
+	a2 := queueTestJob(s, "a2", "link-a", 20)
+	b1 := queueTestJob(s, "b1", "link-b", 30)
+
+	now := time.Now()
+	if j, _ := s.next(now); j != a1 {
+		t.Fatalf("next = %v, want a1", j)
+	}
+
+	s.retry(a1, context.DeadlineExceeded)
+	if j, _ := s.next(now); j != b1 {
+		t.Fatalf("next with a1 backing off = %v, want b1", j)
+	}
+	s.finish(b1)
+	j, wait := s.next(now)
+	if j != nil {
+		t.Fatalf("next with only a1 backing off = %s, want none", j.id)
+	}
+	if wait <= 0 || wait > spoolMinBackoff+time.Second {
+		t.Errorf("wait = %v, want about %v", wait, spoolMinBackoff)
+	}
+	if j, _ := s.next(now.Add(spoolMinBackoff + time.Second)); j != a1 {
+		t.Fatalf("next after backoff = %v, want a1", j)
+	}
+
+	s.finish(a1)
+	if size, ok := s.Pending("link-a"); !ok || size != 20 {
+		t.Errorf("Pending(link-a) = %d, %v; want 20, true", size, ok)
+	}
+	if j, _ := s.next(now); j != a2 {
+		t.Fatalf("next = %v, want a2", j)
+	}
+}
+
+// TestSpoolWait verifies that Wait returns once the last job of a file
+// is finished and honors its context.
+func TestSpoolWait(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+	if err := s.Wait(context.Background(), "link-a"); err != nil {
+		t.Fatalf("Wait with nothing queued: %v", err)
+	}
+
+	j1 := queueTestJob(s, "a1", "link-a", 1)
+	j2 := queueTestJob(s, "a2", "link-a", 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx, "link-a"); err == nil {
+		t.Fatal("Wait with jobs queued returned before its deadline")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Wait(context.Background(), "link-a") }()
+	s.finish(j1)
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v with a job still queued", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.finish(j2)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after the last job finished")
+	}
+	if _, ok := s.Pending("link-a"); ok {
+		t.Error("Pending reports jobs after all finished")
+	}
+}
+
+// TestSpoolFlush verifies that Flush cuts short retry backoff and
+// returns once the queued jobs are finished.
+func TestSpoolFlush(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush with nothing queued: %v", err)
+	}
+
+	a := queueTestJob(s, "a1", "link-a", 1)
+	b := queueTestJob(s, "b1", "link-b", 2)
+	s.retry(a, context.DeadlineExceeded)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Flush(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	s.mu.Lock()
+	due := a.notBefore.IsZero()
+	s.mu.Unlock()
+	if !due {
+		t.Error("Flush left the backing-off job waiting")
+	}
+
+	s.finish(a)
+	select {
+	case err := <-done:
+		t.Fatalf("Flush returned %v with a job still queued", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.finish(b)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Flush did not return after the queue drained")
+	}
+}
+
+// TestSpoolCopySpooled verifies that the content of a job saved as a
+// conflict copy is its spooled blocks over those of its base revision,
+// with a gap past the end of the base reading as zeros.
+func TestSpoolCopySpooled(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+	base := bytes.Repeat([]byte{0x11}, int(2*BlockSize+512))
+	fd := newModifyTestFD(t, base)
+	job := attachTestJob(t, s, fd, "0000000000000001-0001")
+
+	if _, err := fd.WriteAt([]byte("hello"), BlockSize+10); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	if _, err := fd.WriteAt([]byte("tail"), 3*BlockSize+100); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	if err := fd.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	want := make([]byte, 3*BlockSize+104)
+	copy(want, base)
+	copy(want[BlockSize+10:], "hello")
+	copy(want[3*BlockSize+100:], "tail")
+
+	var buf bytes.Buffer
+	if err := copySpooled(&buf, fd, job); err != nil {
+		t.Fatalf("copySpooled: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("copy holds %d bytes, want %d; content does not match", buf.Len(), len(want))
+	}
+}
+
+// TestSpoolPark verifies that a job that cannot be uploaded leaves the
+// queue but stays on disk, and that Flush reports it once.
+func TestSpoolPark(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+	j := queueTestJob(s, "a1", "link-a", 1)
+	if err := os.Mkdir(j.dir, 0700); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	s.park(j, ErrFileNotFound)
+	if s.Len() != 0 {
+		t.Errorf("%d jobs queued, want 0", s.Len())
+	}
+	if _, ok := s.Pending("link-a"); ok {
+		t.Error("Pending reports the parked job")
+	}
+	if names := dirNames(t, dir); len(names) != 1 || names[0] != j.id {
+		t.Errorf("spool directory holds %v, want [%s]", names, j.id)
+	}
+
+	if err := s.Flush(context.Background()); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("Flush = %v, want %v", err, ErrFileNotFound)
+	}
+	if err := s.Flush(context.Background()); err != nil {
+		t.Errorf("second Flush = %v, want nil", err)
+	}
+}
+
+// TestSpoolReadQueued reads a queued job as OpenFD opens it, and
+// verifies that the job's directory outlives its upload until the
+// reader is closed.
+func TestSpoolReadQueued(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, nil)
+	if err != nil {
+		t.Fatalf("NewSpool: %v", err)
+	}
+	base := bytes.Repeat([]byte{0x11}, int(BlockSize+512))
+	w := newModifyTestFD(t, base)
+	job := attachTestJob(t, s, w, "0000000000000001-0001")
+	if _, err := w.WriteAt([]byte("hello"), BlockSize+510); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if s.Len() != 1 {
+		t.Fatalf("%d jobs queued, want 1", s.Len())
+	}
+
+	// OpenFD past opening the base revision.
+	s.mu.Lock()
+	job.readers++
+	s.mu.Unlock()
+	r := &FileDescriptor{
+		linkID:     w.linkID,
+		sessionKey: w.sessionKey,
+		blocks:     w.blocks,
+		store:      w.store,
+		mode:       fdSpoolRead,
+		spool:      job,
+		fileSize:   job.manifest.Size,
+	}
+
+	want := append(bytes.Clone(base[:BlockSize+510]), "hello"...)
+	if got := readAll(t, r); !bytes.Equal(got, want) {
+		t.Fatal("content of the queued job does not match")
+	}
+	if _, err := r.WriteAt([]byte("x"), 0); !errors.Is(err, syscall.EBADF) {
+		t.Errorf("WriteAt = %v, want EBADF", err)
+	}
+
+	s.finish(job)
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Fatalf("spool directory holds %v with a reader open, want the job", names)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close reader: %v", err)
+	}
+	if names := dirNames(t, dir); len(names) != 0 {
+		t.Errorf("spool directory holds %v after the reader closed, want nothing", names)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+
+	"github.com/ProtonMail/go-proton-api"
 )
 
 // StatLink resolves a single link ID within a share into a Link.
@@ -29,6 +31,25 @@ func (c *Client) StatLink(ctx context.Context, share *Share, parentLink *Link, l
 	return link, nil
 }
 
+// FetchRevisionID returns the active revision ID of link as the server
+// currently records it. Unlike StatLink and GetCachedLink it bypasses
+// the link table and object cache, so a revision committed by another
+// client is seen. Returns ErrFileNotFound when the link is no longer
+// active (trashed or deleted).
+func (c *Client) FetchRevisionID(ctx context.Context, share *Share, link *Link) (string, error) {
+	pLink, err := c.Session.Client.GetLink(ctx, share.ProtonShare().ShareID, link.LinkID())
+	if err != nil {
+		return "", fmt.Errorf("stat %s: %w", link.LinkID(), err)
+	}
+	if pLink.State != proton.LinkStateActive {
+		return "", fmt.Errorf("stat %s: %w", link.LinkID(), ErrFileNotFound)
+	}
+	if pLink.FileProperties == nil {
+		return "", nil
+	}
+	return pLink.FileProperties.ActiveRevision.ID, nil
+}
+
 // StatLinks resolves a batch of link IDs concurrently using the
 // session's worker pool. Links that fail to resolve are logged and
 // skipped. Respects context cancellation.
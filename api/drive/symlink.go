@@ -0,0 +1,83 @@
+package drive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SymlinkMIMEType is the MIME type given to files that represent
+// symbolic links. It lets listings identify symlinks without
+// decrypting each revision XAttr.
+const SymlinkMIMEType = "inode/symlink"
+
+// maxSymlinkTarget bounds the content read when a symlink's XAttr
+// carries no target (PATH_MAX on Linux).
+const maxSymlinkTarget = 4096
+
+// Symlink creates a file named name under parent that represents a
+// symbolic link to target. The target is stored in the revision XAttr
+// and mirrored as the file content.
+func (c *Client) Symlink(ctx context.Context, share *Share, parent *Link, name, target string) (*Link, error) {
+	if target == "" {
+		return nil, fmt.Errorf("drive.Symlink %s: %w", name, ErrInvalidPath)
+	}
+
+	fd, err := c.createFD(ctx, share, parent, name, SymlinkMIMEType)
+	if err != nil {
+		return nil, fmt.Errorf("drive.Symlink %s: %w", name, err)
+	}
+
+	fd.SetMode(0o777)
+	fd.SetSymlink(target)
+	if _, err := fd.Write([]byte(target)); err != nil {
+		_ = fd.Close()
+		return nil, fmt.Errorf("drive.Symlink %s: write: %w", name, err)
+	}
+	if err := fd.Close(); err != nil {
+		return nil, fmt.Errorf("drive.Symlink %s: commit: %w", name, err)
+	}
+
+	// The listing returned by CreateFD predates the commit; record the
+	// attributes we just wrote so callers can Readlink immediately.
+	link := fd.Link()
+	link.setCachedUnixAttrs(0o777, time.Time{}, newUnixXAttr(target, nil))
+	return link, nil
+}
+
+// Readlink returns the target of a symlink file. The target is read
+// from the revision XAttr, fetching the revision when the listing did
+// not include it. Files written by clients that dropped the XAttr fall
+// back to the file content.
+func (c *Client) Readlink(ctx context.Context, link *Link) (string, error) {
+	if !link.IsSymlink() {
+		return "", fmt.Errorf("drive.Readlink %s: %w", link.LinkID(), ErrNotASymlink)
+	}
+
+	if target := link.SymlinkTarget(); target != "" {
+		return target, nil
+	}
+
+	c.FetchRevisionXAttr(ctx, link)
+	if target := link.SymlinkTarget(); target != "" {
+		return target, nil
+	}
+
+	fd, err := c.OpenFD(ctx, link)
+	if err != nil {
+		return "", fmt.Errorf("drive.Readlink %s: %w", link.LinkID(), err)
+	}
+	defer func() { _ = fd.Close() }()
+
+	buf := make([]byte, maxSymlinkTarget)
+	n, err := fd.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("drive.Readlink %s: %w", link.LinkID(), err)
+	}
+	if n == 0 {
+		return "", fmt.Errorf("drive.Readlink %s: empty target", link.LinkID())
+	}
+	return string(buf[:n]), nil
+}
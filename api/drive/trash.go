@@ -0,0 +1,117 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+// trashPageSize is the number of trashed links requested per page.
+const trashPageSize = 150
+
+// ListTrash returns the trashed links of a share. Only the top of each
+// trashed subtree is listed: the children of a trashed folder are
+// reached through the folder. The links are not added to the link
+// table; their parents are, so names and keys can be derived.
+func (c *Client) ListTrash(ctx context.Context, share *Share) ([]*Link, error) {
+	shareID := share.ProtonShare().ShareID
+
+	var links []*Link
+	for page := 0; ; page++ {
+		var res struct {
+			Links   []proton.Link
+			Parents map[string]proton.Link
+		}
+		path := fmt.Sprintf("/drive/shares/%s/trash?Page=%d&PageSize=%d", shareID, page, trashPageSize)
+		err := c.Session.DoJSON(ctx, "GET", path, nil, &res)
+		c.noteAPIError(err)
+		if err != nil {
+			return nil, fmt.Errorf("ListTrash %s: %w", shareID, err)
+		}
+
+		for i := range res.Links {
+			pLink := &res.Links[i]
+			parent, err := c.trashParent(ctx, share, pLink.ParentLinkID, res.Parents)
+			if err != nil {
+				return nil, fmt.Errorf("ListTrash %s: %s: %w", shareID, pLink.LinkID, err)
+			}
+			links = append(links, NewLink(pLink, parent, share, c))
+		}
+
+		if len(res.Links) < trashPageSize {
+			return links, nil
+		}
+	}
+}
+
+// trashParent resolves the parent folder of a trashed link, walking up
+// to the share root through the link table, the parents the trash
+// listing returned, and finally the API.
+func (c *Client) trashParent(ctx context.Context, share *Share, linkID string, parents map[string]proton.Link) (*Link, error) {
+	if linkID == "" || linkID == share.Link.LinkID() {
+		return share.Link, nil
+	}
+	if link := c.GetLink(linkID); link != nil {
+		return link, nil
+	}
+
+	pLink, ok := parents[linkID]
+	if !ok {
+		var err error
+		pLink, err = c.GetCachedLink(ctx, share.ProtonShare().ShareID, linkID)
+		if err != nil {
+			return nil, fmt.Errorf("parent %s: %w", linkID, err)
+		}
+	}
+	grandparent, err := c.trashParent(ctx, share, pLink.ParentLinkID, parents)
+	if err != nil {
+		return nil, err
+	}
+	return c.NewChildLink(ctx, grandparent, &pLink), nil
+}
+
+// RestoreTrash moves trashed links back to the folders they were
+// trashed from.
+func (c *Client) RestoreTrash(ctx context.Context, share *Share, links ...*Link) error {
+	shareID := share.ProtonShare().ShareID
+
+	req := struct {
+		LinkIDs []string
+	}{}
+	for _, link := range links {
+		req.LinkIDs = append(req.LinkIDs, link.LinkID())
+	}
+
+	var res struct {
+		Code      int
+		Responses []struct {
+			LinkID   string
+			Response struct {
+				Code  int
+				Error string
+			}
+		}
+	}
+
+	if err := c.Session.DoJSON(ctx, "PUT", "/drive/shares/"+shareID+"/trash/restore_multiple", req, &res); err != nil {
+		return fmt.Errorf("restore trashed links: %w", err)
+	}
+
+	// Invalidate the restored links and the folders they return to.
+	for _, link := range links {
+		c.deleteLink(link.LinkID())
+		_ = c.objectCache.Erase(SanitizeLinkID(link.LinkID()))
+		if parent := link.ParentLink(); parent != nil {
+			c.deleteLink(parent.LinkID())
+			parent.InvalidateChildren()
+		}
+	}
+
+	for _, r := range res.Responses {
+		if r.Response.Code != int(proton.SuccessCode) {
+			return fmt.Errorf("restore trashed link %s: %s (Code=%d)", r.LinkID, r.Response.Error, r.Response.Code)
+		}
+	}
+	return nil
+}
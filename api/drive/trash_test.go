@@ -0,0 +1,97 @@
+package drive
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api"
+)
+
+// newTrashTestClient returns a Client whose session talks to handler,
+// and a share with root link "root" in it.
+func newTrashTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *Share) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := &Client{Session: &api.Session{
+		BaseURL: srv.URL,
+		Sem:     api.NewSemaphore(context.Background(), 4, nil),
+	}}
+	pShare := &proton.Share{ShareMetadata: proton.ShareMetadata{ShareID: "test-share"}}
+	rootPLink := &proton.Link{LinkID: "root", Type: proton.LinkTypeFolder}
+	share := NewShare(pShare, nil, nil, c, "vol-1")
+	share.Link = NewTestLink(rootPLink, nil, share, c, "root")
+	return c, share
+}
+
+func TestListTrash_ResolvesParents(t *testing.T) {
+	c, share := newTrashTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/drive/shares/test-share/trash" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"Code": 1000,
+			"Links": []proton.Link{
+				{LinkID: "t1", ParentLinkID: "root", State: proton.LinkStateTrashed},
+				{LinkID: "t2", ParentLinkID: "p1", State: proton.LinkStateTrashed},
+			},
+			"Parents": map[string]proton.Link{
+				"p1": {LinkID: "p1", ParentLinkID: "root", Type: proton.LinkTypeFolder},
+			},
+		})
+	})
+
+	links, err := c.ListTrash(context.Background(), share)
+	if err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	if len(links) != 2 {
+		t.Fatalf("ListTrash returned %d links, want 2", len(links))
+	}
+	if got := links[0].ParentLink(); got != share.Link {
+		t.Errorf("t1 parent = %v, want share root", got)
+	}
+	p1 := links[1].ParentLink()
+	if p1 == nil || p1.LinkID() != "p1" || p1.ParentLink() != share.Link {
+		t.Fatalf("t2 parent = %v, want p1 under the share root", p1)
+	}
+	if c.GetLink("p1") != p1 {
+		t.Error("parent p1 not added to the link table")
+	}
+	if c.GetLink("t2") != nil {
+		t.Error("trashed link t2 added to the link table")
+	}
+}
+
+func TestRestoreTrash(t *testing.T) {
+	var body string
+	c, share := newTrashTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/drive/shares/test-share/trash/restore_multiple" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"Code":1001,"Responses":[`+
+			`{"LinkID":"t1","Response":{"Code":1000}},`+
+			`{"LinkID":"t2","Response":{"Code":2500,"Error":"A file with that name already exists"}}]}`)
+	})
+
+	t1 := NewTestLink(&proton.Link{LinkID: "t1"}, share.Link, share, c, "a")
+	t2 := NewTestLink(&proton.Link{LinkID: "t2"}, share.Link, share, c, "b")
+	err := c.RestoreTrash(context.Background(), share, t1, t2)
+	if err == nil || !strings.Contains(err.Error(), "t2") {
+		t.Fatalf("RestoreTrash error = %v, want failure for t2", err)
+	}
+	if body != `{"LinkIDs":["t1","t2"]}` {
+		t.Errorf("request body = %s", body)
+	}
+}
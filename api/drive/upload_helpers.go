@@ -12,6 +12,21 @@ import (
 	"github.com/major0/proton-utils/api"
 )
 
+// xattrTimeLayout is the ModificationTime layout written to revision
+// XAttrs. The official clients write RFC 3339; parseXAttrTime accepts
+// both.
+const xattrTimeLayout = "2006-01-02T15:04:05-0700"
+
+// parseXAttrTime parses a revision XAttr ModificationTime.
+func parseXAttrTime(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, xattrTimeLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 // uploadParams holds the crypto and identity state needed for block
 // upload and revision commit. All fields are unexported — this struct
 // is internal to the drive package.
@@ -27,6 +42,9 @@ type uploadParams struct {
 	revisionID string
 	sigAddr    string
 	unixMode   uint32
+	modTime    time.Time // zero means "now"
+	symlink    string    // non-empty marks the revision as a symlink
+	xattrs     map[string][]byte
 }
 
 // encryptAndUploadBlock encrypts a plaintext block, signs it, computes
@@ -106,13 +124,12 @@ func encryptAndUploadBlock(ctx context.Context, p uploadParams, store blockStore
 // ProtonWriter passes context.Background() (ensuring commit completes
 // even after pipeline context cancellation).
 //
-// totalSize is computed by summing rawSize from all tokens.
-// ModificationTime uses time.Now().UTC() (matching current behavior).
+// totalSize is computed by summing rawSize from all tokens. An empty
+// token map commits an empty file; callers that must not create one
+// check for it first. ModificationTime uses p.modTime when set,
+// otherwise time.Now().UTC().
 func commitRevisionFromTokens(ctx context.Context, session *api.Session, p uploadParams, tokens map[int]uploadedBlock) error {
 	nBlocks := len(tokens)
-	if nBlocks == 0 {
-		return nil
-	}
 
 	// Build ordered block token list and manifest hash.
 	blockTokens := make([]proton.BlockToken, nBlocks)
@@ -144,11 +161,22 @@ func commitRevisionFromTokens(ctx context.Context, session *api.Session, p uploa
 	}
 
 	// Build XAttr with file metadata.
-	xAttrCommon := &proton.RevisionXAttrCommon{
-		ModificationTime: time.Now().UTC().Format("2006-01-02T15:04:05-0700"),
-		Size:             totalSize,
-		BlockSizes:       blockSizes,
-		Mode:             p.unixMode,
+	modTime := p.modTime
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	xAttr := &revisionXAttr{
+		Common: proton.RevisionXAttrCommon{
+			ModificationTime: modTime.UTC().Format(xattrTimeLayout),
+			Size:             totalSize,
+			BlockSizes:       blockSizes,
+			Mode:             p.unixMode,
+		},
+	}
+	xAttr.Unix = newUnixXAttr(p.symlink, p.xattrs)
+	encXAttr, err := encryptXAttr(xAttr, p.addrKR, p.nodeKR)
+	if err != nil {
+		return fmt.Errorf("commitRevision: %w", err)
 	}
 
 	req := proton.UpdateRevisionReq{
@@ -156,9 +184,7 @@ func commitRevisionFromTokens(ctx context.Context, session *api.Session, p uploa
 		BlockList:         blockTokens,
 		ManifestSignature: manifestSigStr,
 		SignatureAddress:  p.sigAddr,
-	}
-	if err := req.SetEncXAttrString(p.addrKR, p.nodeKR, xAttrCommon); err != nil {
-		return fmt.Errorf("commitRevision: encrypt xattr: %w", err)
+		XAttr:             encXAttr,
 	}
 
 	commitCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -2,6 +2,7 @@ package drive
 
 import (
 	"testing"
+	"time"
 
 	"pgregory.net/rapid"
 )
@@ -41,3 +42,27 @@ func TestDetectMIMEType_Property(t *testing.T) {
 		}
 	})
 }
+
+func TestParseXAttrTime(t *testing.T) {
+	want := time.Date(2024, 6, 15, 21, 30, 45, 0, time.UTC)
+	tests := []struct {
+		in     string
+		wantOK bool
+	}{
+		{want.Format(xattrTimeLayout), true},
+		{want.Format(time.RFC3339), true},
+		{"2024-06-15T23:30:45+02:00", true},
+		{"2024-06-15", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseXAttrTime(tt.in)
+		if ok != tt.wantOK {
+			t.Errorf("parseXAttrTime(%q) ok = %v, want %v", tt.in, ok, tt.wantOK)
+			continue
+		}
+		if ok && !got.Equal(want) {
+			t.Errorf("parseXAttrTime(%q) = %v, want %v", tt.in, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+)
+
+// revisionXAttr is the decrypted revision XAttr document. Common is the
+// block shared by every Proton Drive client. Unix carries POSIX
+// metadata that has no slot in Common; clients that do not know the
+// key ignore it.
+type revisionXAttr struct {
+	Common proton.RevisionXAttrCommon `json:"Common"`
+	Unix   *unixXAttr                 `json:"Unix,omitempty"`
+}
+
+// unixXAttr holds the proton-utils extensions to the revision XAttr.
+type unixXAttr struct {
+	// Symlink is the target of a symbolic link. The file content
+	// mirrors it so other clients still see the target as text.
+	Symlink string `json:"Symlink,omitempty"`
+
+	// XAttrs holds extended attributes keyed by full name (e.g.
+	// "user.checksum", "system.posix_acl_access"). Values are raw
+	// bytes, base64-encoded in JSON.
+	XAttrs map[string][]byte `json:"XAttrs,omitempty"`
+}
+
+// newUnixXAttr returns the Unix block for the given symlink target and
+// extended attributes, or nil when both are empty.
+func newUnixXAttr(symlink string, xattrs map[string][]byte) *unixXAttr {
+	if symlink == "" && len(xattrs) == 0 {
+		return nil
+	}
+	return &unixXAttr{Symlink: symlink, XAttrs: xattrs}
+}
+
+// encryptXAttr serializes x and encrypts it to nodeKR, signed by
+// addrKR. Returns the armored message for UpdateRevisionReq.XAttr.
+func encryptXAttr(x *revisionXAttr, addrKR, nodeKR *crypto.KeyRing) (string, error) {
+	b, err := json.Marshal(x)
+	if err != nil {
+		return "", fmt.Errorf("marshal xattr: %w", err)
+	}
+	msg, err := nodeKR.Encrypt(crypto.NewPlainMessage(b), addrKR)
+	if err != nil {
+		return "", fmt.Errorf("encrypt xattr: %w", err)
+	}
+	return msg.GetArmored()
+}
+
+// decryptXAttr decrypts an armored revision XAttr and verifies its
+// signature against addrKR.
+func decryptXAttr(armored string, addrKR, nodeKR *crypto.KeyRing) (*revisionXAttr, error) {
+	msg, err := crypto.NewPGPMessageFromArmored(armored)
+	if err != nil {
+		return nil, fmt.Errorf("parse xattr: %w", err)
+	}
+	plain, err := nodeKR.Decrypt(msg, addrKR, crypto.GetUnixTime())
+	if err != nil {
+		return nil, fmt.Errorf("decrypt xattr: %w", err)
+	}
+	var x revisionXAttr
+	if err := json.Unmarshal(plain.GetBinary(), &x); err != nil {
+		return nil, fmt.Errorf("unmarshal xattr: %w", err)
+	}
+	return &x, nil
+}
@@ -0,0 +1,168 @@
+package drive
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+)
+
+func TestXAttrRoundTrip(t *testing.T) {
+	addrKR := genKeyRing(t, "addr")
+	nodeKR := genKeyRing(t, "node")
+
+	tests := []struct {
+		name string
+		in   revisionXAttr
+	}{
+		{"common only", revisionXAttr{
+			Common: proton.RevisionXAttrCommon{ModificationTime: "2024-06-15T12:00:00+0000", Size: 5, Mode: 0o644},
+		}},
+		{"symlink", revisionXAttr{
+			Common: proton.RevisionXAttrCommon{Size: 9, Mode: 0o777},
+			Unix:   &unixXAttr{Symlink: "../target"},
+		}},
+		{"xattrs", revisionXAttr{
+			Common: proton.RevisionXAttrCommon{Size: 3, Mode: 0o600},
+			Unix: &unixXAttr{XAttrs: map[string][]byte{
+				"user.checksum":           []byte("sha256:abc"),
+				"system.posix_acl_access": {0x02, 0x00, 0x00, 0x00, 0xff},
+			}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			armored, err := encryptXAttr(&tt.in, addrKR, nodeKR)
+			if err != nil {
+				t.Fatalf("encryptXAttr: %v", err)
+			}
+			got, err := decryptXAttr(armored, addrKR, nodeKR)
+			if err != nil {
+				t.Fatalf("decryptXAttr: %v", err)
+			}
+			if got.Common.Mode != tt.in.Common.Mode || got.Common.Size != tt.in.Common.Size ||
+				got.Common.ModificationTime != tt.in.Common.ModificationTime {
+				t.Errorf("Common = %+v, want %+v", got.Common, tt.in.Common)
+			}
+			if (got.Unix == nil) != (tt.in.Unix == nil) {
+				t.Fatalf("Unix = %+v, want %+v", got.Unix, tt.in.Unix)
+			}
+			if got.Unix == nil {
+				return
+			}
+			if got.Unix.Symlink != tt.in.Unix.Symlink {
+				t.Errorf("Symlink = %q, want %q", got.Unix.Symlink, tt.in.Unix.Symlink)
+			}
+			if len(got.Unix.XAttrs) != len(tt.in.Unix.XAttrs) {
+				t.Fatalf("XAttrs = %v, want %v", got.Unix.XAttrs, tt.in.Unix.XAttrs)
+			}
+			for k, v := range tt.in.Unix.XAttrs {
+				if !bytes.Equal(got.Unix.XAttrs[k], v) {
+					t.Errorf("XAttrs[%q] = %x, want %x", k, got.Unix.XAttrs[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestXAttrWrongKey(t *testing.T) {
+	addrKR := genKeyRing(t, "addr")
+	nodeKR := genKeyRing(t, "node")
+	otherKR := genKeyRing(t, "other")
+
+	armored, err := encryptXAttr(&revisionXAttr{}, addrKR, nodeKR)
+	if err != nil {
+		t.Fatalf("encryptXAttr: %v", err)
+	}
+	if _, err := decryptXAttr(armored, addrKR, otherKR); err == nil {
+		t.Error("expected error decrypting with the wrong node key")
+	}
+}
+
+func TestLinkIsSymlink(t *testing.T) {
+	tests := []struct {
+		name     string
+		linkType proton.LinkType
+		mime     string
+		want     bool
+	}{
+		{"symlink file", proton.LinkTypeFile, SymlinkMIMEType, true},
+		{"regular file", proton.LinkTypeFile, "text/plain", false},
+		{"folder", proton.LinkTypeFolder, SymlinkMIMEType, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLink(&proton.Link{LinkID: "l", Type: tt.linkType, MIMEType: tt.mime}, nil, nil, nil)
+			if got := l.IsSymlink(); got != tt.want {
+				t.Errorf("IsSymlink() = %v, want %v", got, tt.want)
+			}
+			if got := l.SymlinkTarget(); got != "" {
+				t.Errorf("SymlinkTarget() = %q without XAttr, want empty", got)
+			}
+		})
+	}
+}
+
+func TestNewUnixXAttr(t *testing.T) {
+	if ux := newUnixXAttr("", nil); ux != nil {
+		t.Errorf("newUnixXAttr(empty) = %+v, want nil", ux)
+	}
+	if ux := newUnixXAttr("", map[string][]byte{}); ux != nil {
+		t.Errorf("newUnixXAttr(empty map) = %+v, want nil", ux)
+	}
+	ux := newUnixXAttr("t", map[string][]byte{"user.a": []byte("1")})
+	if ux == nil || ux.Symlink != "t" || string(ux.XAttrs["user.a"]) != "1" {
+		t.Errorf("newUnixXAttr = %+v", ux)
+	}
+}
+
+func TestLinkModifyTimePrefersXAttr(t *testing.T) {
+	pl := &proton.Link{
+		LinkID: "l",
+		Type:   proton.LinkTypeFile,
+		FileProperties: &proton.FileProperties{
+			ActiveRevision: proton.RevisionMetadata{ID: "r", CreateTime: 100},
+		},
+	}
+	l := NewLink(pl, nil, nil, nil)
+	if got := l.ModifyTime(); got != 100 {
+		t.Fatalf("ModifyTime() without XAttr = %d, want revision create time 100", got)
+	}
+
+	l.setCachedUnixAttrs(0o644, time.Unix(5000, 0), nil)
+	if got := l.ModifyTime(); got != 5000 {
+		t.Errorf("ModifyTime() = %d, want XAttr time 5000", got)
+	}
+	if got := l.Mode(); got != 0o644 {
+		t.Errorf("Mode() = %o, want 644", got)
+	}
+
+	l.SetCachedMode(0o600)
+	if got := l.ModifyTime(); got != 5000 {
+		t.Errorf("ModifyTime() after SetCachedMode = %d, want 5000", got)
+	}
+}
+
+// TestLinkModifyTimeCachedWithoutMetadataCache verifies that the XAttr
+// ModificationTime is kept once decoded even when the share does not
+// cache metadata, so that ModifyTime does not decrypt on every call.
+func TestLinkModifyTimeCachedWithoutMetadataCache(t *testing.T) {
+	pl := &proton.Link{
+		LinkID: "l",
+		Type:   proton.LinkTypeFile,
+		FileProperties: &proton.FileProperties{
+			ActiveRevision: proton.RevisionMetadata{ID: "r", CreateTime: 100, XAttr: "not-decryptable"},
+		},
+	}
+	l := NewLink(pl, nil, nil, nil)
+	l.xattrModTime = time.Unix(7000, 0)
+	l.xattrModTimeValid = true
+	if got := l.ModifyTime(); got != 7000 {
+		t.Errorf("ModifyTime() = %d, want cached XAttr time 7000", got)
+	}
+	if l.cachedModeValid {
+		t.Error("ModifyTime cached Mode and Unix extensions without CacheMetadata")
+	}
+}
@@ -15,6 +15,13 @@ type Throttle struct {
 	until    time.Time
 	backoff  time.Duration
 	maxDelay time.Duration
+	stats    ThrottleStats
+}
+
+// ThrottleStats counts the rate-limit backoffs of a Throttle.
+type ThrottleStats struct {
+	Backoffs int64         // Signal calls
+	Delay    time.Duration // sum of the delays requested by those calls
 }
 
 // NewThrottle creates a throttle with the given initial backoff and max delay.
@@ -37,6 +44,8 @@ func (t *Throttle) Signal(retryAfter time.Duration) {
 		delay = t.backoff
 		t.backoff = min(t.backoff*2, t.maxDelay)
 	}
+	t.stats.Backoffs++
+	t.stats.Delay += delay
 
 	until := time.Now().Add(delay)
 	if until.After(t.until) {
@@ -80,3 +89,10 @@ func (t *Throttle) Wait(ctx context.Context) error {
 		return ctx.Err()
 	}
 }
+
+// Stats returns a point-in-time snapshot of the backoff counters.
+func (t *Throttle) Stats() ThrottleStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
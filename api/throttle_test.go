@@ -150,6 +150,20 @@ func TestThrottle(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "stats count signals and delays",
+			fn: func(t *testing.T) {
+				th := NewThrottle(time.Second, 30*time.Second)
+				th.Signal(0)                      // backoff 1s
+				th.Signal(0)                      // backoff 2s
+				th.Signal(500 * time.Millisecond) // Retry-After
+				got := th.Stats()
+				want := ThrottleStats{Backoffs: 3, Delay: 3500 * time.Millisecond}
+				if got != want {
+					t.Fatalf("Stats = %+v, want %+v", got, want)
+				}
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
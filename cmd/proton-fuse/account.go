@@ -0,0 +1,148 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/account"
+	"github.com/major0/proton-utils/api/config"
+	"github.com/major0/proton-utils/api/drive"
+	fusedrv "github.com/major0/proton-utils/internal/fusemount/drive"
+	"github.com/major0/proton-utils/internal/keyring"
+)
+
+// accountMount is an account served by proton-fuse, mounted at
+// <mountpoint>/<name>/drive.
+type accountMount struct {
+	name    string
+	store   api.SessionStore
+	session *api.Session
+	client  *drive.Client
+	handler *fusedrv.DriveHandler
+}
+
+// resolveAccounts returns the accounts to mount, the default first:
+// those given by --account, or else the default account for "protonfs"
+// followed by the protonfs.accounts config list.
+func resolveAccounts(flagAccounts []string, appCfg *config.Config) ([]string, error) {
+	names := flagAccounts
+	if len(names) == 0 {
+		names = []string{appCfg.DefaultAccount("protonfs")}
+		for _, name := range appCfg.Accounts.Value() {
+			if name != names[0] {
+				names = append(names, name)
+			}
+		}
+	}
+	if err := config.ValidateAccounts(names); err != nil {
+		return nil, fmt.Errorf("accounts: %w", err)
+	}
+	return names, nil
+}
+
+// prefetchBlocks returns the configured prefetch in blocks, clamped to
+// 0..64.
+func prefetchBlocks(appCfg *config.Config) int {
+	return min(max(appCfg.PrefetchBlocks.Value(), 0), 64)
+}
+
+// openAccount restores the session of the named account from the
+// session index and loads its shares.
+func openAccount(ctx context.Context, cfg daemonConfig, appCfg *config.Config, name string) (*accountMount, error) {
+	// Build SessionConfig from loaded config.
+	sessionCfg := config.BuildSessionConfig(appCfg, appCfg.MaxJobs.Value())
+
+	// Build Proton options for session restore.
+	svc, err := api.LookupService("drive")
+	if err != nil {
+		return nil, fmt.Errorf("looking up drive service: %w", err)
+	}
+
+	opts := []proton.Option{
+		proton.WithHostURL(svc.Host),
+		proton.WithAppVersion(svc.AppVersion("")),
+		proton.WithUserAgent(userAgent),
+	}
+	if cfg.logLevel == slog.LevelDebug {
+		opts = append(opts, proton.WithDebug(true))
+	}
+
+	// Construct SessionIndex stores.
+	kr := keyring.SystemKeyring{}
+	store := keyring.NewSessionStore(cfg.sessionFile, name, "drive", kr)
+	accountStore := keyring.NewSessionStore(cfg.sessionFile, name, "account", kr)
+	cookieStore := keyring.NewSessionStore(cfg.sessionFile, name, "cookie", kr)
+
+	// Restore session.
+	session, err := account.RestoreServiceSession(
+		ctx, "drive", opts,
+		store, accountStore, cookieStore,
+		svc.AppVersion(""), requestTimeoutHook,
+	)
+	if err != nil {
+		if errors.Is(err, api.ErrNotLoggedIn) {
+			return nil, fmt.Errorf("not logged in (account %q). Run 'proton login' first", name)
+		}
+		return nil, fmt.Errorf("restoring session: %w", err)
+	}
+
+	// Register auth/deauth handlers.
+	session.AddAuthHandler(account.NewAuthHandler(store, session))
+	session.AddDeauthHandler(account.NewDeauthHandler())
+
+	// Set Session.Config and UserAgent.
+	session.Config = sessionCfg
+	session.UserAgent = userAgent
+
+	// Construct drive client.
+	driveClient, err := drive.NewClient(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("creating drive client: %w", err)
+	}
+	driveClient.Config = sessionCfg
+	driveClient.InitObjectCache()
+	driveClient.PrefetchBlocks = prefetchBlocks(appCfg)
+	driveClient.BlockCacheMode = appCfg.BlockCacheMode.Value()
+	driveClient.ShowVirtualDirs = appCfg.ShowVirtualDirs.Value()
+
+	// Open the write-back spool and recover pending uploads.
+	if appCfg.WriteBack.Value() {
+		spool, err := drive.NewSpool(keyring.XDGCachePath(filepath.Join("spool", name)), driveClient)
+		if err != nil {
+			return nil, fmt.Errorf("opening write-back spool: %w", err)
+		}
+		slog.Info("write-back enabled", "account", name, "pending", spool.Len())
+		driveClient.Spool = spool
+	}
+
+	// Open the pin store shared with 'proton fs pin'.
+	pins, err := drive.OpenPinStore(keyring.XDGCachePath(filepath.Join("pinned", name)))
+	if err != nil {
+		return nil, fmt.Errorf("opening pin store: %w", err)
+	}
+	driveClient.Pins = pins
+
+	// Construct DriveHandler and load shares.
+	handler := fusedrv.NewDriveHandler(driveClient)
+	if err := handler.LoadShares(ctx); err != nil {
+		return nil, fmt.Errorf("loading shares: %w", err)
+	}
+	if err := handler.RefreshQuota(ctx); err != nil {
+		slog.Warn("quota refresh failed", "account", name, "error", err)
+	}
+
+	return &accountMount{
+		name:    name,
+		store:   store,
+		session: session,
+		client:  driveClient,
+		handler: handler,
+	}, nil
+}
@@ -0,0 +1,198 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/major0/proton-utils/api/drive"
+	"github.com/major0/proton-utils/internal/control"
+)
+
+// controlState holds what the control commands report on and act on.
+type controlState struct {
+	mountpoint string
+	started    time.Time
+	accounts   []*accountMount // the default first
+}
+
+// account returns the named account, or the default one for "".
+func (st *controlState) account(name string) (*accountMount, error) {
+	for _, acct := range st.accounts {
+		if name == "" || acct.name == name {
+			return acct, nil
+		}
+	}
+	if name == "" {
+		return nil, errors.New("no account mounted")
+	}
+	return nil, fmt.Errorf("account %q is not mounted", name)
+}
+
+// startControl serves the control socket in the background.
+func startControl(st *controlState) (*control.Server, error) {
+	path, err := control.SocketPath()
+	if err != nil {
+		return nil, err
+	}
+	srv, err := control.Listen(path)
+	if err != nil {
+		return nil, err
+	}
+	registerControl(srv, st)
+	go srv.Serve()
+	return srv, nil
+}
+
+// registerControl registers the control commands of st on srv.
+func registerControl(srv *control.Server, st *controlState) {
+	srv.Handle(control.CmdStatus, func(context.Context, json.RawMessage) (any, error) {
+		return st.status(), nil
+	})
+	srv.Handle(control.CmdRefresh, func(ctx context.Context, _ json.RawMessage) (any, error) {
+		var errs []error
+		for _, acct := range st.accounts {
+			if err := acct.handler.RefreshShares(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: refreshing shares: %w", acct.name, err))
+			}
+			if err := acct.handler.RefreshQuota(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: refreshing quota: %w", acct.name, err))
+			}
+		}
+		return nil, errors.Join(errs...)
+	})
+	srv.Handle(control.CmdDropCaches, func(context.Context, json.RawMessage) (any, error) {
+		var errs []error
+		for _, acct := range st.accounts {
+			if err := acct.client.DropCaches(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", acct.name, err))
+			}
+		}
+		return nil, errors.Join(errs...)
+	})
+	srv.Handle(control.CmdFlush, func(ctx context.Context, _ json.RawMessage) (any, error) {
+		var errs []error
+		for _, acct := range st.accounts {
+			if acct.client.Spool == nil {
+				continue
+			}
+			if err := acct.client.Spool.Flush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", acct.name, err))
+			}
+		}
+		return nil, errors.Join(errs...)
+	})
+	srv.Handle(control.CmdPin, func(ctx context.Context, raw json.RawMessage) (any, error) {
+		var args control.PinArgs
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+		acct, err := st.account(args.Account)
+		if err != nil {
+			return nil, err
+		}
+		pin := drive.Pin{ShareID: args.ShareID, LinkID: args.LinkID, Path: args.Path}
+		if err := acct.client.Pins.Add(pin); err != nil {
+			return nil, err
+		}
+		return acct.client.SyncPin(ctx, pin)
+	})
+	srv.Handle(control.CmdUnpin, func(_ context.Context, raw json.RawMessage) (any, error) {
+		var args control.UnpinArgs
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+		acct, err := st.account(args.Account)
+		if err != nil {
+			return nil, err
+		}
+		removed, err := acct.client.Pins.Remove(args.LinkID)
+		if err != nil {
+			return nil, err
+		}
+		if !removed {
+			return nil, errors.New("not pinned")
+		}
+		return nil, nil
+	})
+	srv.Handle(control.CmdLogLevel, func(_ context.Context, raw json.RawMessage) (any, error) {
+		var args control.LogLevelArgs
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+		level, ok := parseLogLevel(args.Level)
+		if !ok {
+			return nil, fmt.Errorf("invalid log level %q (must be debug, info, warn or error)", args.Level)
+		}
+		logLevel.Set(level)
+		slog.Info("log level changed", "level", level)
+		return nil, nil
+	})
+}
+
+// status returns the daemon status reported by the status command.
+func (st *controlState) status() control.Status {
+	s := control.Status{
+		PID:      os.Getpid(),
+		Started:  st.started,
+		LogLevel: strings.ToLower(logLevel.Level().String()),
+		Accounts: make([]control.Account, 0, len(st.accounts)),
+	}
+	for i, acct := range st.accounts {
+		a := acct.status(st.mountpoint)
+		if i == 0 {
+			s.Account = acct.name
+			a.Mounts = append([]control.Mount{{
+				Mountpoint: st.mountpoint,
+				Namespace:  "drive",
+				Path:       filepath.Join(st.mountpoint, "drive"),
+				Shares:     acct.handler.ShareCount(),
+			}}, a.Mounts...)
+		}
+		s.Accounts = append(s.Accounts, a)
+	}
+	return s
+}
+
+// status returns the status of the account mounted under mountpoint.
+func (acct *accountMount) status(mountpoint string) control.Account {
+	a := control.Account{
+		Name:    acct.name,
+		Offline: acct.client.Offline(),
+		Mounts: []control.Mount{{
+			Mountpoint: mountpoint,
+			Namespace:  "drive",
+			Path:       filepath.Join(mountpoint, acct.name, "drive"),
+			Shares:     acct.handler.ShareCount(),
+		}},
+	}
+	if creds, err := acct.store.Load(); err == nil && creds != nil {
+		a.TokenRefresh = creds.LastRefresh
+	}
+
+	usage := acct.client.CacheUsage()
+	a.Cache = control.Cache{
+		Links:      usage.Links,
+		Objects:    usage.Objects,
+		Blocks:     usage.Blocks,
+		BlockBytes: usage.BlockBytes,
+	}
+	if pins, err := acct.client.Pins.List(); err == nil {
+		for _, pin := range pins {
+			a.Cache.Pins++
+			a.Cache.PinBytes += pin.Bytes
+		}
+	}
+	if acct.client.Spool != nil {
+		a.Cache.Queued = acct.client.Spool.Len()
+	}
+	return a
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/major0/proton-utils/internal/control"
+)
+
+func TestControl_LogLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	srv, err := control.Listen(path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	registerControl(srv, &controlState{})
+	go srv.Serve()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	prev := logLevel.Level()
+	t.Cleanup(func() { logLevel.Set(prev) })
+
+	ctx := context.Background()
+	if err := control.Call(ctx, path, control.CmdLogLevel, control.LogLevelArgs{Level: "DEBUG"}, nil); err != nil {
+		t.Fatalf("log-level debug: %v", err)
+	}
+	if got := logLevel.Level(); got != slog.LevelDebug {
+		t.Errorf("level = %v, want debug", got)
+	}
+
+	if err := control.Call(ctx, path, control.CmdLogLevel, control.LogLevelArgs{Level: "loud"}, nil); err == nil {
+		t.Error("log-level loud succeeded")
+	}
+	if got := logLevel.Level(); got != slog.LevelDebug {
+		t.Errorf("level after invalid request = %v, want debug", got)
+	}
+}
+
+func TestControlState_Account(t *testing.T) {
+	personal := &accountMount{name: "personal"}
+	work := &accountMount{name: "work"}
+	st := &controlState{accounts: []*accountMount{personal, work}}
+
+	tests := []struct {
+		name    string
+		want    *accountMount
+		wantErr bool
+	}{
+		{"", personal, false},
+		{"personal", personal, false},
+		{"work", work, false},
+		{"club", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := st.account(tt.name)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("account(%q) = %v, %v", tt.name, got, err)
+			}
+		})
+	}
+
+	if _, err := (&controlState{}).account(""); err == nil {
+		t.Error("account(\"\") with no accounts succeeded")
+	}
+}
@@ -9,33 +9,34 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/ProtonMail/go-proton-api"
-	"github.com/major0/proton-utils/api"
 	"github.com/major0/proton-utils/api/account"
 	"github.com/major0/proton-utils/api/config"
-	"github.com/major0/proton-utils/api/drive"
 	"github.com/major0/proton-utils/internal/fusemount"
-	fusedrv "github.com/major0/proton-utils/internal/fusemount/drive"
 	"github.com/major0/proton-utils/internal/keyring"
+	"github.com/major0/proton-utils/internal/metrics"
 	"github.com/major0/proton-utils/internal/sdnotify"
 	"github.com/spf13/pflag"
 )
 
 // daemonConfig holds the resolved configuration from CLI flags.
 type daemonConfig struct {
-	account     string
+	accounts    []string // --account, repeatable; empty = from config
 	logLevel    slog.Level
 	configPath  string
 	sessionFile string
 	mountpoint  string
+	metricsAddr string // metrics listener; empty = disabled
 }
 
 // parseFlags parses CLI flags from args and returns a resolved daemonConfig.
@@ -44,20 +45,22 @@ func parseFlags(args []string) (daemonConfig, error) {
 	fs := pflag.NewFlagSet("proton-fuse", pflag.ContinueOnError)
 
 	var (
-		account     string
+		accounts    []string
 		logLevel    string
 		verbose     int
 		configPath  string
 		sessionFile string
 		mountpoint  string
+		metricsAddr string
 	)
 
-	fs.StringVar(&account, "account", "", "select which account to use")
+	fs.StringArrayVar(&accounts, "account", nil, "mount an account (repeatable; the first is the default)")
 	fs.StringVar(&logLevel, "log-level", "", "log level: debug, info, warn, error")
 	fs.CountVarP(&verbose, "verbose", "v", "increase verbosity (repeatable: -v = info, -vv = debug)")
 	fs.StringVar(&configPath, "config", "", "override config file path")
 	fs.StringVar(&sessionFile, "session-file", "", "override session index file path")
 	fs.StringVar(&mountpoint, "mountpoint", "", "override mount path")
+	fs.StringVar(&metricsAddr, "metrics-listen", "", "serve metrics on host:port or unix:path")
 
 	if err := fs.Parse(args); err != nil {
 		return daemonConfig{}, err
@@ -86,31 +89,22 @@ func parseFlags(args []string) (daemonConfig, error) {
 	}
 
 	return daemonConfig{
-		account:     account,
+		accounts:    accounts,
 		logLevel:    resolvedLevel,
 		configPath:  configPath,
 		sessionFile: sessionFile,
 		mountpoint:  mountpoint,
+		metricsAddr: metricsAddr,
 	}, nil
 }
 
 // resolveLogLevel determines the effective log level. If logLevel is explicitly
 // set, it takes priority. Otherwise, the verbose count is used.
 func resolveLogLevel(logLevel string, verbose int) slog.Level {
-	if logLevel != "" {
-		switch strings.ToLower(logLevel) {
-		case "debug":
-			return slog.LevelDebug
-		case "info":
-			return slog.LevelInfo
-		case "warn":
-			return slog.LevelWarn
-		case "error":
-			return slog.LevelError
-		default:
-			// Invalid level falls through to verbose-based resolution.
-		}
+	if level, ok := parseLogLevel(logLevel); ok {
+		return level
 	}
+	// Invalid level falls through to verbose-based resolution.
 
 	switch {
 	case verbose >= 2:
@@ -122,6 +116,21 @@ func resolveLogLevel(logLevel string, verbose int) slog.Level {
 	}
 }
 
+// parseLogLevel parses a log level name: debug, info, warn or error.
+func parseLogLevel(name string) (slog.Level, bool) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	}
+	return 0, false
+}
+
 // xdgStatePath returns a path under $XDG_STATE_HOME/protonfs/.
 // If XDG_STATE_HOME is unset, it defaults to ~/.local/state/protonfs/.
 func xdgStatePath(name string) string {
@@ -133,6 +142,10 @@ func xdgStatePath(name string) string {
 	return filepath.Join(stateHome, "protonfs", name)
 }
 
+// logLevel is the level of the default logger. The control socket's
+// log-level command changes it at runtime.
+var logLevel slog.LevelVar
+
 // configureLogging sets up the default slog logger based on the resolved level.
 // It always writes structured JSON logs to stderr at the configured level.
 // When level is debug, it additionally opens persistent debug log files under
@@ -140,10 +153,12 @@ func xdgStatePath(name string) string {
 // The returned cleanup function closes any opened file handles and should be
 // deferred by the caller.
 func configureLogging(level slog.Level) (cleanup func(), err error) {
+	logLevel.Set(level)
+
 	// Non-debug: single JSON handler writing to stderr.
 	if level != slog.LevelDebug {
 		handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
-			Level: level,
+			Level: &logLevel,
 		})
 		slog.SetDefault(slog.New(handler))
 		return func() {}, nil
@@ -173,7 +188,7 @@ func configureLogging(level slog.Level) (cleanup func(), err error) {
 	// can be added later by creating child loggers with distinct writers.
 	debugWriter := io.MultiWriter(os.Stderr, fuseLog, driveLog)
 	handler := slog.NewJSONHandler(debugWriter, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
+		Level: &logLevel,
 	})
 	slog.SetDefault(slog.New(handler))
 
@@ -193,13 +208,19 @@ const userAgent = "proton-fuse/v0.1.0"
 const responseHeaderTimeout = 30 * time.Second
 
 // fuseCacheTimeout controls how long the kernel caches directory entries
-// and file attributes before re-validating with the FUSE daemon. A short
-// timeout balances reducing redundant Getattr/Lookup calls against
-// ensuring freshness for a network-backed filesystem.
-const fuseCacheTimeout = 1 * time.Second
-
-// refreshInterval is the period between share refresh and proactive token
-// refresh checks. Both tasks run on the same ticker to simplify shutdown.
+// and file attributes before re-validating with the FUSE daemon. The
+// change check pushes remote changes to open and the most recently used
+// links only, so the timeout bounds staleness for the links it skips;
+// matching the check interval keeps one bound for both.
+const fuseCacheTimeout = changeCheckInterval
+
+// changeCheckInterval is the period between checks of open and recently
+// used links for changes made by other clients.
+const changeCheckInterval = 30 * time.Second
+
+// refreshInterval is the period between share, quota and pin refresh
+// and proactive token refresh checks. All run on the same ticker to
+// simplify shutdown.
 const refreshInterval = 5 * time.Minute
 
 // requestTimeoutHook sets ResponseHeaderTimeout on the default transport
@@ -250,153 +271,171 @@ func run(cfg daemonConfig) error {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	// Step 2: Build SessionConfig from loaded config.
-	sessionCfg := config.BuildSessionConfig(appCfg, appCfg.MaxJobs.Value())
-
-	// Resolve account name: --account flag → config default for "protonfs".
-	acctName := cfg.account
-	if acctName == "" {
-		acctName = appCfg.DefaultAccount("protonfs")
-	}
-
-	// Build Proton options for session restore.
-	svc, err := api.LookupService("drive")
-	if err != nil {
-		return fmt.Errorf("looking up drive service: %w", err)
-	}
-
-	opts := []proton.Option{
-		proton.WithHostURL(svc.Host),
-		proton.WithAppVersion(svc.AppVersion("")),
-		proton.WithUserAgent(userAgent),
-	}
-	if cfg.logLevel == slog.LevelDebug {
-		opts = append(opts, proton.WithDebug(true))
-	}
-
-	// Step 3: Construct SessionIndex stores.
-	kr := keyring.SystemKeyring{}
-	store := keyring.NewSessionStore(cfg.sessionFile, acctName, "drive", kr)
-	accountStore := keyring.NewSessionStore(cfg.sessionFile, acctName, "account", kr)
-	cookieStore := keyring.NewSessionStore(cfg.sessionFile, acctName, "cookie", kr)
-
-	// Step 4: Restore session.
-	ctx := context.Background()
-	session, err := account.RestoreServiceSession(
-		ctx, "drive", opts,
-		store, accountStore, cookieStore,
-		svc.AppVersion(""), requestTimeoutHook,
-	)
+	// Step 2: Resolve the accounts to mount: --account flags → config
+	// default for "protonfs" plus protonfs.accounts.
+	names, err := resolveAccounts(cfg.accounts, appCfg)
 	if err != nil {
-		if errors.Is(err, api.ErrNotLoggedIn) {
-			return fmt.Errorf("not logged in (account %q). Run 'proton login' first", acctName)
-		}
-		return fmt.Errorf("restoring session: %w", err)
+		return err
 	}
 
-	// Step 5: Register auth/deauth handlers.
-	session.AddAuthHandler(account.NewAuthHandler(store, session))
-	session.AddDeauthHandler(account.NewDeauthHandler())
-
-	// Step 6: Set Session.Config and UserAgent.
-	session.Config = sessionCfg
-	session.UserAgent = userAgent
-
-	// Step 7: Compute prefetch configuration from config.
-	prefetchBlocks := appCfg.PrefetchBlocks.Value()
-	if prefetchBlocks < 0 {
-		prefetchBlocks = 0
-	}
-	if prefetchBlocks > 64 {
-		prefetchBlocks = 64
-	}
-
-	// Step 8: Construct drive client.
-	driveClient, err := drive.NewClient(ctx, session)
-	if err != nil {
-		return fmt.Errorf("creating drive client: %w", err)
-	}
-	driveClient.Config = sessionCfg
-	driveClient.InitObjectCache()
-	driveClient.PrefetchBlocks = prefetchBlocks
-
-	// Step 8b: Validate and propagate block cache mode.
+	// Step 3: Validate the block cache mode shared by all accounts.
 	blockCacheMode := appCfg.BlockCacheMode.Value()
 	if blockCacheMode != "encrypted" && blockCacheMode != "decrypted" {
 		return fmt.Errorf("invalid block_cache_mode %q (must be \"encrypted\" or \"decrypted\")", blockCacheMode)
 	}
 	slog.Info("block cache mode", "mode", blockCacheMode)
-	driveClient.BlockCacheMode = blockCacheMode
 
-	// Step 9: Construct DriveHandler and load shares.
-	handler := fusedrv.NewDriveHandler(driveClient)
-	if err := handler.LoadShares(ctx); err != nil {
-		return fmt.Errorf("loading shares: %w", err)
+	// Step 4: Restore each account's session and load its shares. The
+	// default account is required; others that fail are skipped.
+	ctx := context.Background()
+	var accounts []*accountMount
+	for i, name := range names {
+		acct, err := openAccount(ctx, cfg, appCfg, name)
+		if err != nil {
+			if i == 0 {
+				return err
+			}
+			slog.Warn("skipping account", "account", name, "error", err)
+			continue
+		}
+		accounts = append(accounts, acct)
 	}
 
-	// Step 10: Register in NamespaceRegistry.
+	// Step 5: Register in NamespaceRegistry: every account under
+	// <account>/drive, the default one also at drive.
 	registry := fusemount.NewRegistry()
-	registry.Register("drive", handler)
+	registry.Register("drive", accounts[0].handler)
+	for _, acct := range accounts {
+		sub := fusemount.NewRegistry()
+		sub.Register("drive", acct.handler)
+		registry.RegisterDir(acct.name, sub)
+	}
+
+	// Step 6: Open the metrics listener, if enabled.
+	var metricsListener net.Listener
+	var fuseOps *metrics.HistogramVec
+	metricsRegistry := metrics.NewRegistry()
+	if cfg.metricsAddr != "" {
+		metricsListener, err = metrics.Listen(cfg.metricsAddr)
+		if err != nil {
+			return fmt.Errorf("opening metrics listener: %w", err)
+		}
+		fuseOps = metricsRegistry.HistogramVec("proton_fuse_op_duration_seconds",
+			"Latency of FUSE operations.", "op")
+		for _, acct := range accounts {
+			label := metrics.Label{Name: "account", Value: acct.name}
+			metrics.RegisterSession(metricsRegistry, acct.session, label)
+			metrics.RegisterDrive(metricsRegistry, acct.client, label)
+		}
+	}
 
-	// Step 11: Mount FUSE filesystem.
+	// Step 7: Mount FUSE filesystem.
 	mountCfg := fusemount.MountConfig{
 		Mountpoint:     cfg.mountpoint,
 		EntryTimeout:   fuseCacheTimeout,
 		AttrTimeout:    fuseCacheTimeout,
-		PrefetchBlocks: prefetchBlocks,
+		PrefetchBlocks: prefetchBlocks(appCfg),
+		CheckInterval:  changeCheckInterval,
+	}
+	if fuseOps != nil {
+		mountCfg.Ops = fuseOps
 	}
 	server, err := fusemount.Mount(mountCfg, registry)
 	if err != nil {
+		if metricsListener != nil {
+			_ = metricsListener.Close()
+		}
 		return fmt.Errorf("mounting filesystem: %w", err)
 	}
 
-	// Step 12: Signal systemd readiness.
+	// Step 8: Signal systemd readiness.
 	if err := sdnotify.Ready(); err != nil {
 		fmt.Fprintf(os.Stderr, "warning: sd_notify: %v\n", err)
 	}
 
-	slog.Info("proton-fuse ready", "mountpoint", cfg.mountpoint)
+	slog.Info("proton-fuse ready", "mountpoint", cfg.mountpoint, "accounts", len(accounts))
 
-	// Step 13: Start combined refresh goroutine.
-	// Initialize lastRefresh from persisted credentials.
-	creds, err := store.Load()
-	var lastRefresh time.Time
-	if err == nil && creds != nil {
-		lastRefresh = creds.LastRefresh
+	// Step 8b: Serve the control socket.
+	ctl := &controlState{
+		mountpoint: cfg.mountpoint,
+		started:    time.Now(),
+		accounts:   accounts,
+	}
+	ctlServer, err := startControl(ctl)
+	if err != nil {
+		slog.Warn("control socket unavailable", "error", err)
 	}
 
+	// Step 8c: Serve metrics.
+	metricsCtx, metricsCancel := context.WithCancel(context.Background())
+	defer metricsCancel()
+	if metricsListener != nil {
+		go func() {
+			if err := metrics.Serve(metricsCtx, metricsListener, metricsRegistry); err != nil {
+				slog.Warn("metrics listener failed", "error", err)
+			}
+		}()
+		slog.Info("serving metrics", "addr", cfg.metricsAddr)
+	}
+
+	// Step 9: Start a refresh goroutine and an uploader per account.
 	refreshCtx, refreshCancel := context.WithCancel(context.Background())
-	refreshDone := make(chan struct{})
-	go func() {
-		defer close(refreshDone)
-		startRefreshLoop(refreshCtx, handler, session, lastRefresh)
-	}()
+	spoolCtx, spoolCancel := context.WithCancel(context.Background())
+	var refreshWG, spoolWG sync.WaitGroup
+	for _, acct := range accounts {
+		// Initialize lastRefresh from persisted credentials.
+		var lastRefresh time.Time
+		if creds, err := acct.store.Load(); err == nil && creds != nil {
+			lastRefresh = creds.LastRefresh
+		}
+		refreshWG.Go(func() {
+			startRefreshLoop(refreshCtx, acct, lastRefresh)
+		})
+		if spool := acct.client.Spool; spool != nil {
+			spoolWG.Go(func() { spool.Run(spoolCtx) })
+		}
+	}
 
-	// Step 14: Signal wait — SIGTERM/SIGINT triggers graceful shutdown.
+	// Step 10: Signal wait — SIGTERM/SIGINT triggers graceful shutdown.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
 	<-sigCh
 
 	slog.Info("shutdown signal received, stopping")
 
-	// Cancel refresh goroutine first.
+	// Stop taking control requests, then cancel the refresh goroutines.
+	if ctlServer != nil {
+		_ = ctlServer.Close()
+	}
+	metricsCancel()
 	refreshCancel()
-	<-refreshDone
+	refreshWG.Wait()
 
 	// Unmount and wait for in-flight FUSE operations.
 	if err := server.Unmount(); err != nil {
+		spoolCancel()
 		return fmt.Errorf("unmount: %w", err)
 	}
 	server.Wait()
+
+	// Stop the uploaders last; unfinished jobs resume on the next start.
+	spoolCancel()
+	spoolWG.Wait()
 	return nil
 }
 
-// startRefreshLoop runs the combined share refresh and proactive token
-// refresh on a periodic ticker. It blocks until ctx is cancelled.
-func startRefreshLoop(ctx context.Context, handler *fusedrv.DriveHandler, session *api.Session, lastRefresh time.Time) {
+// startRefreshLoop runs the combined share, quota and pin refresh and
+// proactive token refresh of an account on a periodic ticker. Pins are
+// synced once up front. It blocks until ctx is cancelled.
+func startRefreshLoop(ctx context.Context, acct *accountMount, lastRefresh time.Time) {
+	handler, client, session := acct.handler, acct.client, acct.session
+	log := slog.With("account", acct.name)
 	ticker := time.NewTicker(refreshInterval)
 	defer ticker.Stop()
 
+	// Failures are logged per pin by SyncPins.
+	_ = client.SyncPins(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -404,16 +443,24 @@ func startRefreshLoop(ctx context.Context, handler *fusedrv.DriveHandler, sessio
 		case <-ticker.C:
 			// Share refresh.
 			if err := handler.RefreshShares(ctx); err != nil {
-				slog.Warn("share refresh failed", "error", err)
+				log.Warn("share refresh failed", "error", err)
 			}
 
+			// Quota refresh — keeps statfs(2) current.
+			if err := handler.RefreshQuota(ctx); err != nil {
+				log.Warn("quota refresh failed", "error", err)
+			}
+
+			// Pin sync — fetch new revisions of pinned files.
+			_ = client.SyncPins(ctx)
+
 			// Proactive token refresh — trigger a lightweight API call
 			// if the session's token age exceeds the threshold.
 			if account.NeedsProactiveRefresh(lastRefresh) {
 				if _, err := session.Client.GetUser(ctx); err != nil {
-					slog.Warn("proactive token refresh failed", "error", err)
+					log.Warn("proactive token refresh failed", "error", err)
 				} else {
-					slog.Debug("proactive token refresh succeeded")
+					log.Debug("proactive token refresh succeeded")
 					lastRefresh = time.Now()
 				}
 			}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/major0/proton-utils/api"
@@ -24,8 +25,8 @@ func TestParseFlags_Defaults(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if cfg.account != "" {
-		t.Errorf("account = %q, want empty", cfg.account)
+	if len(cfg.accounts) != 0 {
+		t.Errorf("accounts = %q, want empty", cfg.accounts)
 	}
 	if cfg.logLevel != slog.LevelWarn {
 		t.Errorf("logLevel = %v, want %v", cfg.logLevel, slog.LevelWarn)
@@ -51,13 +52,14 @@ func TestParseFlags_AllExplicit(t *testing.T) {
 		"--config", "/etc/proton/config.yaml",
 		"--session-file", "/tmp/sessions.db",
 		"--mountpoint", "/mnt/proton",
+		"--metrics-listen", "127.0.0.1:9464",
 	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if cfg.account != "work" {
-		t.Errorf("account = %q, want %q", cfg.account, "work")
+	if !slices.Equal(cfg.accounts, []string{"work"}) {
+		t.Errorf("accounts = %q, want [work]", cfg.accounts)
 	}
 	if cfg.logLevel != slog.LevelDebug {
 		t.Errorf("logLevel = %v, want %v", cfg.logLevel, slog.LevelDebug)
@@ -71,6 +73,9 @@ func TestParseFlags_AllExplicit(t *testing.T) {
 	if cfg.mountpoint != "/mnt/proton" {
 		t.Errorf("mountpoint = %q, want %q", cfg.mountpoint, "/mnt/proton")
 	}
+	if cfg.metricsAddr != "127.0.0.1:9464" {
+		t.Errorf("metricsAddr = %q, want %q", cfg.metricsAddr, "127.0.0.1:9464")
+	}
 }
 
 func TestParseFlags_VerboseCount(t *testing.T) {
@@ -526,3 +531,53 @@ func TestBlockCacheModePropagation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFlags_RepeatedAccount(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	cfg, err := parseFlags([]string{"--account", "personal", "--account", "work"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.accounts, []string{"personal", "work"}) {
+		t.Errorf("accounts = %q, want [personal work]", cfg.accounts)
+	}
+}
+
+func TestResolveAccounts(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      []string
+		defaultAcc string   // core.account; "" = unset
+		configured []string // protonfs.accounts; nil = unset
+		want       []string
+		wantErr    bool
+	}{
+		{name: "defaults", want: []string{"default"}},
+		{name: "configured default", defaultAcc: "personal", want: []string{"personal"}},
+		{name: "default added", defaultAcc: "personal", configured: []string{"work", "club"}, want: []string{"personal", "work", "club"}},
+		{name: "default listed", defaultAcc: "work", configured: []string{"personal", "work"}, want: []string{"work", "personal"}},
+		{name: "flags win", flags: []string{"work", "personal"}, defaultAcc: "club", configured: []string{"club"}, want: []string{"work", "personal"}},
+		{name: "flag twice", flags: []string{"work", "work"}, wantErr: true},
+		{name: "shadows namespace", flags: []string{"drive"}, wantErr: true},
+		{name: "path", flags: []string{"a/b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appCfg := config.DefaultConfig()
+			if tt.defaultAcc != "" {
+				appCfg.Account.SetFile(tt.defaultAcc)
+			}
+			if tt.configured != nil {
+				appCfg.Accounts.SetFile(tt.configured)
+			}
+			got, err := resolveAccounts(tt.flags, appCfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveAccounts error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("resolveAccounts = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
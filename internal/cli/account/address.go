@@ -33,16 +33,33 @@ func addressInfoFrom(addrs []account.Address) []addressInfo {
 	return out
 }
 
+// addressRecord is the --output schema of one address.
+type addressRecord struct {
+	Email  string `json:"email"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// addressRecords converts addresses to their --output records.
+func addressRecords(addresses []addressInfo) []addressRecord {
+	out := make([]addressRecord, len(addresses))
+	for i := range addresses {
+		out[i] = addressRecord{
+			Email:  addresses[i].Email,
+			Type:   common.AddressType(addresses[i].Type).String(),
+			Status: common.AddressStatus(addresses[i].Status).String(),
+		}
+	}
+	return out
+}
+
 // renderAddresses writes the address table to the given writer.
 func renderAddresses(w io.Writer, addresses []addressInfo) {
 	t := table.NewWriter()
 	t.SetOutputMirror(w)
 	t.AppendHeader(table.Row{"Address", "Type", "State"})
-	for i := range addresses {
-		addr := addresses[i].Email
-		addrType := common.AddressType(addresses[i].Type).String()
-		addrStatus := common.AddressStatus(addresses[i].Status).String()
-		t.AppendRow(table.Row{addr, addrType, addrStatus})
+	for _, r := range addressRecords(addresses) {
+		t.AppendRow(table.Row{r.Email, r.Type, r.Status})
 	}
 	t.Render()
 }
@@ -68,7 +85,11 @@ var accountAddressCmd = &cobra.Command{
 			return err
 		}
 
-		renderAddresses(os.Stdout, addressInfoFrom(addresses))
+		infos := addressInfoFrom(addresses)
+		if cli.Structured(cmd) {
+			return cli.PrintRecords(cmd, addressRecords(infos))
+		}
+		renderAddresses(os.Stdout, infos)
 		return nil
 	},
 }
@@ -14,19 +14,20 @@ import (
 
 // userInfo holds the primitive values extracted from an opaque account.User
 // for rendering. This keeps the render function testable without needing
-// to construct opaque types.
+// to construct opaque types. The JSON tags are the --output schema; sizes
+// are in bytes.
 type userInfo struct {
-	ID                string
-	DisplayName       string
-	Name              string
-	Email             string
-	UsedSpace         int64
-	MaxSpace          int64
-	MailUsedSpace     int64
-	DriveUsedSpace    int64
-	CalendarUsedSpace int64
-	PassUsedSpace     int64
-	ContactUsedSpace  int64
+	ID                string `json:"id"`
+	DisplayName       string `json:"display_name"`
+	Name              string `json:"username"`
+	Email             string `json:"email"`
+	UsedSpace         int64  `json:"used_space"`
+	MaxSpace          int64  `json:"max_space"`
+	MailUsedSpace     int64  `json:"mail_used_space"`
+	DriveUsedSpace    int64  `json:"drive_used_space"`
+	CalendarUsedSpace int64  `json:"calendar_used_space"`
+	PassUsedSpace     int64  `json:"pass_used_space"`
+	ContactUsedSpace  int64  `json:"contact_used_space"`
 }
 
 // userInfoFrom extracts rendering data from an opaque account.User.
@@ -96,7 +97,11 @@ var accountInfoCmd = &cobra.Command{
 			return err
 		}
 
-		renderUserInfo(os.Stdout, userInfoFrom(user))
+		info := userInfoFrom(user)
+		if cli.Structured(cmd) {
+			return cli.PrintObject(cmd, info)
+		}
+		renderUserInfo(os.Stdout, info)
 		return nil
 	},
 }
@@ -1,7 +1,6 @@
 package accountCmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
@@ -48,7 +47,7 @@ var accountStatusCmd = &cobra.Command{
 
 func init() {
 	accountCmd.AddCommand(accountStatusCmd)
-	accountStatusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output as JSON")
+	accountStatusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output as JSON (same as --output json)")
 }
 
 // buildServiceStatus builds the status for a single service, given the
@@ -122,7 +121,7 @@ func runAccountStatus(cmd *cobra.Command, _ []string) error {
 
 	if len(accounts) == 0 {
 		fmt.Fprintln(os.Stderr, "Not logged in.")
-		os.Exit(1)
+		os.Exit(int(cli.ExitAuth))
 	}
 
 	// Load account session for staleness comparison.
@@ -171,9 +170,10 @@ func runAccountStatus(cmd *cobra.Command, _ []string) error {
 	}
 
 	if statusJSON {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(results)
+		return cli.WriteRecords(os.Stdout, cli.OutputJSON, results)
+	}
+	if cli.Structured(cmd) {
+		return cli.PrintRecords(cmd, results)
 	}
 
 	// Human-readable output.
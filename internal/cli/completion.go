@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	common "github.com/major0/proton-utils/api"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout bounds the API calls made to refresh completion
+// candidates, so pressing Tab never hangs on a slow network.
+const completionTimeout = 2 * time.Second
+
+// CompletionFetcher loads completion candidates from the API. Each
+// candidate is a value, optionally followed by a tab and a description,
+// as cobra expects.
+type CompletionFetcher func(ctx context.Context, session *common.Session) ([]string, error)
+
+// CompleteCached returns the completion candidates stored under key for
+// the current account. Fresh candidates come straight from the on-disk
+// cache; otherwise fetch is called with a short deadline and its result
+// cached. When the fetch fails or times out, stale candidates are
+// returned if there are any.
+//
+// Cobra does not run PersistentPreRunE for completion requests, so the
+// RuntimeContext is set up here first.
+func CompleteCached(cmd *cobra.Command, key string, fetch CompletionFetcher) []string {
+	if err := prepareCompletion(cmd); err != nil {
+		slog.Debug("completion: setup", "error", err)
+		return nil
+	}
+	cache := openCompletionCache(GetContext(cmd))
+
+	cached, fresh, ok := cache.get(key)
+	if fresh {
+		return cached
+	}
+
+	parent := cmd.Context()
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, completionTimeout)
+	defer cancel()
+
+	values, err := fetchCompletions(ctx, cmd, fetch)
+	if err != nil {
+		slog.Debug("completion: fetch", "key", key, "error", err)
+		if ok {
+			return cached
+		}
+		return nil
+	}
+	if err := cache.put(key, values); err != nil {
+		slog.Debug("completion: cache write", "key", key, "error", err)
+	}
+	return values
+}
+
+// fetchCompletions restores the session and calls fetch, both bounded
+// by ctx.
+func fetchCompletions(ctx context.Context, cmd *cobra.Command, fetch CompletionFetcher) ([]string, error) {
+	session, err := SetupSession(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return fetch(ctx, session)
+}
+
+// prepareCompletion runs the PersistentPreRunE that a normal invocation
+// of cmd would run, unless a RuntimeContext is already present.
+func prepareCompletion(cmd *cobra.Command) error {
+	if GetContext(cmd) != nil {
+		return nil
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.PersistentPreRunE != nil {
+			return c.PersistentPreRunE(cmd, nil)
+		}
+	}
+	return nil
+}
+
+// openCompletionCache opens the completion cache of the current account,
+// keyed by its stored credentials. It returns nil (no caching) when the
+// account has no stored session or $XDG_RUNTIME_DIR is unset.
+func openCompletionCache(rc *RuntimeContext) *completionCache {
+	if rc == nil {
+		return nil
+	}
+	for _, store := range []common.SessionStore{rc.SessionStore, rc.AccountStore} {
+		if store == nil {
+			continue
+		}
+		creds, err := store.Load()
+		if err != nil || creds.SaltedKeyPass == "" {
+			continue
+		}
+		cache, err := newCompletionCache(completionCacheDir(creds.UID), []byte(creds.SaltedKeyPass), creds.UID)
+		if err != nil {
+			slog.Debug("completion: cache", "error", err)
+			return nil
+		}
+		return cache
+	}
+	return nil
+}
+
+// FilterCompletions returns the candidates whose value starts with
+// prefix. Descriptions after a tab are ignored when matching.
+func FilterCompletions(candidates []string, prefix string) []string {
+	var out []string
+	for _, c := range candidates {
+		value, _, _ := strings.Cut(c, "\t")
+		if strings.HasPrefix(value, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
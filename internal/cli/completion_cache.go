@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	common "github.com/major0/proton-utils/api"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// completionFresh is how long cached candidates are served without
+	// asking the API again.
+	completionFresh = 2 * time.Minute
+
+	// completionMaxAge is how long stale candidates are kept as a
+	// fallback for when the API is slow or unreachable.
+	completionMaxAge = time.Hour
+
+	// completionKeyInfo is the HKDF info string for the cache keys.
+	completionKeyInfo = "proton-utils completion cache v1"
+)
+
+// completionCache is an on-disk cache of shell completion candidates.
+// Entries are sealed with AES-256-GCM under a key derived from the
+// session's salted key passphrase, so names cached from Drive and Lumo
+// are no more readable on disk than the session itself. File names are
+// keyed HMACs of the cache key, which hides the paths being completed.
+//
+// A nil *completionCache is valid and caches nothing.
+type completionCache struct {
+	store  *common.ObjectCache
+	aead   cipher.AEAD
+	macKey []byte
+	now    func() time.Time
+}
+
+// completionEntry is the plaintext of one cache entry.
+type completionEntry struct {
+	Time   int64    `json:"t"` // Unix seconds when fetched
+	Values []string `json:"v"`
+}
+
+// newCompletionCache returns a cache in dir keyed by secret, or nil when
+// dir or secret is empty.
+func newCompletionCache(dir string, secret []byte, uid string) (*completionCache, error) {
+	if dir == "" || len(secret) == 0 {
+		return nil, nil
+	}
+
+	r := hkdf.New(sha256.New, secret, []byte(uid), []byte(completionKeyInfo))
+	keys := make([]byte, 64)
+	if _, err := io.ReadFull(r, keys); err != nil {
+		return nil, fmt.Errorf("completion cache: derive key: %w", err)
+	}
+	block, err := aes.NewCipher(keys[:32])
+	if err != nil {
+		return nil, fmt.Errorf("completion cache: new cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("completion cache: new gcm: %w", err)
+	}
+	return &completionCache{
+		store:  common.NewObjectCache(dir),
+		aead:   aead,
+		macKey: keys[32:],
+		now:    time.Now,
+	}, nil
+}
+
+// fileKey returns the on-disk name for key.
+func (c *completionCache) fileKey(key string) string {
+	m := hmac.New(sha256.New, c.macKey)
+	m.Write([]byte(key))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// get returns the candidates cached under key and whether they are
+// still fresh. Entries that cannot be opened or are older than
+// completionMaxAge are removed and reported as misses.
+func (c *completionCache) get(key string) (values []string, fresh, ok bool) {
+	if c == nil {
+		return nil, false, false
+	}
+	name := c.fileKey(key)
+	data, _ := c.store.Read(name)
+	if data == nil {
+		return nil, false, false
+	}
+
+	entry, err := c.open(name, data)
+	age := c.now().Sub(time.Unix(entry.Time, 0))
+	if err != nil || age > completionMaxAge || age < -completionFresh {
+		_ = c.store.Erase(name)
+		return nil, false, false
+	}
+	return entry.Values, age < completionFresh, true
+}
+
+// put stores values under key.
+func (c *completionCache) put(key string, values []string) error {
+	if c == nil {
+		return nil
+	}
+	plaintext, err := json.Marshal(completionEntry{Time: c.now().Unix(), Values: values})
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	name := c.fileKey(key)
+	return c.store.Write(name, c.aead.Seal(nonce, nonce, plaintext, []byte(name)))
+}
+
+// open decrypts a stored entry. The file name is the additional data, so
+// an entry moved to another name does not open.
+func (c *completionCache) open(name string, data []byte) (completionEntry, error) {
+	var entry completionEntry
+	n := c.aead.NonceSize()
+	if len(data) < n {
+		return entry, errors.New("completion cache: entry too short")
+	}
+	plaintext, err := c.aead.Open(nil, data[:n], data[n:], []byte(name))
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(plaintext, &entry)
+	return entry, err
+}
+
+// completionCacheDir returns the directory for uid's completion cache
+// under $XDG_RUNTIME_DIR, or "" when it is unset. The runtime directory
+// is private to the user and cleared at logout.
+func completionCacheDir(uid string) string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" || uid == "" {
+		return ""
+	}
+	return filepath.Join(dir, "proton", "completion", uid)
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+func newTestCompletionCache(t *testing.T, dir, secret string) *completionCache {
+	t.Helper()
+	c, err := newCompletionCache(dir, []byte(secret), "uid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCompletionCache_Disabled(t *testing.T) {
+	for _, tc := range []struct{ dir, secret string }{{"", "s"}, {t.TempDir(), ""}} {
+		c, err := newCompletionCache(tc.dir, []byte(tc.secret), "uid")
+		if err != nil || c != nil {
+			t.Fatalf("newCompletionCache(%q, %q) = %v, %v; want nil, nil", tc.dir, tc.secret, c, err)
+		}
+		if err := c.put("k", []string{"v"}); err != nil {
+			t.Fatalf("nil put: %v", err)
+		}
+		if _, _, ok := c.get("k"); ok {
+			t.Fatal("nil get reported a hit")
+		}
+	}
+}
+
+func TestCompletionCache_Age(t *testing.T) {
+	c := newTestCompletionCache(t, t.TempDir(), "secret")
+	now := time.Unix(1700000000, 0)
+	c.now = func() time.Time { return now }
+
+	want := []string{"Documents/", "notes.txt"}
+	if err := c.put("drive/dir/proton:///", want); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		after     time.Duration
+		wantFresh bool
+		wantOK    bool
+	}{
+		{0, true, true},
+		{completionFresh - time.Second, true, true},
+		{completionFresh, false, true},
+		{completionMaxAge, false, true},
+		{completionMaxAge + time.Second, false, false},
+	}
+	for _, tt := range tests {
+		if err := c.put("k", want); err != nil {
+			t.Fatal(err)
+		}
+		c.now = func() time.Time { return now.Add(tt.after) }
+		got, fresh, ok := c.get("k")
+		if fresh != tt.wantFresh || ok != tt.wantOK {
+			t.Errorf("after %v: fresh=%v ok=%v, want %v %v", tt.after, fresh, ok, tt.wantFresh, tt.wantOK)
+		}
+		if ok && !reflect.DeepEqual(got, want) {
+			t.Errorf("after %v: values = %q, want %q", tt.after, got, want)
+		}
+		c.now = func() time.Time { return now }
+	}
+
+	// An expired entry is removed.
+	c.now = func() time.Time { return now.Add(2 * completionMaxAge) }
+	c.get("k")
+	c.now = func() time.Time { return now }
+	if _, _, ok := c.get("k"); ok {
+		t.Error("expired entry was not removed")
+	}
+}
+
+func TestCompletionCache_Encrypted(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestCompletionCache(t, dir, "secret")
+	if err := c.put("drive/dir/proton:///Private Stuff/", []string{"tax-return-2026.pdf"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither the key nor the values appear on disk.
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.Contains(p, "Private") {
+			t.Errorf("cache key visible in path %s", p)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(data), "tax-return") {
+			t.Errorf("cached value visible in %s", p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another key cannot read the entry.
+	other := newTestCompletionCache(t, dir, "other secret")
+	if _, _, ok := other.get("drive/dir/proton:///Private Stuff/"); ok {
+		t.Error("entry readable with a different key")
+	}
+}
+
+// TestCompletionCache_RoundTrip_Property verifies that whatever is put
+// under a key is read back unchanged and fresh.
+func TestCompletionCache_RoundTrip_Property(t *testing.T) {
+	c := newTestCompletionCache(t, t.TempDir(), "secret")
+	rapid.Check(t, func(t *rapid.T) {
+		key := rapid.String().Draw(t, "key")
+		values := rapid.SliceOf(rapid.String()).Draw(t, "values")
+		if err := c.put(key, values); err != nil {
+			t.Fatal(err)
+		}
+		got, fresh, ok := c.get(key)
+		if !ok || !fresh {
+			t.Fatalf("get(%q): fresh=%v ok=%v", key, fresh, ok)
+		}
+		if len(got) != len(values) || (len(values) > 0 && !reflect.DeepEqual(got, values)) {
+			t.Fatalf("get(%q) = %q, want %q", key, got, values)
+		}
+	})
+}
+
+func TestFilterCompletions(t *testing.T) {
+	candidates := []string{"abc123\tFirst chat", "abd456\tSecond", "xyz", "ab\tprefix\tof tab"}
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", candidates},
+		{"ab", []string{"abc123\tFirst chat", "abd456\tSecond", "ab\tprefix\tof tab"}},
+		{"abc", []string{"abc123\tFirst chat"}},
+		{"Fir", nil},
+		{"x", []string{"xyz"}},
+	}
+	for _, tt := range tests {
+		if got := FilterCompletions(candidates, tt.prefix); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterCompletions(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -20,25 +20,33 @@ func init() {
 	configCmd.AddCommand(listCmd)
 }
 
+// listRecord is the --output schema of one config entry.
+type listRecord struct {
+	Selector string `json:"selector"`
+	Value    string `json:"value"`
+}
+
 func runList(cmd *cobra.Command, args []string) error {
 	rc := cli.GetContext(cmd)
-	cfg := rc.Config
-	if cfg == nil {
-		return nil // no config file → no output
+	var records []listRecord
+	if cfg := rc.Config; cfg != nil {
+		var pattern string
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+		for _, entry := range config.List(cfg) {
+			if pattern != "" && !config.MatchPattern(entry.Selector, pattern) {
+				continue
+			}
+			records = append(records, listRecord{Selector: entry.Selector, Value: entry.Value})
+		}
 	}
 
-	entries := config.List(cfg)
-
-	var pattern string
-	if len(args) > 0 {
-		pattern = args[0]
+	if cli.Structured(cmd) {
+		return cli.PrintRecords(cmd, records)
 	}
-
-	for _, entry := range entries {
-		if pattern != "" && !config.MatchPattern(entry.Selector, pattern) {
-			continue
-		}
-		fmt.Printf("%s=%s\n", entry.Selector, entry.Value)
+	for _, r := range records {
+		fmt.Printf("%s=%s\n", r.Selector, r.Value)
 	}
 	return nil
 }
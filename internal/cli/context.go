@@ -55,6 +55,10 @@ type RuntimeContext struct {
 	// Verbose is the -v count from the root command. 0 = default (short IDs),
 	// >= 1 = verbose output (full IDs, extra detail).
 	Verbose int
+
+	// Output is the --output format. Commands that print records check
+	// it with Structured before falling back to their human-readable form.
+	Output OutputFormat
 }
 
 // SetContext stores a RuntimeContext on the cobra command's context.
@@ -0,0 +1,314 @@
+package driveCmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// archiveFormat identifies the container read by drive import and
+// written by drive export.
+type archiveFormat int
+
+const (
+	formatTar archiveFormat = iota
+	formatTarGz
+	formatZip
+)
+
+// Default permissions applied when a link carries no mode in its XAttr
+// (folders, and files uploaded without --preserve=mode).
+const (
+	defaultDirMode  = 0o755
+	defaultFileMode = 0o644
+)
+
+// parseArchiveFormat resolves the archive format from an explicit
+// --format value, falling back to the output file extension. Writing
+// to stdout ("-") without --format produces a plain tar stream.
+func parseArchiveFormat(format, output string) (archiveFormat, error) {
+	switch strings.ToLower(format) {
+	case "tar":
+		return formatTar, nil
+	case "tgz", "tar.gz", "gz":
+		return formatTarGz, nil
+	case "zip":
+		return formatZip, nil
+	case "":
+		// Fall through to extension detection.
+	default:
+		return 0, fmt.Errorf("unknown archive format %q (want tar, tgz, or zip)", format)
+	}
+
+	lower := strings.ToLower(output)
+	switch {
+	case output == "-", strings.HasSuffix(lower, ".tar"):
+		return formatTar, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip, nil
+	default:
+		return 0, fmt.Errorf("%s: cannot determine archive format from extension (use --format)", output)
+	}
+}
+
+// archiveWriter is the minimal interface drive export needs from an
+// archive container. Entries are written strictly in order; file
+// content is streamed through the io.Writer returned by CreateFile and
+// must be fully written before the next entry is created.
+type archiveWriter interface {
+	AddDir(name string, mode os.FileMode, mtime time.Time) error
+	CreateFile(name string, mode os.FileMode, mtime time.Time, size int64) (io.Writer, error)
+	Close() error
+}
+
+// newArchiveWriter wraps w in an archiveWriter for the given format.
+// Closing the archiveWriter flushes all container trailers but does not
+// close w.
+func newArchiveWriter(w io.Writer, format archiveFormat) archiveWriter {
+	switch format {
+	case formatZip:
+		return &zipArchive{zw: zip.NewWriter(w)}
+	case formatTarGz:
+		gz := gzip.NewWriter(w)
+		return &tarArchive{tw: tar.NewWriter(gz), gz: gz}
+	default:
+		return &tarArchive{tw: tar.NewWriter(w)}
+	}
+}
+
+// tarArchive writes PAX tar entries, optionally gzip-compressed.
+type tarArchive struct {
+	tw *tar.Writer
+	gz *gzip.Writer // nil for uncompressed tar
+}
+
+func (a *tarArchive) AddDir(name string, mode os.FileMode, mtime time.Time) error {
+	return a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     strings.TrimSuffix(name, "/") + "/",
+		Mode:     int64(mode.Perm()),
+		ModTime:  mtime,
+		Format:   tar.FormatPAX,
+	})
+}
+
+func (a *tarArchive) CreateFile(name string, mode os.FileMode, mtime time.Time, size int64) (io.Writer, error) {
+	if err := a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(mode.Perm()),
+		ModTime:  mtime,
+		Size:     size,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return nil, err
+	}
+	return a.tw, nil
+}
+
+func (a *tarArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	if a.gz != nil {
+		return a.gz.Close()
+	}
+	return nil
+}
+
+// zipArchive writes deflate-compressed zip entries.
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchive) AddDir(name string, mode os.FileMode, mtime time.Time) error {
+	hdr := &zip.FileHeader{
+		Name:     strings.TrimSuffix(name, "/") + "/",
+		Method:   zip.Store,
+		Modified: mtime,
+	}
+	hdr.SetMode(os.ModeDir | mode.Perm())
+	_, err := a.zw.CreateHeader(hdr)
+	return err
+}
+
+func (a *zipArchive) CreateFile(name string, mode os.FileMode, mtime time.Time, _ int64) (io.Writer, error) {
+	hdr := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: mtime,
+	}
+	hdr.SetMode(mode.Perm())
+	return a.zw.CreateHeader(hdr)
+}
+
+func (a *zipArchive) Close() error { return a.zw.Close() }
+
+// archiveMember describes one entry read from an archive. Only
+// directories and regular files are imported; other entry types
+// (symlinks, hard links, devices) are reported and skipped.
+type archiveMember struct {
+	name  string // member name as stored in the archive
+	isDir bool
+	isReg bool
+	mode  os.FileMode
+	mtime time.Time
+	size  int64
+}
+
+// archiveReader iterates over archive members in order. The io.Reader
+// returned with a regular file member is valid until the next call to
+// Next. Next returns io.EOF after the last member.
+type archiveReader interface {
+	Next() (*archiveMember, io.Reader, error)
+	Close() error
+}
+
+// Magic numbers used to sniff the archive container.
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	zipEmptyMagic = []byte("PK\x05\x06")
+	gzipMagic     = []byte{0x1f, 0x8b}
+)
+
+// openArchiveReader sniffs the container format of f and returns a
+// reader over its members. Tar and tar.gz stream from any reader;
+// zip needs random access and is rejected when f is not seekable
+// (e.g. stdin).
+func openArchiveReader(f *os.File) (archiveReader, error) {
+	br := bufio.NewReaderSize(f, 64*1024)
+	magic, err := br.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, zipMagic), bytes.HasPrefix(magic, zipEmptyMagic):
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s: zip archives must be read from a regular file", f.Name())
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return nil, err
+		}
+		return &zipArchiveReader{files: zr.File}, nil
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchiveReader{tr: tar.NewReader(gz), gz: gz}, nil
+	default:
+		return &tarArchiveReader{tr: tar.NewReader(br)}, nil
+	}
+}
+
+// cleanArchiveName normalizes an archive member name to a relative,
+// slash-separated path. Returns false for names that are empty after
+// cleaning (e.g. "./") or that would escape the destination via "..".
+func cleanArchiveName(name string) (string, bool) {
+	name = strings.TrimLeft(name, "/")
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	name = path.Clean(name)
+	if name == "." || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// tarArchiveReader reads members from a (possibly gzip-compressed)
+// tar stream.
+type tarArchiveReader struct {
+	tr *tar.Reader
+	gz *gzip.Reader // nil for uncompressed tar
+}
+
+func (a *tarArchiveReader) Next() (*archiveMember, io.Reader, error) {
+	hdr, err := a.tr.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	// tar.Reader already maps the legacy TypeRegA flag to TypeReg.
+	perm := os.FileMode(hdr.Mode & 0o777) //nolint:gosec // masked to permission bits
+	m := &archiveMember{
+		name:  hdr.Name,
+		isDir: hdr.Typeflag == tar.TypeDir,
+		isReg: hdr.Typeflag == tar.TypeReg,
+		mode:  perm,
+		mtime: hdr.ModTime,
+		size:  hdr.Size,
+	}
+	return m, a.tr, nil
+}
+
+func (a *tarArchiveReader) Close() error {
+	if a.gz != nil {
+		return a.gz.Close()
+	}
+	return nil
+}
+
+// zipArchiveReader reads members from a zip central directory.
+type zipArchiveReader struct {
+	files []*zip.File
+	next  int
+	cur   io.ReadCloser
+}
+
+func (a *zipArchiveReader) Next() (*archiveMember, io.Reader, error) {
+	if a.cur != nil {
+		_ = a.cur.Close()
+		a.cur = nil
+	}
+	if a.next >= len(a.files) {
+		return nil, nil, io.EOF
+	}
+	f := a.files[a.next]
+	a.next++
+
+	mode := f.Mode()
+	m := &archiveMember{
+		name:  f.Name,
+		isDir: mode.IsDir() || strings.HasSuffix(f.Name, "/"),
+		mode:  mode.Perm(),
+		mtime: f.Modified,
+		size:  int64(f.UncompressedSize64), //nolint:gosec // sizes beyond int64 are not representable on Drive
+	}
+	m.isReg = !m.isDir && mode.IsRegular()
+	if !m.isReg {
+		return m, nil, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	a.cur = rc
+	return m, rc, nil
+}
+
+func (a *zipArchiveReader) Close() error {
+	if a.cur != nil {
+		return a.cur.Close()
+	}
+	return nil
+}
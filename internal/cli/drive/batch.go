@@ -0,0 +1,227 @@
+package driveCmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+var batchFlags struct {
+	continueOnError bool
+	parallel        int
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch [options] [<file>|-]",
+	Short: "Run many drive commands over one session",
+	Long: `Run the drive commands in <file>, or standard input when it is "-" or
+omitted, one per line. The session is restored and the shares listed
+once; every command then runs in-process on the same session, Drive
+client and caches.
+
+A line is a drive command as given to "proton drive", optionally
+prefixed by "proton drive". Arguments are split on white space, with
+quotes or a backslash for names containing spaces. A line starting with
+"[" is a JSON array of the words instead, and a line starting with "{"
+a JSON object {"args": [...]}. Blank lines and lines starting with "#"
+are skipped.
+
+The exit status of every line is reported on standard error, as
+"proton drive" would exit with it: 0 when the command succeeded, 2 when
+the line is invalid and another non-zero status when it failed. The
+batch stops at the first failure unless --continue-on-error is given,
+and exits non-zero if any line failed.
+
+With --parallel, up to that many lines run at once. Commands of the same
+kind (e.g. two cp lines) still run one at a time, as they share flag
+state, and the output of concurrent commands may interleave.`,
+	Args:              cobra.MaximumNArgs(1),
+	PersistentPreRunE: cli.ServicePreRunE("drive"),
+	RunE:              runBatch,
+}
+
+func init() {
+	cli.AddCommand(batchCmd)
+	f := batchCmd.Flags()
+	cli.BoolFlagP(f, &batchFlags.continueOnError, "continue-on-error", "k", false, "Keep running after a command fails")
+	f.IntVarP(&batchFlags.parallel, "parallel", "P", 1, "Number of commands to run at once")
+}
+
+// batchLocks holds one mutex per drive subcommand, so commands sharing
+// package-level flag variables never run concurrently.
+var batchLocks sync.Map // *cobra.Command → *sync.Mutex
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	if batchFlags.parallel < 1 {
+		return fmt.Errorf("batch: --parallel must be at least 1")
+	}
+
+	in := io.Reader(os.Stdin)
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("batch: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	ctx := context.Background()
+	session, err := cli.SetupSession(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return err
+	}
+	cli.ShareSession(session, dc)
+	defer cli.ShareSession(nil, nil)
+
+	ran, failed, err := runBatchScript(in, os.Stderr, batchFlags.parallel, batchFlags.continueOnError, runBatchCommand)
+	if err != nil {
+		return fmt.Errorf("batch: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("batch: %d of %d commands failed", failed, ran)
+	}
+	return nil
+}
+
+// runBatchScript runs the command on each line of in with run, up to
+// parallel at a time, and reports each line's exit status on report.
+// Unless keepGoing is set, no further lines are started after a
+// failure. It returns the number of commands run and of those that
+// failed, and any error reading in.
+func runBatchScript(in io.Reader, report io.Writer, parallel int, keepGoing bool, run func([]string) error) (ran, failed int, err error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex // guards report, ran and failed
+		stopped atomic.Bool
+		sem     = make(chan struct{}, parallel)
+	)
+	finish := func(num int, err error) {
+		status := cli.Classify(err)
+		mu.Lock()
+		defer mu.Unlock()
+		ran++
+		if status != cli.ExitOK {
+			failed++
+			if !keepGoing {
+				stopped.Store(true)
+			}
+			fmt.Fprintf(report, "line %d: exit %d: %v\n", num, status, err)
+			return
+		}
+		fmt.Fprintf(report, "line %d: exit %d\n", num, status)
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for num := 1; scanner.Scan() && !stopped.Load(); num++ {
+		words, perr := parseBatchLine(scanner.Text())
+		if words == nil && perr == nil {
+			continue
+		}
+
+		// Invalid lines take a slot too, so that without --parallel
+		// lines are reported in order.
+		sem <- struct{}{}
+		if stopped.Load() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(num int, words []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if perr != nil {
+				finish(num, cli.Usage(perr))
+				return
+			}
+			finish(num, run(words))
+		}(num, words)
+	}
+	wg.Wait()
+	return ran, failed, scanner.Err()
+}
+
+// parseBatchLine returns the drive command words of one batch line, or
+// nil for a blank line or comment. A leading "proton" and "drive" are
+// dropped.
+func parseBatchLine(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	var words []string
+	switch {
+	case line == "" || strings.HasPrefix(line, "#"):
+		return nil, nil
+	case strings.HasPrefix(line, "["):
+		if err := json.Unmarshal([]byte(line), &words); err != nil {
+			return nil, fmt.Errorf("invalid JSON command: %w", err)
+		}
+	case strings.HasPrefix(line, "{"):
+		var obj struct {
+			Args []string `json:"args"`
+		}
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("invalid JSON command: %w", err)
+		}
+		words = obj.Args
+	default:
+		var err error
+		if words, err = splitShellWords(line); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(words) > 0 && words[0] == "proton" {
+		words = words[1:]
+	}
+	if len(words) > 0 && words[0] == "drive" {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return nil, errors.New("missing command")
+	}
+	return words, nil
+}
+
+// errBatchUsage marks a line that names no command that can run in a
+// batch.
+var errBatchUsage = cli.Usage(errors.New("invalid command"))
+
+// runBatchCommand runs one drive command of a batch in-process.
+func runBatchCommand(words []string) error {
+	sub, args, err := driveCmd.Find(words)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errBatchUsage, err)
+	}
+	switch sub {
+	case driveCmd:
+		return fmt.Errorf("%w: unknown command %q", errBatchUsage, words[0])
+	case driveShellCmd, driveWatchCmd:
+		// These read standard input or never return.
+		return fmt.Errorf("%w: %s cannot run in a batch", errBatchUsage, sub.Name())
+	}
+
+	mu, _ := batchLocks.LoadOrStore(sub, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	if err := runSubcommand(sub, args, nil); err != nil {
+		return fmt.Errorf("%s: %w", sub.Name(), err)
+	}
+	return nil
+}
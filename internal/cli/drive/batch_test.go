@@ -0,0 +1,190 @@
+package driveCmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+func TestParseBatchLine(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"   ", nil, false},
+		{"# comment", nil, false},
+		{"mkdir proton:///a", []string{"mkdir", "proton:///a"}, false},
+		{"drive mkdir proton:///a", []string{"mkdir", "proton:///a"}, false},
+		{"proton drive mkdir proton:///a", []string{"mkdir", "proton:///a"}, false},
+		{`cp "my file.txt" proton:///`, []string{"cp", "my file.txt", "proton:///"}, false},
+		{`["rm", "proton:///a b"]`, []string{"rm", "proton:///a b"}, false},
+		{`{"args": ["drive", "ls", "-l"]}`, []string{"ls", "-l"}, false},
+		{`{"args": ["ls"], "extra": 1}`, nil, true},
+		{`["ls", 1]`, nil, true},
+		{`[]`, nil, true},
+		{"proton drive", nil, true},
+		{`rm "unterminated`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseBatchLine(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBatchLine(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseBatchLine(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// failOn returns a batch runner that fails commands named "fail" and
+// "usage", the latter as an invalid command, and records the rest.
+func failOn(ran *[]string, mu *sync.Mutex) func([]string) error {
+	return func(words []string) error {
+		switch words[0] {
+		case "fail":
+			return errors.New("boom")
+		case "usage":
+			return fmt.Errorf("%w: nope", errBatchUsage)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		*ran = append(*ran, strings.Join(words, " "))
+		return nil
+	}
+}
+
+func TestRunBatchScript(t *testing.T) {
+	script := "mkdir a\n\n# skip\nfail\nmkdir b\nusage\n\"bad\nmkdir c\n"
+	tests := []struct {
+		name       string
+		keepGoing  bool
+		wantRan    []string
+		wantCounts [2]int
+		wantReport string
+	}{
+		{
+			name:       "stop",
+			wantRan:    []string{"mkdir a"},
+			wantCounts: [2]int{2, 1},
+			wantReport: "line 1: exit 0\nline 4: exit 1: boom\n",
+		},
+		{
+			name:       "continue",
+			keepGoing:  true,
+			wantRan:    []string{"mkdir a", "mkdir b", "mkdir c"},
+			wantCounts: [2]int{6, 3},
+			wantReport: "line 1: exit 0\nline 4: exit 1: boom\nline 5: exit 0\n" +
+				"line 6: exit 2: invalid command: nope\nline 7: exit 2: unterminated \" quote\nline 8: exit 0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ran    []string
+				mu     sync.Mutex
+				report bytes.Buffer
+			)
+			n, failed, err := runBatchScript(strings.NewReader(script), &report, 1, tt.keepGoing, failOn(&ran, &mu))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ran, tt.wantRan) {
+				t.Errorf("ran %q, want %q", ran, tt.wantRan)
+			}
+			if [2]int{n, failed} != tt.wantCounts {
+				t.Errorf("counts = %d, %d; want %v", n, failed, tt.wantCounts)
+			}
+			if report.String() != tt.wantReport {
+				t.Errorf("report:\n%s\nwant:\n%s", report.String(), tt.wantReport)
+			}
+		})
+	}
+}
+
+// TestRunBatchScript_Parallel_Property verifies that with
+// --continue-on-error every command runs exactly once, never more than
+// the parallelism at a time, and each line is reported once.
+func TestRunBatchScript_Parallel_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		parallel := rapid.IntRange(1, 4).Draw(t, "parallel")
+		cmds := rapid.SliceOfN(rapid.SampledFrom([]string{"ok", "fail"}), 0, 20).Draw(t, "cmds")
+
+		var (
+			running, peak atomic.Int32
+			mu            sync.Mutex
+			ran           []string
+		)
+		var script strings.Builder
+		for i, c := range cmds {
+			fmt.Fprintf(&script, "%s %d\n", c, i)
+		}
+		run := func(words []string) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			if words[0] == "fail" {
+				return errors.New("boom")
+			}
+			mu.Lock()
+			ran = append(ran, words[1])
+			mu.Unlock()
+			return nil
+		}
+
+		var report bytes.Buffer
+		n, failed, err := runBatchScript(strings.NewReader(script.String()), &report, parallel, true, run)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(cmds) {
+			t.Fatalf("ran %d commands, want %d", n, len(cmds))
+		}
+		if int(peak.Load()) > parallel {
+			t.Fatalf("peak concurrency %d > %d", peak.Load(), parallel)
+		}
+
+		var wantOK []string
+		wantFailed := 0
+		for i, c := range cmds {
+			if c == "ok" {
+				wantOK = append(wantOK, fmt.Sprint(i))
+			} else {
+				wantFailed++
+			}
+		}
+		sort.Strings(ran)
+		sort.Strings(wantOK)
+		if failed != wantFailed || !reflect.DeepEqual(ran, wantOK) {
+			t.Fatalf("failed %d, ran %q; want %d, %q", failed, ran, wantFailed, wantOK)
+		}
+		lines := strings.Count(report.String(), "\n")
+		if lines != len(cmds) {
+			t.Fatalf("report has %d lines, want %d", lines, len(cmds))
+		}
+	})
+}
+
+func TestRunBatchCommand_Invalid(t *testing.T) {
+	for _, words := range [][]string{{"nosuch"}, {"shell"}, {"watch", "a", "b"}} {
+		err := runBatchCommand(words)
+		if !errors.Is(err, errBatchUsage) {
+			t.Errorf("runBatchCommand(%q) = %v, want errBatchUsage", words, err)
+		}
+	}
+}
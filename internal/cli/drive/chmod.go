@@ -3,42 +3,66 @@ package driveCmd
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ProtonMail/go-proton-api"
+	api "github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/drive"
 	cli "github.com/major0/proton-utils/internal/cli"
 	"github.com/spf13/cobra"
 )
 
 var chmodFlags struct {
-	verbose bool
+	recursive bool
+	verbose   bool
+	changes   bool
+	reference string
 }
 
 var driveChmodCmd = &cobra.Command{
-	Use:   "chmod <mode> <path>",
+	Use:   "chmod [options] <mode> <path> [<path> ...]",
 	Short: "Change file mode bits on Proton Drive",
-	Long:  "Update Unix permission bits stored in the file's revision XAttr",
-	Args:  cobra.ExactArgs(2),
-	RunE:  runChmod,
+	Long: `Update Unix permission bits stored in each file's revision XAttr.
+
+The mode is octal (755) or symbolic in the chmod(1) syntax, a
+comma-separated list of [ugoa]*([-+=]([rwxXst]*|[ugo]))+ clauses such
+as u+x,go-w. A clause without u, g, o or a applies to everyone; no
+umask is applied. A mode starting with "-" must follow "--". With
+--reference=<file> the mode of that Drive file is copied instead and
+the mode argument is omitted.
+
+Folders carry no mode on Drive; use -R to change the files below them.
+Symbolic links met during recursion are skipped. Files whose mode would
+not change are left alone, so no new revision is created for them.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runChmod,
 }
 
 func init() {
 	driveCmd.AddCommand(driveChmodCmd)
-	cli.BoolFlagP(driveChmodCmd.Flags(), &chmodFlags.verbose, "verbose", "v", false, "Print each file as its mode is changed")
+	f := driveChmodCmd.Flags()
+	cli.BoolFlagP(f, &chmodFlags.recursive, "recursive", "R", false, "Change files below directories recursively")
+	cli.BoolFlagP(f, &chmodFlags.verbose, "verbose", "v", false, "Report every file processed")
+	cli.BoolFlagP(f, &chmodFlags.changes, "changes", "c", false, "Report only files whose mode changed")
+	f.StringVar(&chmodFlags.reference, "reference", "", "Use the mode of this Drive file")
 }
 
 func runChmod(cmd *cobra.Command, args []string) error {
-	modeStr := args[0]
-	rawPath := args[1]
-
-	// Parse octal mode.
-	mode, err := strconv.ParseUint(modeStr, 8, 32)
-	if err != nil {
-		return fmt.Errorf("chmod: invalid mode %q: %w", modeStr, err)
-	}
-	if mode > 0o7777 {
-		return fmt.Errorf("chmod: mode %q exceeds maximum (7777)", modeStr)
+	var mode chmodMode
+	paths := args
+	if chmodFlags.reference == "" {
+		if len(args) < 2 {
+			return fmt.Errorf("chmod: missing operand after %q", args[0])
+		}
+		var err error
+		mode, err = parseChmodMode(args[0])
+		if err != nil {
+			return fmt.Errorf("chmod: %w", err)
+		}
+		paths = args[1:]
 	}
 
 	ctx := context.Background()
@@ -53,37 +77,144 @@ func runChmod(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	sharePart, pathPart, err := parseProtonURI(rawPath)
-	if err != nil {
-		return fmt.Errorf("chmod: %w", err)
+	if chmodFlags.reference != "" {
+		ref, _, err := ResolveProtonPath(ctx, dc, chmodFlags.reference)
+		if err != nil {
+			return fmt.Errorf("chmod: %w", err)
+		}
+		if !ref.IsFile() {
+			return fmt.Errorf("chmod: %s: not a file", chmodFlags.reference)
+		}
+		dc.FetchRevisionXAttr(ctx, ref)
+		mode = chmodMode{octal: true, bits: linkMode(ref)}
 	}
-	if pathPart == "" {
-		return fmt.Errorf("chmod: missing file path")
+
+	sem := dc.Session.Sem
+	if sem == nil {
+		sem = api.NewSemaphore(ctx, api.DefaultMaxWorkers(), nil)
 	}
 
-	share, err := dc.ResolveShareComponent(ctx, sharePart)
-	if err != nil {
-		return fmt.Errorf("chmod: %s: %w", sharePart, err)
+	c := &chmodder{
+		dc:      dc,
+		sem:     sem,
+		mode:    mode,
+		verbose: chmodFlags.verbose,
+		changes: chmodFlags.changes,
+	}
+	for _, p := range paths {
+		c.run(ctx, p, chmodFlags.recursive)
 	}
+	c.wg.Wait()
+	return c.result()
+}
 
-	pathPart = strings.TrimSuffix(pathPart, "/")
-	link, err := share.Link.ResolvePath(ctx, pathPart, true)
-	if err != nil {
-		return fmt.Errorf("chmod: %s: %w", pathPart, err)
+// chmodder applies a mode to files on the worker pool. Each file is
+// rewritten as a new revision, so files run concurrently; the walk
+// itself stays on the caller's goroutine.
+type chmodder struct {
+	dc      *drive.Client
+	sem     *api.Semaphore
+	mode    chmodMode
+	verbose bool
+	changes bool
+
+	wg     sync.WaitGroup
+	total  atomic.Int64
+	failed atomic.Int64
+}
+
+// result summarises failures once all work is done.
+func (c *chmodder) result() error {
+	if n := c.failed.Load(); n > 0 {
+		return fmt.Errorf("chmod: %d of %d files could not be changed", n, c.total.Load())
 	}
+	return nil
+}
+
+// fail reports a per-path error and counts it.
+func (c *chmodder) fail(p string, err error) {
+	c.total.Add(1)
+	c.failed.Add(1)
+	fmt.Fprintf(os.Stderr, "chmod: %s: %v\n", p, err)
+}
 
-	if link.Type() != proton.LinkTypeFile {
-		return fmt.Errorf("chmod: %s: not a file (directories not supported)", pathPart)
+// run changes rawPath, walking it when it is a folder and recursive
+// is set.
+func (c *chmodder) run(ctx context.Context, rawPath string, recursive bool) {
+	link, share, err := ResolveProtonPath(ctx, c.dc, rawPath)
+	if err != nil {
+		c.fail(rawPath, err)
+		return
+	}
+	if link.Type() != proton.LinkTypeFolder {
+		if link.IsSymlink() {
+			c.fail(rawPath, fmt.Errorf("is a symbolic link"))
+			return
+		}
+		c.submit(share, link, rawPath)
+		return
+	}
+	if !recursive {
+		c.fail(rawPath, fmt.Errorf("is a directory; folders carry no mode (use -R for its files)"))
+		return
 	}
 
-	if err := dc.Chmod(ctx, share, link, uint32(mode)); err != nil {
-		return err
+	results := make(chan drive.WalkEntry, 64)
+	var walkErr error
+	go func() {
+		defer close(results)
+		walkErr = c.dc.TreeWalk(ctx, link, strings.TrimSuffix(rawPath, "/")+"/", drive.BreadthFirst, -1, results)
+	}()
+	for entry := range results {
+		if entry.Err != nil {
+			c.fail(rawPath, entry.Err)
+			continue
+		}
+		if entry.Link.State() != proton.LinkStateActive ||
+			entry.Link.Type() == proton.LinkTypeFolder || entry.Link.IsSymlink() {
+			continue
+		}
+		c.submit(share, entry.Link, entry.Path)
 	}
+	if walkErr != nil {
+		c.fail(rawPath, walkErr)
+	}
+}
+
+// submit queues a chmod of one file on the worker pool.
+func (c *chmodder) submit(share *drive.Share, link *drive.Link, p string) {
+	c.sem.Go(&c.wg, func(ctx context.Context) error {
+		c.dc.FetchRevisionXAttr(ctx, link)
+		old := linkMode(link)
+		mode := c.mode.apply(old, false)
+		if mode != old {
+			if err := c.dc.Chmod(ctx, share, link, mode); err != nil {
+				c.fail(p, err)
+				return nil
+			}
+		}
+		c.total.Add(1)
+		c.report(p, old, mode)
+		return nil
+	})
+}
 
-	if chmodFlags.verbose {
-		name, _ := link.Name()
-		fmt.Printf("mode of '%s' changed to %04o\n", name, mode)
+// report prints the chmod(1) style -v/-c line for one file.
+func (c *chmodder) report(p string, old, mode uint32) {
+	switch {
+	case mode != old && (c.verbose || c.changes):
+		fmt.Printf("mode of '%s' changed from %04o (%s) to %04o (%s)\n",
+			p, old, modeString(old), mode, modeString(mode))
+	case mode == old && c.verbose:
+		fmt.Printf("mode of '%s' retained as %04o (%s)\n", p, old, modeString(old))
 	}
+}
 
-	return nil
+// linkMode returns the mode recorded for a file, or 0600 (the FUSE
+// default) when none was stored.
+func linkMode(link *drive.Link) uint32 {
+	if mode := link.Mode() & 0o7777; mode != 0 {
+		return mode
+	}
+	return 0o600
 }
@@ -0,0 +1,178 @@
+package driveCmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Permission bit masks for each class of user, including the special
+// bit that belongs to it (setuid, setgid, sticky).
+const (
+	modeUser  uint32 = 0o4700
+	modeGroup uint32 = 0o2070
+	modeOther uint32 = 0o1007
+	modeAll          = modeUser | modeGroup | modeOther
+)
+
+// modeClause is one comma-separated element of a symbolic mode, with
+// its operators split out: "go+r-w" becomes two clauses.
+type modeClause struct {
+	who  uint32 // class mask (modeUser etc.)
+	op   byte   // '+', '-' or '='
+	perm string // letters from "rwxXst", or a single class from "ugo"
+}
+
+// chmodMode is a parsed mode argument: either an absolute octal mode
+// or a list of symbolic clauses applied in order.
+type chmodMode struct {
+	octal   bool
+	bits    uint32
+	clauses []modeClause
+}
+
+// parseChmodMode parses an octal mode ("755") or a symbolic one in the
+// chmod(1) syntax: [ugoa]*([-+=]([rwxXst]*|[ugo]))+ clauses separated
+// by commas. A clause without a class applies to all classes; there is
+// no umask on Drive to mask it.
+func parseChmodMode(s string) (chmodMode, error) {
+	if s == "" {
+		return chmodMode{}, fmt.Errorf("invalid mode %q", s)
+	}
+	if s[0] >= '0' && s[0] <= '7' {
+		v, err := strconv.ParseUint(s, 8, 32)
+		if err != nil || len(s) > 4 {
+			return chmodMode{}, fmt.Errorf("invalid mode %q", s)
+		}
+		return chmodMode{octal: true, bits: uint32(v)}, nil
+	}
+
+	var m chmodMode
+	for _, clause := range strings.Split(s, ",") {
+		i := 0
+		var who uint32
+	whoLoop:
+		for ; i < len(clause); i++ {
+			switch clause[i] {
+			case 'u':
+				who |= modeUser
+			case 'g':
+				who |= modeGroup
+			case 'o':
+				who |= modeOther
+			case 'a':
+				who |= modeAll
+			default:
+				break whoLoop
+			}
+		}
+		if who == 0 {
+			who = modeAll
+		}
+		if i == len(clause) {
+			return chmodMode{}, fmt.Errorf("invalid mode %q", s)
+		}
+		for i < len(clause) {
+			op := clause[i]
+			if op != '+' && op != '-' && op != '=' {
+				return chmodMode{}, fmt.Errorf("invalid mode %q", s)
+			}
+			i++
+			j := i
+			if j < len(clause) && strings.IndexByte("ugo", clause[j]) >= 0 {
+				j++
+			} else {
+				for j < len(clause) && strings.IndexByte("rwxXst", clause[j]) >= 0 {
+					j++
+				}
+			}
+			m.clauses = append(m.clauses, modeClause{who: who, op: op, perm: clause[i:j]})
+			i = j
+		}
+	}
+	return m, nil
+}
+
+// apply returns old changed by the mode. isDir selects whether X adds
+// execute permission regardless of the existing bits.
+func (m chmodMode) apply(old uint32, isDir bool) uint32 {
+	if m.octal {
+		return m.bits
+	}
+	mode := old & 0o7777
+	for _, c := range m.clauses {
+		perm := c.bits(mode, isDir) & c.who
+		switch c.op {
+		case '+':
+			mode |= perm
+		case '-':
+			mode &^= perm
+		case '=':
+			mode = mode&^c.who | perm
+		}
+	}
+	return mode
+}
+
+// bits returns the permission bits named by the clause for every
+// class, given the mode it is applied to.
+func (c modeClause) bits(mode uint32, isDir bool) uint32 {
+	switch c.perm {
+	case "u":
+		return spread(mode >> 6 & 7)
+	case "g":
+		return spread(mode >> 3 & 7)
+	case "o":
+		return spread(mode & 7)
+	}
+	var b uint32
+	for _, p := range c.perm {
+		switch p {
+		case 'r':
+			b |= 0o444
+		case 'w':
+			b |= 0o222
+		case 'x':
+			b |= 0o111
+		case 'X':
+			if isDir || mode&0o111 != 0 {
+				b |= 0o111
+			}
+		case 's':
+			b |= 0o6000
+		case 't':
+			b |= 0o1000
+		}
+	}
+	return b
+}
+
+// spread copies a 3-bit rwx value to all three classes.
+func spread(rwx uint32) uint32 {
+	return rwx<<6 | rwx<<3 | rwx
+}
+
+// modeString formats the permission bits of mode as ls(1) does, e.g.
+// "rwsr-xr-t".
+func modeString(mode uint32) string {
+	b := []byte("rwxrwxrwx")
+	for i := range b {
+		if mode&(1<<(8-i)) == 0 {
+			b[i] = '-'
+		}
+	}
+	special := func(i int, bit uint32, set byte) {
+		if mode&bit == 0 {
+			return
+		}
+		if b[i] == 'x' {
+			b[i] = set
+		} else {
+			b[i] = set - 'a' + 'A'
+		}
+	}
+	special(2, 0o4000, 's')
+	special(5, 0o2000, 's')
+	special(8, 0o1000, 't')
+	return string(b)
+}
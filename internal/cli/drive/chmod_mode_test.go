@@ -0,0 +1,152 @@
+package driveCmd
+
+import (
+	"fmt"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+func TestParseChmodMode_Invalid(t *testing.T) {
+	for _, s := range []string{"", "8", "0o755", "17777", "u", "ug", "u+x,", "u+x,,g-w", "uz+x", "u+q", "+xr,g", "u*x"} {
+		if _, err := parseChmodMode(s); err == nil {
+			t.Errorf("parseChmodMode(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestChmodMode_Apply(t *testing.T) {
+	tests := []struct {
+		mode  string
+		old   uint32
+		isDir bool
+		want  uint32
+	}{
+		{"755", 0o600, false, 0o755},
+		{"0", 0o644, false, 0},
+		{"4755", 0o644, false, 0o4755},
+		{"u+x", 0o644, false, 0o744},
+		{"+x", 0o644, false, 0o755},
+		{"a+x", 0o644, false, 0o755},
+		{"go-w", 0o666, false, 0o644},
+		{"u+x,go-w", 0o666, false, 0o744},
+		{"u=rw,go=r", 0o777, false, 0o644},
+		{"o=", 0o777, false, 0o770},
+		{"u+x-w", 0o644, false, 0o544},
+		{"g=u", 0o640, false, 0o660},
+		{"o+g", 0o750, false, 0o755},
+		{"a=u", 0o700, false, 0o777},
+		{"a+X", 0o644, false, 0o644},
+		{"a+X", 0o744, false, 0o755},
+		{"a+X", 0o644, true, 0o755},
+		{"u+s", 0o755, false, 0o4755},
+		{"g+s", 0o755, false, 0o2755},
+		{"+t", 0o755, false, 0o1755},
+		{"u+t", 0o755, false, 0o755},
+		{"u-s", 0o6755, false, 0o2755},
+		{"u=rwx", 0o4755, false, 0o755},
+		{"ug+", 0o644, false, 0o644},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%04o", tt.mode, tt.old), func(t *testing.T) {
+			m, err := parseChmodMode(tt.mode)
+			if err != nil {
+				t.Fatalf("parseChmodMode(%q): %v", tt.mode, err)
+			}
+			if got := m.apply(tt.old, tt.isDir); got != tt.want {
+				t.Errorf("apply(%04o) = %04o, want %04o", tt.old, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModeString(t *testing.T) {
+	tests := []struct {
+		mode uint32
+		want string
+	}{
+		{0o644, "rw-r--r--"},
+		{0o755, "rwxr-xr-x"},
+		{0, "---------"},
+		{0o4755, "rwsr-xr-x"},
+		{0o4644, "rwSr--r--"},
+		{0o2750, "rwxr-s---"},
+		{0o1777, "rwxrwxrwt"},
+		{0o1776, "rwxrwxrwT"},
+	}
+	for _, tt := range tests {
+		if got := modeString(tt.mode); got != tt.want {
+			t.Errorf("modeString(%04o) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+// symbolicFor renders mode as an absolute u=,g=,o= symbolic mode.
+func symbolicFor(mode uint32) string {
+	class := func(who string, rwx uint32, special uint32, specialLetter string) string {
+		s := who + "="
+		for i, l := range "rwx" {
+			if rwx&(4>>i) != 0 {
+				s += string(l)
+			}
+		}
+		if mode&special != 0 {
+			s += specialLetter
+		}
+		return s
+	}
+	return class("u", mode>>6&7, 0o4000, "s") + "," +
+		class("g", mode>>3&7, 0o2000, "s") + "," +
+		class("o", mode&7, 0o1000, "t")
+}
+
+// TestChmodMode_SymbolicMatchesOctal_Property verifies that an absolute
+// symbolic mode yields the same bits as the equivalent octal mode,
+// whatever the previous mode was.
+func TestChmodMode_SymbolicMatchesOctal_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		want := rapid.Uint32Range(0, 0o7777).Draw(t, "mode")
+		old := rapid.Uint32Range(0, 0o7777).Draw(t, "old")
+
+		octal, err := parseChmodMode(fmt.Sprintf("%o", want))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sym, err := parseChmodMode(symbolicFor(want))
+		if err != nil {
+			t.Fatalf("parseChmodMode(%q): %v", symbolicFor(want), err)
+		}
+		if got := octal.apply(old, false); got != want {
+			t.Fatalf("octal apply = %04o, want %04o", got, want)
+		}
+		if got := sym.apply(old, false); got != want {
+			t.Fatalf("%s apply(%04o) = %04o, want %04o", symbolicFor(want), old, got, want)
+		}
+	})
+}
+
+// TestChmodMode_AddRemoveInverse_Property verifies that removing bits
+// after adding them clears exactly those bits.
+func TestChmodMode_AddRemoveInverse_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		who := rapid.SampledFrom([]string{"u", "g", "o", "a", "ug", "go", ""}).Draw(t, "who")
+		perm := rapid.SampledFrom([]string{"r", "w", "x", "rw", "rwx", "s", "t"}).Draw(t, "perm")
+		old := rapid.Uint32Range(0, 0o7777).Draw(t, "old")
+
+		add, err := parseChmodMode(who + "+" + perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		del, err := parseChmodMode(who + "-" + perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added := add.apply(old, false)
+		if added&old != old {
+			t.Fatalf("%s+%s dropped bits: %04o -> %04o", who, perm, old, added)
+		}
+		if got, want := del.apply(added, false), del.apply(old, false); got != want {
+			t.Fatalf("%s-%s after + = %04o, want %04o", who, perm, got, want)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package driveCmd
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	common "github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+// Completion cache keys.
+const (
+	completeKeyShares = "drive/shares"
+	completeKeyDir    = "drive/dir/" // + normalized folder URI
+)
+
+func init() {
+	for _, c := range []*cobra.Command{driveListCmd, driveMvCmd, driveRmCmd, driveStatCmd, driveFindCmd} {
+		c.ValidArgsFunction = completeRemotePath(false)
+	}
+	driveCpCmd.ValidArgsFunction = completeRemotePath(true)
+}
+
+// completeRemotePath returns a ValidArgsFunction that completes
+// proton:// paths. With local set, other arguments are left to the
+// shell's file completion.
+func completeRemotePath(local bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		other := cobra.ShellCompDirectiveNoFileComp
+		if local {
+			other = cobra.ShellCompDirectiveDefault
+		}
+		if !strings.HasPrefix(toComplete, "proton://") {
+			// Local paths come first for cp, so "proton://" is only
+			// offered once "proton:" has been typed.
+			if strings.HasPrefix("proton://", toComplete) && (!local || strings.HasPrefix(toComplete, "proton:")) {
+				return []string{"proton://"}, cobra.ShellCompDirectiveNoSpace
+			}
+			return nil, other
+		}
+		return cli.FilterCompletions(remoteCandidates(cmd, toComplete), toComplete),
+			cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// remoteCandidates returns the completions for a partial proton:// URI:
+// share components while the share is being typed, then the entries of
+// the folder named up to the last "/".
+func remoteCandidates(cmd *cobra.Command, toComplete string) []string {
+	rest := strings.TrimPrefix(toComplete, "proton://")
+	if !strings.Contains(rest, "/") {
+		names := cli.CompleteCached(cmd, completeKeyShares, fetchShareNames)
+		out := []string{"proton:///"}
+		for _, n := range names {
+			out = append(out, "proton://"+n+"/")
+		}
+		return out
+	}
+
+	dir := toComplete[:strings.LastIndex(toComplete, "/")+1]
+	key := parseRemotePath(dir).dir()
+	names := cli.CompleteCached(cmd, completeKeyDir+key, func(ctx context.Context, session *common.Session) ([]string, error) {
+		return fetchFolderNames(ctx, session, key)
+	})
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = dir + n
+	}
+	return out
+}
+
+// fetchFolderNames lists the folder at uri: child names, with "/" after
+// folders, sorted.
+func fetchFolderNames(ctx context.Context, session *common.Session, uri string) ([]string, error) {
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	link, _, err := ResolveProtonPath(ctx, dc, uri)
+	if err != nil {
+		return nil, err
+	}
+	if !link.IsDir() {
+		return nil, drive.ErrNotAFolder
+	}
+	var names []string
+	for entry := range link.Readdir(ctx) {
+		if entry.Err != nil {
+			return nil, entry.Err
+		}
+		name, err := entry.EntryName()
+		if err != nil || name == "." || name == ".." {
+			continue
+		}
+		if entry.Link.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// fetchShareNames returns the decrypted names of all shares, sorted.
+func fetchShareNames(ctx context.Context, session *common.Session) ([]string, error) {
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := dc.ListShares(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(shares))
+	for i := range shares {
+		if name, err := shares[i].GetName(ctx); err == nil && name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CompleteShareName is a ValidArgsFunction for commands whose first
+// argument is a share name.
+func CompleteShareName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := cli.CompleteCached(cmd, completeKeyShares, fetchShareNames)
+	return cli.FilterCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,42 @@
+package driveCmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestCompleteRemotePath_NoPrefix covers arguments that are not yet a
+// proton:// URI, which are completed without a session.
+func TestCompleteRemotePath_NoPrefix(t *testing.T) {
+	tests := []struct {
+		local      bool
+		toComplete string
+		want       []string
+		directive  cobra.ShellCompDirective
+	}{
+		{false, "", []string{"proton://"}, cobra.ShellCompDirectiveNoSpace},
+		{false, "pro", []string{"proton://"}, cobra.ShellCompDirectiveNoSpace},
+		{false, "proton:/", []string{"proton://"}, cobra.ShellCompDirectiveNoSpace},
+		{false, "docs", nil, cobra.ShellCompDirectiveNoFileComp},
+		{true, "", nil, cobra.ShellCompDirectiveDefault},
+		{true, "pro", nil, cobra.ShellCompDirectiveDefault},
+		{true, "proton:", []string{"proton://"}, cobra.ShellCompDirectiveNoSpace},
+		{true, "./local", nil, cobra.ShellCompDirectiveDefault},
+	}
+	for _, tt := range tests {
+		got, directive := completeRemotePath(tt.local)(driveCpCmd, nil, tt.toComplete)
+		if !reflect.DeepEqual(got, tt.want) || directive != tt.directive {
+			t.Errorf("local=%v %q: got %q, %v; want %q, %v", tt.local, tt.toComplete, got, directive, tt.want, tt.directive)
+		}
+	}
+}
+
+func TestCompletionRegistered(t *testing.T) {
+	for _, c := range []*cobra.Command{driveListCmd, driveCpCmd, driveMvCmd, driveRmCmd, driveStatCmd, driveFindCmd} {
+		if c.ValidArgsFunction == nil {
+			t.Errorf("%s has no ValidArgsFunction", c.Name())
+		}
+	}
+}
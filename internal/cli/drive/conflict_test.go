@@ -120,7 +120,7 @@ func TestHandleConflict(t *testing.T) {
 		},
 		{
 			name: "backup renames to tilde suffix",
-			opts: cpOptions{backup: true},
+			opts: cpOptions{backup: backupSimple},
 			setup: func(t *testing.T, tmp string) *resolvedEndpoint {
 				t.Helper()
 				f := filepath.Join(tmp, "backup-me.txt")
@@ -199,7 +199,7 @@ func TestRunCpFlagValidation(t *testing.T) {
 			setup: func() {
 				resetFlags()
 				cpFlags.removeDest = true
-				cpFlags.backup = true
+				cpFlags.backup = "simple"
 			},
 			args:    []string{"src", "dst"},
 			wantErr: "mutually exclusive",
@@ -769,7 +769,7 @@ func TestRunCpDirectoryWithoutRecursive(t *testing.T) {
 // TestRunCpBackupExistingFile exercises the backup path in runCp.
 func TestRunCpBackupExistingFile(t *testing.T) {
 	resetFlags()
-	cpFlags.backup = true
+	cpFlags.backup = "simple"
 	tmp := t.TempDir()
 	src := filepath.Join(tmp, "src.txt")
 	dst := filepath.Join(tmp, "dst.txt")
@@ -2315,7 +2315,7 @@ func TestRunCpRecursiveIntoExistingDir(t *testing.T) {
 func TestRunCpRecursiveWithRemoveDestAndBackup(t *testing.T) {
 	resetFlags()
 	cpFlags.removeDest = true
-	cpFlags.backup = true
+	cpFlags.backup = "simple"
 
 	err := runCp(nil, []string{"src", "dst"})
 	if err == nil {
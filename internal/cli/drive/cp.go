@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ProtonMail/go-proton-api"
 	api "github.com/major0/proton-utils/api"
@@ -22,11 +23,12 @@ var cpFlags struct {
 	noDeref     bool   // -d (skip symlinks; implied by -a)
 	verbose     bool   // -v, --verbose
 	progress    bool   // --progress
-	preserve    string // --preserve=mode,timestamps
+	preserve    string // --preserve=mode,timestamps,links,xattr,acl
 	targetDir   string // -t, --target-directory
 	removeDest  bool   // --remove-destination (trash Proton / remove local before copy)
 	force       bool   // -f, --force (overwrite destination)
-	backup      bool   // --backup (local: rename to <name>~; Proton: no-op)
+	backup      string // --backup[=CONTROL], -b (none, simple, numbered, existing)
+	suffix      string // -S, --suffix (simple backup suffix, default "~")
 }
 
 var driveCpCmd = &cobra.Command{
@@ -53,16 +55,26 @@ func init() {
 	cli.BoolFlagP(f, &cpFlags.noDeref, "no-dereference", "d", false, "Skip symbolic links (default; explicit for -a)")
 	cli.BoolFlagP(f, &cpFlags.verbose, "verbose", "v", false, "Print each file as it completes")
 	cli.BoolFlag(f, &cpFlags.progress, "progress", false, "Show aggregate transfer progress")
-	f.StringVar(&cpFlags.preserve, "preserve", "", "Preserve attributes: mode,timestamps")
+	f.StringVar(&cpFlags.preserve, "preserve", "", "Preserve attributes: mode,timestamps,links,xattr,acl")
 	f.StringVarP(&cpFlags.targetDir, "target-directory", "t", "", "Copy all sources into this directory")
 	cli.BoolFlag(f, &cpFlags.removeDest, "remove-destination", false, "Trash/remove destination before copy (disables versioning)")
 	cli.BoolFlagP(f, &cpFlags.force, "force", "f", false, "Overwrite existing destination files")
-	cli.BoolFlag(f, &cpFlags.backup, "backup", false, "Backup existing local files as <name>~")
+	f.StringVarP(&cpFlags.backup, "backup", "b", "", "Backup existing destination files: none, simple, numbered, existing")
+	f.Lookup("backup").NoOptDefVal = backupFromEnv
+	f.StringVarP(&cpFlags.suffix, "suffix", "S", "", "Suffix for simple backups (default \"~\"); implies --backup")
 }
 
 func runCp(cmd *cobra.Command, args []string) error {
+	backup, err := resolveBackupMode(cpFlags.backup, cmd.Flags().Changed("backup"), cmd.Flags().Changed("suffix"), os.Getenv)
+	if err != nil {
+		return fmt.Errorf("cp: --backup: %w", err)
+	}
+	if strings.Contains(cpFlags.suffix, "/") {
+		return fmt.Errorf("cp: --suffix: must not contain '/'")
+	}
+
 	// Validate mutually exclusive flags.
-	if cpFlags.removeDest && cpFlags.backup {
+	if cpFlags.removeDest && backup != backupNone {
 		return fmt.Errorf("cp: --remove-destination and --backup are mutually exclusive")
 	}
 
@@ -82,7 +94,8 @@ func runCp(cmd *cobra.Command, args []string) error {
 		dereference: cpFlags.dereference,
 		removeDest:  cpFlags.removeDest,
 		force:       cpFlags.force,
-		backup:      cpFlags.backup,
+		backup:      backup,
+		suffix:      cpFlags.suffix,
 		preserve:    cpFlags.preserve,
 		verbose:     cpFlags.verbose,
 		progress:    cpFlags.progress,
@@ -185,6 +198,14 @@ func runCp(cmd *cobra.Command, args []string) error {
 			}
 		}
 
+		// Symlink sources: recreate the link, not its content.
+		if isSymlinkSource(srcEp, opts) {
+			if err := copySymlink(ctx, dc, srcEp, fileDst, opts); err != nil {
+				return fmt.Errorf("cp: %s: %w", srcEp.raw, err)
+			}
+			continue
+		}
+
 		// Directory sources: expand recursively or skip.
 		if srcEp.isDir() {
 			if !opts.recursive {
@@ -228,13 +249,16 @@ func runCp(cmd *cobra.Command, args []string) error {
 				dstPath: fileDst.localPath,
 				mode:    srcEp.localInfo.Mode().Perm(),
 				mtime:   srcEp.localInfo.ModTime(),
+				xattrs:  sourceXAttrs(ctx, dc, srcEp, opts),
 			})
 		}
 		if fileDst.pathType == PathLocal && srcEp.pathType == PathProton && srcEp.link != nil {
-			if m := srcEp.link.Mode(); m != 0 {
+			xattrs := sourceXAttrs(ctx, dc, srcEp, opts)
+			if m := srcEp.link.Mode(); m != 0 || xattrs != nil {
 				preserves = append(preserves, preserveEntry{
 					dstPath: fileDst.localPath,
 					mode:    os.FileMode(m),
+					xattrs:  xattrs,
 				})
 			}
 		}
@@ -303,133 +327,154 @@ func buildCopyJob(ctx context.Context, dc *drive.Client, src, dst *resolvedEndpo
 		}
 		job.Dst = drive.NewLocalWriter(dst.localPath)
 	case PathProton:
-		name := filepath.Base(dst.raw)
-
-		// When the destination already exists as a file (dst.link
-		// points to the file itself, not its parent), determine the
-		// right strategy based on the file's state.
-		if dst.link != nil && dst.link.Type() == proton.LinkTypeFile {
-			parent := dst.link.Parent()
-			pLink := dst.link.ProtonLink()
-			hasActiveRevision := pLink.FileProperties != nil &&
-				pLink.FileProperties.ActiveRevision.ID != "" &&
-				pLink.FileProperties.ActiveRevision.State == proton.RevisionStateActive
-
-			if hasActiveRevision {
-				// Healthy file — overwrite via new revision.
-				fh, err := dc.OverwriteFile(ctx, dst.share, dst.link)
-				if err == nil {
-					store := dc.InternalBlockStore()
-					pw := drive.NewProtonWriter(fh, store, dc.Session)
-					setProtonWriterMode(pw, src, opts)
-					job.Dst = pw
-					return &job, nil
-				}
-				// OverwriteFile failed (stale link, server-side
-				// deletion). Delete and create fresh.
-				slog.Debug("cp: overwrite failed, removing stale link", "link", dst.link.LinkID(), "error", err)
-			}
+		fh, err := openProtonDest(ctx, dc, dst, opts)
+		if err != nil {
+			return nil, fmt.Errorf("cp: %w", err)
+		}
+		store := dc.InternalBlockStore()
+		pw := drive.NewProtonWriter(fh, store, dc.Session)
+		setProtonWriterMode(pw, src, opts)
+		setProtonWriterXAttrs(ctx, dc, pw, src, opts)
+		job.Dst = pw
+	}
+
+	return &job, nil
+}
 
-			// Ghost or broken file (no active revision, or
-			// OverwriteFile failed) — delete and create fresh.
-			if delErr := dc.Remove(ctx, dst.share, dst.link, drive.RemoveOpts{Permanent: true}); delErr != nil {
-				slog.Debug("cp: remove stale link failed", "link", dst.link.LinkID(), "error", delErr)
+// openProtonDest creates or reopens the Proton destination file described
+// by dst and returns a FileHandle ready for upload. dst.link is either
+// the parent folder (new file named by the base of dst.raw) or an
+// existing file, which is overwritten via a new revision. Existing-name
+// conflicts are resolved according to opts.force and opts.removeDest.
+// Errors are not prefixed with a command name; callers add their own.
+func openProtonDest(ctx context.Context, dc *drive.Client, dst *resolvedEndpoint, opts cpOptions) (*drive.FileHandle, error) {
+	name := filepath.Base(dst.raw)
+
+	// When the destination already exists as a file (dst.link
+	// points to the file itself, not its parent), determine the
+	// right strategy based on the file's state.
+	if dst.link != nil && dst.link.Type() == proton.LinkTypeFile {
+		parent := dst.link.Parent()
+		pLink := dst.link.ProtonLink()
+		hasActiveRevision := pLink.FileProperties != nil &&
+			pLink.FileProperties.ActiveRevision.ID != "" &&
+			pLink.FileProperties.ActiveRevision.State == proton.RevisionStateActive
+
+		if hasActiveRevision {
+			// Healthy file — overwrite via new revision.
+			fh, err := dc.OverwriteFile(ctx, dst.share, dst.link)
+			if err == nil {
+				return fh, nil
 			}
-			dst.link = parent
+			// OverwriteFile failed (stale link, server-side
+			// deletion). Delete and create fresh.
+			slog.Debug("overwrite failed, removing stale link", "link", dst.link.LinkID(), "error", err)
 		}
 
-		fh, err := dc.CreateFile(ctx, dst.share, dst.link, name)
-		if err != nil {
+		// Ghost or broken file (no active revision, or
+		// OverwriteFile failed) — delete and create fresh.
+		if delErr := dc.Remove(ctx, dst.share, dst.link, drive.RemoveOpts{Permanent: true}); delErr != nil {
+			slog.Debug("remove stale link failed", "link", dst.link.LinkID(), "error", delErr)
+		}
+		dst.link = parent
+	}
+
+	fh, err := dc.CreateFile(ctx, dst.share, dst.link, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, drive.ErrFileNameExist):
+			if !opts.force && !opts.removeDest && opts.backup == backupNone {
+				return nil, fmt.Errorf("%s: file exists (use -f to overwrite)", name)
+			}
+			// Lookup the blocker to determine type and get the Link.
+			blocker, lookupErr := dst.link.Lookup(ctx, name)
+			if lookupErr != nil {
+				return nil, fmt.Errorf("%s: lookup: %w", name, lookupErr)
+			}
 			switch {
-			case errors.Is(err, drive.ErrFileNameExist):
-				if !opts.force && !opts.removeDest {
-					return nil, fmt.Errorf("cp: %s: file exists (use -f to overwrite)", name)
+			case blocker == nil:
+				// Race: blocker removed between CreateFile and Lookup — retry.
+				fh, err = dc.CreateFile(ctx, dst.share, dst.link, name)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
 				}
-				// Lookup the blocker to determine type and get the Link.
-				blocker, lookupErr := dst.link.Lookup(ctx, name)
-				if lookupErr != nil {
-					return nil, fmt.Errorf("cp: %s: lookup: %w", name, lookupErr)
+			case blocker.Type() == proton.LinkTypeFolder:
+				return nil, fmt.Errorf("%s: cannot overwrite directory with non-directory", name)
+			case blocker.State() == proton.LinkStateTrashed:
+				// Trashed link still occupies the name hash — permanently delete, then create new.
+				if delErr := dc.Remove(ctx, dst.share, blocker, drive.RemoveOpts{Permanent: true}); delErr != nil {
+					return nil, fmt.Errorf("%s: remove trashed: %w", name, delErr)
 				}
+				fh, err = dc.CreateFile(ctx, dst.share, dst.link, name)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+			default:
+				// Active file or draft — check if it has a committed revision.
+				pLink := blocker.ProtonLink()
+				hasActiveRevision := pLink.FileProperties != nil &&
+					pLink.FileProperties.ActiveRevision.ID != "" &&
+					pLink.FileProperties.ActiveRevision.State == proton.RevisionStateActive
 				switch {
-				case blocker == nil:
-					// Race: blocker removed between CreateFile and Lookup — retry.
+				case hasActiveRevision && opts.backup != backupNone:
+					// Keep the existing file under its backup name.
+					if bErr := backupProton(ctx, dc, dst.share, blocker, opts); bErr != nil {
+						return nil, fmt.Errorf("%s: %w", name, bErr)
+					}
 					fh, err = dc.CreateFile(ctx, dst.share, dst.link, name)
 					if err != nil {
-						return nil, fmt.Errorf("cp: %s: %w", name, err)
+						return nil, fmt.Errorf("%s: %w", name, err)
 					}
-				case blocker.Type() == proton.LinkTypeFolder:
-					return nil, fmt.Errorf("cp: %s: cannot overwrite directory with non-directory", name)
-				case blocker.State() == proton.LinkStateTrashed:
-					// Trashed link still occupies the name hash — permanently delete, then create new.
+				case !hasActiveRevision:
+					// No committed revision — delete the link and create fresh.
 					if delErr := dc.Remove(ctx, dst.share, blocker, drive.RemoveOpts{Permanent: true}); delErr != nil {
-						return nil, fmt.Errorf("cp: %s: remove trashed: %w", name, delErr)
+						return nil, fmt.Errorf("%s: remove draft link: %w", name, delErr)
 					}
 					fh, err = dc.CreateFile(ctx, dst.share, dst.link, name)
 					if err != nil {
-						return nil, fmt.Errorf("cp: %s: %w", name, err)
+						return nil, fmt.Errorf("%s: %w", name, err)
 					}
 				default:
-					// Active file or draft — check if it has a committed revision.
-					pLink := blocker.ProtonLink()
-					hasActiveRevision := pLink.FileProperties != nil &&
-						pLink.FileProperties.ActiveRevision.ID != "" &&
-						pLink.FileProperties.ActiveRevision.State == proton.RevisionStateActive
-					if !hasActiveRevision {
-						// No committed revision — delete the link and create fresh.
-						if delErr := dc.Remove(ctx, dst.share, blocker, drive.RemoveOpts{Permanent: true}); delErr != nil {
-							return nil, fmt.Errorf("cp: %s: remove draft link: %w", name, delErr)
-						}
-						fh, err = dc.CreateFile(ctx, dst.share, dst.link, name)
-						if err != nil {
-							return nil, fmt.Errorf("cp: %s: %w", name, err)
-						}
-					} else {
-						// Has active revision — overwrite via CreateRevision.
-						fh, err = dc.OverwriteFile(ctx, dst.share, blocker)
-						if err != nil {
-							return nil, fmt.Errorf("cp: %s: %w", name, err)
-						}
+					// Has active revision — overwrite via CreateRevision.
+					fh, err = dc.OverwriteFile(ctx, dst.share, blocker)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", name, err)
 					}
 				}
+			}
 
-			case errors.Is(err, drive.ErrDraftExist):
-				if !opts.force && !opts.removeDest {
-					return nil, fmt.Errorf("cp: %s: file exists (use -f to overwrite)", name)
+		case errors.Is(err, drive.ErrDraftExist):
+			if !opts.force && !opts.removeDest {
+				return nil, fmt.Errorf("%s: file exists (use -f to overwrite)", name)
+			}
+			// Draft-only link or stale draft — lookup and handle.
+			blocker, lookupErr := dst.link.Lookup(ctx, name)
+			if lookupErr != nil {
+				return nil, fmt.Errorf("%s: lookup draft: %w", name, lookupErr)
+			}
+			switch {
+			case blocker != nil && blocker.State() == proton.LinkStateDraft:
+				// Draft-only link: delete and recreate.
+				if delErr := dc.Remove(ctx, dst.share, blocker, drive.RemoveOpts{Permanent: true}); delErr != nil {
+					return nil, fmt.Errorf("%s: remove draft: %w", name, delErr)
 				}
-				// Draft-only link or stale draft — lookup and handle.
-				blocker, lookupErr := dst.link.Lookup(ctx, name)
-				if lookupErr != nil {
-					return nil, fmt.Errorf("cp: %s: lookup draft: %w", name, lookupErr)
+				fh, err = dc.CreateFile(ctx, dst.share, dst.link, name)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
 				}
-				switch {
-				case blocker != nil && blocker.State() == proton.LinkStateDraft:
-					// Draft-only link: delete and recreate.
-					if delErr := dc.Remove(ctx, dst.share, blocker, drive.RemoveOpts{Permanent: true}); delErr != nil {
-						return nil, fmt.Errorf("cp: %s: remove draft: %w", name, delErr)
-					}
-					fh, err = dc.CreateFile(ctx, dst.share, dst.link, name)
-					if err != nil {
-						return nil, fmt.Errorf("cp: %s: %w", name, err)
-					}
-				case blocker != nil:
-					// Active file with stale draft — overwrite.
-					fh, err = dc.OverwriteFile(ctx, dst.share, blocker)
-					if err != nil {
-						return nil, fmt.Errorf("cp: %s: %w", name, err)
-					}
-				default:
-					return nil, fmt.Errorf("cp: %s: %w", name, err)
+			case blocker != nil:
+				// Active file with stale draft — overwrite.
+				fh, err = dc.OverwriteFile(ctx, dst.share, blocker)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
 				}
-
 			default:
-				return nil, fmt.Errorf("cp: %s: %w", dst.raw, err)
+				return nil, fmt.Errorf("%s: %w", name, err)
 			}
+
+		default:
+			return nil, fmt.Errorf("%s: %w", dst.raw, err)
 		}
-		store := dc.InternalBlockStore()
-		pw := drive.NewProtonWriter(fh, store, dc.Session)
-		setProtonWriterMode(pw, src, opts)
-		job.Dst = pw
 	}
-
-	return &job, nil
+	return fh, nil
 }
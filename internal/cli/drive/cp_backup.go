@@ -0,0 +1,163 @@
+package driveCmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// backupMode selects how an existing destination is kept before it is
+// overwritten, following the GNU --backup CONTROL values.
+type backupMode int
+
+const (
+	backupNone     backupMode = iota // never make backups
+	backupSimple                     // always <name><suffix>
+	backupNumbered                   // always <name>.~N~
+	backupExisting                   // numbered if numbered backups exist, else simple
+)
+
+// defaultBackupSuffix is the simple backup suffix when --suffix is unset.
+const defaultBackupSuffix = "~"
+
+// backupFromEnv is the --backup value when CONTROL is omitted: the mode
+// is taken from $VERSION_CONTROL.
+const backupFromEnv = "$VERSION_CONTROL"
+
+// resolveBackupMode returns the backup mode for the --backup value,
+// given whether --backup and --suffix were set. As with GNU cp, a
+// --backup without CONTROL, or a --suffix alone, takes the mode from
+// $VERSION_CONTROL (read with getenv), defaulting to existing.
+func resolveBackupMode(value string, backupSet, suffixSet bool, getenv func(string) string) (backupMode, error) {
+	if value != backupFromEnv && (backupSet || !suffixSet) {
+		return parseBackupMode(value)
+	}
+	vc := getenv("VERSION_CONTROL")
+	if vc == "" {
+		return backupExisting, nil
+	}
+	mode, err := parseBackupMode(vc)
+	if err != nil {
+		return backupNone, fmt.Errorf("VERSION_CONTROL: %w", err)
+	}
+	return mode, nil
+}
+
+// parseBackupMode parses a --backup CONTROL value. The GNU aliases
+// (off, never, nil, t) are accepted; an empty value means none.
+func parseBackupMode(s string) (backupMode, error) {
+	switch s {
+	case "", "none", "off":
+		return backupNone, nil
+	case "simple", "never":
+		return backupSimple, nil
+	case "numbered", "t":
+		return backupNumbered, nil
+	case "existing", "nil":
+		return backupExisting, nil
+	}
+	return backupNone, fmt.Errorf("invalid backup type %q (valid: none, simple, numbered, existing)", s)
+}
+
+// backupName returns the name an existing entry called name is renamed
+// to, given the other names in its directory. An empty suffix means
+// defaultBackupSuffix.
+func backupName(name string, mode backupMode, suffix string, siblings []string) string {
+	if suffix == "" {
+		suffix = defaultBackupSuffix
+	}
+	switch mode {
+	case backupSimple:
+		return name + suffix
+	case backupNumbered, backupExisting:
+		n := highestBackup(name, siblings)
+		if n == 0 && mode == backupExisting {
+			return name + suffix
+		}
+		return fmt.Sprintf("%s.~%d~", name, n+1)
+	}
+	return ""
+}
+
+// highestBackup returns the largest N among siblings named
+// <name>.~N~, or 0 when there are none.
+func highestBackup(name string, siblings []string) int {
+	prefix := name + ".~"
+	highest := 0
+	for _, s := range siblings {
+		if len(s) <= len(prefix)+1 || !strings.HasPrefix(s, prefix) || !strings.HasSuffix(s, "~") {
+			continue
+		}
+		n, err := strconv.Atoi(s[len(prefix) : len(s)-1])
+		if err != nil || n <= 0 {
+			continue
+		}
+		highest = max(highest, n)
+	}
+	return highest
+}
+
+// backupLocal renames the local file p out of the way according to
+// opts.backup. A previous simple backup is replaced, as with GNU cp.
+func backupLocal(p string, opts cpOptions) error {
+	dir, name := filepath.Split(p)
+
+	var siblings []string
+	if opts.backup == backupNumbered || opts.backup == backupExisting {
+		entries, err := os.ReadDir(filepath.Clean(dir))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			siblings = append(siblings, e.Name())
+		}
+	}
+
+	return os.Rename(p, filepath.Join(dir, backupName(name, opts.backup, opts.suffix, siblings)))
+}
+
+// backupProton renames the Drive file link out of the way according to
+// opts.backup. The rename keeps the existing link and all its revisions
+// intact, so nothing is lost even when the share has revisions
+// disabled. A previous backup of the same name is kept: replacing it
+// would mean deleting it permanently, as a trashed link still holds
+// its name, so the new backup is numbered instead.
+func backupProton(ctx context.Context, dc *drive.Client, share *drive.Share, link *drive.Link, opts cpOptions) error {
+	parent := link.ParentLink()
+	if parent == nil {
+		return fmt.Errorf("backup: cannot rename share root")
+	}
+	name, err := link.Name()
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+
+	children, err := parent.ListChildren(ctx, true)
+	if err != nil {
+		return fmt.Errorf("backup: listing %s: %w", name, err)
+	}
+	siblings := make([]string, 0, len(children))
+	for _, c := range children {
+		n, err := c.Name()
+		if err != nil {
+			continue
+		}
+		siblings = append(siblings, n)
+	}
+
+	bname := backupName(name, opts.backup, opts.suffix, siblings)
+	if slices.Contains(siblings, bname) {
+		bname = backupName(name, backupNumbered, opts.suffix, siblings)
+	}
+
+	if err := dc.Rename(ctx, share, link, bname); err != nil {
+		return fmt.Errorf("backup: %s -> %s: %w", name, bname, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package driveCmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+func TestParseBackupMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    backupMode
+		wantErr bool
+	}{
+		{"", backupNone, false},
+		{"none", backupNone, false},
+		{"off", backupNone, false},
+		{"simple", backupSimple, false},
+		{"never", backupSimple, false},
+		{"numbered", backupNumbered, false},
+		{"t", backupNumbered, false},
+		{"existing", backupExisting, false},
+		{"nil", backupExisting, false},
+		{"always", backupNone, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseBackupMode(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBackupMode(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseBackupMode(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBackupMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		backupSet bool
+		suffixSet bool
+		env       string
+		want      backupMode
+		wantErr   bool
+	}{
+		{"unset", "", false, false, "numbered", backupNone, false},
+		{"explicit", "simple", true, false, "numbered", backupSimple, false},
+		{"bare default", backupFromEnv, true, false, "", backupExisting, false},
+		{"bare from env", backupFromEnv, true, false, "numbered", backupNumbered, false},
+		{"bare env none", backupFromEnv, true, false, "off", backupNone, false},
+		{"bare env invalid", backupFromEnv, true, false, "always", backupNone, true},
+		{"suffix alone", "", false, true, "", backupExisting, false},
+		{"suffix alone from env", "", false, true, "t", backupNumbered, false},
+		{"suffix with explicit", "simple", true, true, "numbered", backupSimple, false},
+		{"suffix with none", "none", true, true, "", backupNone, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				if key == "VERSION_CONTROL" {
+					return tt.env
+				}
+				return ""
+			}
+			got, err := resolveBackupMode(tt.value, tt.backupSet, tt.suffixSet, getenv)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveBackupMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveBackupMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackupName(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     backupMode
+		suffix   string
+		siblings []string
+		want     string
+	}{
+		{"simple default suffix", backupSimple, "", nil, "f.txt~"},
+		{"simple custom suffix", backupSimple, ".bak", nil, "f.txt.bak"},
+		{"simple ignores numbered", backupSimple, "", []string{"f.txt.~3~"}, "f.txt~"},
+		{"numbered first", backupNumbered, "", []string{"f.txt"}, "f.txt.~1~"},
+		{"numbered next", backupNumbered, "", []string{"f.txt.~1~", "f.txt.~4~", "f.txt.~2~"}, "f.txt.~5~"},
+		{"numbered ignores others", backupNumbered, "", []string{"g.txt.~7~", "f.txt.~x~", "f.txt.~~", "f.txt.~0~"}, "f.txt.~1~"},
+		{"existing without numbered", backupExisting, ".old", []string{"f.txt~"}, "f.txt.old"},
+		{"existing with numbered", backupExisting, "", []string{"f.txt.~2~"}, "f.txt.~3~"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backupName("f.txt", tt.mode, tt.suffix, tt.siblings); got != tt.want {
+				t.Errorf("backupName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBackupNameNumberedUnique verifies that a numbered backup name
+// never collides with an existing sibling.
+func TestBackupNameNumberedUnique(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		nums := rapid.SliceOf(rapid.IntRange(1, 1000)).Draw(t, "nums")
+		siblings := []string{"f"}
+		for _, n := range nums {
+			siblings = append(siblings, fmt.Sprintf("f.~%d~", n))
+		}
+		got := backupName("f", backupNumbered, "", siblings)
+		if slices.Contains(siblings, got) {
+			t.Fatalf("backupName() = %q collides with %v", got, siblings)
+		}
+	})
+}
+
+func TestBackupLocalNumbered(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "f.txt")
+
+	for i, content := range []string{"one", "two"} {
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := backupLocal(p, cpOptions{backup: backupNumbered}); err != nil {
+			t.Fatalf("backupLocal #%d: %v", i+1, err)
+		}
+	}
+
+	for i, want := range []string{"one", "two"} {
+		got, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("f.txt.~%d~", i+1))) //nolint:gosec // test temp path
+		if err != nil {
+			t.Fatalf("backup %d: %v", i+1, err)
+		}
+		if string(got) != want {
+			t.Errorf("backup %d = %q, want %q", i+1, got, want)
+		}
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("original still present after backup: %v", err)
+	}
+}
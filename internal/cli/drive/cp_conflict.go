@@ -15,7 +15,8 @@ import (
 //   - No flags: refuse to overwrite (return error)
 //   - -f / --force: overwrite (truncate local, trash remote)
 //   - --remove-destination: remove before copy (local rm, remote trash)
-//   - --backup: rename local to <name>~ before copy
+//   - --backup: rename the existing file (local or remote) out of the
+//     way before copy; see backupName for the naming
 //
 // Directories always merge — no conflict.
 func handleConflict(ctx context.Context, dc *drive.Client, dst *resolvedEndpoint, opts cpOptions) error {
@@ -28,8 +29,8 @@ func handleConflict(ctx context.Context, dc *drive.Client, dst *resolvedEndpoint
 		if dst.localInfo == nil {
 			return nil // doesn't exist, no conflict
 		}
-		if opts.backup {
-			return os.Rename(dst.localPath, dst.localPath+"~")
+		if opts.backup != backupNone {
+			return backupLocal(dst.localPath, opts)
 		}
 		if opts.removeDest {
 			return os.Remove(dst.localPath)
@@ -46,6 +47,16 @@ func handleConflict(ctx context.Context, dc *drive.Client, dst *resolvedEndpoint
 		if dst.link.Type() == proton.LinkTypeFolder {
 			return nil // directory, merge
 		}
+		if opts.backup != backupNone {
+			// The renamed file keeps its link; the copy creates a new
+			// file under the parent.
+			parent := dst.link.ParentLink()
+			if err := backupProton(ctx, dc, dst.share, dst.link, opts); err != nil {
+				return err
+			}
+			dst.link = parent
+			return nil
+		}
 		if opts.removeDest {
 			return dc.Remove(ctx, dst.share, dst.link, drive.RemoveOpts{})
 		}
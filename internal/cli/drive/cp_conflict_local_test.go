@@ -96,7 +96,7 @@ func TestHandleConflict_LocalBackup(t *testing.T) {
 		localInfo: info,
 	}
 
-	err := handleConflict(context.TODO(), nil, dst, cpOptions{backup: true})
+	err := handleConflict(context.TODO(), nil, dst, cpOptions{backup: backupSimple})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -99,6 +99,13 @@ func expandLocalRecursive(ctx context.Context, dc *drive.Client, src, dstBase *r
 
 		// Symlink handling.
 		if d.Type()&os.ModeSymlink != 0 {
+			if !opts.dereference && parsePreserve(opts).links {
+				linkSrc := &resolvedEndpoint{pathType: PathLocal, raw: path, localPath: path}
+				if err := copySymlink(ctx, dc, linkSrc, makeFileDst(dstBase, rel), opts); err != nil {
+					fmt.Fprintf(os.Stderr, "cp: %s: %v\n", path, err)
+				}
+				return nil
+			}
 			if !opts.dereference {
 				fmt.Fprintf(os.Stderr, "cp: %s: skipping symbolic link\n", path)
 				return nil
@@ -154,6 +161,7 @@ func expandLocalRecursive(ctx context.Context, dc *drive.Client, src, dstBase *r
 				dstPath: fileDst.localPath,
 				mode:    info.Mode().Perm(),
 				mtime:   info.ModTime(),
+				xattrs:  sourceXAttrs(ctx, dc, fileSrc, opts),
 			})
 		}
 
@@ -204,6 +212,13 @@ func expandProtonRecursive(ctx context.Context, dc *drive.Client, src, dstBase *
 
 		fileDst := makeFileDst(dstBase, entry.Path)
 
+		if isSymlinkSource(fileSrc, opts) {
+			if err := copySymlink(ctx, dc, fileSrc, fileDst, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "cp: %s: %v\n", entry.Path, err)
+			}
+			continue
+		}
+
 		if err := handleConflict(ctx, dc, fileDst, opts); err != nil {
 			fmt.Fprintf(os.Stderr, "cp: %s: %v\n", entry.Path, err)
 			continue
@@ -218,10 +233,12 @@ func expandProtonRecursive(ctx context.Context, dc *drive.Client, src, dstBase *
 
 		// Collect preservation metadata for Proton→local.
 		if fileDst.pathType == PathLocal {
-			if m := entry.Link.Mode(); m != 0 {
+			xattrs := sourceXAttrs(ctx, dc, fileSrc, opts)
+			if m := entry.Link.Mode(); m != 0 || xattrs != nil {
 				preserves = append(preserves, preserveEntry{
 					dstPath: fileDst.localPath,
 					mode:    os.FileMode(m),
+					xattrs:  xattrs,
 				})
 			}
 		}
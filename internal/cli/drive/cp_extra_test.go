@@ -191,7 +191,7 @@ func TestRunCpPreserveBoth(t *testing.T) {
 func TestRunCpRecursiveWithBackup(t *testing.T) {
 	resetFlags()
 	cpFlags.recursive = true
-	cpFlags.backup = true
+	cpFlags.backup = "simple"
 	tmp := t.TempDir()
 
 	// Source tree.
@@ -1,38 +1,118 @@
 package driveCmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"time"
+
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// POSIX ACLs are stored by Linux as these extended attributes.
+const (
+	aclAccessXAttr  = "system.posix_acl_access"
+	aclDefaultXAttr = "system.posix_acl_default"
 )
 
-// preserveEntry tracks metadata to apply after copy completes.
+// preserveEntry tracks metadata to apply after copy completes. Zero
+// mode and mtime mean "not known" and are skipped.
 type preserveEntry struct {
 	dstPath string
 	mode    os.FileMode
 	mtime   time.Time
+	xattrs  map[string][]byte
 }
 
 // preserveFlags holds parsed --preserve flag values.
 type preserveFlags struct {
 	mode       bool
 	timestamps bool
+	links      bool // copy symlinks as symlinks
+	xattr      bool // extended attributes other than ACLs
+	acl        bool // POSIX ACLs (system.posix_acl_*)
+}
+
+// wantXAttr reports whether the extended attribute name is selected by
+// the xattr or acl preserve flags.
+func (pf preserveFlags) wantXAttr(name string) bool {
+	if name == aclAccessXAttr || name == aclDefaultXAttr {
+		return pf.acl
+	}
+	return pf.xattr
+}
+
+// filterXAttrs returns the entries of xattrs selected by pf, or nil.
+func filterXAttrs(xattrs map[string][]byte, pf preserveFlags) map[string][]byte {
+	var out map[string][]byte
+	for name, val := range xattrs {
+		if !pf.wantXAttr(name) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string][]byte)
+		}
+		out[name] = val
+	}
+	return out
 }
 
-// applyPreserve applies preserved mode and mtime to destination files.
+// sourceXAttrs returns the extended attributes of src selected by
+// --preserve=xattr,acl. Proton sources have their revision XAttr
+// fetched first. Read failures are reported and yield nil.
+func sourceXAttrs(ctx context.Context, dc *drive.Client, src *resolvedEndpoint, opts cpOptions) map[string][]byte {
+	pf := parsePreserve(opts)
+	if !pf.xattr && !pf.acl {
+		return nil
+	}
+	switch src.pathType {
+	case PathLocal:
+		xattrs, err := readLocalXAttrs(src.localPath, pf)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cp: preserve xattrs %s: %v\n", src.localPath, err)
+			return nil
+		}
+		return xattrs
+	case PathProton:
+		if src.link == nil {
+			return nil
+		}
+		dc.FetchRevisionXAttr(ctx, src.link)
+		return filterXAttrs(src.link.XAttrs(), pf)
+	}
+	return nil
+}
+
+// setProtonWriterXAttrs stores the source's selected extended
+// attributes in the ProtonWriter's revision XAttr.
+func setProtonWriterXAttrs(ctx context.Context, dc *drive.Client, pw *drive.ProtonWriter, src *resolvedEndpoint, opts cpOptions) {
+	if xattrs := sourceXAttrs(ctx, dc, src, opts); len(xattrs) > 0 {
+		pw.SetXAttrs(xattrs)
+	}
+}
+
+// applyPreserve applies preserved mode, mtime, and extended attributes
+// to destination files.
 func applyPreserve(entries []preserveEntry, opts cpOptions) {
 	preserve := parsePreserve(opts)
-	if !preserve.mode && !preserve.timestamps {
+	if !preserve.mode && !preserve.timestamps && !preserve.xattr && !preserve.acl {
 		return
 	}
 	for _, e := range entries {
-		if preserve.mode {
+		// Extended attributes first: setting an ACL rewrites the group
+		// permission bits, which the preserved mode then restores.
+		if len(e.xattrs) > 0 {
+			if err := writeLocalXAttrs(e.dstPath, e.xattrs); err != nil {
+				fmt.Fprintf(os.Stderr, "cp: preserve xattrs %s: %v\n", e.dstPath, err)
+			}
+		}
+		if preserve.mode && e.mode != 0 {
 			if err := os.Chmod(e.dstPath, e.mode); err != nil {
 				fmt.Fprintf(os.Stderr, "cp: preserve mode %s: %v\n", e.dstPath, err)
 			}
 		}
-		if preserve.timestamps {
+		if preserve.timestamps && !e.mtime.IsZero() {
 			if err := os.Chtimes(e.dstPath, e.mtime, e.mtime); err != nil {
 				fmt.Fprintf(os.Stderr, "cp: preserve timestamps %s: %v\n", e.dstPath, err)
 			}
@@ -49,6 +129,12 @@ func parsePreserve(opts cpOptions) preserveFlags {
 			pf.mode = true
 		case "timestamps":
 			pf.timestamps = true
+		case "links":
+			pf.links = true
+		case "xattr":
+			pf.xattr = true
+		case "acl":
+			pf.acl = true
 		}
 	}
 	return pf
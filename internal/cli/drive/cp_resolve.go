@@ -111,7 +111,8 @@ var errSkipSymlink = errors.New("skipping symbolic link")
 
 // resolveSource resolves a source path argument to a resolvedEndpoint.
 // For local paths, uses os.Lstat to detect symlinks. With -L, follows
-// symlinks via os.Stat. Without -L, returns errSkipSymlink.
+// symlinks via os.Stat. With --preserve=links, returns the symlink
+// itself. Otherwise returns errSkipSymlink.
 func resolveSource(ctx context.Context, dc *drive.Client, arg pathArg, opts cpOptions) (*resolvedEndpoint, error) {
 	ep := &resolvedEndpoint{pathType: arg.pathType, raw: arg.raw}
 	switch arg.pathType {
@@ -127,7 +128,7 @@ func resolveSource(ctx context.Context, dc *drive.Client, arg pathArg, opts cpOp
 		if err != nil {
 			return nil, fmt.Errorf("cp: %s: %w", arg.raw, err)
 		}
-		if info.Mode()&os.ModeSymlink != 0 {
+		if info.Mode()&os.ModeSymlink != 0 && (opts.dereference || !parsePreserve(opts).links) {
 			if !opts.dereference {
 				return nil, fmt.Errorf("cp: %s: %w", arg.raw, errSkipSymlink)
 			}
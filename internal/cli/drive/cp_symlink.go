@@ -0,0 +1,130 @@
+package driveCmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// isSymlinkSource reports whether src should be copied as a symbolic
+// link rather than as file content. Only true with --preserve=links.
+func isSymlinkSource(src *resolvedEndpoint, opts cpOptions) bool {
+	if !parsePreserve(opts).links {
+		return false
+	}
+	switch src.pathType {
+	case PathLocal:
+		return src.localInfo != nil && src.localInfo.Mode()&os.ModeSymlink != 0
+	case PathProton:
+		return src.link != nil && src.link.IsSymlink()
+	}
+	return false
+}
+
+// copySymlink recreates the symbolic link src at dst. Local symlinks
+// become Drive symlink files and Drive symlink files become local
+// symlinks. The target is copied verbatim — relative targets are not
+// rewritten.
+func copySymlink(ctx context.Context, dc *drive.Client, src, dst *resolvedEndpoint, opts cpOptions) error {
+	var target string
+	switch src.pathType {
+	case PathLocal:
+		t, err := os.Readlink(src.localPath)
+		if err != nil {
+			return err
+		}
+		target = t
+	case PathProton:
+		t, err := dc.Readlink(ctx, src.link)
+		if err != nil {
+			return err
+		}
+		target = t
+	}
+
+	var err error
+	switch dst.pathType {
+	case PathLocal:
+		err = symlinkLocal(target, dst.localPath, opts)
+	case PathProton:
+		err = symlinkProton(ctx, dc, target, dst, opts)
+	}
+	if err == nil && opts.verbose {
+		fmt.Fprintf(os.Stderr, "'%s' -> '%s'\n", src.raw, dst.raw)
+	}
+	return err
+}
+
+// symlinkLocal creates a local symlink at p, replacing an existing
+// non-directory entry when opts allow it.
+func symlinkLocal(target, p string, opts cpOptions) error {
+	if info, err := os.Lstat(p); err == nil {
+		switch {
+		case info.IsDir():
+			return fmt.Errorf("%s: cannot overwrite directory with non-directory", p)
+		case opts.backup != backupNone:
+			if err := backupLocal(p, opts); err != nil {
+				return err
+			}
+		case opts.force || opts.removeDest:
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: file exists (use -f to overwrite)", p)
+		}
+	}
+	return os.Symlink(target, p)
+}
+
+// symlinkProton creates a Drive symlink file for dst. A symlink cannot
+// be stored as a new revision of a regular file, so with -f or
+// --remove-destination an existing file is trashed first. A trashed
+// link still holds its name, so it is then deleted permanently. With
+// --backup an existing file is renamed instead.
+func symlinkProton(ctx context.Context, dc *drive.Client, target string, dst *resolvedEndpoint, opts cpOptions) error {
+	name := filepath.Base(dst.raw)
+	parent := dst.link
+	if parent.Type() == proton.LinkTypeFile {
+		parent = parent.ParentLink()
+	}
+
+	for attempt := 0; ; attempt++ {
+		_, err := dc.Symlink(ctx, dst.share, parent, name, target)
+		if !errors.Is(err, drive.ErrFileNameExist) {
+			return err
+		}
+		if !opts.force && !opts.removeDest && opts.backup == backupNone {
+			return fmt.Errorf("%s: file exists (use -f to overwrite)", name)
+		}
+		if attempt == 2 {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		blocker, err := parent.Lookup(ctx, name)
+		if err != nil {
+			return fmt.Errorf("%s: lookup: %w", name, err)
+		}
+		if blocker == nil {
+			continue // removed concurrently — retry
+		}
+		if blocker.Type() == proton.LinkTypeFolder {
+			return fmt.Errorf("%s: cannot overwrite directory with non-directory", name)
+		}
+		if opts.backup != backupNone && !blocker.IsTrashed() {
+			if err := backupProton(ctx, dc, dst.share, blocker, opts); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		permanent := blocker.State() == proton.LinkStateTrashed
+		if err := dc.Remove(ctx, dst.share, blocker, drive.RemoveOpts{Permanent: permanent}); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package driveCmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopySymlinkLocal(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		opts     cpOptions
+		wantErr  string
+	}{
+		{"new destination", false, cpOptions{}, ""},
+		{"existing without force", true, cpOptions{}, "file exists"},
+		{"existing with force", true, cpOptions{force: true}, ""},
+		{"existing with backup", true, cpOptions{backup: backupSimple}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			link := filepath.Join(dir, "link")
+			if err := os.Symlink("../some/target", link); err != nil {
+				t.Skip("symlinks not supported")
+			}
+			info, err := os.Lstat(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := filepath.Join(dir, "copy")
+			if tt.existing {
+				if err := os.WriteFile(dst, []byte("old"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			src := &resolvedEndpoint{pathType: PathLocal, raw: link, localPath: link, localInfo: info}
+			dstEp := &resolvedEndpoint{pathType: PathLocal, raw: dst, localPath: dst}
+			opts := tt.opts
+			opts.preserve = "links"
+			if !isSymlinkSource(src, opts) {
+				t.Fatal("isSymlinkSource = false for a local symlink")
+			}
+
+			err = copySymlink(context.Background(), nil, src, dstEp, opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("copySymlink: %v", err)
+			}
+			got, err := os.Readlink(dst)
+			if err != nil {
+				t.Fatalf("Readlink: %v", err)
+			}
+			if got != "../some/target" {
+				t.Errorf("target = %q, want %q", got, "../some/target")
+			}
+			if tt.opts.backup != backupNone {
+				if data, err := os.ReadFile(dst + "~"); err != nil || string(data) != "old" { //nolint:gosec // test temp file
+					t.Errorf("backup = %q, %v; want %q", data, err, "old")
+				}
+			}
+		})
+	}
+}
+
+func TestIsSymlinkSourceRequiresPreserve(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink("target", link); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &resolvedEndpoint{pathType: PathLocal, raw: link, localPath: link, localInfo: info}
+	if isSymlinkSource(src, cpOptions{preserve: "mode,timestamps"}) {
+		t.Error("isSymlinkSource = true without --preserve=links")
+	}
+}
@@ -49,7 +49,8 @@ func TestArgSplitting(t *testing.T) {
 			targetDir   string
 			removeDest  bool
 			force       bool
-			backup      bool
+			backup      string
+			suffix      string
 		}{}
 	}
 
@@ -110,7 +111,7 @@ func TestArgSplitting(t *testing.T) {
 			args: []string{srcFile, dstFile},
 			setup: func() {
 				cpFlags.removeDest = true
-				cpFlags.backup = true
+				cpFlags.backup = "simple"
 			},
 			wantErr: "mutually exclusive",
 		},
@@ -153,7 +154,8 @@ func resetFlags() {
 		targetDir   string
 		removeDest  bool
 		force       bool
-		backup      bool
+		backup      string
+		suffix      string
 	}{}
 }
 
@@ -534,7 +536,7 @@ func TestConflictHandling(t *testing.T) {
 
 	t.Run("--backup renames existing to tilde suffix", func(t *testing.T) {
 		resetFlags()
-		cpFlags.backup = true
+		cpFlags.backup = "simple"
 		tmp := t.TempDir()
 		src := filepath.Join(tmp, "src.txt")
 		dst := filepath.Join(tmp, "dst.txt")
@@ -1091,7 +1093,7 @@ func TestErrorDiagnostics(t *testing.T) {
 	t.Run("mutually exclusive flags", func(t *testing.T) {
 		resetFlags()
 		cpFlags.removeDest = true
-		cpFlags.backup = true
+		cpFlags.backup = "simple"
 		tmp := t.TempDir()
 		src := filepath.Join(tmp, "src.txt")
 		dst := filepath.Join(tmp, "dst.txt")
@@ -16,7 +16,8 @@ type cpOptions struct {
 	dereference bool
 	removeDest  bool
 	force       bool
-	backup      bool
+	backup      backupMode
+	suffix      string
 	preserve    string
 	verbose     bool
 	progress    bool
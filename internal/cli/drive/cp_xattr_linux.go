@@ -0,0 +1,70 @@
+//go:build linux
+
+package driveCmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// readLocalXAttrs returns the extended attributes of path selected by
+// pf. Symlinks are not followed. A filesystem without xattr support
+// yields an empty result.
+func readLocalXAttrs(path string, pf preserveFlags) (map[string][]byte, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	names := make([]byte, size)
+	size, err = unix.Llistxattr(path, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var out map[string][]byte
+	for _, raw := range bytes.Split(names[:size], []byte{0}) {
+		name := string(raw)
+		if name == "" || !pf.wantXAttr(name) {
+			continue
+		}
+		n, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			if errors.Is(err, unix.ENODATA) {
+				continue // removed since listing
+			}
+			return nil, err
+		}
+		val := make([]byte, n)
+		if n > 0 {
+			if n, err = unix.Lgetxattr(path, name, val); err != nil {
+				return nil, err
+			}
+		}
+		if out == nil {
+			out = make(map[string][]byte)
+		}
+		out[name] = val[:n]
+	}
+	return out, nil
+}
+
+// writeLocalXAttrs sets each extended attribute on path. Every
+// attribute is attempted; the first failure is returned.
+func writeLocalXAttrs(path string, xattrs map[string][]byte) error {
+	var first error
+	for name, val := range xattrs {
+		if err := unix.Lsetxattr(path, name, val, 0); err != nil && first == nil {
+			first = fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return first
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package driveCmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestLocalXAttrsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	for _, p := range []string{src, dst} {
+		if err := os.WriteFile(p, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := unix.Lsetxattr(src, "user.checksum", []byte("sha256:abc"), 0); err != nil {
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EPERM) {
+			t.Skip("user xattrs not supported on temp filesystem")
+		}
+		t.Fatal(err)
+	}
+
+	xattrs, err := readLocalXAttrs(src, preserveFlags{xattr: true})
+	if err != nil {
+		t.Fatalf("readLocalXAttrs: %v", err)
+	}
+	if string(xattrs["user.checksum"]) != "sha256:abc" {
+		t.Fatalf("xattrs = %v", xattrs)
+	}
+
+	if none, err := readLocalXAttrs(src, preserveFlags{acl: true}); err != nil || none != nil {
+		t.Errorf("readLocalXAttrs(acl only) = %v, %v; want nil", none, err)
+	}
+
+	if err := writeLocalXAttrs(dst, xattrs); err != nil {
+		t.Fatalf("writeLocalXAttrs: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, err := unix.Lgetxattr(dst, "user.checksum", buf)
+	if err != nil {
+		t.Fatalf("Lgetxattr: %v", err)
+	}
+	if string(buf[:n]) != "sha256:abc" {
+		t.Errorf("dst xattr = %q", buf[:n])
+	}
+}
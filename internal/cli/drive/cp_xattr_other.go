@@ -0,0 +1,19 @@
+//go:build !linux
+
+package driveCmd
+
+import "errors"
+
+// readLocalXAttrs is a no-op on platforms without Linux xattr syscalls.
+func readLocalXAttrs(_ string, _ preserveFlags) (map[string][]byte, error) {
+	return nil, nil
+}
+
+// writeLocalXAttrs fails on platforms without Linux xattr syscalls
+// unless there is nothing to write.
+func writeLocalXAttrs(_ string, xattrs map[string][]byte) error {
+	if len(xattrs) == 0 {
+		return nil
+	}
+	return errors.New("extended attributes are not supported on this platform")
+}
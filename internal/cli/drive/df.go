@@ -89,6 +89,54 @@ func printVolumeRows(volumes []drive.Volume, nameIndex map[string]string, shareI
 	}
 }
 
+// dfRecord is the --output schema of drive df. Sizes are in bytes; a
+// volume's max_space is null when it is unlimited.
+type dfRecord struct {
+	Volumes []volumeRecord `json:"volumes"`
+	Total   quotaRecord    `json:"total"`
+}
+
+// volumeRecord describes the usage of one volume.
+type volumeRecord struct {
+	VolumeID        string `json:"volume_id"`
+	ShareID         string `json:"share_id"`
+	Name            string `json:"name,omitempty"`
+	ShareType       string `json:"share_type,omitempty"`
+	MaxSpace        *int64 `json:"max_space"`
+	UsedSpace       int64  `json:"used_space"`
+	DownloadedBytes int64  `json:"downloaded_bytes"`
+	UploadedBytes   int64  `json:"uploaded_bytes"`
+	State           string `json:"state"`
+}
+
+// quotaRecord describes the account-wide storage quota.
+type quotaRecord struct {
+	MaxSpace  int64 `json:"max_space"`
+	UsedSpace int64 `json:"used_space"`
+}
+
+// volumeRecords converts volumes to their --output records.
+func volumeRecords(volumes []drive.Volume, nameIndex map[string]string, shareIndex map[string]proton.ShareMetadata) []volumeRecord {
+	out := make([]volumeRecord, len(volumes))
+	for i, v := range volumes {
+		pv := v.ProtonVolume
+		out[i] = volumeRecord{
+			VolumeID:        pv.VolumeID,
+			ShareID:         pv.Share.ShareID,
+			Name:            nameIndex[pv.VolumeID],
+			MaxSpace:        pv.MaxSpace,
+			UsedSpace:       pv.UsedSpace,
+			DownloadedBytes: pv.DownloadedBytes,
+			UploadedBytes:   pv.UploadedBytes,
+			State:           dfVolState(pv.State),
+		}
+		if s, ok := shareIndex[pv.Share.ShareID]; ok {
+			out[i].ShareType = drive.FormatShareType(s.Type)
+		}
+	}
+	return out
+}
+
 func runDf(cmd *cobra.Command, _ []string) error {
 	rc := cli.GetContext(cmd)
 	ctx := context.Background()
@@ -137,6 +185,13 @@ func runDf(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
+	if cli.Structured(cmd) {
+		return cli.PrintObject(cmd, dfRecord{
+			Volumes: volumeRecords(volumes, nameIndex, shareIndex),
+			Total:   quotaRecord{MaxSpace: user.MaxSpace(), UsedSpace: user.UsedSpace()},
+		})
+	}
+
 	fmt.Printf("%-20s %10s %10s %10s %5s %10s %10s %s\n",
 		"Volume", "Size", "Used", "Avail", "Use%", "Down", "Up", "State")
 
@@ -0,0 +1,360 @@
+package driveCmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+// editPromptFn reads the conflict choice. Replaced in tests.
+var editPromptFn = cli.UserPrompt
+
+var editFlags struct {
+	keepOnFailure bool
+}
+
+var driveEditCmd = &cobra.Command{
+	Use:   "edit <path>",
+	Short: "Edit a Proton Drive file in $EDITOR",
+	Long: `Download a file to a private temporary directory, open it in $VISUAL
+or $EDITOR (default vi), and upload the result as a new revision.
+
+The temporary copy is created mode 0600 inside a 0700 directory under
+$XDG_RUNTIME_DIR (or the system temporary directory when unset). It is
+overwritten and removed when the command exits, whatever the outcome,
+so changes that cannot be uploaded are lost. With --keep-on-failure the
+edited copy is instead moved to a new 0700 directory next to it when
+the editor fails or the upload does not happen, and the error gives its
+path. That copy is plaintext; remove it yourself once recovered.
+
+Nothing is uploaded when the file was not changed. If another client
+committed a new revision while the editor was open, the upload is held
+back and you are asked whether to overwrite that revision, save your
+version alongside it as a separate file, or discard your changes. The
+file's mode and extended attributes are kept.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEdit,
+}
+
+func init() {
+	driveCmd.AddCommand(driveEditCmd)
+	cli.BoolFlag(driveEditCmd.Flags(), &editFlags.keepOnFailure, "keep-on-failure", false, "Keep the edited copy when your changes cannot be uploaded")
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	rawPath := args[0]
+	ctx := context.Background()
+
+	session, err := cli.SetupSession(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	link, share, err := ResolveProtonPath(ctx, dc, rawPath)
+	if err != nil {
+		return fmt.Errorf("edit: %w", err)
+	}
+	if !link.IsFile() {
+		return fmt.Errorf("edit: %s: not a regular file", rawPath)
+	}
+	if link.IsSymlink() {
+		return fmt.Errorf("edit: %s: is a symbolic link", rawPath)
+	}
+	name, err := link.Name()
+	if err != nil {
+		return fmt.Errorf("edit: %s: %w", rawPath, err)
+	}
+	dc.FetchRevisionXAttr(ctx, link)
+	baseRev := link.RevisionID()
+
+	dir, err := os.MkdirTemp(editTempDir(), "proton-edit-*")
+	if err != nil {
+		return fmt.Errorf("edit: %w", err)
+	}
+	defer func() {
+		if err := shredDir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "edit: removing %s: %v\n", dir, err)
+		}
+	}()
+	tmp := filepath.Join(dir, name)
+	// notUploaded reports changes that were not uploaded. The edited
+	// copy is shredded with dir unless --keep-on-failure asks for it.
+	notUploaded := func(err error) error {
+		if !editFlags.keepOnFailure {
+			return err
+		}
+		return keepEdit(tmp, err)
+	}
+
+	before, err := downloadForEdit(ctx, dc, link, tmp)
+	if err != nil {
+		return fmt.Errorf("edit: %s: download: %w", rawPath, err)
+	}
+
+	argv := editorArgv(os.Getenv, tmp)
+	ed := exec.Command(argv[0], argv[1:]...) //nolint:gosec // editor is user-controlled via $VISUAL/$EDITOR
+	ed.Stdin = os.Stdin
+	ed.Stdout = os.Stdout
+	ed.Stderr = os.Stderr
+	edErr := ed.Run()
+
+	after, err := hashFile(tmp)
+	if err != nil {
+		return notUploaded(fmt.Errorf("edit: %w; changes not uploaded", err))
+	}
+	switch {
+	case edErr != nil && bytes.Equal(before, after):
+		return fmt.Errorf("edit: %s: %w; changes not uploaded", argv[0], edErr)
+	case edErr != nil:
+		return notUploaded(fmt.Errorf("edit: %s: %w; changes not uploaded", argv[0], edErr))
+	case bytes.Equal(before, after):
+		fmt.Fprintf(os.Stderr, "edit: %s: no changes\n", rawPath)
+		return nil
+	}
+
+	curRev, err := dc.FetchRevisionID(ctx, share, link)
+	gone := errors.Is(err, drive.ErrFileNotFound)
+	if err != nil && !gone {
+		return notUploaded(fmt.Errorf("edit: %s: %w; changes not uploaded", rawPath, err))
+	}
+
+	if gone || curRev != baseRev {
+		copyName := drive.ConflictName(name, time.Now())
+		choice, err := promptEditConflict(rawPath, copyName, gone)
+		if err != nil {
+			return notUploaded(fmt.Errorf("edit: %w; changes not uploaded", err))
+		}
+		switch choice {
+		case conflictDiscard:
+			fmt.Fprintf(os.Stderr, "edit: %s: changes discarded\n", rawPath)
+			return nil
+		case conflictSaveCopy:
+			fd, err := dc.CreateFD(ctx, share, link.ParentLink(), copyName)
+			if err != nil {
+				return notUploaded(fmt.Errorf("edit: %s: %w", copyName, err))
+			}
+			if err := uploadEdit(fd, link, tmp); err != nil {
+				return notUploaded(fmt.Errorf("edit: %s: %w", copyName, err))
+			}
+			fmt.Fprintf(os.Stderr, "edit: saved as '%s'\n", copyName)
+			return nil
+		}
+	}
+
+	fd, err := dc.OverwriteFD(ctx, share, link)
+	if err != nil {
+		return notUploaded(fmt.Errorf("edit: %s: %w", rawPath, err))
+	}
+	if err := uploadEdit(fd, link, tmp); err != nil {
+		return notUploaded(fmt.Errorf("edit: %s: %w", rawPath, err))
+	}
+	return nil
+}
+
+// keepEdit moves the edited file at p out of the edit session's
+// directory, which is shredded on exit, into a new private directory
+// next to it, and returns err amended with where the file now is.
+func keepEdit(p string, err error) error {
+	dir, kerr := os.MkdirTemp(filepath.Dir(filepath.Dir(p)), "proton-edit-kept-*")
+	if kerr == nil {
+		kept := filepath.Join(dir, filepath.Base(p))
+		if kerr = os.Rename(p, kept); kerr == nil {
+			return fmt.Errorf("%w; the edited copy is kept at %s", err, kept)
+		}
+		_ = os.Remove(dir)
+	}
+	return fmt.Errorf("%w; the edited copy could not be kept: %w", err, kerr)
+}
+
+// editTempDir returns the directory that holds edit sessions:
+// $XDG_RUNTIME_DIR, which is private to the user and usually memory
+// backed, or the system temporary directory.
+func editTempDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+// editorArgv returns the command line that edits file: $VISUAL, then
+// $EDITOR, then vi. The variable may carry arguments ("code --wait").
+func editorArgv(getenv func(string) string, file string) []string {
+	editor := strings.TrimSpace(getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(getenv("EDITOR"))
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	return append(strings.Fields(editor), file)
+}
+
+// downloadForEdit writes the content of link to a new 0600 file at p
+// and returns its SHA-256.
+func downloadForEdit(ctx context.Context, dc *drive.Client, link *drive.Link, p string) ([]byte, error) {
+	in, err := dc.OpenFD(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600) //nolint:gosec // p is inside our private temp dir
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// hashFile returns the SHA-256 of the file at p.
+func hashFile(p string) ([]byte, error) {
+	f, err := os.Open(p) //nolint:gosec // p is inside our private temp dir
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// uploadEdit writes the edited file at p through fd, keeping the mode
+// and extended attributes of the original link.
+func uploadEdit(fd *drive.FileDescriptor, orig *drive.Link, p string) error {
+	f, err := os.Open(p) //nolint:gosec // p is inside our private temp dir
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	fd.SetMode(orig.Mode())
+	fd.SetXAttrs(orig.XAttrs())
+	fd.SetModTime(time.Now())
+	fd.SetCommitEmpty(true)
+	if _, err := io.Copy(fd, f); err != nil {
+		// Do not Close — that would commit the partial content.
+		return err
+	}
+	return fd.Close()
+}
+
+// conflictChoice is the answer to the edit conflict prompt.
+type conflictChoice int
+
+const (
+	conflictOverwrite conflictChoice = iota + 1 // replace the remote revision
+	conflictSaveCopy                            // upload as a separate file
+	conflictDiscard                             // drop the local changes
+)
+
+// parseConflictChoice interprets an answer to the conflict prompt.
+// Overwrite is not offered when the remote file is gone.
+func parseConflictChoice(s string, gone bool) (conflictChoice, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "o", "overwrite":
+		if gone {
+			return 0, false
+		}
+		return conflictOverwrite, true
+	case "s", "save":
+		return conflictSaveCopy, true
+	case "d", "discard":
+		return conflictDiscard, true
+	}
+	return 0, false
+}
+
+// promptEditConflict asks what to do with changes to a file that was
+// modified or removed on Drive during the edit.
+func promptEditConflict(rawPath, copyName string, gone bool) (conflictChoice, error) {
+	var prompt string
+	if gone {
+		fmt.Fprintf(os.Stderr, "edit: %s was removed from Drive while it was being edited.\n", rawPath)
+		prompt = fmt.Sprintf("[s]ave as '%s' or [d]iscard changes", copyName)
+	} else {
+		fmt.Fprintf(os.Stderr, "edit: %s was changed on Drive while it was being edited.\n", rawPath)
+		prompt = fmt.Sprintf("[o]verwrite it, [s]ave as '%s', or [d]iscard changes", copyName)
+	}
+	for {
+		answer, err := editPromptFn(prompt, false)
+		if err != nil {
+			return 0, err
+		}
+		if choice, ok := parseConflictChoice(answer, gone); ok {
+			return choice, nil
+		}
+	}
+}
+
+// shredDir overwrites every regular file below dir with zeros, syncs
+// it, and removes the directory. Editors may leave swap or backup
+// files next to the one being edited; they are shredded too.
+func shredDir(dir string) error {
+	var errs []error
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if err := zeroFile(p); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return nil
+	})
+	errs = append(errs, err, os.RemoveAll(dir))
+	return errors.Join(errs...)
+}
+
+// zeroFile overwrites the content of the file at p with zeros and
+// flushes it to storage.
+func zeroFile(p string) error {
+	f, err := os.OpenFile(p, os.O_WRONLY, 0) //nolint:gosec // p is inside our private temp dir
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		_, err = io.CopyN(f, zeroReader{}, info.Size())
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	return errors.Join(err, f.Close())
+}
+
+// zeroReader is an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
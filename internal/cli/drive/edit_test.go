@@ -0,0 +1,167 @@
+package driveCmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEditorArgv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"default", nil, []string{"vi", "/f"}},
+		{"editor", map[string]string{"EDITOR": "nano"}, []string{"nano", "/f"}},
+		{"visual wins", map[string]string{"VISUAL": "emacs", "EDITOR": "nano"}, []string{"emacs", "/f"}},
+		{"blank visual", map[string]string{"VISUAL": "  ", "EDITOR": "nano"}, []string{"nano", "/f"}},
+		{"arguments", map[string]string{"EDITOR": "code --wait"}, []string{"code", "--wait", "/f"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := editorArgv(func(k string) string { return tt.env[k] }, "/f")
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("editorArgv = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditTempDir(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := editTempDir(); got != "/run/user/1000" {
+		t.Errorf("editTempDir = %q, want $XDG_RUNTIME_DIR", got)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	if got := editTempDir(); got != os.TempDir() {
+		t.Errorf("editTempDir = %q, want %q", got, os.TempDir())
+	}
+}
+
+func TestParseConflictChoice(t *testing.T) {
+	tests := []struct {
+		in     string
+		gone   bool
+		want   conflictChoice
+		wantOK bool
+	}{
+		{"o", false, conflictOverwrite, true},
+		{" Overwrite\n", false, conflictOverwrite, true},
+		{"o", true, 0, false},
+		{"s", false, conflictSaveCopy, true},
+		{"save", true, conflictSaveCopy, true},
+		{"D", false, conflictDiscard, true},
+		{"", false, 0, false},
+		{"x", false, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseConflictChoice(tt.in, tt.gone)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseConflictChoice(%q, %v) = %v, %v; want %v, %v", tt.in, tt.gone, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPromptEditConflict_RetriesUntilValid(t *testing.T) {
+	answers := []string{"?", "o", "s"}
+	orig := editPromptFn
+	defer func() { editPromptFn = orig }()
+	editPromptFn = func(string, bool) (string, error) {
+		a := answers[0]
+		answers = answers[1:]
+		return a, nil
+	}
+
+	// "o" is not accepted once the remote file is gone.
+	got, err := promptEditConflict("a.txt", "a.conflict.txt", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != conflictSaveCopy || len(answers) != 0 {
+		t.Errorf("got %v with %d answers left, want save after 3 prompts", got, len(answers))
+	}
+}
+
+func TestPromptEditConflict_Error(t *testing.T) {
+	orig := editPromptFn
+	defer func() { editPromptFn = orig }()
+	want := errors.New("EOF")
+	editPromptFn = func(string, bool) (string, error) { return "", want }
+
+	if _, err := promptEditConflict("a.txt", "a.conflict.txt", false); !errors.Is(err, want) {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+}
+
+func TestShredDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "edit")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(f, []byte("top secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", ".secret.txt.swp"), []byte("swap"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Keep a second link to observe the overwrite after removal.
+	keep := filepath.Join(t.TempDir(), "keep")
+	if err := os.Link(f, keep); err != nil {
+		t.Skipf("hard links unsupported: %v", err)
+	}
+
+	if err := shredDir(dir); err != nil {
+		t.Fatalf("shredDir: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("directory still exists: %v", err)
+	}
+	data, err := os.ReadFile(keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := make([]byte, len("top secret")); string(data) != string(want) {
+		t.Errorf("content = %q, want zeros", data)
+	}
+}
+
+func TestKeepEdit(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "proton-edit-1")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(f, []byte("edited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cause := errors.New("upload failed")
+	err := keepEdit(f, cause)
+	if !errors.Is(err, cause) {
+		t.Fatalf("err = %v, want it to wrap %v", err, cause)
+	}
+	kept, _ := filepath.Glob(filepath.Join(base, "proton-edit-kept-*", "notes.txt"))
+	if len(kept) != 1 {
+		t.Fatalf("kept copies = %v, want one", kept)
+	}
+	if !strings.Contains(err.Error(), kept[0]) {
+		t.Errorf("err = %q, want it to name %s", err, kept[0])
+	}
+	data, rerr := os.ReadFile(kept[0])
+	if rerr != nil || string(data) != "edited" {
+		t.Errorf("kept content = %q, %v; want %q", data, rerr, "edited")
+	}
+	// The copy must survive shredding the edit session's directory.
+	if err := shredDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(kept[0]); err != nil {
+		t.Errorf("kept copy lost: %v", err)
+	}
+}
@@ -0,0 +1,377 @@
+package driveCmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	api "github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+var exportFlags struct {
+	output   string // -o, --output (archive path or "-" for stdout)
+	format   string // --format (tar, tgz, zip; default from extension)
+	prefetch int    // --prefetch (blocks in flight across all files)
+	verbose  bool   // -v, --verbose
+}
+
+var driveExportCmd = &cobra.Command{
+	Use:   "export [options] <path> -o <archive|->",
+	Short: "Export a file or directory tree as a tar or zip archive",
+	Long: `Export a Proton Drive file or directory tree as a tar, tar.gz, or zip
+archive. Files are decrypted and streamed straight into the archive —
+nothing is staged on the local filesystem.
+
+The archive format is taken from the output extension (.tar, .tar.gz,
+.tgz, .zip) unless --format is given. Use "-o -" to write a tar stream
+to stdout.
+
+Blocks are fetched ahead of the archive writer in parallel. At most
+--prefetch blocks (4 MiB each) are held in memory at any time.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+func init() {
+	driveCmd.AddCommand(driveExportCmd)
+	f := driveExportCmd.Flags()
+	f.StringVarP(&exportFlags.output, "output", "o", "", "Archive file to write, or - for stdout")
+	f.StringVar(&exportFlags.format, "format", "", "Archive format: tar, tgz, zip (default: from extension)")
+	f.IntVar(&exportFlags.prefetch, "prefetch", 0, "Maximum blocks fetched ahead (default: --max-jobs)")
+	cli.BoolFlagP(f, &exportFlags.verbose, "verbose", "v", false, "Print each archived path to stderr")
+}
+
+// exportOptions carries the resolved export flags to the walker and
+// archive writer.
+type exportOptions struct {
+	format  archiveFormat
+	window  int // prefetch slots (blocks held in memory)
+	verbose bool
+}
+
+// exportEntry is a single archive member queued between the tree
+// walker and the archive writer. For files, blocks holds one channel
+// per block; the prefetcher fills them in order.
+type exportEntry struct {
+	name   string // archive-relative path
+	isDir  bool
+	mode   os.FileMode
+	mtime  time.Time
+	size   int64
+	blocks []chan exportBlock
+}
+
+// exportBlock is the result of fetching and decrypting one block.
+type exportBlock struct {
+	data []byte
+	err  error
+}
+
+// blockPrefetcher bounds the number of decrypted blocks held in memory.
+// A slot is taken before a block fetch starts and returned by the
+// consumer once the block is written to the archive. Slots are taken
+// in archive order, so the oldest unconsumed block always holds a slot
+// and the consumer can never starve.
+type blockPrefetcher struct {
+	slots chan struct{}
+}
+
+func newBlockPrefetcher(n int) *blockPrefetcher {
+	if n < 1 {
+		n = 1
+	}
+	return &blockPrefetcher{slots: make(chan struct{}, n)}
+}
+
+// fetch starts a fetch for every block of r, delivering each result on
+// the matching channel in out. Blocks until all fetches are started or
+// ctx is cancelled.
+func (p *blockPrefetcher) fetch(ctx context.Context, r drive.BlockReader, out []chan exportBlock) error {
+	for i := range out {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		go func(i int) {
+			buf := make([]byte, r.BlockSize(i))
+			n, err := r.ReadBlock(ctx, i, buf)
+			out[i] <- exportBlock{data: buf[:n], err: err}
+		}(i)
+	}
+	return nil
+}
+
+// release returns a slot taken by fetch.
+func (p *blockPrefetcher) release() { <-p.slots }
+
+func runExport(cmd *cobra.Command, args []string) error {
+	if exportFlags.output == "" {
+		return fmt.Errorf("export: missing -o <archive|->")
+	}
+	format, err := parseArchiveFormat(exportFlags.format, exportFlags.output)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session, err := cli.SetupSession(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	root, _, err := ResolveProtonPath(ctx, dc, args[0])
+	if err != nil {
+		return fmt.Errorf("export: %s: %w", args[0], err)
+	}
+	rootName, err := root.Name()
+	if err != nil {
+		return fmt.Errorf("export: %s: %w", args[0], err)
+	}
+	rootPath := rootName
+	if root.IsDir() {
+		rootPath += "/"
+	}
+
+	opts := exportOptions{
+		format:  format,
+		window:  exportFlags.prefetch,
+		verbose: exportFlags.verbose,
+	}
+	if opts.window <= 0 {
+		opts.window = api.DefaultMaxWorkers()
+		if dc.Session.Sem != nil {
+			opts.window = dc.Session.Sem.Limit()
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	var outFile *os.File
+	if exportFlags.output != "-" {
+		outFile, err = os.Create(exportFlags.output)
+		if err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		out = outFile
+	}
+
+	err = writeExport(ctx, dc, root, rootPath, out, opts)
+	if outFile != nil {
+		if closeErr := outFile.Close(); err == nil {
+			err = closeErr
+		}
+		// An incomplete archive is kept: it holds everything that could
+		// be read.
+		if err != nil && !errors.Is(err, errExportIncomplete) {
+			_ = os.Remove(exportFlags.output)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	return nil
+}
+
+// errExportIncomplete indicates that the archive was written, but some
+// entries of the tree could not be read and are missing from it.
+var errExportIncomplete = errors.New("archive is incomplete")
+
+// writeExport walks root and streams every entry into an archive
+// written to out. The walker and prefetcher run in a separate goroutine
+// so block fetches overlap with archive writes. Folders the walk fails
+// to read and files that cannot be opened are skipped; the archive is
+// completed and errExportIncomplete returned.
+func writeExport(ctx context.Context, dc *drive.Client, root *drive.Link, rootPath string, out io.Writer, opts exportOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pf := newBlockPrefetcher(opts.window)
+	entries := make(chan *exportEntry, opts.window)
+
+	var produceErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(entries)
+		produceErr = produceExport(ctx, dc, root, rootPath, pf, entries)
+	}()
+
+	bw := bufio.NewWriterSize(out, 256*1024)
+	aw := newArchiveWriter(bw, opts.format)
+	consumeErr := consumeExport(ctx, aw, pf, entries, opts)
+	if consumeErr != nil {
+		// Unblock the producer, then drain anything it already queued.
+		cancel()
+		for range entries {
+		}
+	}
+	wg.Wait()
+
+	if consumeErr != nil {
+		return consumeErr
+	}
+	if produceErr != nil && !errors.Is(produceErr, errExportIncomplete) {
+		return produceErr
+	}
+	if err := aw.Close(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return produceErr
+}
+
+// produceExport converts TreeWalk entries into exportEntries. Files are
+// opened and their blocks scheduled on the prefetcher after the entry
+// is queued, so the consumer can begin writing while later blocks are
+// still in flight. Walk errors and files that cannot be opened are
+// reported as they happen and skipped; if there were any, it returns
+// errExportIncomplete once the walk is done.
+func produceExport(ctx context.Context, dc *drive.Client, root *drive.Link, rootPath string, pf *blockPrefetcher, entries chan<- *exportEntry) error {
+	results := make(chan drive.WalkEntry, 64)
+	var walkErr error
+	go func() {
+		defer close(results)
+		walkErr = dc.TreeWalk(ctx, root, rootPath, drive.BreadthFirst, -1, results)
+	}()
+	// Drain the walker on early return so its goroutine can exit.
+	defer func() {
+		for range results {
+		}
+	}()
+
+	store := dc.InternalBlockStore()
+	// Entries that cannot be read are reported and left out; the rest
+	// of the tree is still exported.
+	var failed int
+	var firstErr error
+	skip := func(err error) {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		if failed++; firstErr == nil {
+			firstErr = err
+		}
+	}
+	for we := range results {
+		if we.Err != nil {
+			skip(we.Err)
+			continue
+		}
+		state := we.Link.State()
+		if state == proton.LinkStateTrashed || state == proton.LinkStateDeleted {
+			continue
+		}
+
+		e := &exportEntry{
+			name:  we.Path,
+			isDir: we.Link.IsDir(),
+			mtime: time.Unix(we.Link.ModifyTime(), 0),
+		}
+
+		if e.isDir {
+			e.mode = defaultDirMode
+			select {
+			case entries <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+
+		e.mode = defaultFileMode
+		if m := we.Link.Mode(); m != 0 {
+			e.mode = os.FileMode(m).Perm()
+		}
+
+		fh, err := dc.OpenFile(ctx, we.Link)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			skip(fmt.Errorf("%s: %w", strings.TrimSuffix(we.Path, "/"), err))
+			continue
+		}
+		if !fh.ModTime.IsZero() {
+			e.mtime = fh.ModTime
+		}
+		r := drive.NewProtonReader(fh.LinkID, fh.Blocks, fh.SessionKey, fh.FileSize, nil, store)
+		e.size = fh.FileSize
+		e.blocks = make([]chan exportBlock, r.BlockCount())
+		for i := range e.blocks {
+			e.blocks[i] = make(chan exportBlock, 1)
+		}
+
+		select {
+		case entries <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := pf.fetch(ctx, r, e.blocks); err != nil {
+			return err
+		}
+	}
+
+	if walkErr != nil {
+		return walkErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d entries could not be read, the first: %w", errExportIncomplete, failed, firstErr)
+	}
+	return nil
+}
+
+// consumeExport writes queued entries to the archive in order, waiting
+// on each file block and releasing its prefetch slot once written.
+func consumeExport(ctx context.Context, aw archiveWriter, pf *blockPrefetcher, entries <-chan *exportEntry, opts exportOptions) error {
+	for e := range entries {
+		if opts.verbose {
+			fmt.Fprintln(os.Stderr, e.name)
+		}
+
+		if e.isDir {
+			if err := aw.AddDir(e.name, e.mode, e.mtime); err != nil {
+				return fmt.Errorf("%s: %w", e.name, err)
+			}
+			continue
+		}
+
+		w, err := aw.CreateFile(e.name, e.mode, e.mtime, e.size)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.name, err)
+		}
+		for i, ch := range e.blocks {
+			var b exportBlock
+			select {
+			case b = <-ch:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			pf.release()
+			if b.err != nil {
+				return fmt.Errorf("%s: block %d: %w", e.name, i, b.err)
+			}
+			if _, err := w.Write(b.data); err != nil {
+				return fmt.Errorf("%s: %w", e.name, err)
+			}
+		}
+	}
+	return nil
+}
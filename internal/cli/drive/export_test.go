@@ -0,0 +1,176 @@
+package driveCmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/major0/proton-utils/api/drive"
+)
+
+func TestParseArchiveFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		output  string
+		want    archiveFormat
+		wantErr bool
+	}{
+		{"stdout defaults to tar", "", "-", formatTar, false},
+		{"tar extension", "", "out.tar", formatTar, false},
+		{"tar.gz extension", "", "out.tar.gz", formatTarGz, false},
+		{"tgz extension", "", "OUT.TGZ", formatTarGz, false},
+		{"zip extension", "", "out.zip", formatZip, false},
+		{"explicit overrides extension", "zip", "out.tar", formatZip, false},
+		{"explicit tgz to stdout", "tgz", "-", formatTarGz, false},
+		{"unknown extension", "", "out.rar", 0, true},
+		{"unknown format", "rar", "out.tar", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArchiveFormat(tt.format, tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("format = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// exportFixture writes a multi-block local file and returns its
+// content along with a LocalReader over it.
+func exportFixture(t *testing.T) ([]byte, drive.BlockReader) {
+	t.Helper()
+	data := make([]byte, 2*drive.BlockSize+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	p := filepath.Join(t.TempDir(), "src.bin")
+	if err := os.WriteFile(p, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := drive.NewLocalReader(p, int64(len(data)))
+	t.Cleanup(func() { _ = r.Close() })
+	return data, r
+}
+
+// runExportFixture archives one directory and the fixture file through
+// the prefetcher and returns the file content and the archive bytes.
+func runExportFixture(t *testing.T, format archiveFormat, window int) ([]byte, []byte) {
+	t.Helper()
+	ctx := context.Background()
+	data, r := exportFixture(t)
+	mtime := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	pf := newBlockPrefetcher(window)
+	entries := make(chan *exportEntry, 1)
+	go func() {
+		defer close(entries)
+		entries <- &exportEntry{name: "proj/", isDir: true, mode: defaultDirMode, mtime: mtime}
+		file := &exportEntry{name: "proj/data.bin", mode: 0o600, mtime: mtime, size: r.TotalSize()}
+		file.blocks = make([]chan exportBlock, r.BlockCount())
+		for i := range file.blocks {
+			file.blocks[i] = make(chan exportBlock, 1)
+		}
+		entries <- file
+		_ = pf.fetch(ctx, r, file.blocks)
+	}()
+
+	var buf bytes.Buffer
+	aw := newArchiveWriter(&buf, format)
+	if err := consumeExport(ctx, aw, pf, entries, exportOptions{format: format, window: window}); err != nil {
+		t.Fatalf("consumeExport: %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return data, buf.Bytes()
+}
+
+func TestExportTar(t *testing.T) {
+	for _, format := range []archiveFormat{formatTar, formatTarGz} {
+		// A single prefetch slot must still make progress.
+		data, archive := runExportFixture(t, format, 1)
+
+		var rd io.Reader = bytes.NewReader(archive)
+		if format == formatTarGz {
+			gz, err := gzip.NewReader(rd)
+			if err != nil {
+				t.Fatalf("gzip: %v", err)
+			}
+			rd = gz
+		}
+		tr := tar.NewReader(rd)
+
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeDir || hdr.Name != "proj/" || hdr.Mode != defaultDirMode {
+			t.Errorf("dir header = %q type %c mode %o", hdr.Name, hdr.Typeflag, hdr.Mode)
+		}
+
+		hdr, err = tr.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if hdr.Name != "proj/data.bin" || hdr.Mode != 0o600 || hdr.Size != int64(len(data)) {
+			t.Errorf("file header = %q mode %o size %d", hdr.Name, hdr.Mode, hdr.Size)
+		}
+		got, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Error("file content mismatch")
+		}
+		if _, err := tr.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("expected EOF after two entries, got %v", err)
+		}
+	}
+}
+
+func TestExportZip(t *testing.T) {
+	data, archive := runExportFixture(t, formatZip, 4)
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Fatalf("got %d entries, want 2", len(zr.File))
+	}
+
+	dir := zr.File[0]
+	if dir.Name != "proj/" || !dir.Mode().IsDir() {
+		t.Errorf("dir entry = %q mode %v", dir.Name, dir.Mode())
+	}
+
+	f := zr.File[1]
+	if f.Name != "proj/data.bin" || f.Mode().Perm() != 0o600 {
+		t.Errorf("file entry = %q mode %v", f.Name, f.Mode())
+	}
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("file content mismatch")
+	}
+}
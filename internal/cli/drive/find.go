@@ -3,6 +3,7 @@ package driveCmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"path"
 	"strings"
 	"time"
@@ -183,6 +184,12 @@ func runFind(cmd *cobra.Command, args []string) error {
 
 	preds := buildPredicates()
 
+	// With --output, each match is written as a record instead.
+	var rw *cli.RecordWriter
+	if cli.Structured(cmd) {
+		rw = cli.NewRecordWriter(os.Stdout, cli.Output(cmd))
+	}
+
 	for i, root := range roots {
 		results := make(chan drive.WalkEntry, 64)
 		var walkErr error
@@ -207,9 +214,16 @@ func runFind(cmd *cobra.Command, args []string) error {
 				continue
 			}
 
-			if matchAll(preds, entry.Path, entry.Link, entry.Depth, entry.EntryName) {
-				fmt.Print(entry.Path + sep)
+			if !matchAll(preds, entry.Path, entry.Link, entry.Depth, entry.EntryName) {
+				continue
 			}
+			if rw != nil {
+				if err := rw.Write(newLinkRecord(entry.Path, entry.EntryName, entry.Link)); err != nil {
+					return err
+				}
+				continue
+			}
+			fmt.Print(entry.Path + sep)
 		}
 
 		if walkErr != nil {
@@ -217,5 +231,8 @@ func runFind(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if rw != nil {
+		return rw.Close()
+	}
 	return nil
 }
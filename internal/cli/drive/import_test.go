@@ -0,0 +1,168 @@
+package driveCmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/drive"
+)
+
+func TestCleanArchiveName(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"dir/", "dir", true},
+		{"./dir/file.txt", "dir/file.txt", true},
+		{"/abs/file.txt", "abs/file.txt", true},
+		{"a//b/./c", "a/b/c", true},
+		{"./", "", false},
+		{".", "", false},
+		{"", "", false},
+		{"../escape", "", false},
+		{"a/../../escape", "", false},
+		{"a/..", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := cleanArchiveName(tt.in)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("cleanArchiveName(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// writeTestArchive builds an archive with one directory and one file
+// using the export writers and returns the file path.
+func writeTestArchive(t *testing.T, format archiveFormat, data []byte, mtime time.Time) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "archive")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aw := newArchiveWriter(f, format)
+	if err := aw.AddDir("proj/", defaultDirMode, mtime); err != nil {
+		t.Fatal(err)
+	}
+	w, err := aw.CreateFile("proj/run.sh", 0o750, mtime, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestOpenArchiveReader(t *testing.T) {
+	data := []byte("#!/bin/sh\necho hello\n")
+	mtime := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []archiveFormat{formatTar, formatTarGz, formatZip} {
+		p := writeTestArchive(t, format, data, mtime)
+		f, err := os.Open(p) //nolint:gosec // test temp file
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ar, err := openArchiveReader(f)
+		if err != nil {
+			t.Fatalf("format %d: openArchiveReader: %v", format, err)
+		}
+
+		m, _, err := ar.Next()
+		if err != nil {
+			t.Fatalf("format %d: Next: %v", format, err)
+		}
+		if !m.isDir || m.isReg || m.name != "proj/" {
+			t.Errorf("format %d: dir member = %+v", format, m)
+		}
+
+		m, r, err := ar.Next()
+		if err != nil {
+			t.Fatalf("format %d: Next: %v", format, err)
+		}
+		if m.isDir || !m.isReg || m.name != "proj/run.sh" || m.mode != 0o750 {
+			t.Errorf("format %d: file member = %+v", format, m)
+		}
+		if !m.mtime.Equal(mtime) {
+			t.Errorf("format %d: mtime = %v, want %v", format, m.mtime, mtime)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("format %d: ReadAll: %v", format, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("format %d: content = %q, want %q", format, got, data)
+		}
+
+		if _, _, err := ar.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("format %d: expected EOF, got %v", format, err)
+		}
+		_ = ar.Close()
+		_ = f.Close()
+	}
+}
+
+// memBlockWriter collects uploaded blocks in memory.
+type memBlockWriter struct {
+	mu     sync.Mutex
+	blocks map[int][]byte
+	closed bool
+}
+
+func (w *memBlockWriter) WriteBlock(_ context.Context, index int, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.blocks[index] = append([]byte(nil), data...)
+	return nil
+}
+
+func (w *memBlockWriter) Describe() string { return "mem" }
+
+func (w *memBlockWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestUploadStream(t *testing.T) {
+	data := make([]byte, 2*drive.BlockSize+77)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+
+	w := &memBlockWriter{blocks: make(map[int][]byte)}
+	sem := api.NewSemaphore(context.Background(), 2, nil)
+	if err := uploadStream(sem, w, bytes.NewReader(data)); err != nil {
+		t.Fatalf("uploadStream: %v", err)
+	}
+	if !w.closed {
+		t.Error("writer not closed after successful upload")
+	}
+	if len(w.blocks) != drive.BlockCount(int64(len(data))) {
+		t.Fatalf("got %d blocks, want %d", len(w.blocks), drive.BlockCount(int64(len(data))))
+	}
+
+	var got []byte
+	for i := 0; i < len(w.blocks); i++ {
+		got = append(got, w.blocks[i]...)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("content mismatch: got %d bytes, want %d", len(got), len(data))
+	}
+}
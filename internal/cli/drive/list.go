@@ -381,6 +381,12 @@ func formatSize(size int64, opts listOpts) string {
 // applied: 0700 for directories, 0600 for files (matching FUSE defaults).
 // The returned string omits the leading type character (e.g. "rwx------").
 func formatMode(link *drive.Link) string {
+	return os.FileMode(displayMode(link)).String()[1:] // strip the leading type char
+}
+
+// displayMode returns the permission bits of a link, with the defaults
+// described at formatMode when none are stored.
+func displayMode(link *drive.Link) uint32 {
 	mode := link.Mode()
 	if mode == 0 {
 		if link.IsDir() {
@@ -389,7 +395,7 @@ func formatMode(link *drive.Link) string {
 			mode = 0600
 		}
 	}
-	return os.FileMode(mode).String()[1:] // strip the leading type char
+	return mode
 }
 
 func formatTimestamp(epoch int64, style timeStyle) string {
@@ -590,6 +596,37 @@ func listRecursive(ctx context.Context, prefix string, entries []listEntry, opts
 	return nil
 }
 
+// writeEntryRecords writes the --output record of each entry, then with
+// -R the records of each folder's contents, paths relative to the
+// listed argument.
+func writeEntryRecords(ctx context.Context, rw *cli.RecordWriter, prefix string, entries []listEntry, opts listOpts) error {
+	for _, e := range entries {
+		if err := rw.Write(newLinkRecord(prefix+e.name, e.name, e.entry.Link)); err != nil {
+			return err
+		}
+	}
+	if !opts.recursive {
+		return nil
+	}
+	for _, e := range entries {
+		l := e.entry.Link
+		if l.Type() != proton.LinkTypeFolder || e.name == "." || e.name == ".." {
+			continue
+		}
+		path := prefix + e.name + "/"
+		children, err := collectEntries(ctx, l, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		children = filterEntries(children, opts)
+		sortEntries(children, opts)
+		if err := writeEntryRecords(ctx, rw, path, children, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func runList(cmd *cobra.Command, args []string) error {
 	opts, err := resolveOpts()
 	if err != nil {
@@ -619,6 +656,14 @@ func runList(cmd *cobra.Command, args []string) error {
 	entries = filterEntries(entries, opts)
 	sortEntries(entries, opts)
 
+	if cli.Structured(cmd) {
+		rw := cli.NewRecordWriter(os.Stdout, cli.Output(cmd))
+		if err := writeEntryRecords(ctx, rw, "", entries, opts); err != nil {
+			return err
+		}
+		return rw.Close()
+	}
+
 	// Compute short IDs for inode display when not verbose.
 	if opts.inode && rc.Verbose < 1 {
 		ids := make([]string, len(entries))
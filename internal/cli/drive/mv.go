@@ -19,9 +19,14 @@ var driveMvCmd = &cobra.Command{
 	Use:     "mv [options] <source> [<source> ...] <dest>",
 	Aliases: []string{"rename"},
 	Short:   "Move or rename files and directories in Proton Drive",
-	Long:    "Move or rename files and directories in Proton Drive",
-	Args:    cobra.MinimumNArgs(2),
-	RunE:    runMv,
+	Long: `Move or rename files and directories in Proton Drive.
+
+Moves between volumes (for example into a share owned by another user)
+are performed as a copy re-encrypted under the destination's keys. Each
+file is read back and verified before its source is moved to the trash;
+files that fail are left in place and reported.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runMv,
 }
 
 func init() {
@@ -112,10 +117,11 @@ func mvMultiple(ctx context.Context, dc *drive.Client, srcPaths []string, destPa
 	return nil
 }
 
-func doMove(ctx context.Context, dc *drive.Client, srcShare *drive.Share, src *drive.Link, _ *drive.Share, destParent *drive.Link, newName string) error {
-	// Currently only support moves within the same volume.
+// doMove moves src to newName under destParent. Links can only be
+// relinked within a volume; anything else falls back to crossMove.
+func doMove(ctx context.Context, dc *drive.Client, srcShare *drive.Share, src *drive.Link, destShare *drive.Share, destParent *drive.Link, newName string) error {
 	if !drive.SameDevice(src, destParent) {
-		return fmt.Errorf("mv: cross-volume moves not supported (source and destination are on different volumes)")
+		return crossMove(ctx, dc, srcShare, src, destShare, destParent, newName)
 	}
 
 	if err := dc.Move(ctx, srcShare, src, destParent, newName); err != nil {
@@ -0,0 +1,269 @@
+package driveCmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// Steps of a cross-volume move that reach the Drive API. Replaced in
+// tests.
+var (
+	mvCopyFileFn = (*crossMover).copyFile
+	mvVerifyFn   = (*crossMover).verify
+	mvMkDirFn    = func(ctx context.Context, dc *drive.Client, share *drive.Share, parent *drive.Link, name string) (*drive.Link, error) {
+		return dc.MkDir(ctx, share, parent, name)
+	}
+	mvRemoveFn = func(ctx context.Context, dc *drive.Client, share *drive.Share, link *drive.Link, opts drive.RemoveOpts) error {
+		return dc.Remove(ctx, share, link, opts)
+	}
+)
+
+// crossMover moves a tree between volumes by copying each file under
+// the destination's keys and trashing the source only once the copy
+// has been read back and verified. It tallies outcomes so a partial
+// failure can be reported without aborting the remaining entries.
+type crossMover struct {
+	dc        *drive.Client
+	srcShare  *drive.Share
+	destShare *drive.Share
+	moved     int
+	failed    int
+}
+
+// crossMove moves src to newName under destParent when the two are on
+// different volumes. Drive cannot relink across volumes, so every file
+// is re-encrypted by uploading it as a new file. Each file is atomic:
+// either the verified copy exists and the source is trashed, or the
+// copy is deleted and the source is left untouched.
+func crossMove(ctx context.Context, dc *drive.Client, srcShare *drive.Share, src *drive.Link, destShare *drive.Share, destParent *drive.Link, newName string) error {
+	m := &crossMover{dc: dc, srcShare: srcShare, destShare: destShare}
+	srcName, _ := src.Name()
+	m.move(ctx, src, destParent, newName, srcName)
+	return m.result()
+}
+
+// result summarizes the run. Individual failures have already been
+// printed as they happened.
+func (m *crossMover) result() error {
+	if m.failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("mv: %d of %d entries could not be moved; their sources were kept", m.failed, m.moved+m.failed)
+}
+
+// fail records and reports a failure for the entry shown as display.
+func (m *crossMover) fail(display string, err error) {
+	m.failed++
+	fmt.Fprintf(os.Stderr, "mv: %s: %v\n", display, err)
+}
+
+// move moves a single entry and reports whether its source is gone.
+func (m *crossMover) move(ctx context.Context, src, destParent *drive.Link, name, display string) bool {
+	if src.Type() == proton.LinkTypeFolder {
+		return m.moveDir(ctx, src, destParent, name, display)
+	}
+
+	if err := m.moveFile(ctx, src, destParent, name); err != nil {
+		m.fail(display, err)
+		return false
+	}
+	m.moved++
+	if mvFlags.verbose {
+		destParentName, _ := destParent.Name()
+		fmt.Printf("'%s' -> '%s/%s'\n", display, destParentName, name)
+	}
+	return true
+}
+
+// moveDir recreates src under destParent, or reuses the folder of that
+// name already there, and moves its children into it. The source folder
+// is trashed only when every child moved, so a partial failure leaves
+// the unmoved entries where they were.
+func (m *crossMover) moveDir(ctx context.Context, src, destParent *drive.Link, name, display string) bool {
+	dir, err := m.destDir(ctx, destParent, name)
+	if err != nil {
+		m.fail(display, err)
+		return false
+	}
+
+	children, err := src.ListChildren(ctx, false)
+	if err != nil {
+		m.fail(display, err)
+		return false
+	}
+
+	ok := true
+	for _, child := range children {
+		if !child.IsActive() {
+			continue
+		}
+		childName, err := child.Name()
+		if err != nil {
+			m.fail(path.Join(display, child.LinkID()), err)
+			ok = false
+			continue
+		}
+		if !m.move(ctx, child, dir, childName, path.Join(display, childName)) {
+			ok = false
+		}
+	}
+	if !ok {
+		return false
+	}
+
+	if err := mvRemoveFn(ctx, m.dc, m.srcShare, src, drive.RemoveOpts{Recursive: true}); err != nil {
+		m.fail(display, fmt.Errorf("copied, but trashing the source failed: %w", err))
+		return false
+	}
+	m.moved++
+	if mvFlags.verbose {
+		destParentName, _ := destParent.Name()
+		fmt.Printf("'%s' -> '%s/%s'\n", display, destParentName, name)
+	}
+	return true
+}
+
+// destDir returns the folder name under destParent, creating it if it
+// does not exist.
+func (m *crossMover) destDir(ctx context.Context, destParent *drive.Link, name string) (*drive.Link, error) {
+	dir, err := destParent.Lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if dir == nil {
+		return mvMkDirFn(ctx, m.dc, m.destShare, destParent, name)
+	}
+	if dir.Type() != proton.LinkTypeFolder {
+		return nil, fmt.Errorf("%s: %w", name, drive.ErrNotAFolder)
+	}
+	return dir, nil
+}
+
+// moveFile copies one file and trashes the source once the copy is
+// verified. On any failure before the trash step the copy is deleted.
+func (m *crossMover) moveFile(ctx context.Context, src, destParent *drive.Link, name string) error {
+	dst, sum, size, err := mvCopyFileFn(m, ctx, src, destParent, name)
+	if err != nil {
+		if dst != nil {
+			m.discard(ctx, dst)
+		}
+		return err
+	}
+
+	if err := mvVerifyFn(m, ctx, destParent, dst, name, sum, size); err != nil {
+		m.discard(ctx, dst)
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	if err := mvRemoveFn(ctx, m.dc, m.srcShare, src, drive.RemoveOpts{}); err != nil {
+		return fmt.Errorf("copied, but trashing the source failed: %w", err)
+	}
+	return nil
+}
+
+// copyFile uploads src as name under destParent, carrying over the
+// mode, modification time, extended attributes and symlink target. It
+// returns the new link with the SHA-256 and length of the bytes
+// written. The link is returned on a failed upload so the caller can
+// delete the draft.
+func (m *crossMover) copyFile(ctx context.Context, src, destParent *drive.Link, name string) (*drive.Link, []byte, int64, error) {
+	m.dc.FetchRevisionXAttr(ctx, src)
+
+	if src.IsSymlink() {
+		target, err := m.dc.Readlink(ctx, src)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		dst, err := m.dc.Symlink(ctx, m.destShare, destParent, name, target)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		sum := sha256.Sum256([]byte(target))
+		return dst, sum[:], int64(len(target)), nil
+	}
+
+	in, err := m.dc.OpenFD(ctx, src)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := m.dc.CreateFD(ctx, m.destShare, destParent, name)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	out.SetMode(src.Mode())
+	out.SetModTime(in.ModTime())
+	out.SetXAttrs(src.XAttrs())
+	out.SetCommitEmpty(true)
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), in)
+	if err != nil {
+		// Do not Close — that would commit the partial content.
+		return out.Link(), nil, 0, fmt.Errorf("copy: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return out.Link(), nil, 0, fmt.Errorf("commit: %w", err)
+	}
+	return out.Link(), h.Sum(nil), n, nil
+}
+
+// verify re-fetches the committed copy and compares its content with
+// what was uploaded. The blocks cached while uploading are dropped
+// first, so that the content is read back from the server.
+func (m *crossMover) verify(ctx context.Context, destParent, dst *drive.Link, name string, sum []byte, size int64) error {
+	fresh, err := destParent.Lookup(ctx, name)
+	if err != nil {
+		return err
+	}
+	if fresh == nil || fresh.LinkID() != dst.LinkID() {
+		return fmt.Errorf("%s: copy not found after commit", name)
+	}
+
+	m.dc.InvalidateBlocks(fresh)
+	fd, err := m.dc.OpenFD(ctx, fresh)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fd.Close() }()
+
+	h := sha256.New()
+	n, err := io.Copy(h, fd)
+	if err != nil {
+		return err
+	}
+	return checkCopy(sum, size, h.Sum(nil), n)
+}
+
+// discard permanently deletes a failed copy. Errors are reported but
+// do not change the outcome — the source is kept either way.
+func (m *crossMover) discard(ctx context.Context, dst *drive.Link) {
+	if err := mvRemoveFn(ctx, m.dc, m.destShare, dst, drive.RemoveOpts{Permanent: true}); err != nil {
+		name, _ := dst.Name()
+		fmt.Fprintf(os.Stderr, "mv: %s: removing incomplete copy: %v\n", name, err)
+	}
+}
+
+// errCopyMismatch indicates the read-back copy differs from the source.
+var errCopyMismatch = errors.New("copy does not match source")
+
+// checkCopy compares the written digest and length with those read back.
+func checkCopy(wantSum []byte, wantSize int64, gotSum []byte, gotSize int64) error {
+	if gotSize != wantSize {
+		return fmt.Errorf("%w: size %d, want %d", errCopyMismatch, gotSize, wantSize)
+	}
+	if !bytes.Equal(gotSum, wantSum) {
+		return fmt.Errorf("%w: checksum differs", errCopyMismatch)
+	}
+	return nil
+}
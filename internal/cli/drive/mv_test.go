@@ -2,7 +2,10 @@ package driveCmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -44,27 +47,50 @@ func TestMvVerboseFlag(t *testing.T) {
 	}
 }
 
-// TestDoMove_CrossVolume verifies that doMove returns an error when
-// source and destination are on different volumes.
-func TestDoMove_CrossVolume(t *testing.T) {
-	resolver := &testResolver{}
+// TestCheckCopy verifies the read-back comparison used before a
+// cross-volume move trashes its source.
+func TestCheckCopy(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello"))
+	other := sha256.Sum256([]byte("hellp"))
 
-	// Source on volume "vol-1".
-	srcShare := makeTestShareWithVolume(resolver, "src-share", "vol-1")
-	srcPLink := &proton.Link{LinkID: "src-link", Type: proton.LinkTypeFile}
-	srcLink := drive.NewTestLink(srcPLink, srcShare.Link, srcShare, resolver, "file.txt")
+	tests := []struct {
+		name    string
+		gotSum  []byte
+		gotSize int64
+		wantErr bool
+	}{
+		{"match", sum[:], 5, false},
+		{"short", sum[:], 4, true},
+		{"corrupt", other[:], 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCopy(sum[:], 5, tt.gotSum, tt.gotSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkCopy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errCopyMismatch) {
+				t.Errorf("checkCopy() error = %v, want errCopyMismatch", err)
+			}
+		})
+	}
+}
 
-	// Destination parent on volume "vol-2".
-	dstShare := makeTestShareWithVolume(resolver, "dst-share", "vol-2")
-	dstPLink := &proton.Link{LinkID: "dst-dir", Type: proton.LinkTypeFolder}
-	dstLink := drive.NewTestLink(dstPLink, dstShare.Link, dstShare, resolver, "dest")
+// TestCrossMoverResult verifies that partial failures are summarized
+// with the number of entries left behind.
+func TestCrossMoverResult(t *testing.T) {
+	m := &crossMover{moved: 3}
+	if err := m.result(); err != nil {
+		t.Fatalf("result() = %v, want nil", err)
+	}
 
-	err := doMove(context.Background(), nil, srcShare, srcLink, dstShare, dstLink, "file.txt")
+	m.failed = 2
+	err := m.result()
 	if err == nil {
-		t.Fatal("expected cross-volume error, got nil")
+		t.Fatal("result() = nil, want partial failure")
 	}
-	if !strings.Contains(err.Error(), "cross-volume") {
-		t.Fatalf("unexpected error: %v", err)
+	if !strings.Contains(err.Error(), "2 of 5") {
+		t.Errorf("result() = %v, want count of failed entries", err)
 	}
 }
 
@@ -104,3 +130,160 @@ func makeTestShareWithVolume(resolver drive.LinkResolver, name, volumeID string)
 	share.Link = rootLink
 	return share
 }
+
+// crossMoveFake replaces the Drive API steps of a cross-volume move
+// and records what the move asked of them.
+type crossMoveFake struct {
+	verifyErr error            // returned by verify
+	removeErr map[string]error // returned by remove, by LinkID
+	removed   []string         // LinkIDs removed, with " permanent" for deletions
+	made      []string         // names of folders created
+	copiedTo  []string         // LinkIDs of the folders files were copied into
+}
+
+// install swaps the fake in for the duration of t.
+func (f *crossMoveFake) install(t *testing.T) {
+	t.Helper()
+	copyFile, verify, mkDir, remove := mvCopyFileFn, mvVerifyFn, mvMkDirFn, mvRemoveFn
+	t.Cleanup(func() { mvCopyFileFn, mvVerifyFn, mvMkDirFn, mvRemoveFn = copyFile, verify, mkDir, remove })
+
+	mvCopyFileFn = func(_ *crossMover, _ context.Context, _, destParent *drive.Link, name string) (*drive.Link, []byte, int64, error) {
+		f.copiedTo = append(f.copiedTo, destParent.LinkID())
+		dst := drive.NewTestLink(&proton.Link{LinkID: "copy-" + name, Type: proton.LinkTypeFile}, destParent, destParent.Share(), nil, name)
+		sum := sha256.Sum256([]byte(name))
+		return dst, sum[:], int64(len(name)), nil
+	}
+	mvVerifyFn = func(_ *crossMover, _ context.Context, _, _ *drive.Link, _ string, _ []byte, _ int64) error {
+		return f.verifyErr
+	}
+	mvMkDirFn = func(_ context.Context, _ *drive.Client, share *drive.Share, parent *drive.Link, name string) (*drive.Link, error) {
+		f.made = append(f.made, name)
+		return drive.NewTestLink(&proton.Link{LinkID: "new-" + name, Type: proton.LinkTypeFolder}, parent, share, nil, name), nil
+	}
+	mvRemoveFn = func(_ context.Context, _ *drive.Client, _ *drive.Share, link *drive.Link, opts drive.RemoveOpts) error {
+		id := link.LinkID()
+		if opts.Permanent {
+			id += " permanent"
+		}
+		f.removed = append(f.removed, id)
+		return f.removeErr[link.LinkID()]
+	}
+}
+
+// crossMoveFixture returns a mover between two volumes, the source
+// file "a.txt", and the destination folder "dest" whose children are
+// those of resolver under "dest".
+func crossMoveFixture(resolver *testResolver) (*crossMover, *drive.Link, *drive.Link) {
+	srcShare := makeTestShareWithVolume(resolver, "src", "vol-1")
+	destShare := makeTestShareWithVolume(resolver, "dst", "vol-2")
+	src := drive.NewTestLink(&proton.Link{LinkID: "a.txt", Type: proton.LinkTypeFile, State: proton.LinkStateActive}, srcShare.Link, srcShare, resolver, "a.txt")
+	dest := drive.NewTestLink(&proton.Link{LinkID: "dest", Type: proton.LinkTypeFolder, State: proton.LinkStateActive}, destShare.Link, destShare, resolver, "dest")
+	return &crossMover{srcShare: srcShare, destShare: destShare}, src, dest
+}
+
+// TestCrossMove_VerifyMismatch verifies that a copy that does not read
+// back as written is deleted and its source kept.
+func TestCrossMove_VerifyMismatch(t *testing.T) {
+	f := &crossMoveFake{verifyErr: fmt.Errorf("%w: checksum differs", errCopyMismatch)}
+	f.install(t)
+	m, src, dest := crossMoveFixture(&testResolver{})
+
+	if m.move(context.Background(), src, dest, "a.txt", "a.txt") {
+		t.Fatal("move() = true, want false")
+	}
+	if want := []string{"copy-a.txt permanent"}; !slices.Equal(f.removed, want) {
+		t.Errorf("removed = %v, want %v (copy deleted, source kept)", f.removed, want)
+	}
+	if err := m.result(); err == nil || !strings.Contains(err.Error(), "sources were kept") {
+		t.Errorf("result() = %v, want the source kept", err)
+	}
+}
+
+// TestCrossMove_TrashFailure verifies that when the source cannot be
+// trashed after a verified copy, the error is reported and the copy is
+// kept, so that the content exists at least once.
+func TestCrossMove_TrashFailure(t *testing.T) {
+	f := &crossMoveFake{removeErr: map[string]error{"a.txt": errors.New("permission denied")}}
+	f.install(t)
+	m, src, dest := crossMoveFixture(&testResolver{})
+
+	err := m.moveFile(context.Background(), src, dest, "a.txt")
+	if err == nil || !strings.Contains(err.Error(), "trashing the source failed") {
+		t.Fatalf("moveFile() = %v, want the trash failure", err)
+	}
+	if want := []string{"a.txt"}; !slices.Equal(f.removed, want) {
+		t.Errorf("removed = %v, want %v (copy kept)", f.removed, want)
+	}
+
+	if m.move(context.Background(), src, dest, "a.txt", "a.txt") || m.failed != 1 {
+		t.Errorf("move() counted failed = %d, want 1", m.failed)
+	}
+}
+
+// TestCrossMove_DestDir verifies that a folder of the same name in the
+// destination is reused, that a file of that name is an error, and
+// that a missing folder is created.
+func TestCrossMove_DestDir(t *testing.T) {
+	resolver := &testResolver{children: map[string][]proton.Link{
+		"dest": {
+			{LinkID: "docs", Type: proton.LinkTypeFolder, State: proton.LinkStateActive},
+			{LinkID: "notes", Type: proton.LinkTypeFile, State: proton.LinkStateActive},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		wantID   string
+		wantErr  error
+		wantMade []string
+	}{
+		{"docs", "docs", nil, nil},
+		{"notes", "", drive.ErrNotAFolder, nil},
+		{"new", "new-new", nil, []string{"new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &crossMoveFake{}
+			f.install(t)
+			m, _, dest := crossMoveFixture(resolver)
+
+			dir, err := m.destDir(context.Background(), dest, tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("destDir() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && dir.LinkID() != tt.wantID {
+				t.Errorf("destDir() = %s, want %s", dir.LinkID(), tt.wantID)
+			}
+			if !slices.Equal(f.made, tt.wantMade) {
+				t.Errorf("created %v, want %v", f.made, tt.wantMade)
+			}
+		})
+	}
+}
+
+// TestCrossMove_MergesIntoExistingFolder verifies that moving a folder
+// onto one of the same name moves its children into the existing
+// folder and then trashes the source folder.
+func TestCrossMove_MergesIntoExistingFolder(t *testing.T) {
+	resolver := &testResolver{children: map[string][]proton.Link{
+		"dest":     {{LinkID: "docs", Type: proton.LinkTypeFolder, State: proton.LinkStateActive}},
+		"src-docs": {{LinkID: "b.txt", Type: proton.LinkTypeFile, State: proton.LinkStateActive}},
+	}}
+	f := &crossMoveFake{}
+	f.install(t)
+	m, _, dest := crossMoveFixture(resolver)
+	srcDir := drive.NewTestLink(&proton.Link{LinkID: "src-docs", Type: proton.LinkTypeFolder, State: proton.LinkStateActive}, m.srcShare.Link, m.srcShare, resolver, "docs")
+
+	if !m.move(context.Background(), srcDir, dest, "docs", "docs") {
+		t.Fatalf("move() = false, result %v", m.result())
+	}
+	if len(f.made) != 0 {
+		t.Errorf("created %v, want the existing folder reused", f.made)
+	}
+	if want := []string{"docs"}; !slices.Equal(f.copiedTo, want) {
+		t.Errorf("copied into %v, want %v", f.copiedTo, want)
+	}
+	if want := []string{"b.txt", "src-docs"}; !slices.Equal(f.removed, want) {
+		t.Errorf("removed = %v, want %v", f.removed, want)
+	}
+}
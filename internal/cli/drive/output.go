@@ -0,0 +1,72 @@
+package driveCmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+)
+
+// linkRecord is the --output schema of a file or folder, shared by
+// ls, find and stat. IDs are always in full; sizes are in bytes and
+// times in UTC.
+type linkRecord struct {
+	Path       string    `json:"path"`
+	Name       string    `json:"name"`
+	LinkID     string    `json:"link_id"`
+	Type       string    `json:"type"`  // file, folder or symlink
+	State      string    `json:"state"` // active, draft, trashed or deleted
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"` // octal permission bits
+	MIMEType   string    `json:"mime_type,omitempty"`
+	Target     string    `json:"target,omitempty"` // symlink target
+	CreateTime time.Time `json:"create_time"`
+	ModifyTime time.Time `json:"modify_time"`
+}
+
+// newLinkRecord builds the record of link, displayed as name at p.
+func newLinkRecord(p, name string, link *drive.Link) linkRecord {
+	return linkRecord{
+		Path:       p,
+		Name:       name,
+		LinkID:     link.LinkID(),
+		Type:       linkTypeName(link),
+		State:      linkStateName(link.State()),
+		Size:       link.Size(),
+		Mode:       fmt.Sprintf("%04o", displayMode(link)),
+		MIMEType:   link.MIMEType(),
+		Target:     link.SymlinkTarget(),
+		CreateTime: cli.EpochTime(link.CreateTime()),
+		ModifyTime: cli.EpochTime(link.ModifyTime()),
+	}
+}
+
+// linkTypeName returns the record type of a link.
+func linkTypeName(link *drive.Link) string {
+	switch {
+	case link.IsDir():
+		return "folder"
+	case link.IsSymlink():
+		return "symlink"
+	default:
+		return "file"
+	}
+}
+
+// linkStateName returns the record name of a link state.
+func linkStateName(state proton.LinkState) string {
+	switch state {
+	case proton.LinkStateActive:
+		return "active"
+	case proton.LinkStateDraft:
+		return "draft"
+	case proton.LinkStateTrashed:
+		return "trashed"
+	case proton.LinkStateDeleted:
+		return "deleted"
+	default:
+		return fmt.Sprintf("unknown(%d)", state)
+	}
+}
@@ -0,0 +1,101 @@
+package driveCmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+)
+
+func TestNewLinkRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		link *proton.Link
+		want linkRecord
+	}{
+		{
+			name: "folder",
+			link: &proton.Link{LinkID: "d1", Type: proton.LinkTypeFolder, State: proton.LinkStateActive, CreateTime: 1700000000, ModifyTime: 1718487045},
+			want: linkRecord{Type: "folder", State: "active", Mode: "0700", LinkID: "d1"},
+		},
+		{
+			name: "file",
+			link: &proton.Link{LinkID: "f1", Type: proton.LinkTypeFile, State: proton.LinkStateTrashed, MIMEType: "text/plain"},
+			want: linkRecord{Type: "file", State: "trashed", Mode: "0600", LinkID: "f1", MIMEType: "text/plain"},
+		},
+		{
+			name: "symlink",
+			link: &proton.Link{LinkID: "s1", Type: proton.LinkTypeFile, State: proton.LinkStateActive, MIMEType: drive.SymlinkMIMEType},
+			want: linkRecord{Type: "symlink", State: "active", Mode: "0600", LinkID: "s1", MIMEType: drive.SymlinkMIMEType},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := drive.NewTestLink(tt.link, nil, nil, nil, tt.name)
+			got := newLinkRecord("dir/"+tt.name, tt.name, l)
+			if got.Path != "dir/"+tt.name || got.Name != tt.name {
+				t.Errorf("path, name = %q, %q", got.Path, got.Name)
+			}
+			if got.LinkID != tt.want.LinkID || got.Type != tt.want.Type || got.State != tt.want.State ||
+				got.Mode != tt.want.Mode || got.MIMEType != tt.want.MIMEType {
+				t.Errorf("record = %+v, want %+v", got, tt.want)
+			}
+			if tt.link.CreateTime == 0 && !got.CreateTime.IsZero() {
+				t.Errorf("CreateTime = %v, want zero", got.CreateTime)
+			}
+			if tt.link.CreateTime != 0 && !got.CreateTime.Equal(time.Unix(tt.link.CreateTime, 0)) {
+				t.Errorf("CreateTime = %v, want %d", got.CreateTime, tt.link.CreateTime)
+			}
+		})
+	}
+}
+
+func TestLinkStateName(t *testing.T) {
+	tests := []struct {
+		state proton.LinkState
+		want  string
+	}{
+		{proton.LinkStateActive, "active"},
+		{proton.LinkStateDraft, "draft"},
+		{proton.LinkStateTrashed, "trashed"},
+		{proton.LinkStateDeleted, "deleted"},
+		{proton.LinkState(99), "unknown(99)"},
+	}
+	for _, tt := range tests {
+		if got := linkStateName(tt.state); got != tt.want {
+			t.Errorf("linkStateName(%d) = %q, want %q", tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestWriteEntryRecords(t *testing.T) {
+	var entries []listEntry
+	for _, name := range []string{"a.txt", "b.txt"} {
+		l := drive.NewTestLink(&proton.Link{LinkID: "id-" + name, Type: proton.LinkTypeFile, State: proton.LinkStateActive}, nil, nil, nil, name)
+		entries = append(entries, listEntry{entry: drive.DirEntry{Link: l}, name: name})
+	}
+
+	var buf bytes.Buffer
+	rw := cli.NewRecordWriter(&buf, cli.OutputNDJSON)
+	if err := writeEntryRecords(context.Background(), rw, "docs/", entries, listOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	for i, name := range []string{"a.txt", "b.txt"} {
+		if !strings.Contains(lines[i], `"path":"docs/`+name+`"`) || !strings.Contains(lines[i], `"link_id":"id-`+name+`"`) {
+			t.Errorf("line %d = %s", i, lines[i])
+		}
+	}
+}
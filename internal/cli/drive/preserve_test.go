@@ -35,3 +35,57 @@ func TestParsePreserve(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePreserveLinks(t *testing.T) {
+	tests := []struct {
+		preserve string
+		want     bool
+	}{
+		{"", false},
+		{"links", true},
+		{"mode,timestamps", false},
+		{"mode, links ,timestamps", true},
+	}
+	for _, tt := range tests {
+		if got := parsePreserve(cpOptions{preserve: tt.preserve}).links; got != tt.want {
+			t.Errorf("parsePreserve(%q).links = %v, want %v", tt.preserve, got, tt.want)
+		}
+	}
+}
+
+func TestPreserveWantXAttr(t *testing.T) {
+	tests := []struct {
+		preserve string
+		name     string
+		want     bool
+	}{
+		{"xattr", "user.checksum", true},
+		{"xattr", "security.selinux", true},
+		{"xattr", aclAccessXAttr, false},
+		{"acl", aclAccessXAttr, true},
+		{"acl", aclDefaultXAttr, true},
+		{"acl", "user.checksum", false},
+		{"xattr,acl", aclDefaultXAttr, true},
+		{"mode", "user.checksum", false},
+	}
+	for _, tt := range tests {
+		pf := parsePreserve(cpOptions{preserve: tt.preserve})
+		if got := pf.wantXAttr(tt.name); got != tt.want {
+			t.Errorf("--preserve=%s wantXAttr(%q) = %v, want %v", tt.preserve, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterXAttrs(t *testing.T) {
+	in := map[string][]byte{
+		"user.a":       []byte("1"),
+		aclAccessXAttr: []byte("acl"),
+	}
+	got := filterXAttrs(in, preserveFlags{xattr: true})
+	if len(got) != 1 || string(got["user.a"]) != "1" {
+		t.Errorf("filterXAttrs(xattr) = %v", got)
+	}
+	if got := filterXAttrs(in, preserveFlags{}); got != nil {
+		t.Errorf("filterXAttrs(none) = %v, want nil", got)
+	}
+}
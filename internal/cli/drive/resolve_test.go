@@ -218,6 +218,24 @@ func TestResolveSource(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "symlink with preserve links",
+			setup: func(t *testing.T, tmp string) pathArg {
+				t.Helper()
+				link := filepath.Join(tmp, "link.txt")
+				if err := os.Symlink("target.txt", link); err != nil {
+					t.Skip("symlinks not supported")
+				}
+				return pathArg{raw: link, pathType: PathLocal}
+			},
+			opts: cpOptions{preserve: "links"},
+			check: func(t *testing.T, ep *resolvedEndpoint) {
+				t.Helper()
+				if ep.localInfo == nil || ep.localInfo.Mode()&os.ModeSymlink == 0 {
+					t.Error("localInfo should describe the symlink itself")
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
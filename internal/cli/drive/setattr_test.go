@@ -0,0 +1,37 @@
+package driveCmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseXAttrArg(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantName  string
+		wantValue []byte
+		wantErr   bool
+	}{
+		{"user.tag=blue", "user.tag", []byte("blue"), false},
+		{"user.tag=", "user.tag", []byte{}, false},
+		{`user.tag="a b"`, "user.tag", []byte("a b"), false},
+		{"user.tag=a=b", "user.tag", []byte("a=b"), false},
+		{"user.bin=0x00ff", "user.bin", []byte{0x00, 0xff}, false},
+		{"user.bin=0saGk=", "user.bin", []byte("hi"), false},
+		{"user.bin=0xzz", "", nil, true},
+		{"user.bin=0s!!", "", nil, true},
+		{"user.tag", "", nil, true},
+		{"=value", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			name, value, err := parseXAttrArg(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseXAttrArg(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if name != tt.wantName || !bytes.Equal(value, tt.wantValue) {
+				t.Errorf("parseXAttrArg(%q) = %q, %q; want %q, %q", tt.in, name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
@@ -41,6 +41,15 @@ func runShareList(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
+	if cli.Structured(cmd) {
+		records := make([]shareRecord, len(shares))
+		for i := range shares {
+			name, _ := shares[i].GetName(ctx)
+			records[i] = newShareRecord(shares[i], name)
+		}
+		return cli.PrintRecords(cmd, records)
+	}
+
 	// Collect share IDs for short ID formatting.
 	ids := make([]string, len(shares))
 	for i := range shares {
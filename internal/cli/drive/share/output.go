@@ -0,0 +1,106 @@
+package shareCmd
+
+import (
+	"time"
+
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+)
+
+// shareRecord is the --output schema of one share in share list. IDs
+// are always in full; times are in UTC.
+type shareRecord struct {
+	ShareID      string    `json:"share_id"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Creator      string    `json:"creator"`
+	CreationTime time.Time `json:"creation_time"`
+}
+
+// shareDetailRecord is the --output schema of share show. Members and
+// invitations are null when they could not be listed, and empty for
+// main and photos shares, which cannot have any.
+type shareDetailRecord struct {
+	shareRecord
+	Origin              string             `json:"origin"`
+	PublicURL           *publicURLRecord   `json:"public_url"`
+	Members             []memberRecord     `json:"members"`
+	Invitations         []invitationRecord `json:"invitations"`
+	ExternalInvitations []invitationRecord `json:"external_invitations"`
+}
+
+// publicURLRecord describes the public URL of a share.
+type publicURLRecord struct {
+	Enabled   bool `json:"enabled"`
+	Downloads int  `json:"downloads"`
+}
+
+// memberRecord describes one member of a share.
+type memberRecord struct {
+	MemberID    string `json:"member_id"`
+	Email       string `json:"email"`
+	Permissions string `json:"permissions"`
+}
+
+// invitationRecord describes one pending invitation, for a Proton user
+// or an external address.
+type invitationRecord struct {
+	InvitationID string    `json:"invitation_id"`
+	Email        string    `json:"email"`
+	Permissions  string    `json:"permissions"`
+	CreateTime   time.Time `json:"create_time"`
+}
+
+// newShareRecord builds the record of a share, named name.
+func newShareRecord(s *drive.Share, name string) shareRecord {
+	meta := s.Metadata()
+	return shareRecord{
+		ShareID:      meta.ShareID,
+		Name:         name,
+		Type:         drive.FormatShareType(meta.Type),
+		Creator:      meta.Creator,
+		CreationTime: cli.EpochTime(meta.CreationTime),
+	}
+}
+
+// memberRecords converts members to their records.
+func memberRecords(members []drive.Member) []memberRecord {
+	out := make([]memberRecord, len(members))
+	for i, m := range members {
+		out[i] = memberRecord{
+			MemberID:    m.MemberID,
+			Email:       m.Email,
+			Permissions: drive.FormatPermissions(m.Permissions),
+		}
+	}
+	return out
+}
+
+// invitationRecords converts Proton-user invitations to their records.
+func invitationRecords(invs []drive.Invitation) []invitationRecord {
+	out := make([]invitationRecord, len(invs))
+	for i, inv := range invs {
+		out[i] = invitationRecord{
+			InvitationID: inv.InvitationID,
+			Email:        inv.InviteeEmail,
+			Permissions:  drive.FormatPermissions(inv.Permissions),
+			CreateTime:   cli.EpochTime(inv.CreateTime),
+		}
+	}
+	return out
+}
+
+// externalInvitationRecords converts external invitations to their
+// records.
+func externalInvitationRecords(exts []drive.ExternalInvitation) []invitationRecord {
+	out := make([]invitationRecord, len(exts))
+	for i, ext := range exts {
+		out[i] = invitationRecord{
+			InvitationID: ext.ExternalInvitationID,
+			Email:        ext.InviteeEmail,
+			Permissions:  drive.FormatPermissions(ext.Permissions),
+			CreateTime:   cli.EpochTime(ext.CreateTime),
+		}
+	}
+	return out
+}
@@ -98,4 +98,11 @@ func init() {
 	shareCmd.AddCommand(shareShowCmd)
 	shareCmd.AddCommand(shareInviteCmd)
 	shareCmd.AddCommand(shareRevokeCmd)
+
+	for _, c := range []*cobra.Command{
+		shareShowCmd, shareInviteCmd, shareRevokeCmd, shareDelCmd, shareRenameCmd,
+		shareURLEnableCmd, shareURLDisableCmd, shareURLPasswordCmd,
+	} {
+		c.ValidArgsFunction = driveCmd.CompleteShareName
+	}
 }
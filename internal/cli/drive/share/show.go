@@ -60,6 +60,10 @@ func runShareShow(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if cli.Structured(cmd) {
+		return cli.PrintObject(cmd, buildShareDetail(ctx, dc, resolved))
+	}
+
 	printShareMetadata(ctx, resolved)
 
 	// Show origin volume.
@@ -91,6 +95,50 @@ func runShareShow(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// buildShareDetail collects the share show record of s. Listing
+// failures are logged and leave the affected fields null.
+func buildShareDetail(ctx context.Context, dc *drive.Client, s *drive.Share) shareDetailRecord {
+	meta := s.Metadata()
+	name, _ := s.GetName(ctx)
+	rec := shareDetailRecord{
+		shareRecord: newShareRecord(s, name),
+		Origin:      dc.VolumeOrigin(ctx, s.VolumeID()),
+	}
+
+	if urls, err := listShareURLsFn(ctx, dc, meta.ShareID); err != nil {
+		slog.Error("share show: listing URLs", "error", err)
+	} else {
+		rec.PublicURL = &publicURLRecord{}
+		if len(urls) > 0 {
+			rec.PublicURL = &publicURLRecord{Enabled: true, Downloads: urls[0].NumAccesses}
+		}
+	}
+
+	if meta.Type == proton.ShareTypeMain || meta.Type == drive.ShareTypePhotos {
+		rec.Members = []memberRecord{}
+		rec.Invitations = []invitationRecord{}
+		rec.ExternalInvitations = []invitationRecord{}
+		return rec
+	}
+
+	if members, err := listMembersFn(ctx, dc, meta.ShareID); err != nil {
+		slog.Error("share show: listing members", "error", err)
+	} else {
+		rec.Members = memberRecords(members)
+	}
+	if invs, err := listInvitationsFn(ctx, dc, meta.ShareID); err != nil {
+		slog.Error("share show: listing invitations", "error", err)
+	} else {
+		rec.Invitations = invitationRecords(invs)
+	}
+	if exts, err := listExternalInvitationsFn(ctx, dc, meta.ShareID); err != nil {
+		slog.Error("share show: listing external invitations", "error", err)
+	} else {
+		rec.ExternalInvitations = externalInvitationRecords(exts)
+	}
+	return rec
+}
+
 func printShareMetadata(ctx context.Context, s *drive.Share) {
 	meta := s.Metadata()
 	shareName, _ := s.GetName(ctx)
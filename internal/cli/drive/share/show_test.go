@@ -166,3 +166,45 @@ func TestPrintExternalInvitations_Error(t *testing.T) {
 		t.Errorf("stderr missing warning, got: %q", stderrOut)
 	}
 }
+
+func TestBuildShareDetail(t *testing.T) {
+	saveAndRestore(t)
+	listShareURLsFn = func(_ context.Context, _ *drive.Client, _ string) ([]drive.ShareURL, error) {
+		return []drive.ShareURL{{NumAccesses: 7}}, nil
+	}
+	listMembersFn = func(_ context.Context, _ *drive.Client, _ string) ([]drive.Member, error) {
+		return []drive.Member{{MemberID: "m1", Email: "bob@proton.me", Permissions: 4}}, nil
+	}
+	listInvitationsFn = func(_ context.Context, _ *drive.Client, _ string) ([]drive.Invitation, error) {
+		return nil, fmt.Errorf("boom")
+	}
+	listExternalInvitationsFn = func(_ context.Context, _ *drive.Client, _ string) ([]drive.ExternalInvitation, error) {
+		return []drive.ExternalInvitation{{ExternalInvitationID: "x1", InviteeEmail: "eve@example.com", CreateTime: 1705276800}}, nil
+	}
+
+	m := proton.New()
+	dc := &drive.Client{Session: &api.Session{Client: m.NewClient("test-uid", "test-acc", "test-ref")}}
+	pShare := &proton.Share{ShareMetadata: testShareMetadata("share-123", proton.ShareTypeStandard)}
+	share := drive.NewShare(pShare, nil, drive.NewTestLink(&proton.Link{LinkID: "link-1"}, nil, nil, nil, "My Folder"), nil, "vol-1")
+
+	var rec shareDetailRecord
+	captureStdStreams(t, func() {
+		rec = buildShareDetail(context.Background(), dc, share)
+	})
+
+	if rec.ShareID != "share-123" || rec.Type != "shared" || rec.Creator != "test@proton.me" {
+		t.Errorf("share fields = %+v", rec.shareRecord)
+	}
+	if rec.PublicURL == nil || !rec.PublicURL.Enabled || rec.PublicURL.Downloads != 7 {
+		t.Errorf("PublicURL = %+v", rec.PublicURL)
+	}
+	if len(rec.Members) != 1 || rec.Members[0].Email != "bob@proton.me" {
+		t.Errorf("Members = %+v", rec.Members)
+	}
+	if rec.Invitations != nil {
+		t.Errorf("Invitations = %+v, want nil after a listing error", rec.Invitations)
+	}
+	if len(rec.ExternalInvitations) != 1 || rec.ExternalInvitations[0].InvitationID != "x1" || rec.ExternalInvitations[0].CreateTime.Unix() != 1705276800 {
+		t.Errorf("ExternalInvitations = %+v", rec.ExternalInvitations)
+	}
+}
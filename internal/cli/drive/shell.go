@@ -0,0 +1,551 @@
+package driveCmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// shellHelp summarizes the shell's commands for help and --help.
+const shellHelp = `Commands:
+  cd [<dir>]                 change the remote directory
+  pwd                        print the remote directory
+  ls [options] [<path> ...]  list remote files (as drive list)
+  get [options] <remote> ... [<local>]
+                             download (as drive cp; default local dir .)
+  put [options] <local> ... [<remote>]
+                             upload (as drive cp; default the remote dir)
+  mkdir [options] <dir> ...  create remote directories
+  rm [options] <path> ...    remove remote files
+  mv [options] <src> ... <dest>
+                             move or rename remote files
+  lcd [<dir>]                change the local directory (default $HOME)
+  lpwd                       print the local directory
+  lls [<dir> ...]            list local files
+  help                       show this list
+  exit, quit                 leave the shell (also Ctrl-D)`
+
+var driveShellCmd = &cobra.Command{
+	Use:   "shell [<path>]",
+	Short: "Interactive shell for Proton Drive",
+	Long: `Start an interactive sftp-like shell on Proton Drive. The session is
+restored once and kept for the life of the shell, so shares, folder
+listings and decrypted names are fetched once and reused by every
+command.
+
+Remote paths are relative to the current remote directory, which starts
+at <path> (default proton:///). A path starting with "/" is relative to
+the root of the current share, and a full proton:// URI may be used
+anywhere. Local paths are relative to the local working directory.
+
+` + shellHelp + `
+
+Arguments are split on white space; use quotes or a backslash for names
+containing spaces. Tab completes command names, remote names from the
+current directory, and local names for lcd, lls and put.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runShell,
+}
+
+func init() {
+	driveCmd.AddCommand(driveShellCmd)
+}
+
+// shellCommands lists the shell's commands, for help and completion.
+var shellCommands = []string{
+	"cd", "exit", "get", "help", "lcd", "lls", "lpwd", "ls",
+	"mkdir", "mv", "put", "pwd", "quit", "rm",
+}
+
+// shellDelegates maps shell commands to the drive subcommands that
+// implement them.
+var shellDelegates = map[string]string{
+	"ls":    "list",
+	"get":   "cp",
+	"put":   "cp",
+	"mkdir": "mkdir",
+	"rm":    "rm",
+	"mv":    "mv",
+}
+
+// errShellExit ends the shell loop.
+var errShellExit = errors.New("exit")
+
+// driveShell is the state of one interactive shell.
+type driveShell struct {
+	ctx  context.Context
+	dc   *drive.Client
+	home remotePath // starting directory, the target of a bare cd
+	cwd  remotePath // current remote directory
+	out  io.Writer
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	session, err := cli.SetupSession(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	cli.ShareSession(session, dc)
+	defer cli.ShareSession(nil, nil)
+
+	sh := &driveShell{ctx: ctx, dc: dc, out: os.Stdout}
+	start := "proton:///"
+	if len(args) > 0 {
+		start = args[0]
+	}
+	if err := sh.cd(start); err != nil {
+		return fmt.Errorf("shell: %w", err)
+	}
+	sh.home = sh.cwd
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return sh.runTerminal()
+	}
+	return sh.runScript(os.Stdin)
+}
+
+// runScript executes the commands read from r, one per line, stopping at
+// the end of input or an exit command.
+func (sh *driveShell) runScript(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if err := sh.exec(scanner.Text()); errors.Is(err, errShellExit) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// runTerminal runs the shell with line editing and tab completion. The
+// terminal is in raw mode only while a line is read, so commands run
+// with normal output processing.
+func (sh *driveShell) runTerminal() error {
+	fd := int(os.Stdin.Fd())
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		newLine, newPos, matches := sh.complete(line, pos)
+		if len(matches) > 1 && newLine == line {
+			_, _ = fmt.Fprintln(t, strings.Join(matches, "  "))
+		}
+		return newLine, newPos, true
+	}
+
+	for {
+		if w, _, err := term.GetSize(fd); err == nil {
+			_ = t.SetSize(w, 0)
+		}
+		t.SetPrompt(sh.prompt())
+
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("shell: %w", err)
+		}
+		line, err := t.ReadLine()
+		_ = term.Restore(fd, state)
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(sh.out)
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return fmt.Errorf("shell: %w", err)
+		}
+
+		if err := sh.exec(line); errors.Is(err, errShellExit) {
+			return nil
+		}
+	}
+}
+
+// prompt returns the prompt showing the current remote directory.
+func (sh *driveShell) prompt() string {
+	return "proton:" + sh.cwd.display() + "> "
+}
+
+// exec runs one command line. Command errors are printed; only
+// errShellExit is returned.
+func (sh *driveShell) exec(line string) error {
+	words, err := splitShellWords(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "shell: %v\n", err)
+		return nil
+	}
+	if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+		return nil
+	}
+
+	name, args := words[0], words[1:]
+	switch name {
+	case "exit", "quit":
+		return errShellExit
+	case "help":
+		sh.help()
+		return nil
+	}
+
+	if err := sh.run(name, args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+	}
+	return nil
+}
+
+// run executes a shell command other than help and exit.
+func (sh *driveShell) run(name string, args []string) error {
+	switch name {
+	case "cd":
+		target := sh.home.String()
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+		if len(args) == 1 {
+			target = args[0]
+		}
+		return sh.cd(target)
+
+	case "pwd":
+		fmt.Fprintln(sh.out, sh.cwd.String())
+		return nil
+
+	case "lcd":
+		dir := os.Getenv("HOME")
+		if len(args) > 1 {
+			return fmt.Errorf("too many arguments")
+		}
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		return os.Chdir(dir)
+
+	case "lpwd":
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(sh.out, dir)
+		return nil
+
+	case "lls":
+		return sh.lls(args)
+	}
+
+	if _, ok := shellDelegates[name]; !ok {
+		return fmt.Errorf("unknown command (type help for a list)")
+	}
+	return sh.delegate(name, args)
+}
+
+// cd changes the remote directory to raw, which must be a folder.
+func (sh *driveShell) cd(raw string) error {
+	target := sh.cwd.join(raw)
+	link, _, err := ResolveProtonPath(sh.ctx, sh.dc, target.String())
+	if err != nil {
+		return err
+	}
+	if !link.IsDir() {
+		return fmt.Errorf("%s: %w", raw, drive.ErrNotAFolder)
+	}
+	sh.cwd = target
+	return nil
+}
+
+// delegate runs the drive subcommand behind a shell command with args,
+// after resolving its remote operands against the current directory.
+func (sh *driveShell) delegate(name string, args []string) error {
+	sub, _, err := driveCmd.Find([]string{shellDelegates[name]})
+	if err != nil {
+		return err
+	}
+	return runSubcommand(sub, args, func(operands []string) []string {
+		return shellOperands(name, operands, sh.cwd)
+	})
+}
+
+// runSubcommand runs sub, a drive subcommand, in-process with args. The
+// operands left after flag parsing are passed through operands, when
+// set, before they are validated.
+func runSubcommand(sub *cobra.Command, args []string, operands func([]string) []string) error {
+	if !sub.Runnable() {
+		return cli.Usage(fmt.Errorf("%s: missing subcommand", sub.CommandPath()))
+	}
+
+	// Flag values live in package variables and survive between
+	// commands, so put them back to their defaults first.
+	resetFlagDefaults(sub.LocalNonPersistentFlags())
+	if f := sub.Flags().Lookup("help"); f != nil {
+		_ = f.Value.Set("false")
+		f.Changed = false
+	}
+	if err := sub.ParseFlags(args); err != nil {
+		return sub.FlagErrorFunc()(sub, err)
+	}
+	if help, _ := sub.Flags().GetBool("help"); help {
+		_ = sub.Help()
+		return nil
+	}
+
+	rest := sub.Flags().Args()
+	if operands != nil {
+		rest = operands(rest)
+	}
+	if err := sub.ValidateArgs(rest); err != nil {
+		return cli.Usage(err)
+	}
+	if sub.RunE == nil {
+		sub.Run(sub, rest)
+		return nil
+	}
+	return sub.RunE(sub, rest)
+}
+
+// shellOperands turns the operands of a delegated shell command into
+// those of the drive subcommand: remote operands are resolved against
+// cwd, and the defaults sftp users expect are filled in.
+func shellOperands(name string, args []string, cwd remotePath) []string {
+	remote := func(s string) string { return cwd.join(s).String() }
+	out := make([]string, 0, len(args)+1)
+
+	switch name {
+	case "ls":
+		if len(args) == 0 {
+			return []string{cwd.String()}
+		}
+		for _, a := range args {
+			out = append(out, remote(a))
+		}
+	case "get":
+		// get <remote> → download into the local directory.
+		if len(args) == 1 {
+			return []string{remote(args[0]), "."}
+		}
+		for i, a := range args {
+			if i < len(args)-1 {
+				a = remote(a)
+			}
+			out = append(out, a)
+		}
+	case "put":
+		// put <local> → upload into the remote directory.
+		if len(args) == 1 {
+			return []string{args[0], cwd.dir()}
+		}
+		out = append(out, args...)
+		if len(out) > 0 {
+			out[len(out)-1] = remote(out[len(out)-1])
+		}
+	default:
+		for _, a := range args {
+			out = append(out, remote(a))
+		}
+	}
+	return out
+}
+
+// lls lists local directories, or the working directory when none is
+// given, one name per line with "/" after directories.
+func (sh *driveShell) lls(args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var errs []error
+	for i, dir := range args {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(args) > 1 {
+			if i > 0 {
+				fmt.Fprintln(sh.out)
+			}
+			fmt.Fprintf(sh.out, "%s:\n", dir)
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() {
+				name += "/"
+			}
+			fmt.Fprintln(sh.out, name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// help prints the command summary.
+func (sh *driveShell) help() {
+	fmt.Fprintln(sh.out, shellHelp)
+}
+
+// resetFlagDefaults returns every flag in fs to its default value.
+// Count and string-array values only accumulate and cannot be reset
+// this way; the commands the shell delegates to do not use them.
+func resetFlagDefaults(fs *pflag.FlagSet) {
+	fs.VisitAll(func(f *pflag.Flag) {
+		switch v := f.Value.(type) {
+		case pflag.SliceValue:
+			_ = v.Replace(nil)
+		default:
+			def := f.DefValue
+			if def == "" && f.Value.Type() == "boolFunc" {
+				// cli.BoolFlag values do not report their default;
+				// all of them default to false.
+				def = "false"
+			}
+			_ = f.Value.Set(def)
+		}
+		f.Changed = false
+	})
+}
+
+// remotePath is a location on Drive: a share component as accepted in
+// proton:// URIs ("" for the root share) and a clean path within it.
+type remotePath struct {
+	share string
+	path  string // no leading or trailing "/"; "" for the share root
+}
+
+// parseRemotePath splits a proton:// URI into a remotePath. It does not
+// validate the share.
+func parseRemotePath(raw string) remotePath {
+	rest := strings.TrimPrefix(raw, "proton://")
+	if p, ok := strings.CutPrefix(rest, "/"); ok {
+		return remotePath{path: cleanRemote(p)}
+	}
+	share, p, _ := strings.Cut(rest, "/")
+	return remotePath{share: share, path: cleanRemote(p)}
+}
+
+// cleanRemote normalizes a share-relative path: "." and ".." are
+// resolved, ".." stops at the share root, and slashes are trimmed.
+func cleanRemote(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// String returns the proton:// URI of p.
+func (p remotePath) String() string {
+	return "proton://" + p.share + "/" + p.path
+}
+
+// dir returns the URI of p with a trailing "/", marking it a folder.
+func (p remotePath) dir() string {
+	if p.path == "" {
+		return p.String()
+	}
+	return p.String() + "/"
+}
+
+// display returns the short form used in the prompt: the share name (if
+// any) and the path from the share root.
+func (p remotePath) display() string {
+	if p.share == "" {
+		return "/" + p.path
+	}
+	return p.share + ":/" + p.path
+}
+
+// join resolves arg against p the way a shell resolves a path against
+// its working directory. A proton:// URI stands alone, a leading "/" is
+// the share root, and anything else is relative to p.
+func (p remotePath) join(arg string) remotePath {
+	switch {
+	case strings.HasPrefix(arg, "proton://"):
+		return parseRemotePath(arg)
+	case strings.HasPrefix(arg, "/"):
+		return remotePath{share: p.share, path: cleanRemote(arg)}
+	}
+	return remotePath{share: p.share, path: cleanRemote(p.path + "/" + arg)}
+}
+
+// splitShellWords splits a command line into words. Words are separated
+// by unquoted white space; single quotes keep everything literally,
+// double quotes allow \" and \\, and a backslash outside quotes escapes
+// the next character.
+func splitShellWords(line string) ([]string, error) {
+	var (
+		words   []string
+		cur     strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				cur.WriteRune('\\')
+			}
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	switch {
+	case quote != 0:
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	case escaped:
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// shellEscape quotes s so splitShellWords reads it back as one word.
+func shellEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(" \t'\"\\", r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package driveCmd
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// complete handles Tab for the line editor. It completes the word before
+// pos: a command name for the first word, a local name for lcd, lls and
+// put, and otherwise a remote name. It returns the new line and cursor
+// position, and every candidate when the word is ambiguous.
+func (sh *driveShell) complete(line string, pos int) (string, int, []string) {
+	head := line[:pos]
+	start := wordStart(head)
+	before, err := splitShellWords(head[:start])
+	if err != nil {
+		return line, pos, nil
+	}
+	words, err := splitShellWords(head[start:])
+	if err != nil || len(words) > 1 {
+		return line, pos, nil
+	}
+	word := ""
+	if len(words) == 1 {
+		word = words[0]
+	}
+
+	var dir, base string
+	var names map[string]bool
+	if len(before) == 0 {
+		base = word
+		names = make(map[string]bool, len(shellCommands))
+		for _, c := range shellCommands {
+			names[c] = false
+		}
+	} else {
+		if i := strings.LastIndex(word, "/"); i >= 0 {
+			dir, base = word[:i+1], word[i+1:]
+		} else {
+			base = word
+		}
+		switch before[0] {
+		case "lcd", "lls", "put":
+			names = localNames(dir)
+		default:
+			names = sh.remoteNames(dir)
+		}
+	}
+
+	completion, matches, done := completeWord(base, names)
+	repl := shellEscape(dir + completion)
+	if done && !strings.HasSuffix(completion, "/") {
+		repl += " "
+	}
+	newHead := head[:start] + repl
+	return newHead + line[pos:], len(newHead), matches
+}
+
+// wordStart returns the index in s where its last word begins: just
+// after the last white space that is not escaped by a backslash.
+func wordStart(s string) int {
+	start, escaped := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == ' ' || s[i] == '\t':
+			start = i + 1
+		}
+	}
+	return start
+}
+
+// completeWord completes base against names, which map each candidate
+// to whether it is a folder. It returns the completed text, the sorted
+// candidates (folders with a trailing "/"), and whether the completion
+// is unique. Names starting with "." are only offered when base does.
+func completeWord(base string, names map[string]bool) (string, []string, bool) {
+	var matches []string
+	for name, isDir := range names {
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if isDir {
+			name += "/"
+		}
+		matches = append(matches, name)
+	}
+	sort.Strings(matches)
+
+	switch len(matches) {
+	case 0:
+		return base, nil, false
+	case 1:
+		return matches[0], matches, true
+	}
+	prefix := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	if len(prefix) < len(base) {
+		prefix = base
+	}
+	return prefix, matches, false
+}
+
+// remoteNames returns the names in the remote folder dir, relative to
+// the current directory, mapped to whether each is a folder. Folder
+// listings come from the Link Table, so repeated completion in the same
+// folder does not go back to the API.
+func (sh *driveShell) remoteNames(dir string) map[string]bool {
+	link, _, err := ResolveProtonPath(sh.ctx, sh.dc, sh.cwd.join(dir).String())
+	if err != nil || !link.IsDir() {
+		return nil
+	}
+	names := make(map[string]bool)
+	for entry := range link.Readdir(sh.ctx) {
+		if entry.Err != nil {
+			continue
+		}
+		name, err := entry.EntryName()
+		if err != nil || name == "." || name == ".." {
+			continue
+		}
+		names[name] = entry.Link.IsDir()
+	}
+	return names
+}
+
+// localNames returns the names in the local directory dir ("" for the
+// working directory), mapped to whether each is a directory.
+func localNames(dir string) map[string]bool {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		names[e.Name()] = e.IsDir()
+	}
+	return names
+}
@@ -0,0 +1,299 @@
+package driveCmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/pflag"
+	"pgregory.net/rapid"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"   ", nil, false},
+		{"ls", []string{"ls"}, false},
+		{"  ls  -l\tdocs ", []string{"ls", "-l", "docs"}, false},
+		{`get "My Files/a b.txt" .`, []string{"get", "My Files/a b.txt", "."}, false},
+		{`cd 'it''s'`, []string{"cd", "its"}, false},
+		{`cd "it's"`, []string{"cd", "it's"}, false},
+		{`cd My\ Files`, []string{"cd", "My Files"}, false},
+		{`rm a\\b`, []string{"rm", `a\b`}, false},
+		{`rm "a\"b" "c\\d" "e\f"`, []string{"rm", `a"b`, `c\d`, `e\f`}, false},
+		{`rm 'a\b'`, []string{"rm", `a\b`}, false},
+		{`mkdir ""`, []string{"mkdir", ""}, false},
+		{`cd "unterminated`, nil, true},
+		{`cd 'unterminated`, nil, true},
+		{`cd trailing\`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := splitShellWords(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitShellWords(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitShellWords(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestShellEscape_RoundTrip_Property verifies that an escaped name is
+// read back by splitShellWords as the same single word.
+func TestShellEscape_RoundTrip_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		s := rapid.StringMatching(`[a-z .'"\\\t]{1,12}`).Draw(t, "s")
+		got, err := splitShellWords(shellEscape(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0] != s {
+			t.Fatalf("splitShellWords(shellEscape(%q)) = %q", s, got)
+		}
+	})
+}
+
+func TestRemotePath_Join(t *testing.T) {
+	root := remotePath{}
+	docs := remotePath{path: "Documents/work"}
+	photos := remotePath{share: "Photos", path: "2026"}
+	tests := []struct {
+		cwd  remotePath
+		arg  string
+		want string
+	}{
+		{root, "Documents", "proton:///Documents"},
+		{root, ".", "proton:///"},
+		{root, "..", "proton:///"},
+		{docs, "..", "proton:///Documents"},
+		{docs, "../../..", "proton:///"},
+		{docs, "notes.txt", "proton:///Documents/work/notes.txt"},
+		{docs, "./a//b/", "proton:///Documents/work/a/b"},
+		{docs, "/Music", "proton:///Music"},
+		{docs, "/", "proton:///"},
+		{photos, "/", "proton://Photos/"},
+		{photos, "../2025", "proton://Photos/2025"},
+		{docs, "proton://Photos/2024", "proton://Photos/2024"},
+		{photos, "proton:///Documents/", "proton:///Documents"},
+		{photos, "proton://Shared", "proton://Shared/"},
+	}
+	for _, tt := range tests {
+		if got := tt.cwd.join(tt.arg).String(); got != tt.want {
+			t.Errorf("%v.join(%q) = %q, want %q", tt.cwd, tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestRemotePath_DirAndDisplay(t *testing.T) {
+	tests := []struct {
+		p            remotePath
+		dir, display string
+	}{
+		{remotePath{}, "proton:///", "/"},
+		{remotePath{path: "a/b"}, "proton:///a/b/", "/a/b"},
+		{remotePath{share: "Photos"}, "proton://Photos/", "Photos:/"},
+		{remotePath{share: "Photos", path: "2026"}, "proton://Photos/2026/", "Photos:/2026"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.dir(); got != tt.dir {
+			t.Errorf("%v.dir() = %q, want %q", tt.p, got, tt.dir)
+		}
+		if got := tt.p.display(); got != tt.display {
+			t.Errorf("%v.display() = %q, want %q", tt.p, got, tt.display)
+		}
+	}
+}
+
+// TestRemotePath_JoinIdempotent_Property verifies that resolving the URI
+// of a resolved path yields the same path, from any directory.
+func TestRemotePath_JoinIdempotent_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		seg := rapid.SampledFrom([]string{"a", "b", ".", "..", "", "My Files"})
+		parts := rapid.SliceOfN(seg, 0, 6).Draw(t, "parts")
+		arg := ""
+		for i, p := range parts {
+			if i > 0 {
+				arg += "/"
+			}
+			arg += p
+		}
+		cwd := remotePath{share: rapid.SampledFrom([]string{"", "Photos"}).Draw(t, "share"), path: "x/y"}
+		other := remotePath{path: "z"}
+
+		p := cwd.join(arg)
+		if again := other.join(p.String()); again != p {
+			t.Fatalf("join(%q) = %v, re-resolved as %v", arg, p, again)
+		}
+	})
+}
+
+func TestShellOperands(t *testing.T) {
+	cwd := remotePath{path: "Documents"}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"ls", nil, []string{"proton:///Documents"}},
+		{"ls", []string{"a", "/b"}, []string{"proton:///Documents/a", "proton:///b"}},
+		{"get", []string{"a.txt"}, []string{"proton:///Documents/a.txt", "."}},
+		{"get", []string{"a.txt", "b.txt", "/tmp"}, []string{"proton:///Documents/a.txt", "proton:///Documents/b.txt", "/tmp"}},
+		{"put", []string{"a.txt"}, []string{"a.txt", "proton:///Documents/"}},
+		{"put", []string{"a.txt", "b.txt", "sub"}, []string{"a.txt", "b.txt", "proton:///Documents/sub"}},
+		{"mkdir", []string{"x", "y/z"}, []string{"proton:///Documents/x", "proton:///Documents/y/z"}},
+		{"mv", []string{"a", ".."}, []string{"proton:///Documents/a", "proton:///"}},
+		{"rm", []string{"proton://Photos/old"}, []string{"proton://Photos/old"}},
+	}
+	for _, tt := range tests {
+		got := shellOperands(tt.name, tt.args, cwd)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shellOperands(%s, %q) = %q, want %q", tt.name, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestWordStart(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"ls", 0},
+		{"ls ", 3},
+		{"ls doc", 3},
+		{`cd My\ Fi`, 3},
+		{"get a b", 6},
+	}
+	for _, tt := range tests {
+		if got := wordStart(tt.in); got != tt.want {
+			t.Errorf("wordStart(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteWord(t *testing.T) {
+	names := map[string]bool{
+		"Documents": true,
+		"Downloads": true,
+		"docs.txt":  false,
+		"notes.txt": false,
+		".hidden":   false,
+	}
+	tests := []struct {
+		base    string
+		want    string
+		matches []string
+		done    bool
+	}{
+		{"n", "notes.txt", []string{"notes.txt"}, true},
+		{"Doc", "Documents/", []string{"Documents/"}, true},
+		{"Do", "Do", []string{"Documents/", "Downloads/"}, false},
+		{"D", "Do", []string{"Documents/", "Downloads/"}, false},
+		{"x", "x", nil, false},
+		{".", ".hidden", []string{".hidden"}, true},
+		{"", "", []string{"Documents/", "Downloads/", "docs.txt", "notes.txt"}, false},
+	}
+	for _, tt := range tests {
+		got, matches, done := completeWord(tt.base, names)
+		if got != tt.want || done != tt.done || !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("completeWord(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.base, got, matches, done, tt.want, tt.matches, tt.done)
+		}
+	}
+}
+
+func TestShellComplete_Commands(t *testing.T) {
+	sh := &driveShell{}
+	tests := []struct {
+		line    string
+		want    string
+		matches int
+	}{
+		{"pw", "pwd ", 1},
+		{"mk", "mkdir ", 1},
+		{"l", "l", 4},
+		{"zz", "zz", 0},
+	}
+	for _, tt := range tests {
+		got, pos, matches := sh.complete(tt.line, len(tt.line))
+		if got != tt.want || pos != len(tt.want) || len(matches) != tt.matches {
+			t.Errorf("complete(%q) = %q, %d, %q; want %q, %d matches", tt.line, got, pos, matches, tt.want, tt.matches)
+		}
+	}
+}
+
+func TestShellComplete_Local(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, err := range []error{
+		os.Mkdir("My Photos", 0o755),
+		os.WriteFile("My Photos/a.jpg", nil, 0o644),
+		os.WriteFile("report.pdf", nil, 0o644),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sh := &driveShell{}
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"lcd My", `lcd My\ Photos/`},
+		{`lls My\ Photos/`, `lls My\ Photos/a.jpg `},
+		{"put rep", "put report.pdf "},
+	}
+	for _, tt := range tests {
+		got, _, _ := sh.complete(tt.line, len(tt.line))
+		if got != tt.want {
+			t.Errorf("complete(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	// Text after the cursor is kept.
+	got, pos, _ := sh.complete("put rep other", len("put rep"))
+	if got != "put report.pdf  other" || pos != len("put report.pdf ") {
+		t.Errorf("complete mid-line = %q, %d", got, pos)
+	}
+}
+
+func TestResetFlagDefaults(t *testing.T) {
+	var (
+		b   bool
+		fb  bool
+		s   string
+		n   int
+		arr []string
+	)
+	fs := pflag.NewFlagSet("t", pflag.ContinueOnError)
+	fs.BoolVar(&b, "b", false, "")
+	cli.BoolFlag(fs, &fb, "fb", false, "")
+	fs.StringVar(&s, "s", "dflt", "")
+	fs.IntVar(&n, "n", 3, "")
+	fs.StringSliceVar(&arr, "arr", nil, "")
+
+	if err := fs.Parse([]string{"--b", "--fb", "--s=x", "--n=9", "--arr=a", "--arr=b"}); err != nil {
+		t.Fatal(err)
+	}
+	if !b || !fb || s != "x" || n != 9 || len(arr) != 2 {
+		t.Fatalf("parse: b=%v fb=%v s=%q n=%d arr=%q", b, fb, s, n, arr)
+	}
+
+	resetFlagDefaults(fs)
+	if b || fb || s != "dflt" || n != 3 || len(arr) != 0 {
+		t.Errorf("reset: b=%v fb=%v s=%q n=%d arr=%q", b, fb, s, n, arr)
+	}
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			t.Errorf("flag %s still marked changed", f.Name)
+		}
+	})
+}
@@ -3,9 +3,11 @@ package driveCmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
 	cli "github.com/major0/proton-utils/internal/cli"
 	"github.com/spf13/cobra"
 )
@@ -58,6 +60,10 @@ func runStat(cmd *cobra.Command, args []string) error {
 	pLink := link.ProtonLink()
 	name, _ := link.Name()
 
+	if cli.Structured(cmd) {
+		return cli.PrintObject(cmd, buildStatRecord(ctx, dc, share, rawPath, name, link))
+	}
+
 	fmt.Printf("  File: %s\n", name)
 	fmt.Printf("LinkID: %s\n", pLink.LinkID)
 	fmt.Printf("  Type: %d (1=file, 2=folder)\n", pLink.Type)
@@ -116,6 +122,76 @@ func runStat(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// statRecord is the --output schema of drive stat: the link record,
+// plus the active revision of a file.
+type statRecord struct {
+	linkRecord
+	Revision *revisionRecord `json:"revision,omitempty"`
+}
+
+// revisionRecord describes a file revision. XAttr is omitted when the
+// revision's extended attributes cannot be fetched or decrypted.
+type revisionRecord struct {
+	ID    string       `json:"id"`
+	State int          `json:"state"`
+	Size  int64        `json:"size"`
+	XAttr *xattrRecord `json:"xattr,omitempty"`
+}
+
+// xattrRecord holds the decrypted common extended attributes of a
+// revision.
+type xattrRecord struct {
+	ModificationTime string            `json:"modification_time,omitempty"`
+	Size             int64             `json:"size"`
+	BlockSizes       []int64           `json:"block_sizes,omitempty"`
+	Digests          map[string]string `json:"digests,omitempty"`
+	Mode             string            `json:"mode,omitempty"`
+}
+
+// buildStatRecord collects the stat record of link. Failures to load
+// the revision XAttr are logged and leave it out of the record.
+func buildStatRecord(ctx context.Context, dc *drive.Client, share *drive.Share, p, name string, link *drive.Link) statRecord {
+	rec := statRecord{linkRecord: newLinkRecord(p, name, link)}
+	pLink := link.ProtonLink()
+	if pLink.Type != proton.LinkTypeFile || pLink.FileProperties == nil {
+		return rec
+	}
+
+	rev := &pLink.FileProperties.ActiveRevision
+	rec.Revision = &revisionRecord{ID: rev.ID, State: int(rev.State), Size: rev.Size}
+
+	fullRev, err := dc.Session.Client.GetRevisionAllBlocks(ctx, share.ProtonShare().ShareID, pLink.LinkID, rev.ID)
+	if err != nil {
+		slog.Debug("stat: fetch revision", "revisionID", rev.ID, "error", err)
+		return rec
+	}
+	nodeKR, err := link.KeyRing()
+	if err != nil {
+		slog.Debug("stat: node keyring", "error", err)
+		return rec
+	}
+	addrKR, err := dc.AddrKRForLink(link)
+	if err != nil {
+		slog.Debug("stat: address keyring", "error", err)
+		return rec
+	}
+	xattr, err := fullRev.GetDecXAttrString(addrKR, nodeKR)
+	if err != nil || xattr == nil {
+		slog.Debug("stat: decrypt xattr", "error", err)
+		return rec
+	}
+	rec.Revision.XAttr = &xattrRecord{
+		ModificationTime: xattr.ModificationTime,
+		Size:             xattr.Size,
+		BlockSizes:       xattr.BlockSizes,
+		Digests:          xattr.Digests,
+	}
+	if xattr.Mode != 0 {
+		rec.Revision.XAttr.Mode = fmt.Sprintf("%04o", xattr.Mode)
+	}
+	return rec
+}
+
 func truncate(s string, n int) string {
 	if len(s) <= n {
 		return s
@@ -0,0 +1,210 @@
+package driveCmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+var touchFlags struct {
+	noCreate  bool
+	date      string
+	reference string
+	verbose   bool
+}
+
+var driveTouchCmd = &cobra.Command{
+	Use:   "touch [options] <path> [<path> ...]",
+	Short: "Create empty files or set modification times on Proton Drive",
+	Long: `Set the modification time of each file to now, or to the time given
+by -d or taken from -r. A file that does not exist is created empty
+(mode 0644) unless -c is given.
+
+The modification time lives in the revision XAttr, so changing it on an
+existing file commits a new revision with the same content. Folders
+carry no modification time on Drive and are reported as errors.
+
+-d accepts "now", "@<unix seconds>", RFC 3339 ("2026-01-02T15:04:05Z"),
+or local "2026-01-02 15:04:05", "2026-01-02 15:04", and "2026-01-02".
+-r takes a Drive path or a local file.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTouch,
+}
+
+func init() {
+	driveCmd.AddCommand(driveTouchCmd)
+	f := driveTouchCmd.Flags()
+	cli.BoolFlagP(f, &touchFlags.noCreate, "no-create", "c", false, "Do not create files that do not exist")
+	f.StringVarP(&touchFlags.date, "date", "d", "", "Use this time instead of now")
+	f.StringVarP(&touchFlags.reference, "reference", "r", "", "Use the modification time of this file")
+	cli.BoolFlagP(f, &touchFlags.verbose, "verbose", "v", false, "Print each file as it is touched")
+}
+
+func runTouch(cmd *cobra.Command, args []string) error {
+	if touchFlags.date != "" && touchFlags.reference != "" {
+		return fmt.Errorf("touch: cannot specify times from more than one source")
+	}
+
+	var mtime time.Time
+	if touchFlags.date != "" {
+		var err error
+		mtime, err = parseTouchDate(touchFlags.date, time.Now())
+		if err != nil {
+			return fmt.Errorf("touch: %w", err)
+		}
+	}
+
+	ctx := context.Background()
+
+	session, err := cli.SetupSession(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	if touchFlags.reference != "" {
+		mtime, err = referenceModTime(ctx, dc, touchFlags.reference)
+		if err != nil {
+			return fmt.Errorf("touch: %s: %w", touchFlags.reference, err)
+		}
+	}
+
+	failed := 0
+	for _, arg := range args {
+		if err := touchOne(ctx, dc, arg, mtime); err != nil {
+			fmt.Fprintf(os.Stderr, "touch: %s: %v\n", arg, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("touch: %d of %d files could not be touched", failed, len(args))
+	}
+	return nil
+}
+
+// touchOne sets the modification time of rawPath, creating it empty
+// when missing. A zero mtime means now.
+func touchOne(ctx context.Context, dc *drive.Client, rawPath string, mtime time.Time) error {
+	sharePart, pathPart, err := parseProtonURI(rawPath)
+	if err != nil {
+		return err
+	}
+	pathPart = strings.TrimSuffix(pathPart, "/")
+	if pathPart == "" {
+		return fmt.Errorf("is a share root")
+	}
+	share, err := dc.ResolveShareComponent(ctx, sharePart)
+	if err != nil {
+		return err
+	}
+
+	link, err := share.Link.ResolvePath(ctx, pathPart, true)
+	switch {
+	case err == nil:
+		if link.IsDir() {
+			return fmt.Errorf("is a directory; folders carry no modification time")
+		}
+		if err := dc.Chtimes(ctx, share, link, mtime); err != nil {
+			return err
+		}
+		if touchFlags.verbose {
+			fmt.Printf("touched '%s'\n", rawPath)
+		}
+		return nil
+
+	case !errors.Is(err, drive.ErrFileNotFound):
+		return err
+
+	case touchFlags.noCreate:
+		return nil
+	}
+
+	parent := share.Link
+	dir, name := "", pathPart
+	if idx := strings.LastIndex(pathPart, "/"); idx >= 0 {
+		dir, name = pathPart[:idx], pathPart[idx+1:]
+		parent, err = share.Link.ResolvePath(ctx, dir, true)
+		if err != nil {
+			return err
+		}
+	}
+	if !parent.IsDir() {
+		return fmt.Errorf("%s: %w", dir, drive.ErrNotAFolder)
+	}
+
+	if _, err := dc.CreateEmpty(ctx, share, parent, name, 0o644, mtime); err != nil {
+		return err
+	}
+	if touchFlags.verbose {
+		fmt.Printf("created '%s'\n", rawPath)
+	}
+	return nil
+}
+
+// parseTouchDate parses a -d argument. Forms without a zone are in
+// local time.
+func parseTouchDate(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "now" {
+		return now, nil
+	}
+	if rest, ok := strings.CutPrefix(s, "@"); ok {
+		secs, err := strconv.ParseFloat(rest, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", s)
+		}
+		sec := int64(secs)
+		return time.Unix(sec, int64((secs-float64(sec))*1e9)), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// referenceModTime returns the modification time of a Drive file or,
+// for a path without the proton:// prefix, a local file.
+func referenceModTime(ctx context.Context, dc *drive.Client, ref string) (time.Time, error) {
+	if !strings.HasPrefix(ref, "proton://") {
+		info, err := os.Stat(ref)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return info.ModTime(), nil
+	}
+
+	link, _, err := ResolveProtonPath(ctx, dc, ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !link.IsFile() {
+		return time.Unix(link.ModifyTime(), 0), nil
+	}
+	fd, err := dc.OpenFD(ctx, link)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() { _ = fd.Close() }()
+	if t := fd.ModTime(); !t.IsZero() {
+		return t, nil
+	}
+	// No XAttr time: fall back to the upload time, as listings do.
+	return time.Unix(link.ModifyTime(), 0), nil
+}
@@ -0,0 +1,62 @@
+package driveCmd
+
+import (
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+func TestParseTouchDate(t *testing.T) {
+	now := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"now", now, false},
+		{" now ", now, false},
+		{"@0", time.Unix(0, 0), false},
+		{"@1700000000", time.Unix(1700000000, 0), false},
+		{"@1700000000.5", time.Unix(1700000000, 500000000), false},
+		{"2026-01-02T15:04:05Z", time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), false},
+		{"2026-01-02T15:04:05.25+02:00", time.Date(2026, 1, 2, 13, 4, 5, 250000000, time.UTC), false},
+		{"2026-01-02T15:04:05", time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local), false},
+		{"2026-01-02 15:04:05", time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local), false},
+		{"2026-01-02 15:04", time.Date(2026, 1, 2, 15, 4, 0, 0, time.Local), false},
+		{"2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local), false},
+		{"", time.Time{}, true},
+		{"@", time.Time{}, true},
+		{"@abc", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"2026-13-01", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseTouchDate(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTouchDate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTouchDate(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseTouchDate_RFC3339RoundTrip_Property verifies that any time
+// formatted as RFC 3339 parses back to the same instant.
+func TestParseTouchDate_RFC3339RoundTrip_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		sec := rapid.Int64Range(0, 4102444800).Draw(t, "sec")
+		nsec := rapid.Int64Range(0, 999999999).Draw(t, "nsec")
+		want := time.Unix(sec, nsec)
+		got, err := parseTouchDate(want.Format(time.RFC3339Nano), time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
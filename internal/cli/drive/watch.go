@@ -0,0 +1,175 @@
+package driveCmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	api "github.com/major0/proton-utils/api"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/major0/proton-utils/internal/keyring"
+	"github.com/spf13/cobra"
+)
+
+var watchFlags struct {
+	debounce  time.Duration // --debounce
+	stateFile string        // --state-file
+	verbose   bool          // -v, --verbose
+}
+
+var driveWatchCmd = &cobra.Command{
+	Use:   "watch [options] <local-dir> <dest>",
+	Short: "Continuously upload changes from a local directory",
+	Long: `Watch a local directory and mirror changes into a Proton Drive folder
+until interrupted.
+
+New and modified files are uploaded once they have been quiet for the
+debounce interval; an existing Drive file gets a new revision. Renames
+and moves inside the directory become Drive renames and moves, and
+deletions move the Drive copy to the trash.
+
+What has been uploaded is recorded in a state file. On start the local
+tree is compared with it, so changes made while the watcher was not
+running are uploaded (or trashed) before watching resumes. When run
+under systemd with Type=notify, readiness is signalled once the initial
+comparison is queued. Linux only.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runWatch,
+}
+
+func init() {
+	driveCmd.AddCommand(driveWatchCmd)
+	f := driveWatchCmd.Flags()
+	f.DurationVar(&watchFlags.debounce, "debounce", 2*time.Second, "Quiet period before a changed file is uploaded")
+	f.StringVar(&watchFlags.stateFile, "state-file", "", "State file (default: under $XDG_STATE_HOME/proton-utils/watch/)")
+	cli.BoolFlagP(f, &watchFlags.verbose, "verbose", "v", false, "Print each change as it is applied")
+}
+
+// defaultWatchStatePath returns the state file for a local directory
+// and destination pair.
+func defaultWatchStatePath(local, dest string) string {
+	sum := sha256.Sum256([]byte(local + "\x00" + dest))
+	return keyring.XDGStatePath(filepath.Join("watch", hex.EncodeToString(sum[:8])+".json"))
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	local, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	dest := args[1]
+	if info, err := os.Stat(local); err != nil {
+		return fmt.Errorf("watch: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("watch: %s: not a directory", local)
+	}
+	if watchFlags.debounce <= 0 {
+		return fmt.Errorf("watch: --debounce must be positive")
+	}
+
+	statePath := watchFlags.stateFile
+	if statePath == "" {
+		statePath = defaultWatchStatePath(local, dest)
+	}
+	state, err := loadWatchState(statePath)
+	if err != nil {
+		return fmt.Errorf("watch: state: %w", err)
+	}
+	if (state.Local != "" && state.Local != local) || (state.Remote != "" && state.Remote != dest) {
+		return fmt.Errorf("watch: %s belongs to %s -> %s", statePath, state.Local, state.Remote)
+	}
+	state.Local, state.Remote = local, dest
+
+	ctx := context.Background()
+
+	session, err := cli.SetupSession(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	sharePart, pathPart, err := parseProtonURI(dest)
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	share, err := dc.ResolveShareComponent(ctx, sharePart)
+	if err != nil {
+		return fmt.Errorf("watch: %s: %w", sharePart, err)
+	}
+	root, err := dc.MkDirAll(ctx, share, share.Link, pathPart)
+	if err != nil {
+		return fmt.Errorf("watch: %s: %w", dest, err)
+	}
+
+	// Watch before comparing so nothing changed in between is missed;
+	// events for entries the comparison also found coalesce in the queue.
+	w, err := newTreeWatcher(local)
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	sem := dc.Session.Sem
+	if sem == nil {
+		sem = api.NewSemaphore(ctx, api.DefaultMaxWorkers(), nil)
+	}
+
+	s := &watchSyncer{
+		dc:      dc,
+		share:   share,
+		root:    root,
+		local:   local,
+		remote:  dest,
+		state:   state,
+		queue:   newWatchQueue(watchFlags.debounce),
+		sem:     sem,
+		verbose: watchFlags.verbose,
+	}
+	if err := s.rescan(time.Now()); err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	// Drive operations use ctx so work in flight when a signal arrives
+	// still completes; the signal only stops the watcher.
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	events := make(chan watchEvent, 1024)
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.run(sigCtx, events) }()
+
+	notifyReady()
+
+	// Upload what the comparison found straight away.
+	s.flush(ctx, time.Now())
+
+	ticker := time.NewTicker(max(watchFlags.debounce/4, 100*time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sigCtx.Done():
+			s.flushAll(ctx)
+			return nil
+		case err := <-errCh:
+			s.flushAll(ctx)
+			if err != nil {
+				return fmt.Errorf("watch: %w", err)
+			}
+			return nil
+		case ev := <-events:
+			s.queue.add(ev, time.Now())
+		case now := <-ticker.C:
+			s.flush(ctx, now)
+		}
+	}
+}
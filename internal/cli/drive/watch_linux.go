@@ -0,0 +1,274 @@
+//go:build linux
+
+package driveCmd
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/major0/proton-utils/internal/sdnotify"
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask selects the events the watcher subscribes to for each
+// directory. IN_MODIFY feeds the debouncer; IN_CLOSE_WRITE marks the
+// end of a write burst.
+const inotifyMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE |
+	unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_DELETE_SELF | unix.IN_MOVE_SELF | unix.IN_ONLYDIR
+
+// inotifyEvent is one decoded record from an inotify read.
+type inotifyEvent struct {
+	wd     int32
+	mask   uint32
+	cookie uint32
+	name   string
+}
+
+// parseInotifyEvents decodes the records in buf. A truncated trailing
+// record is ignored.
+func parseInotifyEvents(buf []byte) []inotifyEvent {
+	var events []inotifyEvent
+	for len(buf) >= unix.SizeofInotifyEvent {
+		nameLen := int(binary.NativeEndian.Uint32(buf[12:16]))
+		end := unix.SizeofInotifyEvent + nameLen
+		if end > len(buf) {
+			break
+		}
+		events = append(events, inotifyEvent{
+			wd:     int32(binary.NativeEndian.Uint32(buf[0:4])), //nolint:gosec // kernel watch descriptors are int32
+			mask:   binary.NativeEndian.Uint32(buf[4:8]),
+			cookie: binary.NativeEndian.Uint32(buf[8:12]),
+			name:   strings.TrimRight(string(buf[unix.SizeofInotifyEvent:end]), "\x00"),
+		})
+		buf = buf[end:]
+	}
+	return events
+}
+
+// treeWatcher reports changes below a local directory using one inotify
+// watch per directory. Watches are added as directories appear, so
+// entries created before a new directory's watch is in place are
+// reported from a scan of that directory.
+type treeWatcher struct {
+	root string
+	fd   int              // inotify descriptor for add/remove watch calls
+	file *os.File         // fd wrapped for poller-backed, interruptible reads
+	wds  map[int32]string // watch descriptor → relative dir ("" for root)
+}
+
+// newTreeWatcher starts watching every directory below root.
+func newTreeWatcher(root string) (*treeWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify: %w", err)
+	}
+	w := &treeWatcher{
+		root: root,
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		wds:  make(map[int32]string),
+	}
+	if _, err := w.addTree(""); err != nil {
+		_ = w.file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// addTree adds watches for rel and every directory below it, returning
+// the entries found below rel (in walk order) so callers can report
+// ones created before their watch existed.
+func (w *treeWatcher) addTree(rel string) ([]watchEvent, error) {
+	var found []watchEvent
+	base := localPath(w.root, rel)
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p != base && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		sub, _ := filepath.Rel(w.root, p)
+		sub = filepath.ToSlash(sub)
+		if sub == "." {
+			sub = ""
+		}
+		switch {
+		case d.IsDir():
+			wd, err := unix.InotifyAddWatch(w.fd, p, inotifyMask)
+			if err != nil {
+				if p != base && errors.Is(err, fs.ErrNotExist) {
+					return fs.SkipDir
+				}
+				return fmt.Errorf("inotify: %s: %w", p, err)
+			}
+			w.wds[int32(wd)] = sub //nolint:gosec // kernel watch descriptors are int32
+			if p != base {
+				found = append(found, watchEvent{op: watchMkdir, path: sub, dir: true})
+			}
+		case d.Type().IsRegular():
+			found = append(found, watchEvent{op: watchWrite, path: sub})
+		}
+		return nil
+	})
+	return found, err
+}
+
+// dropTree removes the watches for rel and below.
+func (w *treeWatcher) dropTree(rel string) {
+	for wd, p := range w.wds {
+		if _, ok := underPath(p, rel); ok {
+			_, _ = unix.InotifyRmWatch(w.fd, uint32(wd)) //nolint:gosec // wd came from InotifyAddWatch
+			delete(w.wds, wd)
+		}
+	}
+}
+
+// renameTree updates the recorded paths of watches below a renamed
+// directory. The kernel keeps the watches themselves.
+func (w *treeWatcher) renameTree(from, to string) {
+	for wd, p := range w.wds {
+		if rel, ok := underPath(p, from); ok {
+			w.wds[wd] = joinRel(to, rel)
+		}
+	}
+}
+
+// run reads inotify events and sends them to out until ctx is done or
+// the watched root disappears.
+func (w *treeWatcher) run(ctx context.Context, out chan<- watchEvent) error {
+	go func() {
+		<-ctx.Done()
+		_ = w.file.Close()
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("inotify: %w", err)
+		}
+		if err := w.handle(parseInotifyEvents(buf[:n]), out); err != nil {
+			return err
+		}
+	}
+}
+
+// pendingMove is the first half of a rename, waiting for its
+// IN_MOVED_TO with the same cookie.
+type pendingMove struct {
+	path string
+	dir  bool
+}
+
+// handle translates one read's worth of inotify records. The kernel
+// queues both halves of a rename together, so a move-from left
+// unmatched at the end of the batch left the tree and is a remove; a
+// move-to without a move-from entered the tree and is a create.
+func (w *treeWatcher) handle(events []inotifyEvent, out chan<- watchEvent) error {
+	moves := make(map[uint32]pendingMove)
+
+	for _, ev := range events {
+		if ev.mask&unix.IN_Q_OVERFLOW != 0 {
+			out <- watchEvent{op: watchRescan}
+			continue
+		}
+		dir, ok := w.wds[ev.wd]
+		if !ok {
+			continue
+		}
+		if ev.mask&unix.IN_IGNORED != 0 {
+			delete(w.wds, ev.wd)
+			continue
+		}
+		if ev.mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF) != 0 {
+			if dir == "" {
+				return fmt.Errorf("%s: watched directory was removed or moved", w.root)
+			}
+			continue // reported by the parent as IN_DELETE / IN_MOVED_FROM
+		}
+
+		rel := joinRel(dir, ev.name)
+		isDir := ev.mask&unix.IN_ISDIR != 0
+
+		switch {
+		case ev.mask&unix.IN_MOVED_FROM != 0:
+			moves[ev.cookie] = pendingMove{path: rel, dir: isDir}
+
+		case ev.mask&unix.IN_MOVED_TO != 0:
+			if m, ok := moves[ev.cookie]; ok {
+				delete(moves, ev.cookie)
+				if isDir {
+					w.renameTree(m.path, rel)
+				}
+				out <- watchEvent{op: watchRename, from: m.path, path: rel, dir: isDir}
+				continue
+			}
+			if err := w.created(rel, isDir, out); err != nil {
+				return err
+			}
+
+		case ev.mask&unix.IN_CREATE != 0:
+			// New files are reported by IN_MODIFY/IN_CLOSE_WRITE.
+			if isDir {
+				if err := w.created(rel, true, out); err != nil {
+					return err
+				}
+			}
+
+		case ev.mask&unix.IN_DELETE != 0:
+			out <- watchEvent{op: watchRemove, path: rel, dir: isDir}
+
+		case ev.mask&(unix.IN_MODIFY|unix.IN_CLOSE_WRITE) != 0:
+			if !isDir {
+				out <- watchEvent{op: watchWrite, path: rel}
+			}
+		}
+	}
+
+	for _, m := range moves {
+		if m.dir {
+			w.dropTree(m.path)
+		}
+		out <- watchEvent{op: watchRemove, path: m.path, dir: m.dir}
+	}
+	return nil
+}
+
+// created reports an entry that appeared in the tree. New directories
+// are watched and scanned.
+func (w *treeWatcher) created(rel string, dir bool, out chan<- watchEvent) error {
+	if !dir {
+		out <- watchEvent{op: watchWrite, path: rel}
+		return nil
+	}
+	out <- watchEvent{op: watchMkdir, path: rel, dir: true}
+	found, err := w.addTree(rel)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // already gone; its IN_DELETE follows
+		}
+		return err
+	}
+	for _, ev := range found {
+		out <- ev
+	}
+	return nil
+}
+
+// notifyReady signals systemd that the watcher is running.
+func notifyReady() {
+	if err := sdnotify.Ready(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: sd_notify: %v\n", err)
+	}
+}
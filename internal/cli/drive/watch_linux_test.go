@@ -0,0 +1,114 @@
+//go:build linux
+
+package driveCmd
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseInotifyEvents(t *testing.T) {
+	record := func(wd int32, mask, cookie uint32, name string) []byte {
+		n := 0
+		if name != "" {
+			n = (len(name) + 1 + 15) / 16 * 16 // NUL-padded like the kernel
+		}
+		b := make([]byte, unix.SizeofInotifyEvent+n)
+		binary.NativeEndian.PutUint32(b[0:4], uint32(wd)) //nolint:gosec // test data
+		binary.NativeEndian.PutUint32(b[4:8], mask)
+		binary.NativeEndian.PutUint32(b[8:12], cookie)
+		binary.NativeEndian.PutUint32(b[12:16], uint32(n)) //nolint:gosec // test data
+		copy(b[unix.SizeofInotifyEvent:], name)
+		return b
+	}
+
+	var buf []byte
+	buf = append(buf, record(1, unix.IN_MOVED_FROM, 7, "old.txt")...)
+	buf = append(buf, record(2, unix.IN_Q_OVERFLOW, 0, "")...)
+	buf = append(buf, record(1, unix.IN_MOVED_TO, 7, "a-much-longer-file-name.txt")...)
+	truncated := record(3, unix.IN_CREATE, 0, "cut")
+	buf = append(buf, truncated[:len(truncated)-4]...)
+
+	got := parseInotifyEvents(buf)
+	want := []inotifyEvent{
+		{wd: 1, mask: unix.IN_MOVED_FROM, cookie: 7, name: "old.txt"},
+		{wd: 2, mask: unix.IN_Q_OVERFLOW},
+		{wd: 1, mask: unix.IN_MOVED_TO, cookie: 7, name: "a-much-longer-file-name.txt"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parsed %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestTreeWatcher drives a real inotify instance through a create,
+// nested mkdir, rename, and delete.
+func TestTreeWatcher(t *testing.T) {
+	root := t.TempDir()
+	w, err := newTreeWatcher(root)
+	if err != nil {
+		t.Fatalf("newTreeWatcher: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan watchEvent, 64)
+	go func() { _ = w.run(ctx, events) }()
+
+	expect := func(want watchEvent) {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case ev := <-events:
+				if ev == want {
+					return
+				}
+			case <-deadline:
+				t.Fatalf("timed out waiting for %+v", want)
+			}
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expect(watchEvent{op: watchWrite, path: "a.txt"})
+
+	if err := os.MkdirAll(filepath.Join(root, "d", "e"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	expect(watchEvent{op: watchMkdir, path: "d", dir: true})
+
+	// The nested directory is watched once d's scan has run.
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(root, "d", "e", "f.txt"), []byte("y"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expect(watchEvent{op: watchWrite, path: "d/e/f.txt"})
+
+	if err := os.Rename(filepath.Join(root, "d"), filepath.Join(root, "r")); err != nil {
+		t.Fatal(err)
+	}
+	expect(watchEvent{op: watchRename, from: "d", path: "r", dir: true})
+
+	// Watches below a renamed directory report the new path.
+	if err := os.WriteFile(filepath.Join(root, "r", "e", "g.txt"), []byte("z"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expect(watchEvent{op: watchWrite, path: "r/e/g.txt"})
+
+	if err := os.Remove(filepath.Join(root, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	expect(watchEvent{op: watchRemove, path: "a.txt"})
+}
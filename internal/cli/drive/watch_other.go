@@ -0,0 +1,24 @@
+//go:build !linux
+
+package driveCmd
+
+import (
+	"context"
+	"errors"
+)
+
+// treeWatcher is unavailable on platforms without inotify.
+type treeWatcher struct{}
+
+// newTreeWatcher fails on platforms without inotify.
+func newTreeWatcher(_ string) (*treeWatcher, error) {
+	return nil, errors.New("watching directories is only supported on Linux")
+}
+
+// run is never reached; newTreeWatcher always fails.
+func (w *treeWatcher) run(_ context.Context, _ chan<- watchEvent) error {
+	return nil
+}
+
+// notifyReady is a no-op without systemd.
+func notifyReady() {}
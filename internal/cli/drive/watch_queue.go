@@ -0,0 +1,147 @@
+package driveCmd
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// watchOp is the kind of change reported by a tree watcher.
+type watchOp int
+
+const (
+	watchWrite  watchOp = iota + 1 // file created or modified
+	watchMkdir                     // directory created
+	watchRemove                    // file or directory deleted
+	watchRename                    // file or directory renamed within the tree
+	watchRescan                    // events were lost; compare the whole tree
+)
+
+// watchEvent is a change to a slash-separated path relative to the
+// watched root.
+type watchEvent struct {
+	op   watchOp
+	path string
+	from string // source path for watchRename
+	dir  bool   // path is a directory
+}
+
+// pendingWrite tracks a debounced upload.
+type pendingWrite struct {
+	first time.Time // first write since the last upload
+	last  time.Time // most recent write
+}
+
+// watchQueue coalesces watcher events. Writes to a path are held until
+// the path has been quiet for the debounce interval (or has been busy
+// for maxDelay), so a burst of writes becomes a single upload. Removes,
+// renames, and directory creations carry no content; they are released
+// on the next flush in arrival order, ahead of any uploads, so the
+// remote tree changes in the same order as the local one.
+//
+// A watchQueue is not safe for concurrent use.
+type watchQueue struct {
+	debounce time.Duration
+	maxDelay time.Duration
+	writes   map[string]pendingWrite
+	ordered  []watchEvent
+}
+
+// newWatchQueue returns a queue with the given debounce interval. A
+// continuously written file is uploaded at least every 10 intervals.
+func newWatchQueue(debounce time.Duration) *watchQueue {
+	return &watchQueue{
+		debounce: debounce,
+		maxDelay: 10 * debounce,
+		writes:   make(map[string]pendingWrite),
+	}
+}
+
+// add records ev as observed at now.
+func (q *watchQueue) add(ev watchEvent, now time.Time) {
+	switch ev.op {
+	case watchWrite:
+		pw, ok := q.writes[ev.path]
+		if !ok {
+			pw.first = now
+		}
+		pw.last = now
+		q.writes[ev.path] = pw
+
+	case watchRemove:
+		// Nothing left to upload under the removed path.
+		q.dropWrites(ev.path)
+		q.ordered = append(q.ordered, ev)
+
+	case watchRename:
+		// The destination's old content is gone; pending writes move
+		// with the renamed entry.
+		q.dropWrites(ev.path)
+		for p, pw := range q.writes {
+			if rel, ok := underPath(p, ev.from); ok {
+				delete(q.writes, p)
+				q.writes[joinRel(ev.path, rel)] = pw
+			}
+		}
+		q.ordered = append(q.ordered, ev)
+
+	case watchMkdir, watchRescan:
+		q.ordered = append(q.ordered, ev)
+	}
+}
+
+// dropWrites forgets pending writes at or below p.
+func (q *watchQueue) dropWrites(p string) {
+	for w := range q.writes {
+		if _, ok := underPath(w, p); ok {
+			delete(q.writes, w)
+		}
+	}
+}
+
+// flush returns the queued structural events and the paths whose
+// writes are due at now, removing them from the queue. Paths are
+// returned sorted.
+func (q *watchQueue) flush(now time.Time) ([]watchEvent, []string) {
+	ordered := q.ordered
+	q.ordered = nil
+
+	var due []string
+	for p, pw := range q.writes {
+		if now.Sub(pw.last) >= q.debounce || now.Sub(pw.first) >= q.maxDelay {
+			due = append(due, p)
+			delete(q.writes, p)
+		}
+	}
+	slices.Sort(due)
+	return ordered, due
+}
+
+// flushAll returns everything queued regardless of deadlines.
+func (q *watchQueue) flushAll() ([]watchEvent, []string) {
+	return q.flush(time.Now().Add(q.maxDelay + q.debounce))
+}
+
+// underPath reports whether p is root or lies below it, returning the
+// remainder of p relative to root ("" when p == root).
+func underPath(p, root string) (string, bool) {
+	if p == root {
+		return "", true
+	}
+	if strings.HasPrefix(p, root+"/") {
+		return p[len(root)+1:], true
+	}
+	return "", false
+}
+
+// joinRel joins a relative remainder onto base. Either may be empty
+// (the watched root).
+func joinRel(base, rel string) string {
+	if rel == "" {
+		return base
+	}
+	if base == "" {
+		return rel
+	}
+	return base + "/" + rel
+}
@@ -0,0 +1,136 @@
+package driveCmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatchQueueDebounce(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	q := newWatchQueue(time.Second)
+
+	q.add(watchEvent{op: watchWrite, path: "a"}, t0)
+	q.add(watchEvent{op: watchWrite, path: "a"}, t0.Add(800*time.Millisecond))
+
+	if _, due := q.flush(t0.Add(time.Second)); len(due) != 0 {
+		t.Fatalf("flush before quiet period = %v, want none", due)
+	}
+	if _, due := q.flush(t0.Add(1800 * time.Millisecond)); !slices.Equal(due, []string{"a"}) {
+		t.Fatalf("flush after quiet period = %v, want [a]", due)
+	}
+	if _, due := q.flush(t0.Add(time.Hour)); len(due) != 0 {
+		t.Fatalf("second flush = %v, want none", due)
+	}
+}
+
+func TestWatchQueueMaxDelay(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	q := newWatchQueue(time.Second)
+
+	// Written every half second — never quiet, but due after maxDelay.
+	var due []string
+	for i := 0; i <= 20 && len(due) == 0; i++ {
+		now := t0.Add(time.Duration(i) * 500 * time.Millisecond)
+		q.add(watchEvent{op: watchWrite, path: "log"}, now)
+		_, due = q.flush(now)
+	}
+	if !slices.Equal(due, []string{"log"}) {
+		t.Fatalf("busy file never became due: %v", due)
+	}
+}
+
+func TestWatchQueueCoalesce(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	later := t0.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		events      []watchEvent
+		wantOrdered []watchEvent
+		wantWrites  []string
+	}{
+		{
+			name: "remove cancels write",
+			events: []watchEvent{
+				{op: watchWrite, path: "a"},
+				{op: watchRemove, path: "a"},
+			},
+			wantOrdered: []watchEvent{{op: watchRemove, path: "a"}},
+		},
+		{
+			name: "remove cancels writes below a directory",
+			events: []watchEvent{
+				{op: watchWrite, path: "d/x"},
+				{op: watchWrite, path: "dx"},
+				{op: watchRemove, path: "d", dir: true},
+			},
+			wantOrdered: []watchEvent{{op: watchRemove, path: "d", dir: true}},
+			wantWrites:  []string{"dx"},
+		},
+		{
+			name: "write follows rename",
+			events: []watchEvent{
+				{op: watchWrite, path: "d/x"},
+				{op: watchRename, from: "d", path: "e", dir: true},
+			},
+			wantOrdered: []watchEvent{{op: watchRename, from: "d", path: "e", dir: true}},
+			wantWrites:  []string{"e/x"},
+		},
+		{
+			name: "rename replaces pending write of target",
+			events: []watchEvent{
+				{op: watchWrite, path: "b"},
+				{op: watchRename, from: "a", path: "b"},
+			},
+			wantOrdered: []watchEvent{{op: watchRename, from: "a", path: "b"}},
+		},
+		{
+			name: "structural events keep order",
+			events: []watchEvent{
+				{op: watchMkdir, path: "d", dir: true},
+				{op: watchRemove, path: "b"},
+				{op: watchRename, from: "a", path: "b"},
+			},
+			wantOrdered: []watchEvent{
+				{op: watchMkdir, path: "d", dir: true},
+				{op: watchRemove, path: "b"},
+				{op: watchRename, from: "a", path: "b"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newWatchQueue(time.Second)
+			for _, ev := range tt.events {
+				q.add(ev, t0)
+			}
+			ordered, writes := q.flush(later)
+			if !slices.Equal(ordered, tt.wantOrdered) {
+				t.Errorf("ordered = %+v, want %+v", ordered, tt.wantOrdered)
+			}
+			if !slices.Equal(writes, tt.wantWrites) {
+				t.Errorf("writes = %v, want %v", writes, tt.wantWrites)
+			}
+		})
+	}
+}
+
+func TestUnderPath(t *testing.T) {
+	tests := []struct {
+		p, root string
+		rel     string
+		ok      bool
+	}{
+		{"a", "a", "", true},
+		{"a/b/c", "a", "b/c", true},
+		{"ab", "a", "", false},
+		{"b", "a", "", false},
+	}
+	for _, tt := range tests {
+		rel, ok := underPath(tt.p, tt.root)
+		if rel != tt.rel || ok != tt.ok {
+			t.Errorf("underPath(%q, %q) = %q, %v; want %q, %v", tt.p, tt.root, rel, ok, tt.rel, tt.ok)
+		}
+	}
+}
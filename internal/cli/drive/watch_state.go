@@ -0,0 +1,200 @@
+package driveCmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// watchEntry is what was last uploaded for one path.
+type watchEntry struct {
+	Size    int64 `json:"size,omitempty"`
+	ModTime int64 `json:"mtime,omitempty"` // Unix nanoseconds
+	Dir     bool  `json:"dir,omitempty"`
+}
+
+// watchState records the local files and directories that have been
+// synchronized to Drive, keyed by slash-separated path relative to the
+// watched root. It lets a restarted watcher upload only what changed
+// and trash what was deleted while it was not running.
+type watchState struct {
+	mu      sync.Mutex
+	path    string
+	Local   string                `json:"local"`
+	Remote  string                `json:"remote"`
+	Entries map[string]watchEntry `json:"entries"`
+}
+
+// loadWatchState reads the state file at p. A missing file yields an
+// empty state.
+func loadWatchState(p string) (*watchState, error) {
+	s := &watchState{path: p, Entries: make(map[string]watchEntry)}
+	data, err := os.ReadFile(p) //nolint:gosec // path from flag or XDG state dir
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	if s.Entries == nil {
+		s.Entries = make(map[string]watchEntry)
+	}
+	return s, nil
+}
+
+// save writes the state atomically (temp file + rename).
+func (s *watchState) save() error {
+	s.mu.Lock()
+	data, err := json.Marshal(s)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// entryFor returns the state entry describing info.
+func entryFor(info fs.FileInfo) watchEntry {
+	if info.IsDir() {
+		return watchEntry{Dir: true}
+	}
+	return watchEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+}
+
+// unchanged reports whether rel was last synchronized with the same
+// size and modification time as info.
+func (s *watchState) unchanged(rel string, info fs.FileInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.Entries[rel]
+	return ok && e == entryFor(info)
+}
+
+// set records rel as synchronized.
+func (s *watchState) set(rel string, e watchEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Entries[rel] = e
+}
+
+// remove forgets rel and everything below it.
+func (s *watchState) remove(rel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := range s.Entries {
+		if _, ok := underPath(p, rel); ok {
+			delete(s.Entries, p)
+		}
+	}
+}
+
+// rename moves the entries at and below from to to.
+func (s *watchState) rename(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	moved := make(map[string]watchEntry)
+	for p, e := range s.Entries {
+		if _, ok := underPath(p, to); ok {
+			delete(s.Entries, p)
+		}
+		if rel, ok := underPath(p, from); ok {
+			delete(s.Entries, p)
+			moved[joinRel(to, rel)] = e
+		}
+	}
+	for p, e := range moved {
+		s.Entries[p] = e
+	}
+}
+
+// diff compares the local tree at root with the recorded state and
+// returns the events that bring Drive up to date: directory creations
+// and uploads for new or changed entries, then removals for entries
+// that no longer exist locally. Only the topmost removed path of a
+// subtree is reported. Symlinks and special files are ignored.
+func (s *watchState) diff(root string) ([]watchEvent, error) {
+	var events []watchEvent
+	seen := make(map[string]bool)
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // removed during the walk
+			}
+			return err
+		}
+		seen[rel] = true
+		if s.unchanged(rel, info) {
+			return nil
+		}
+		if d.IsDir() {
+			events = append(events, watchEvent{op: watchMkdir, path: rel, dir: true})
+		} else {
+			events = append(events, watchEvent{op: watchWrite, path: rel})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	var gone []string
+	for p := range s.Entries {
+		if !seen[p] {
+			gone = append(gone, p)
+		}
+	}
+	dirs := make(map[string]bool, len(gone))
+	for _, p := range gone {
+		dirs[p] = s.Entries[p].Dir
+	}
+	s.mu.Unlock()
+
+	// Sorted order visits each parent before its children.
+	slices.Sort(gone)
+	removed := make(map[string]bool, len(gone))
+	for _, p := range gone {
+		removed[p] = true
+		if removed[path.Dir(p)] {
+			continue
+		}
+		events = append(events, watchEvent{op: watchRemove, path: p, dir: dirs[p]})
+	}
+	return events, nil
+}
+
+// localPath returns the local filesystem path for rel under root.
+func localPath(root, rel string) string {
+	return filepath.Join(root, filepath.FromSlash(rel))
+}
@@ -0,0 +1,102 @@
+package driveCmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWatchStateDiff(t *testing.T) {
+	root := t.TempDir()
+	mustWrite := func(rel, data string) {
+		t.Helper()
+		p := localPath(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite("same.txt", "same")
+	mustWrite("changed.txt", "new content")
+	mustWrite("new/file.txt", "new")
+
+	s := &watchState{Entries: map[string]watchEntry{}}
+	info, err := os.Stat(localPath(root, "same.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.set("same.txt", entryFor(info))
+	s.set("changed.txt", watchEntry{Size: 3})
+	s.set("gone", watchEntry{Dir: true})
+	s.set("gone/a.txt", watchEntry{Size: 1})
+	s.set("gone.txt", watchEntry{Size: 1})
+
+	events, err := s.diff(root)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	want := []watchEvent{
+		{op: watchWrite, path: "changed.txt"},
+		{op: watchMkdir, path: "new", dir: true},
+		{op: watchWrite, path: "new/file.txt"},
+		{op: watchRemove, path: "gone", dir: true},
+		{op: watchRemove, path: "gone.txt"},
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("diff =\n%+v\nwant\n%+v", events, want)
+	}
+}
+
+func TestWatchStateRename(t *testing.T) {
+	s := &watchState{Entries: map[string]watchEntry{
+		"d":     {Dir: true},
+		"d/x":   {Size: 1},
+		"dx":    {Size: 2},
+		"e/old": {Size: 3},
+		"e":     {Dir: true},
+	}}
+	s.rename("d", "e")
+
+	want := map[string]watchEntry{
+		"e":   {Dir: true},
+		"e/x": {Size: 1},
+		"dx":  {Size: 2},
+	}
+	if len(s.Entries) != len(want) {
+		t.Fatalf("entries = %v, want %v", s.Entries, want)
+	}
+	for k, v := range want {
+		if s.Entries[k] != v {
+			t.Errorf("entries[%q] = %+v, want %+v", k, s.Entries[k], v)
+		}
+	}
+}
+
+func TestWatchStateSaveLoad(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "sub", "state.json")
+
+	s, err := loadWatchState(p)
+	if err != nil {
+		t.Fatalf("load missing: %v", err)
+	}
+	if len(s.Entries) != 0 {
+		t.Fatalf("missing state has entries: %v", s.Entries)
+	}
+
+	s.Local, s.Remote = "/home/u/docs", "proton://My files/docs"
+	s.set("a.txt", watchEntry{Size: 5, ModTime: 42})
+	if err := s.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	got, err := loadWatchState(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.Local != s.Local || got.Remote != s.Remote || got.Entries["a.txt"] != s.Entries["a.txt"] {
+		t.Errorf("loaded %+v, want %+v", got, s)
+	}
+}
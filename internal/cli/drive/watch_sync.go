@@ -0,0 +1,280 @@
+package driveCmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// watchSyncer applies watcher events to a Drive folder.
+//
+// Structural events (mkdir, remove, rename) run one at a time on the
+// caller's goroutine. Uploads run concurrently on the worker semaphore
+// and only touch the state, which is locked.
+type watchSyncer struct {
+	dc      *drive.Client
+	share   *drive.Share
+	root    *drive.Link
+	local   string // absolute local root
+	remote  string // destination as given on the command line
+	state   *watchState
+	queue   *watchQueue
+	sem     *api.Semaphore
+	verbose bool
+}
+
+// flush applies everything in the queue that is due at now.
+func (s *watchSyncer) flush(ctx context.Context, now time.Time) {
+	ordered, writes := s.queue.flush(now)
+	s.process(ctx, now, ordered, writes)
+}
+
+// flushAll applies everything in the queue regardless of deadlines.
+func (s *watchSyncer) flushAll(ctx context.Context) {
+	ordered, writes := s.queue.flushAll()
+	s.process(ctx, time.Now(), ordered, writes)
+}
+
+// process applies the structural events in order, uploads the written
+// paths concurrently, and saves the state. Failures are reported and
+// left out of the state, so the next start retries them.
+func (s *watchSyncer) process(ctx context.Context, now time.Time, ordered []watchEvent, writes []string) {
+	if len(ordered) == 0 && len(writes) == 0 {
+		return
+	}
+
+	for _, ev := range ordered {
+		if err := s.apply(ctx, ev, now); err != nil {
+			fmt.Fprintf(os.Stderr, "watch: %s: %v\n", ev.path, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, rel := range writes {
+		s.sem.Go(&wg, func(ctx context.Context) error {
+			if err := s.upload(ctx, rel); err != nil {
+				fmt.Fprintf(os.Stderr, "watch: %s: %v\n", rel, err)
+			}
+			return nil
+		})
+	}
+	wg.Wait()
+
+	if err := s.state.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "watch: saving state: %v\n", err)
+	}
+}
+
+// apply performs a structural event. Follow-up uploads are queued as
+// due at now.
+func (s *watchSyncer) apply(ctx context.Context, ev watchEvent, now time.Time) error {
+	switch ev.op {
+	case watchMkdir:
+		if _, err := s.dc.MkDirAll(ctx, s.share, s.root, ev.path); err != nil {
+			return err
+		}
+		s.state.set(ev.path, watchEntry{Dir: true})
+		s.logf("created directory '%s'", s.remotePath(ev.path))
+
+	case watchRemove:
+		link, err := s.lookup(ctx, ev.path)
+		if err != nil || link == nil {
+			s.state.remove(ev.path)
+			return err
+		}
+		if err := s.dc.Remove(ctx, s.share, link, drive.RemoveOpts{Recursive: true}); err != nil {
+			return err
+		}
+		s.state.remove(ev.path)
+		s.logf("removed '%s'", s.remotePath(ev.path))
+
+	case watchRename:
+		return s.rename(ctx, ev, now)
+
+	case watchRescan:
+		return s.rescan(now)
+	}
+	return nil
+}
+
+// rename mirrors a local rename. When the source never reached Drive
+// the destination is uploaded instead. A file renamed over another
+// file becomes a new revision of the destination (keeping its
+// history) and the source is trashed.
+func (s *watchSyncer) rename(ctx context.Context, ev watchEvent, now time.Time) error {
+	src, err := s.lookup(ctx, ev.from)
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		s.state.remove(ev.from)
+		return s.queueTree(ev.path, ev.dir, now)
+	}
+
+	dir, name := path.Split(ev.path)
+	parent, err := s.dc.MkDirAll(ctx, s.share, s.root, dir)
+	if err != nil {
+		return err
+	}
+	target, err := parent.Lookup(ctx, name)
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		if target.Type() == proton.LinkTypeFolder || src.Type() == proton.LinkTypeFolder {
+			return fmt.Errorf("cannot replace '%s' on Drive", ev.path)
+		}
+		s.queue.add(watchEvent{op: watchWrite, path: ev.path}, now.Add(-s.queue.debounce))
+		if err := s.dc.Remove(ctx, s.share, src, drive.RemoveOpts{}); err != nil {
+			return err
+		}
+		s.state.remove(ev.from)
+		s.state.remove(ev.path)
+		return nil
+	}
+
+	if parent.LinkID() == src.ParentLink().LinkID() {
+		err = s.dc.Rename(ctx, s.share, src, name)
+	} else {
+		err = s.dc.Move(ctx, s.share, src, parent, name)
+	}
+	if err != nil {
+		return err
+	}
+	s.state.rename(ev.from, ev.path)
+	s.logf("renamed '%s' -> '%s'", s.remotePath(ev.from), s.remotePath(ev.path))
+	return nil
+}
+
+// rescan compares the whole local tree with the state and queues the
+// differences as due at now.
+func (s *watchSyncer) rescan(now time.Time) error {
+	events, err := s.state.diff(s.local)
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		s.queue.add(ev, now.Add(-s.queue.debounce))
+	}
+	return nil
+}
+
+// queueTree queues rel for upload: a single write for a file, or a
+// directory creation plus every entry below it for a directory.
+func (s *watchSyncer) queueTree(rel string, dir bool, now time.Time) error {
+	due := now.Add(-s.queue.debounce)
+	if !dir {
+		s.queue.add(watchEvent{op: watchWrite, path: rel}, due)
+		return nil
+	}
+	sub := &watchState{Entries: map[string]watchEntry{}}
+	events, err := sub.diff(localPath(s.local, rel))
+	if err != nil {
+		return err
+	}
+	s.queue.add(watchEvent{op: watchMkdir, path: rel, dir: true}, due)
+	for _, ev := range events {
+		ev.path = joinRel(rel, ev.path)
+		s.queue.add(ev, due)
+	}
+	return nil
+}
+
+// upload copies the local file rel to Drive, as a new revision when the
+// remote file exists. Files that vanished, are no longer regular, or
+// have not changed since the last upload are skipped.
+func (s *watchSyncer) upload(ctx context.Context, rel string) error {
+	f, err := os.Open(localPath(s.local, rel)) //nolint:gosec // path below the watched root
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // removed since; the remove event follows
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || s.state.unchanged(rel, info) {
+		return nil
+	}
+
+	dir, name := path.Split(rel)
+	parent, err := s.dc.MkDirAll(ctx, s.share, s.root, dir)
+	if err != nil {
+		return err
+	}
+	existing, err := parent.Lookup(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	var fd *drive.FileDescriptor
+	switch {
+	case existing == nil:
+		fd, err = s.dc.CreateFD(ctx, s.share, parent, name)
+	case existing.Type() == proton.LinkTypeFolder:
+		return fmt.Errorf("a directory exists on Drive")
+	case existing.HasActiveRevision():
+		fd, err = s.dc.OverwriteFD(ctx, s.share, existing)
+	default:
+		// Draft left by an interrupted upload — replace it.
+		if err := s.dc.Remove(ctx, s.share, existing, drive.RemoveOpts{Permanent: true}); err != nil {
+			return err
+		}
+		fd, err = s.dc.CreateFD(ctx, s.share, parent, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	fd.SetMode(uint32(info.Mode().Perm()))
+	fd.SetModTime(info.ModTime())
+	fd.SetCommitEmpty(true)
+	if _, err := io.Copy(fd, f); err != nil {
+		// Do not Close — that would commit the partial content.
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+
+	s.state.set(rel, entryFor(info))
+	s.logf("'%s' -> '%s'", localPath(s.local, rel), s.remotePath(rel))
+	return nil
+}
+
+// lookup resolves rel below the remote root, returning nil when it
+// does not exist.
+func (s *watchSyncer) lookup(ctx context.Context, rel string) (*drive.Link, error) {
+	link, err := s.root.ResolvePath(ctx, rel, true)
+	if errors.Is(err, drive.ErrFileNotFound) {
+		return nil, nil
+	}
+	return link, err
+}
+
+// remotePath returns the Drive path of rel for messages.
+func (s *watchSyncer) remotePath(rel string) string {
+	return strings.TrimSuffix(s.remote, "/") + "/" + rel
+}
+
+// logf prints a verbose progress line.
+func (s *watchSyncer) logf(format string, args ...any) {
+	if s.verbose {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-proton-api"
+	common "github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/drive"
+	"github.com/major0/proton-utils/api/lumo"
+	"github.com/major0/proton-utils/internal/cli/shortid"
+	"github.com/spf13/cobra"
+)
+
+// ExitCode is the process exit status proton returns for a class of
+// error. The values are stable so scripts can branch on them.
+type ExitCode int
+
+// Exit statuses. See the "Exit status" section of the README.
+const (
+	ExitOK          ExitCode = 0   // success
+	ExitFailure     ExitCode = 1   // any error not covered below
+	ExitUsage       ExitCode = 2   // invalid command, flag, argument or path
+	ExitNotFound    ExitCode = 3   // file, share, space or ID not found
+	ExitPermission  ExitCode = 4   // permission denied or plan not eligible
+	ExitConflict    ExitCode = 5   // the target already exists or is not empty
+	ExitQuota       ExitCode = 6   // storage quota exceeded
+	ExitRateLimited ExitCode = 7   // too many requests (HTTP 429)
+	ExitAuth        ExitCode = 8   // not logged in, session expired or verification required
+	ExitTimeout     ExitCode = 9   // a request or operation timed out
+	ExitUnavailable ExitCode = 10  // network failure or server error (HTTP 5xx)
+	ExitInterrupted ExitCode = 130 // canceled, e.g. by SIGINT
+)
+
+// exitNames are the error codes reported in structured error output.
+var exitNames = map[ExitCode]string{
+	ExitOK:          "ok",
+	ExitFailure:     "error",
+	ExitUsage:       "usage",
+	ExitNotFound:    "not_found",
+	ExitPermission:  "permission_denied",
+	ExitConflict:    "conflict",
+	ExitQuota:       "quota_exceeded",
+	ExitRateLimited: "rate_limited",
+	ExitAuth:        "unauthenticated",
+	ExitTimeout:     "timeout",
+	ExitUnavailable: "unavailable",
+	ExitInterrupted: "interrupted",
+}
+
+// String returns the error code name of c, e.g. "not_found".
+func (c ExitCode) String() string {
+	if name, ok := exitNames[c]; ok {
+		return name
+	}
+	return "error"
+}
+
+// Proton API error codes with a dedicated exit status.
+const (
+	apiCodeNotAllowed       = 2011
+	apiCodeAlreadyExists    = 2500
+	apiCodeNotFound         = 2501
+	apiCodeHV               = 9001
+	apiCodePaidPlanRequired = 10004
+	apiCodeRefreshInvalid   = 10013
+	apiCodeQuota            = 200001
+	apiCodeSpace            = 200002
+	apiCodeVolumeQuota      = 200100
+	apiCodeDeviceQuota      = 200101
+)
+
+// UsageError marks an error in how a command was invoked: an unknown
+// command or flag, a missing or extra argument, or a malformed operand.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// Usage wraps err in a UsageError. A nil err stays nil.
+func Usage(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UsageError{Err: err}
+}
+
+// Classify returns the exit status for err: ExitOK for nil, the class
+// of the first recognized error in its chain otherwise, and
+// ExitFailure when nothing is recognized.
+func Classify(err error) ExitCode {
+	if err == nil {
+		return ExitOK
+	}
+
+	var (
+		usageErr  *UsageError
+		ambiguous *shortid.AmbiguousError
+		notFound  *shortid.NotFoundError
+	)
+	switch {
+	case errors.As(err, &usageErr), errors.As(err, &ambiguous),
+		errors.Is(err, drive.ErrInvalidPath), errors.Is(err, drive.ErrNotAFolder),
+		errors.Is(err, drive.ErrNotASymlink), errors.Is(err, drive.ErrNotStandardShare):
+		return ExitUsage
+	case errors.As(err, &notFound), errors.Is(err, drive.ErrFileNotFound),
+		errors.Is(err, drive.ErrNoShareURL), errors.Is(err, lumo.ErrNotFound),
+		errors.Is(err, common.ErrKeyNotFound), errors.Is(err, fs.ErrNotExist):
+		return ExitNotFound
+	case errors.Is(err, lumo.ErrNotEligible), errors.Is(err, fs.ErrPermission):
+		return ExitPermission
+	case errors.Is(err, drive.ErrFileNameExist), errors.Is(err, drive.ErrDraftExist),
+		errors.Is(err, drive.ErrShareURLExists), errors.Is(err, drive.ErrNotEmpty),
+		errors.Is(err, proton.ErrFileNameExist), errors.Is(err, proton.ErrFolderNameExist),
+		errors.Is(err, proton.ErrADraftExist), errors.Is(err, lumo.ErrConflict),
+		errors.Is(err, fs.ErrExist):
+		// FileExistsError and DraftExistsError match ErrFileNameExist
+		// and ErrDraftExist.
+		return ExitConflict
+	case errors.Is(err, common.ErrNotLoggedIn), errors.Is(err, common.ErrMissingUID),
+		errors.Is(err, common.ErrMissingAccessToken), errors.Is(err, common.ErrMissingRefreshToken):
+		return ExitAuth
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.Is(err, lumo.ErrTimeout):
+		return ExitTimeout
+	case errors.Is(err, lumo.ErrStreamClosed), errors.Is(err, io.ErrUnexpectedEOF):
+		return ExitUnavailable
+	}
+
+	if status, code, ok := apiError(err); ok {
+		return classifyAPI(status, code)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ExitTimeout
+		}
+		return ExitUnavailable
+	}
+	return ExitFailure
+}
+
+// apiError returns the HTTP status and Proton error code of the first
+// API error in err's chain, from either this module's client or
+// go-proton-api.
+func apiError(err error) (status, code int, ok bool) {
+	var apiErr *common.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Status, apiErr.Code, true
+	}
+	var protonErr *proton.APIError
+	if errors.As(err, &protonErr) {
+		return protonErr.Status, int(protonErr.Code), true
+	}
+	return 0, 0, false
+}
+
+// classifyAPI maps an API error response to an exit status. The Proton
+// error code is more specific than the HTTP status and is checked first.
+func classifyAPI(status, code int) ExitCode {
+	switch code {
+	case apiCodeNotFound:
+		return ExitNotFound
+	case apiCodeAlreadyExists:
+		return ExitConflict
+	case apiCodeNotAllowed, apiCodePaidPlanRequired:
+		return ExitPermission
+	case apiCodeQuota, apiCodeSpace, apiCodeVolumeQuota, apiCodeDeviceQuota:
+		return ExitQuota
+	case apiCodeHV, apiCodeRefreshInvalid:
+		return ExitAuth
+	}
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return ExitAuth
+	case status == http.StatusForbidden:
+		return ExitPermission
+	case status == http.StatusNotFound:
+		return ExitNotFound
+	case status == http.StatusConflict:
+		return ExitConflict
+	case status == http.StatusInsufficientStorage:
+		return ExitQuota
+	case status == http.StatusTooManyRequests:
+		return ExitRateLimited
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		return ExitTimeout
+	case status >= 500:
+		return ExitUnavailable
+	}
+	return ExitFailure
+}
+
+// errorRecord is the structured form of a failed command, written to
+// standard error in --output json, yaml and ndjson modes.
+type errorRecord struct {
+	Error    string `json:"error"`
+	Code     string `json:"code"`
+	ExitCode int    `json:"exit_code"`
+	Status   int    `json:"status,omitempty"`   // HTTP status of an API error
+	APICode  int    `json:"api_code,omitempty"` // Proton error code of an API error
+}
+
+// newErrorRecord describes err.
+func newErrorRecord(err error) errorRecord {
+	exit := Classify(err)
+	rec := errorRecord{Error: err.Error(), Code: exit.String(), ExitCode: int(exit)}
+	rec.Status, rec.APICode, _ = apiError(err)
+	return rec
+}
+
+// ReportError writes err to w as cobra would, or as an error record when
+// cmd selected a structured output format.
+func ReportError(w io.Writer, cmd *cobra.Command, err error) {
+	format := Output(cmd)
+	if GetContext(cmd) == nil {
+		// The command failed before the root pre-run parsed --output.
+		format, _ = ParseOutputFormat(rootParams.Output)
+	}
+	if format == OutputText || format == "" {
+		_, _ = io.WriteString(w, "Error: "+err.Error()+"\n")
+		return
+	}
+	if format == OutputJSON {
+		// One compact line, so that a script reading standard error
+		// line by line sees the whole record.
+		format = OutputNDJSON
+	}
+	if werr := WriteObject(w, format, newErrorRecord(err)); werr != nil {
+		_, _ = io.WriteString(w, "Error: "+err.Error()+"\n")
+	}
+}
+
+// markUsageErrors wraps the argument validators of cmd and its
+// subcommands, and the errors cobra reports for unknown flags, so that
+// they classify as ExitUsage. Commands without an Args validator keep
+// cobra's legacy handling of unknown subcommands.
+func markUsageErrors(cmd *cobra.Command) {
+	if cmd.Args != nil {
+		validate := cmd.Args
+		cmd.Args = func(c *cobra.Command, args []string) error {
+			return Usage(validate(c, args))
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+// isCobraUsage reports whether err is one of the invocation errors cobra
+// raises without a hook: an unknown subcommand or a missing required
+// flag.
+func isCobraUsage(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "unknown command ") ||
+		strings.HasPrefix(msg, "required flag(s) ") ||
+		strings.HasPrefix(msg, "if any flags in the group ")
+}
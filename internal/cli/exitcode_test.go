@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	common "github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/drive"
+	"github.com/major0/proton-utils/api/lumo"
+	"github.com/major0/proton-utils/internal/cli/shortid"
+	"github.com/spf13/cobra"
+	"pgregory.net/rapid"
+)
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ExitCode
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", errors.New("boom"), ExitFailure},
+		{"usage", Usage(errors.New("accepts 1 arg(s), received 2")), ExitUsage},
+		{"invalid path", drive.ErrInvalidPath, ExitUsage},
+		{"ambiguous id", &shortid.AmbiguousError{Prefix: "ab"}, ExitUsage},
+		{"file not found", fmt.Errorf("stat: %w", drive.ErrFileNotFound), ExitNotFound},
+		{"id not found", &shortid.NotFoundError{Prefix: "ab"}, ExitNotFound},
+		{"lumo not found", lumo.ErrNotFound, ExitNotFound},
+		{"local not found", &fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}, ExitNotFound},
+		{"local permission", fs.ErrPermission, ExitPermission},
+		{"not eligible", lumo.ErrNotEligible, ExitPermission},
+		{"file exists", &drive.FileExistsError{}, ExitConflict},
+		{"draft exists", &drive.DraftExistsError{}, ExitConflict},
+		{"not empty", drive.ErrNotEmpty, ExitConflict},
+		{"lumo conflict", lumo.ErrConflict, ExitConflict},
+		{"not logged in", common.ErrNotLoggedIn, ExitAuth},
+		{"canceled", context.Canceled, ExitInterrupted},
+		{"deadline", fmt.Errorf("list: %w", context.DeadlineExceeded), ExitTimeout},
+		{"net timeout", timeoutError{}, ExitTimeout},
+		{"api 2501", &common.Error{Status: 422, Code: 2501}, ExitNotFound},
+		{"api 2500", &common.Error{Status: 422, Code: 2500}, ExitConflict},
+		{"api 2011", &common.Error{Status: 422, Code: 2011}, ExitPermission},
+		{"api quota", &common.Error{Status: 422, Code: 200001}, ExitQuota},
+		{"api 401", &common.Error{Status: 401, Code: 401}, ExitAuth},
+		{"api 403", &common.Error{Status: 403}, ExitPermission},
+		{"api 404", &common.Error{Status: 404}, ExitNotFound},
+		{"api 409", &common.Error{Status: 409}, ExitConflict},
+		{"api 429", &common.Error{Status: 429}, ExitRateLimited},
+		{"api 503", &common.Error{Status: 503}, ExitUnavailable},
+		{"api 400", &common.Error{Status: 400, Code: 2001}, ExitFailure},
+		{"proton hv", &proton.APIError{Status: 422, Code: proton.HumanVerificationRequired}, ExitAuth},
+		{"proton 429", fmt.Errorf("get: %w", &proton.APIError{Status: 429}), ExitRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %d (%s), want %d (%s)", tt.err, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+// TestClassify_Wrapped_Property verifies that wrapping an error with
+// context never changes its exit status.
+func TestClassify_Wrapped_Property(t *testing.T) {
+	errs := []error{
+		errors.New("boom"), drive.ErrFileNotFound, drive.ErrFileNameExist,
+		common.ErrNotLoggedIn, context.Canceled, &common.Error{Status: 429},
+		Usage(errors.New("bad flag")),
+	}
+	rapid.Check(t, func(t *rapid.T) {
+		err := rapid.SampledFrom(errs).Draw(t, "err")
+		want := Classify(err)
+		depth := rapid.IntRange(1, 4).Draw(t, "depth")
+		for i := range depth {
+			err = fmt.Errorf("layer %d: %w", i, err)
+		}
+		if got := Classify(err); got != want {
+			t.Fatalf("Classify(%v) = %d, want %d", err, got, want)
+		}
+	})
+}
+
+func TestExitCodeString(t *testing.T) {
+	seen := make(map[string]ExitCode)
+	for code, name := range exitNames {
+		if other, dup := seen[name]; dup {
+			t.Errorf("codes %d and %d share name %q", code, other, name)
+		}
+		seen[name] = code
+		if code.String() != name {
+			t.Errorf("ExitCode(%d).String() = %q, want %q", code, code.String(), name)
+		}
+	}
+	if got := ExitCode(42).String(); got != "error" {
+		t.Errorf("ExitCode(42).String() = %q, want %q", got, "error")
+	}
+}
+
+func TestReportError(t *testing.T) {
+	apiErr := fmt.Errorf("mkdir: %w", &common.Error{Status: 422, Code: 2500, Message: "exists"})
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"", "Error: mkdir: api: 422/2500: exists\n"},
+		{"json", `{"error":"mkdir: api: 422/2500: exists","code":"conflict","exit_code":5,"status":422,"api_code":2500}` + "\n"},
+		{"ndjson", `{"error":"mkdir: api: 422/2500: exists","code":"conflict","exit_code":5,"status":422,"api_code":2500}` + "\n"},
+		{"yaml", "error: 'mkdir: api: 422/2500: exists'\ncode: conflict\nexit_code: 5\nstatus: 422\napi_code: 2500\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			saved := rootParams.Output
+			defer func() { rootParams.Output = saved }()
+			rootParams.Output = tt.output
+
+			var buf bytes.Buffer
+			ReportError(&buf, &cobra.Command{}, apiErr)
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkUsageErrors(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	sub := &cobra.Command{Use: "sub", Args: cobra.ExactArgs(1), RunE: func(*cobra.Command, []string) error { return nil }}
+	root.AddCommand(sub)
+	markUsageErrors(root)
+
+	if root.Args != nil {
+		t.Error("root.Args set; want nil to keep unknown-command handling")
+	}
+	err := sub.ValidateArgs([]string{"a", "b"})
+	if Classify(err) != ExitUsage {
+		t.Errorf("ValidateArgs error %v classifies as %d, want ExitUsage", err, Classify(err))
+	}
+	if err := sub.ValidateArgs([]string{"a"}); err != nil {
+		t.Errorf("ValidateArgs(valid) = %v", err)
+	}
+}
+
+func TestIsCobraUsage(t *testing.T) {
+	for msg, want := range map[string]bool{
+		`unknown command "x" for "proton drive"`: true,
+		`required flag(s) "name" not set`:        true,
+		`unknown command`:                        false,
+		`mkdir: directory not empty`:             false,
+	} {
+		if got := isCobraUsage(errors.New(msg)); got != want {
+			t.Errorf("isCobraUsage(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package fsCmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/docker/go-units"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/major0/proton-utils/internal/control"
+	"github.com/spf13/cobra"
+)
+
+var fsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the running proton-fuse",
+	Long:  "Show the sessions, mounts and cache usage of each account of the running proton-fuse",
+	Args:  cobra.NoArgs,
+	RunE:  runStatus,
+}
+
+var fsRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refresh the shares of the running proton-fuse now",
+	Long:  "Reload the share lists and storage quotas of the running proton-fuse",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return callDaemon(cmd, control.CmdRefresh, nil, nil)
+	},
+}
+
+var fsDropCachesCmd = &cobra.Command{
+	Use:   "drop-caches",
+	Short: "Empty the caches of the running proton-fuse",
+	Long: `Empty the caches of the running proton-fuse
+
+Drops the link table, the on-disk object cache and the in-memory block
+cache, so that metadata and file content are fetched again. Pinned data
+and queued uploads are kept.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return callDaemon(cmd, control.CmdDropCaches, nil, nil)
+	},
+}
+
+var fsFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Upload the write-back queue of the running proton-fuse",
+	Long: `Upload the write-back queue of the running proton-fuse
+
+Retries failed uploads at once and waits until every file queued for
+upload has been uploaded.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return callDaemon(cmd, control.CmdFlush, nil, nil)
+	},
+}
+
+var fsLogLevelCmd = &cobra.Command{
+	Use:       "log-level <debug|info|warn|error>",
+	Short:     "Set the log level of the running proton-fuse",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"debug", "info", "warn", "error"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return callDaemon(cmd, control.CmdLogLevel, control.LogLevelArgs{Level: args[0]}, nil)
+	},
+}
+
+func init() {
+	fsCmd.AddCommand(fsStatusCmd)
+	fsCmd.AddCommand(fsRefreshCmd)
+	fsCmd.AddCommand(fsDropCachesCmd)
+	fsCmd.AddCommand(fsFlushCmd)
+	fsCmd.AddCommand(fsLogLevelCmd)
+}
+
+// callDaemon sends a control command to the running proton-fuse.
+func callDaemon(cmd *cobra.Command, command string, args, result any) error {
+	path, err := control.SocketPath()
+	if err != nil {
+		return err
+	}
+	return control.Call(cmd.Context(), path, command, args, result)
+}
+
+// tryDaemon is callDaemon for commands that can also run without
+// proton-fuse: it reports false when proton-fuse is not running.
+func tryDaemon(ctx context.Context, command string, args, result any) (bool, error) {
+	path, err := control.SocketPath()
+	if err != nil {
+		return false, nil
+	}
+	err = control.Call(ctx, path, command, args, result)
+	if errors.Is(err, control.ErrNotRunning) {
+		return false, nil
+	}
+	return true, err
+}
+
+// tryAccountDaemon is tryDaemon for commands acting on an account: it
+// also reports false when the running proton-fuse does not mount it.
+func tryAccountDaemon(ctx context.Context, account, command string, args, result any) (bool, error) {
+	var st control.Status
+	if ok, err := tryDaemon(ctx, control.CmdStatus, nil, &st); !ok || err != nil {
+		return ok, err
+	}
+	if !slices.ContainsFunc(st.Accounts, func(a control.Account) bool { return a.Name == account }) {
+		return false, nil
+	}
+	return tryDaemon(ctx, command, args, result)
+}
+
+func runStatus(cmd *cobra.Command, _ []string) error {
+	var st control.Status
+	if err := callDaemon(cmd, control.CmdStatus, nil, &st); err != nil {
+		return err
+	}
+	if cli.Structured(cmd) {
+		return cli.PrintObject(cmd, st)
+	}
+
+	fmt.Printf("proton-fuse:   pid %d, up %s\n", st.PID, time.Since(st.Started).Round(time.Second))
+	fmt.Printf("log level:     %s\n", st.LogLevel)
+	for _, a := range st.Accounts {
+		state := "online"
+		if a.Offline {
+			state = "offline"
+		}
+		name := a.Name
+		if name == st.Account {
+			name += " (default)"
+		}
+		fmt.Printf("\naccount:       %s, %s\n", name, state)
+		if !a.TokenRefresh.IsZero() {
+			fmt.Printf("session:       token refreshed %s (%s ago)\n",
+				cli.FormatLocalTime(a.TokenRefresh), time.Since(a.TokenRefresh).Round(time.Second))
+		}
+		for _, m := range a.Mounts {
+			fmt.Printf("mount:         %s (%s, %d shares)\n", m.Path, m.Namespace, m.Shares)
+		}
+		fmt.Printf("link table:    %d links\n", a.Cache.Links)
+		fmt.Printf("object cache:  %d objects\n", a.Cache.Objects)
+		fmt.Printf("block cache:   %d blocks, %s\n", a.Cache.Blocks, units.BytesSize(float64(a.Cache.BlockBytes)))
+		fmt.Printf("pins:          %d, %s\n", a.Cache.Pins, units.BytesSize(float64(a.Cache.PinBytes)))
+		fmt.Printf("upload queue:  %d jobs\n", a.Cache.Queued)
+	}
+	return nil
+}
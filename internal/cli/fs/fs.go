@@ -0,0 +1,22 @@
+// Package fsCmd implements the fs subcommands for proton-cli, which
+// manage how ProtonFS keeps Proton Drive data on this machine.
+package fsCmd
+
+import (
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+var fsCmd = &cobra.Command{
+	Use:               "fs",
+	Short:             "Manage ProtonFS local data",
+	Long:              "Manage the Proton Drive data ProtonFS keeps on this machine",
+	PersistentPreRunE: cli.ServicePreRunE("drive"),
+	Run: func(cmd *cobra.Command, _ []string) {
+		_ = cmd.Help()
+	},
+}
+
+func init() {
+	cli.AddCommand(fsCmd)
+}
@@ -0,0 +1,204 @@
+package fsCmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/major0/proton-utils/api/drive"
+	cli "github.com/major0/proton-utils/internal/cli"
+	driveCmd "github.com/major0/proton-utils/internal/cli/drive"
+	"github.com/major0/proton-utils/internal/control"
+	"github.com/major0/proton-utils/internal/keyring"
+	"github.com/spf13/cobra"
+)
+
+var fsPinCmd = &cobra.Command{
+	Use:   "pin [<path> ...]",
+	Short: "Keep files or folders available offline",
+	Long: `Keep files or folders available offline
+
+Pinned files and folders are downloaded into the pin cache, where they
+are kept until unpinned. ProtonFS serves pinned data while the Proton
+API is unreachable and refreshes it while the mount is running. While
+proton-fuse runs, it downloads new pins itself. Without arguments,
+lists the pins.`,
+	RunE: runPin,
+}
+
+var fsUnpinCmd = &cobra.Command{
+	Use:   "unpin <path> ...",
+	Short: "Stop keeping files or folders available offline",
+	Long:  "Remove pins and erase the pinned data no other pin references",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runUnpin,
+}
+
+func init() {
+	fsCmd.AddCommand(fsPinCmd)
+	fsCmd.AddCommand(fsUnpinCmd)
+}
+
+// pinRecord is the structured form of a pin.
+type pinRecord struct {
+	Path    string    `json:"path"`
+	ShareID string    `json:"share_id"`
+	LinkID  string    `json:"link_id"`
+	Files   int       `json:"files"`
+	Bytes   int64     `json:"bytes"`
+	Synced  time.Time `json:"synced,omitzero"`
+}
+
+// openPinStore opens the pin store of the current account, which is
+// shared with proton-fuse.
+func openPinStore(cmd *cobra.Command) (*drive.PinStore, error) {
+	rc := cli.GetContext(cmd)
+	return drive.OpenPinStore(keyring.XDGCachePath(filepath.Join("pinned", rc.Account)))
+}
+
+func runPin(cmd *cobra.Command, args []string) error {
+	rc := cli.GetContext(cmd)
+	pins, err := openPinStore(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return listPins(cmd, pins)
+	}
+
+	ctx := context.Background()
+	session, err := cli.SetupSession(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	dc, err := cli.NewDriveClient(ctx, session)
+	if err != nil {
+		return err
+	}
+	dc.Pins = pins
+
+	for _, arg := range args {
+		link, share, err := driveCmd.ResolveProtonPath(ctx, dc, arg)
+		if err != nil {
+			return fmt.Errorf("pin: %w", err)
+		}
+		pin := drive.Pin{
+			ShareID: share.ProtonShare().ShareID,
+			LinkID:  link.ProtonLink().LinkID,
+			Path:    arg,
+		}
+		pin, err = syncPin(ctx, dc, pins, pin, rc.Account)
+		if err != nil {
+			return fmt.Errorf("pin: %s: %w", arg, err)
+		}
+		if !cli.Structured(cmd) {
+			fmt.Printf("%s: %d files, %s\n", arg, pin.Files, units.BytesSize(float64(pin.Bytes)))
+		}
+	}
+	if cli.Structured(cmd) {
+		return listPins(cmd, pins)
+	}
+	return nil
+}
+
+// syncPin adds pin and downloads it, through the running proton-fuse
+// when it mounts account.
+func syncPin(ctx context.Context, dc *drive.Client, pins *drive.PinStore, pin drive.Pin, account string) (drive.Pin, error) {
+	args := control.PinArgs{Account: account, ShareID: pin.ShareID, LinkID: pin.LinkID, Path: pin.Path}
+	var synced drive.Pin
+	if ok, err := tryAccountDaemon(ctx, account, control.CmdPin, args, &synced); ok {
+		return synced, err
+	}
+	if err := pins.Add(pin); err != nil {
+		return pin, err
+	}
+	return dc.SyncPin(ctx, pin)
+}
+
+// listPins prints the pins.
+func listPins(cmd *cobra.Command, pins *drive.PinStore) error {
+	list, err := pins.List()
+	if err != nil {
+		return err
+	}
+	if cli.Structured(cmd) {
+		records := make([]pinRecord, len(list))
+		for i, pin := range list {
+			records[i] = pinRecord{
+				Path:    pin.Path,
+				ShareID: pin.ShareID,
+				LinkID:  pin.LinkID,
+				Files:   pin.Files,
+				Bytes:   pin.Bytes,
+				Synced:  pin.Synced,
+			}
+		}
+		return cli.PrintRecords(cmd, records)
+	}
+	for _, pin := range list {
+		synced := "never synced"
+		if !pin.Synced.IsZero() {
+			synced = "synced " + cli.FormatLocalTime(pin.Synced)
+		}
+		fmt.Printf("%-40s %6d files %10s  %s\n", pin.Path, pin.Files, units.BytesSize(float64(pin.Bytes)), synced)
+	}
+	return nil
+}
+
+func runUnpin(cmd *cobra.Command, args []string) error {
+	rc := cli.GetContext(cmd)
+	pins, err := openPinStore(cmd)
+	if err != nil {
+		return err
+	}
+	list, err := pins.List()
+	if err != nil {
+		return err
+	}
+
+	// Pins are matched by the path they were pinned by first, so that a
+	// pin whose link is gone can still be removed.
+	ctx := context.Background()
+	var dc *drive.Client
+	for _, arg := range args {
+		linkID := ""
+		for _, pin := range list {
+			if pin.Path == arg {
+				linkID = pin.LinkID
+			}
+		}
+		if linkID == "" {
+			if dc == nil {
+				session, err := cli.SetupSession(ctx, cmd)
+				if err != nil {
+					return err
+				}
+				if dc, err = cli.NewDriveClient(ctx, session); err != nil {
+					return err
+				}
+			}
+			link, _, err := driveCmd.ResolveProtonPath(ctx, dc, arg)
+			if err != nil {
+				return fmt.Errorf("unpin: %w", err)
+			}
+			linkID = link.ProtonLink().LinkID
+		}
+		unpin := control.UnpinArgs{Account: rc.Account, LinkID: linkID}
+		if ok, err := tryAccountDaemon(ctx, rc.Account, control.CmdUnpin, unpin, nil); ok {
+			if err != nil {
+				return fmt.Errorf("unpin: %s: %w", arg, err)
+			}
+			continue
+		}
+		removed, err := pins.Remove(linkID)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("unpin: %s: not pinned", arg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package lumoCmd
+
+import (
+	"context"
+	"strings"
+
+	common "github.com/major0/proton-utils/api"
+	"github.com/major0/proton-utils/api/lumo"
+	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/major0/proton-utils/internal/cli/shortid"
+	"github.com/spf13/cobra"
+)
+
+// Completion cache keys.
+const (
+	completeKeyConversations = "lumo/conversations"
+	completeKeySpaces        = "lumo/spaces"
+)
+
+func init() {
+	chatResumeCmd.ValidArgsFunction = completeConversationID
+	chatDeleteCmd.ValidArgsFunction = completeConversationID
+	chatLogCmd.ValidArgsFunction = completeConversationID
+	spaceConfigCmd.ValidArgsFunction = completeSpaceID
+	spaceDeleteCmd.ValidArgsFunction = func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeSpaceID(cmd, nil, toComplete)
+	}
+	_ = chatCmd.RegisterFlagCompletionFunc("space", func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeSpaceID(cmd, nil, toComplete)
+	})
+}
+
+// completeConversationID completes a conversation ID as its short form,
+// described by the conversation title.
+func completeConversationID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	candidates := cli.CompleteCached(cmd, completeKeyConversations, fetchConversationCandidates)
+	return cli.FilterCompletions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeSpaceID completes a space ID as its short form, described by
+// the space name.
+func completeSpaceID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	candidates := cli.CompleteCached(cmd, completeKeySpaces, fetchSpaceCandidates)
+	return cli.FilterCompletions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// fetchConversationCandidates lists the active conversations of all
+// spaces with their decrypted titles.
+func fetchConversationCandidates(ctx context.Context, session *common.Session) ([]string, error) {
+	client := lumo.NewClient(session)
+	pairs, err := client.ListAllConversations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deks := map[string][]byte{}
+	var ids, titles []string
+	for _, p := range pairs {
+		if p.Conversation.DeleteTime != "" || p.Space.DeleteTime != "" {
+			continue
+		}
+		dek, ok := deks[p.Space.ID]
+		if !ok {
+			dek, _ = client.DeriveSpaceDEK(ctx, p.Space)
+			deks[p.Space.ID] = dek
+		}
+		title := ""
+		if dek != nil {
+			title = decryptConversationTitle(p.Conversation, dek, p.Space.SpaceTag)
+		}
+		ids = append(ids, p.Conversation.ID)
+		titles = append(titles, title)
+	}
+	return idCandidates(ids, titles), nil
+}
+
+// fetchSpaceCandidates lists the spaces that are not deleted with their
+// decrypted names.
+func fetchSpaceCandidates(ctx context.Context, session *common.Session) ([]string, error) {
+	client := lumo.NewClient(session)
+	spaces, err := client.ListSpaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids, names []string
+	for i := range spaces {
+		if spaces[i].DeleteTime != "" {
+			continue
+		}
+		ids = append(ids, spaces[i].ID)
+		names = append(names, decryptSpaceName(ctx, client, &spaces[i]))
+	}
+	return idCandidates(ids, names), nil
+}
+
+// idCandidates pairs the short form of each ID with its description in
+// cobra's "value\tdescription" form. Tabs and newlines in descriptions
+// are replaced with spaces.
+func idCandidates(ids, descs []string) []string {
+	short := shortid.FormatShortIDs(ids)
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		value := id
+		if s, ok := short[id]; ok {
+			value = s
+		}
+		desc := strings.Join(strings.Fields(descs[i]), " ")
+		if desc == "" {
+			out[i] = value
+			continue
+		}
+		out[i] = value + "\t" + desc
+	}
+	return out
+}
@@ -0,0 +1,18 @@
+package lumoCmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIDCandidates(t *testing.T) {
+	ids := []string{"AAAAAAAAaaaa==", "AAAAAAAAbbbb==", "ZZZZZZZZZZZZ=="}
+	descs := []string{"Trip\tplanning", "", "Multi\nline  title"}
+	want := []string{"AAAAAAAAa\tTrip planning", "AAAAAAAAb", "ZZZZZZZZ\tMulti line title"}
+	if got := idCandidates(ids, descs); !reflect.DeepEqual(got, want) {
+		t.Errorf("idCandidates = %q, want %q", got, want)
+	}
+	if got := idCandidates(nil, nil); len(got) != 0 {
+		t.Errorf("idCandidates(nil) = %q, want empty", got)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/major0/proton-utils/api/lumo"
+	"github.com/major0/proton-utils/internal/metrics"
 )
 
 // chatHandler returns an http.HandlerFunc that proxies OpenAI-format chat
@@ -139,6 +140,21 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// metricsMiddleware records the latency of each request by the route
+// pattern it matched in the mux below it; requests that match no route
+// are recorded as "none".
+func metricsMiddleware(h *metrics.HistogramVec, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		route := r.Pattern
+		if route == "" {
+			route = "none"
+		}
+		h.ObserveSince(route, start)
+	})
+}
+
 // statusWriter wraps http.ResponseWriter to capture the status code.
 type statusWriter struct {
 	http.ResponseWriter
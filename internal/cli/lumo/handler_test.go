@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/major0/proton-utils/api/lumo"
+	"github.com/major0/proton-utils/internal/metrics"
 )
 
 // TestMapLumoError_Table verifies the error mapping table from Lumo sentinel
@@ -111,3 +112,31 @@ func TestLoggingMiddleware(t *testing.T) {
 		t.Error("log contains request body content")
 	}
 }
+
+// TestMetricsMiddleware verifies that requests are recorded by the route
+// they matched, and unmatched requests as "none".
+func TestMetricsMiddleware(t *testing.T) {
+	reg := metrics.NewRegistry()
+	h := reg.HistogramVec("requests", "", "route")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/models", modelsHandler())
+	handler := metricsMiddleware(h, mux)
+
+	for _, path := range []string{"/v1/models", "/v1/models", "/nope"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var out strings.Builder
+	if err := reg.Write(&out, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`requests_count{route="GET /v1/models"} 2`,
+		`requests_count{route="none"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics lack %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/major0/proton-utils/api/lumo"
 	cli "github.com/major0/proton-utils/internal/cli"
+	"github.com/major0/proton-utils/internal/metrics"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +26,7 @@ type serveFlags struct {
 	tlsCert   string
 	tlsKey    string
 	noTLS     bool
+	metrics   string
 }
 
 var sFlags serveFlags
@@ -41,6 +45,7 @@ func init() {
 	serveCmd.Flags().StringVar(&sFlags.tlsCert, "tls-cert", "", "Custom TLS certificate path")
 	serveCmd.Flags().StringVar(&sFlags.tlsKey, "tls-key", "", "Custom TLS key path")
 	serveCmd.Flags().BoolVar(&sFlags.noTLS, "no-tls", false, "Disable TLS, serve plain HTTP")
+	serveCmd.Flags().StringVar(&sFlags.metrics, "metrics-listen", "", "Serve metrics on host:port or unix:path")
 }
 
 func runServe(cmd *cobra.Command, _ []string) error {
@@ -67,7 +72,21 @@ func runServe(cmd *cobra.Command, _ []string) error {
 	mux.HandleFunc("POST /v1/chat/completions", chatHandler(client))
 	mux.HandleFunc("GET /v1/models", modelsHandler())
 
-	handler := authMiddleware(apiKey, loggingMiddleware(mux))
+	var inner http.Handler = loggingMiddleware(mux)
+	var metricsListener net.Listener
+	reg := metrics.NewRegistry()
+	if sFlags.metrics != "" {
+		metricsListener, err = metrics.Listen(sFlags.metrics)
+		if err != nil {
+			return fmt.Errorf("opening metrics listener: %w", err)
+		}
+		requests := reg.HistogramVec("proton_lumo_request_duration_seconds",
+			"Latency of OpenAI-compatible API requests.", "route")
+		metrics.RegisterSession(reg, session)
+		inner = metricsMiddleware(requests, inner)
+	}
+
+	handler := authMiddleware(apiKey, inner)
 	srv := &http.Server{
 		Addr:              sFlags.addr,
 		Handler:           handler,
@@ -85,6 +104,14 @@ func runServe(cmd *cobra.Command, _ []string) error {
 		_ = srv.Shutdown(shutdownCtx)
 	}()
 
+	if metricsListener != nil {
+		go func() {
+			if err := metrics.Serve(sigCtx, metricsListener, reg); err != nil {
+				slog.Warn("metrics listener failed", "error", err)
+			}
+		}()
+	}
+
 	printBanner(sFlags, apiKey, certFile)
 
 	if sFlags.noTLS {
@@ -141,5 +168,8 @@ func printBanner(f serveFlags, apiKey, certFile string) {
 	if certFile != "" {
 		fmt.Fprintf(os.Stderr, "  TLS Cert:  %s\n", certFile)
 	}
+	if f.metrics != "" {
+		fmt.Fprintf(os.Stderr, "  Metrics:   %s\n", f.metrics)
+	}
 	fmt.Fprintf(os.Stderr, "\n")
 }
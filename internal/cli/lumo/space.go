@@ -62,7 +62,7 @@ func runSpaceList(cmd *cobra.Command, _ []string) error {
 	}
 
 	if spaceShowEmpty {
-		return runSpaceListEmpty(ctx, client, spaces)
+		return runSpaceListEmpty(cmd, client, spaces)
 	}
 
 	// Filter by type: -A shows all, --simple shows simple, default shows projects.
@@ -89,6 +89,10 @@ func runSpaceList(cmd *cobra.Command, _ []string) error {
 		spaces = visible
 	}
 
+	if cli.Structured(cmd) {
+		return cli.PrintRecords(cmd, buildSpaceRecords(ctx, client, spaces))
+	}
+
 	rows := buildSpaceRows(ctx, client, spaces)
 
 	// Apply short IDs.
@@ -109,8 +113,10 @@ func runSpaceList(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
-// runSpaceListEmpty identifies, verifies, and lists empty spaces.
-func runSpaceListEmpty(ctx context.Context, client *lumo.Client, spaces []lumo.Space) error {
+// runSpaceListEmpty identifies, verifies, and lists empty spaces. With
+// --output only the verified spaces are written, as space records.
+func runSpaceListEmpty(cmd *cobra.Command, client *lumo.Client, spaces []lumo.Space) error {
+	ctx := cmd.Context()
 	total := len(spaces)
 
 	// Phase 1: identify candidates (0 embedded conversations).
@@ -159,6 +165,13 @@ func runSpaceListEmpty(ctx context.Context, client *lumo.Client, spaces []lumo.S
 	}
 
 	// Phase 3: list verified empty spaces.
+	if cli.Structured(cmd) {
+		empty := make([]lumo.Space, len(verified))
+		for i := range verified {
+			empty[i] = verified[i].space
+		}
+		return cli.PrintRecords(cmd, buildSpaceRecords(ctx, client, empty))
+	}
 	if len(verified) == 0 {
 		_, _ = fmt.Fprintf(os.Stdout, "No empty spaces found (%d total).\n", total)
 		return nil
@@ -288,6 +301,41 @@ func buildSpaceRows(ctx context.Context, client *lumo.Client, spaces []lumo.Spac
 	return rows
 }
 
+// spaceRecord is the --output schema of one space in space list. IDs
+// are always in full. Type is "project", "simple" or "unknown" when the
+// space metadata cannot be decrypted.
+type spaceRecord struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	CreateTime    string `json:"create_time"`
+	Conversations int    `json:"conversations"`
+	Encrypted     bool   `json:"encrypted"`
+	Deleted       bool   `json:"deleted"`
+}
+
+// buildSpaceRecords converts API spaces into --output records, newest
+// first like FormatSpaceList.
+func buildSpaceRecords(ctx context.Context, client *lumo.Client, spaces []lumo.Space) []spaceRecord {
+	records := make([]spaceRecord, len(spaces))
+	for i := range spaces {
+		s := &spaces[i]
+		records[i] = spaceRecord{
+			ID:            s.ID,
+			Name:          decryptSpaceName(ctx, client, s),
+			Type:          classifySpace(ctx, client, s),
+			CreateTime:    s.CreateTime,
+			Conversations: len(s.Conversations),
+			Encrypted:     s.Encrypted != "",
+			Deleted:       s.DeleteTime != "",
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreateTime > records[j].CreateTime
+	})
+	return records
+}
+
 // filterProjectSpaces returns only spaces that are project spaces
 // (isProject=true in encrypted metadata). Simple chat spaces and
 // spaces that can't be decrypted are excluded.
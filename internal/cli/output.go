@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat selects how commands print their results.
+type OutputFormat string
+
+// Output formats accepted by --output.
+const (
+	OutputText   OutputFormat = "text"   // human-readable tables (default)
+	OutputJSON   OutputFormat = "json"   // one indented JSON document
+	OutputYAML   OutputFormat = "yaml"   // one YAML document
+	OutputNDJSON OutputFormat = "ndjson" // one compact JSON object per line
+)
+
+// ParseOutputFormat validates an --output value. The empty string
+// selects OutputText.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(s); f {
+	case "":
+		return OutputText, nil
+	case OutputText, OutputJSON, OutputYAML, OutputNDJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid --output %q (use: text, json, yaml, ndjson)", s)
+}
+
+// Output returns the output format selected for cmd, OutputText when
+// none was selected.
+func Output(cmd *cobra.Command) OutputFormat {
+	if rc := GetContext(cmd); rc != nil && rc.Output != "" {
+		return rc.Output
+	}
+	return OutputText
+}
+
+// Structured reports whether cmd should print machine-readable records
+// instead of human-readable text.
+func Structured(cmd *cobra.Command) bool {
+	return Output(cmd) != OutputText
+}
+
+// RecordWriter writes a list of records in a structured format. JSON
+// and YAML collect the records and write a single array on Close;
+// NDJSON writes each record as it arrives so long listings stream.
+//
+// Records are encoded via their JSON tags in every format, so the field
+// names of a schema are the same in JSON and YAML.
+type RecordWriter struct {
+	w       io.Writer
+	format  OutputFormat
+	records []any
+}
+
+// NewRecordWriter returns a RecordWriter for format writing to w.
+func NewRecordWriter(w io.Writer, format OutputFormat) *RecordWriter {
+	return &RecordWriter{w: w, format: format}
+}
+
+// Write adds one record.
+func (rw *RecordWriter) Write(v any) error {
+	if rw.format == OutputNDJSON {
+		return json.NewEncoder(rw.w).Encode(v)
+	}
+	rw.records = append(rw.records, v)
+	return nil
+}
+
+// Close writes the collected records. An empty list is written as an
+// empty array, never as null.
+func (rw *RecordWriter) Close() error {
+	if rw.format == OutputNDJSON {
+		return nil
+	}
+	records := rw.records
+	if records == nil {
+		records = []any{}
+	}
+	return WriteObject(rw.w, rw.format, records)
+}
+
+// WriteRecords writes records as a list in format.
+func WriteRecords[T any](w io.Writer, format OutputFormat, records []T) error {
+	rw := NewRecordWriter(w, format)
+	for i := range records {
+		if err := rw.Write(records[i]); err != nil {
+			return err
+		}
+	}
+	return rw.Close()
+}
+
+// WriteObject writes a single value in format. NDJSON writes it on one
+// line.
+func WriteObject(w io.Writer, format OutputFormat, v any) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputNDJSON:
+		return json.NewEncoder(w).Encode(v)
+	case OutputYAML:
+		return writeYAML(w, v)
+	}
+	return fmt.Errorf("output format %q is not structured", format)
+}
+
+// PrintRecords writes records to stdout in cmd's output format.
+func PrintRecords[T any](cmd *cobra.Command, records []T) error {
+	return WriteRecords(os.Stdout, Output(cmd), records)
+}
+
+// PrintObject writes v to stdout in cmd's output format.
+func PrintObject(cmd *cobra.Command, v any) error {
+	return WriteObject(os.Stdout, Output(cmd), v)
+}
+
+// writeYAML encodes v as YAML by way of its JSON encoding, so the JSON
+// tags and field order of v apply. JSON is valid YAML; the parsed node
+// tree is restyled into block form before encoding.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	blockStyle(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// blockStyle clears the flow and quoting styles that parsing JSON leaves
+// on n and its children. Scalar tags are made explicit first, so strings
+// that would read back as another type are still quoted.
+func blockStyle(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode {
+		n.Tag = n.ShortTag()
+	}
+	if n.Kind == yaml.ScalarNode || len(n.Content) > 0 {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"pgregory.net/rapid"
+)
+
+type testRecord struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    OutputFormat
+		wantErr bool
+	}{
+		{"", OutputText, false},
+		{"text", OutputText, false},
+		{"json", OutputJSON, false},
+		{"yaml", OutputYAML, false},
+		{"ndjson", OutputNDJSON, false},
+		{"JSON", "", true},
+		{"xml", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOutputFormat(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v; want %q, err %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteRecords(t *testing.T) {
+	records := []testRecord{{Name: "a.txt", Size: 3}, {Name: "123", Size: 0, Tag: "true"}}
+	tests := []struct {
+		format  OutputFormat
+		records []testRecord
+		want    string
+	}{
+		{OutputJSON, records, `[
+  {
+    "name": "a.txt",
+    "size": 3
+  },
+  {
+    "name": "123",
+    "size": 0,
+    "tag": "true"
+  }
+]
+`},
+		{OutputNDJSON, records, `{"name":"a.txt","size":3}
+{"name":"123","size":0,"tag":"true"}
+`},
+		{OutputYAML, records, `- name: a.txt
+  size: 3
+- name: "123"
+  size: 0
+  tag: "true"
+`},
+		{OutputJSON, nil, "[]\n"},
+		{OutputYAML, nil, "[]\n"},
+		{OutputNDJSON, nil, ""},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteRecords(&buf, tt.format, tt.records); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestWriteObject(t *testing.T) {
+	v := struct {
+		ID    string       `json:"id"`
+		Items []testRecord `json:"items"`
+		Max   *int64       `json:"max"`
+	}{ID: "x", Items: []testRecord{}}
+
+	tests := []struct {
+		format OutputFormat
+		want   string
+	}{
+		{OutputNDJSON, `{"id":"x","items":[],"max":null}` + "\n"},
+		{OutputYAML, "id: x\nitems: []\nmax: null\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteObject(&buf, tt.format, v); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	if err := WriteObject(&bytes.Buffer{}, OutputText, v); err == nil {
+		t.Error("WriteObject(text) succeeded, want error")
+	}
+}
+
+// TestWriteRecords_SameData_Property verifies that every structured
+// format carries the same data: NDJSON lines and the YAML document
+// decode to the JSON array.
+func TestWriteRecords_SameData_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		records := rapid.SliceOfN(rapid.Custom(func(t *rapid.T) testRecord {
+			return testRecord{
+				Name: rapid.StringMatching(`[a-z0-9 :#'"\-]{0,10}`).Draw(t, "name"),
+				Size: rapid.Int64().Draw(t, "size"),
+				Tag:  rapid.SampledFrom([]string{"", "yes", "null", "1.5", "~"}).Draw(t, "tag"),
+			}
+		}), 1, 5).Draw(t, "records")
+
+		encode := func(f OutputFormat) []byte {
+			var buf bytes.Buffer
+			if err := WriteRecords(&buf, f, records); err != nil {
+				t.Fatalf("%s: %v", f, err)
+			}
+			return buf.Bytes()
+		}
+
+		var want []any
+		if err := json.Unmarshal(encode(OutputJSON), &want); err != nil {
+			t.Fatal(err)
+		}
+
+		var lines []any
+		for _, line := range strings.Split(strings.TrimSuffix(string(encode(OutputNDJSON)), "\n"), "\n") {
+			var v any
+			if err := json.Unmarshal([]byte(line), &v); err != nil {
+				t.Fatalf("ndjson line %q: %v", line, err)
+			}
+			lines = append(lines, v)
+		}
+		if !reflect.DeepEqual(lines, want) {
+			t.Fatalf("ndjson = %v, want %v", lines, want)
+		}
+
+		var fromYAML []any
+		if err := yaml.Unmarshal(encode(OutputYAML), &fromYAML); err != nil {
+			t.Fatal(err)
+		}
+		// Normalize YAML's integer types through JSON.
+		data, err := json.Marshal(fromYAML)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []any
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("yaml = %v, want %v", got, want)
+		}
+	})
+}
@@ -27,6 +27,7 @@ type rootParamsType struct {
 	Verbose            int
 	Timeout            time.Duration
 	AppVersionOverride string
+	Output             string
 }
 
 var (
@@ -67,7 +68,12 @@ var (
 					logLevel.Set(slog.LevelWarn)
 				}
 			default:
-				return fmt.Errorf("invalid --log-level %q (use: debug, info, warn, error)", rootParams.LogLevel)
+				return Usage(fmt.Errorf("invalid --log-level %q (use: debug, info, warn, error)", rootParams.LogLevel))
+			}
+
+			output, err := ParseOutputFormat(rootParams.Output)
+			if err != nil {
+				return Usage(err)
 			}
 
 			if logLevel.Level() <= slog.LevelDebug {
@@ -113,6 +119,7 @@ var (
 				Config:             cfg,
 				SessionFile:        rootParams.SessionFile,
 				Verbose:            rootParams.Verbose,
+				Output:             output,
 			}
 
 			// Apply CLI flag overrides to Config Params.
@@ -199,6 +206,21 @@ func resolveMaxJobs(cmd *cobra.Command, rc *RuntimeContext) int {
 	return common.DefaultMaxWorkers()
 }
 
+// shared holds the session and Drive client of a long-lived command such
+// as "drive shell". While set, SetupSession and NewDriveClient return them
+// instead of restoring a new session, so the commands it runs in-process
+// share one session, Link Table and object cache.
+var shared struct {
+	session *common.Session
+	dc      *drive.Client
+}
+
+// ShareSession makes SetupSession and NewDriveClient return session and
+// dc until it is called again with nil arguments.
+func ShareSession(session *common.Session, dc *drive.Client) {
+	shared.session, shared.dc = session, dc
+}
+
 // SetupSession returns a fully initialized, ready-to-use session by
 // reading all per-invocation state from RuntimeContext. It calls
 // api/account/ restore primitives, sets BaseURL/AppVersion/UserAgent,
@@ -208,6 +230,10 @@ func resolveMaxJobs(cmd *cobra.Command, rc *RuntimeContext) int {
 // it uses RestoreServiceSession which handles auto-forking from the
 // account session.
 func SetupSession(ctx context.Context, cmd *cobra.Command) (*common.Session, error) {
+	if shared.session != nil {
+		return shared.session, nil
+	}
+
 	rc := GetContext(cmd)
 
 	if rc.ServiceName != "" && rc.ServiceName != "*" {
@@ -261,6 +287,9 @@ func requestTimeoutHook(_ *proton.Manager) {
 // NewDriveClient creates a drive client from a session and applies config
 // from RuntimeContext.
 func NewDriveClient(ctx context.Context, session *common.Session) (*drive.Client, error) {
+	if shared.dc != nil && shared.dc.Session == session {
+		return shared.dc, nil
+	}
 	dc, err := drive.NewClient(ctx, session)
 	if err != nil {
 		return nil, err
@@ -270,11 +299,19 @@ func NewDriveClient(ctx context.Context, session *common.Session) (*drive.Client
 	return dc, nil
 }
 
-// Execute runs the root command and exits on error.
+// Execute runs the root command and exits on error with the status
+// Classify assigns to it.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	markUsageErrors(rootCmd)
+	cmd, err := rootCmd.ExecuteC()
+	if err == nil {
+		return
+	}
+	if isCobraUsage(err) {
+		err = Usage(err)
 	}
+	ReportError(os.Stderr, cmd, err)
+	os.Exit(int(Classify(err)))
 }
 
 // ConfigFilePath returns the resolved config file path.
@@ -298,6 +335,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&rootParams.SessionFile, "session-file", "", "Session file to use. Defaults to value XDG_CACHE_FILE")
 	rootCmd.PersistentFlags().DurationVarP(&rootParams.Timeout, "timeout", "t", 60*time.Second, "Timeout for requests.")
 	rootCmd.PersistentFlags().IntVarP(&rootParams.MaxWorkers, "max-jobs", "j", 10, "Maximum number of jobs to run in parallel.")
+	rootCmd.PersistentFlags().StringVarP(&rootParams.Output, "output", "o", "", "Output format: text, json, yaml, ndjson")
 	rootCmd.PersistentFlags().StringVar(&rootParams.AppVersionOverride, "app-version", "", "Override the app version string for this invocation")
 
 	// Profile flag — only registers when built with -tags profile.
@@ -306,6 +344,11 @@ func init() {
 	// Hide the help flags as it ends up sorted into everything, which is a bit confusing.
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
 	rootCmd.SilenceUsage = true
+	// Execute reports errors itself, as records in structured output.
+	rootCmd.SilenceErrors = true
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return Usage(err)
+	})
 	rootCmd.PersistentFlags().BoolP("help", "h", false, "Help for proton-utils")
 	rootCmd.PersistentFlags().Lookup("help").Hidden = true
 }
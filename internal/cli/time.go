@@ -26,3 +26,12 @@ func FormatEpoch(epoch int64) string {
 	}
 	return time.Unix(epoch, 0).Local().Format("2006-01-02")
 }
+
+// EpochTime converts a Unix epoch to a UTC time.Time for structured
+// output. Returns the zero time for zero.
+func EpochTime(epoch int64) time.Time {
+	if epoch == 0 {
+		return time.Time{}
+	}
+	return time.Unix(epoch, 0).UTC()
+}
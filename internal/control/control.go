@@ -0,0 +1,162 @@
+// Package control implements the control API of a running proton-fuse:
+// a Unix socket under $XDG_RUNTIME_DIR/proton/ that accepts one JSON
+// request per connection and answers with one JSON response. Only
+// processes of the user running proton-fuse may connect.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Commands understood by proton-fuse.
+const (
+	CmdStatus     = "status"      // no args; returns Status
+	CmdRefresh    = "refresh"     // no args; refreshes shares and quota of every account
+	CmdDropCaches = "drop-caches" // no args; empties the link, object and block caches
+	CmdFlush      = "flush"       // no args; uploads the write-back queues and waits for them
+	CmdPin        = "pin"         // PinArgs; adds and downloads a pin
+	CmdUnpin      = "unpin"       // UnpinArgs; removes a pin
+	CmdLogLevel   = "log-level"   // LogLevelArgs; sets the log level
+)
+
+// ErrNotRunning is returned by Call when no proton-fuse listens on the
+// socket.
+var ErrNotRunning = errors.New("proton-fuse is not running")
+
+// Request is a control request.
+type Request struct {
+	Command string          `json:"command"`
+	Args    json.RawMessage `json:"args,omitempty"`
+}
+
+// Response is the answer to a Request. Error is set when the command
+// failed, otherwise Result holds its result, if any.
+type Response struct {
+	Error  string          `json:"error,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// Status is the result of CmdStatus.
+type Status struct {
+	PID      int       `json:"pid"`
+	Account  string    `json:"account"` // the default account
+	Started  time.Time `json:"started"`
+	LogLevel string    `json:"log_level"`
+	Accounts []Account `json:"accounts"` // the default first
+}
+
+// Account is the status of an account served by proton-fuse.
+type Account struct {
+	Name         string    `json:"name"`
+	TokenRefresh time.Time `json:"token_refresh,omitzero"` // last session token refresh
+	Offline      bool      `json:"offline"`
+	Mounts       []Mount   `json:"mounts"`
+	Cache        Cache     `json:"cache"`
+}
+
+// Mount describes a namespace mounted by proton-fuse. Path is where the
+// namespace appears below Mountpoint.
+type Mount struct {
+	Mountpoint string `json:"mountpoint"`
+	Namespace  string `json:"namespace"`
+	Path       string `json:"path"`
+	Shares     int    `json:"shares"`
+}
+
+// Cache reports the cache usage of proton-fuse.
+type Cache struct {
+	Links      int   `json:"links"`       // entries in the link table
+	Objects    int   `json:"objects"`     // API objects in the on-disk object cache
+	Blocks     int   `json:"blocks"`      // blocks in the in-memory block cache
+	BlockBytes int64 `json:"block_bytes"` // bytes held by those blocks
+	Pins       int   `json:"pins"`
+	PinBytes   int64 `json:"pin_bytes"`
+	Queued     int   `json:"queued"` // write-back jobs waiting for upload
+}
+
+// PinArgs are the arguments of CmdPin. An empty Account is the default
+// account.
+type PinArgs struct {
+	Account string `json:"account,omitempty"`
+	ShareID string `json:"share_id"`
+	LinkID  string `json:"link_id"`
+	Path    string `json:"path"`
+}
+
+// UnpinArgs are the arguments of CmdUnpin, with Account as in PinArgs.
+type UnpinArgs struct {
+	Account string `json:"account,omitempty"`
+	LinkID  string `json:"link_id"`
+}
+
+// LogLevelArgs are the arguments of CmdLogLevel. Level is one of debug,
+// info, warn or error.
+type LogLevelArgs struct {
+	Level string `json:"level"`
+}
+
+// SocketPath returns the path of the control socket,
+// $XDG_RUNTIME_DIR/proton/control.sock.
+func SocketPath() (string, error) {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		return "", errors.New("XDG_RUNTIME_DIR is not set")
+	}
+	return filepath.Join(runtimeDir, "proton", "control.sock"), nil
+}
+
+// Call sends command with args to the proton-fuse listening on path and
+// decodes the result into result, which may be nil. Returns
+// ErrNotRunning when nothing listens on path.
+func Call(ctx context.Context, path, command string, args, result any) error {
+	req := Request{Command: command}
+	if args != nil {
+		data, err := json.Marshal(args)
+		if err != nil {
+			return fmt.Errorf("%s: %w", command, err)
+		}
+		req.Args = data
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return ErrNotRunning
+		}
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Unblock the exchange when ctx is cancelled.
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: %w", command, ctx.Err())
+		}
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%s: %s", command, resp.Error)
+	}
+	if result != nil && resp.Result != nil {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("%s: %w", command, err)
+		}
+	}
+	return nil
+}
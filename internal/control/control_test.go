@@ -0,0 +1,135 @@
+//go:build linux
+
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startServer returns a serving Server on a socket in a temp directory.
+func startServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "proton", "control.sock")
+	s, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go s.Serve()
+	t.Cleanup(func() { _ = s.Close() })
+	return s, path
+}
+
+func TestCall_RoundTrip(t *testing.T) {
+	s, path := startServer(t)
+	s.Handle(CmdLogLevel, func(_ context.Context, args json.RawMessage) (any, error) {
+		var a LogLevelArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return nil, err
+		}
+		return Status{LogLevel: a.Level}, nil
+	})
+
+	var st Status
+	if err := Call(context.Background(), path, CmdLogLevel, LogLevelArgs{Level: "debug"}, &st); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if st.LogLevel != "debug" {
+		t.Errorf("LogLevel = %q, want debug", st.LogLevel)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %04o, want 0600", perm)
+	}
+}
+
+func TestCall_Errors(t *testing.T) {
+	s, path := startServer(t)
+	s.Handle(CmdRefresh, func(context.Context, json.RawMessage) (any, error) {
+		return nil, errors.New("API unreachable")
+	})
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"handler error", CmdRefresh, "refresh: API unreachable"},
+		{"unknown command", "bogus", `bogus: unknown command "bogus"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Call(context.Background(), path, tt.command, nil, nil)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("Call error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCall_NotRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	if err := Call(context.Background(), path, CmdStatus, nil, nil); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Call error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestCall_CancelStopsHandler(t *testing.T) {
+	s, path := startServer(t)
+	stopped := make(chan struct{})
+	s.Handle(CmdFlush, func(ctx context.Context, _ json.RawMessage) (any, error) {
+		<-ctx.Done()
+		close(stopped)
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Call(ctx, path, CmdFlush, nil, nil); err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("Call error = %v, want deadline exceeded", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("handler not cancelled after the client went away")
+	}
+}
+
+func TestListen_InUse(t *testing.T) {
+	_, path := startServer(t)
+	if _, err := Listen(path); err == nil {
+		t.Fatal("Listen on a live socket succeeded")
+	}
+}
+
+func TestListen_ReplacesStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen over a stale socket: %v", err)
+	}
+	_ = s.Close()
+}
+
+func TestClose_RemovesSocket(t *testing.T) {
+	s, path := startServer(t)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Lstat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket left after Close: %v", err)
+	}
+}
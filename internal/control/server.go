@@ -0,0 +1,182 @@
+//go:build linux
+
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// HandlerFunc runs a command. args holds the raw arguments of the
+// request, or nil. The result, if not nil, is encoded as JSON. ctx is
+// cancelled when the client disconnects or the server is closed.
+type HandlerFunc func(ctx context.Context, args json.RawMessage) (any, error)
+
+// Server serves the control socket.
+type Server struct {
+	ln   *net.UnixListener
+	path string
+	uid  uint32
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+}
+
+// Listen creates the control socket at path, mode 0600 in a directory
+// of mode 0700. A stale socket left by a process that exited is
+// replaced; a socket another proton-fuse listens on is an error.
+func Listen(path string) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("control socket %s: already in use", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		ln:       ln,
+		path:     path,
+		uid:      uint32(os.Getuid()), //nolint:gosec // UID fits uint32 on Linux
+		ctx:      ctx,
+		cancel:   cancel,
+		handlers: make(map[string]HandlerFunc),
+	}, nil
+}
+
+// Handle registers fn for command.
+func (s *Server) Handle(command string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = fn
+}
+
+// Serve accepts connections until Close is called.
+func (s *Server) Serve() {
+	for {
+		conn, err := s.ln.AcceptUnix()
+		if err != nil {
+			if s.ctx.Err() == nil {
+				slog.Warn("control: accept failed", "error", err)
+			}
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections, cancels running commands, waits
+// for them and removes the socket.
+func (s *Server) Close() error {
+	s.cancel()
+	err := s.ln.Close()
+	s.wg.Wait()
+	if rerr := os.Remove(s.path); rerr != nil && !errors.Is(rerr, os.ErrNotExist) {
+		err = errors.Join(err, rerr)
+	}
+	return err
+}
+
+// serveConn answers the single request of a connection.
+func (s *Server) serveConn(conn *net.UnixConn) {
+	defer func() { _ = conn.Close() }()
+	enc := json.NewEncoder(conn)
+
+	uid, err := peerUID(conn)
+	if err != nil || uid != s.uid {
+		slog.Warn("control: rejected connection", "uid", uid, "error", err)
+		_ = enc.Encode(Response{Error: "permission denied"})
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		_ = enc.Encode(Response{Error: "invalid request: " + err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	fn := s.handlers[req.Command]
+	s.mu.Unlock()
+	if fn == nil {
+		_ = enc.Encode(Response{Error: fmt.Sprintf("unknown command %q", req.Command)})
+		return
+	}
+
+	// The client sends nothing after its request: a read returning
+	// means it went away.
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	go func() {
+		var buf [1]byte
+		_, _ = conn.Read(buf[:])
+		cancel()
+	}()
+
+	slog.Debug("control: request", "command", req.Command)
+	result, err := fn(ctx, req.Args)
+	if err != nil {
+		_ = enc.Encode(Response{Error: err.Error()})
+		return
+	}
+	var resp Response
+	if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			_ = enc.Encode(Response{Error: err.Error()})
+			return
+		}
+		resp.Result = data
+	}
+	_ = enc.Encode(resp)
+}
+
+// peerUID returns the UID of the process at the other end of conn.
+func peerUID(conn *net.UnixConn) (uint32, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, credErr
+	}
+	return cred.Uid, nil
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"runtime/debug"
 	"syscall"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
@@ -28,6 +29,14 @@ var _ = (fs.NodeFsyncer)((*DispatchNode)(nil))
 var _ = (fs.NodeUnlinker)((*DispatchNode)(nil))
 var _ = (fs.NodeRmdirer)((*DispatchNode)(nil))
 var _ = (fs.NodeRenamer)((*DispatchNode)(nil))
+var _ = (fs.NodeSymlinker)((*DispatchNode)(nil))
+var _ = (fs.NodeReadlinker)((*DispatchNode)(nil))
+var _ = (fs.NodeGetxattrer)((*DispatchNode)(nil))
+var _ = (fs.NodeSetxattrer)((*DispatchNode)(nil))
+var _ = (fs.NodeRemovexattrer)((*DispatchNode)(nil))
+var _ = (fs.NodeListxattrer)((*DispatchNode)(nil))
+var _ = (fs.NodeStatfser)((*DispatchNode)(nil))
+var _ = (fs.NodeOnForgetter)((*DispatchNode)(nil))
 
 // DispatchNode bridges a namespace handler's Node to go-fuse's InodeEmbedder.
 // It operates in two modes:
@@ -38,8 +47,31 @@ type DispatchNode struct {
 	handler NamespaceHandler // always set (for capability checks)
 	node    Node             // nil when isRoot=true
 	isRoot  bool
-	uid     uint32 // owner UID — propagated to all child nodes
-	gid     uint32 // owner GID — propagated to all child nodes
+	uid     uint32        // owner UID — propagated to all child nodes
+	gid     uint32        // owner GID — propagated to all child nodes
+	tracker *inodeTracker // remote change tracking — propagated to all child nodes
+	ops     OpObserver    // operation latencies — propagated to all child nodes
+}
+
+// newChild creates the inode for a node returned by the handler and
+// registers it for remote change tracking.
+func (d *DispatchNode) newChild(ctx context.Context, n Node, mode uint32) (*DispatchNode, *fs.Inode) {
+	childNode := &DispatchNode{handler: d.handler, node: n, isRoot: false, uid: d.uid, gid: d.gid, tracker: d.tracker, ops: d.ops}
+	inode := d.NewInode(ctx, childNode, fs.StableAttr{Mode: mode})
+	d.tracker.add(childNode)
+	return childNode, inode
+}
+
+// observe records the latency of an operation started at start.
+func (d *DispatchNode) observe(op string, start time.Time) {
+	if d.ops != nil {
+		d.ops.ObserveSince(op, start)
+	}
+}
+
+// OnForget stops tracking the inode once the kernel has forgotten it.
+func (d *DispatchNode) OnForget() {
+	d.tracker.remove(d)
 }
 
 // checkAccess verifies the calling process UID matches the daemon owner.
@@ -68,6 +100,7 @@ func (d *DispatchNode) checkAccess(ctx context.Context) syscall.Errno {
 // For namespace roots (isRoot=true), Getattr is allowed without access
 // check so the redirector and mount-root ls can stat the "drive/" entry.
 func (d *DispatchNode) Getattr(ctx context.Context, _ fs.FileHandle, out *fuse.AttrOut) (errno syscall.Errno) {
+	defer d.observe("getattr", time.Now())
 	if !d.isRoot {
 		if err := d.checkAccess(ctx); err != 0 {
 			return err
@@ -84,6 +117,7 @@ func (d *DispatchNode) Getattr(ctx context.Context, _ fs.FileHandle, out *fuse.A
 	if d.isRoot {
 		attr, errno = d.handler.Getattr(ctx)
 	} else {
+		d.tracker.touch(d)
 		attr, errno = d.node.Getattr(ctx)
 	}
 	if errno != 0 {
@@ -111,6 +145,7 @@ func (d *DispatchNode) Getattr(ctx context.Context, _ fs.FileHandle, out *fuse.A
 // Nodes that don't implement NodeSetattrer get silent success (0).
 // ENOSYS is never returned — go-fuse caches it per-connection.
 func (d *DispatchNode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.SetAttrIn, _ *fuse.AttrOut) syscall.Errno {
+	defer d.observe("setattr", time.Now())
 	if d.isRoot {
 		return syscall.EPERM
 	}
@@ -162,6 +197,7 @@ func (d *DispatchNode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.Se
 
 // Readdir returns directory entries, delegating to the handler or DirNode.
 func (d *DispatchNode) Readdir(ctx context.Context) (stream fs.DirStream, errno syscall.Errno) {
+	defer d.observe("readdir", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return nil, err
 	}
@@ -200,6 +236,7 @@ func (d *DispatchNode) Readdir(ctx context.Context) (stream fs.DirStream, errno
 
 // Lookup finds a child node by name, delegating to the handler or DirNode.
 func (d *DispatchNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (child *fs.Inode, errno syscall.Errno) {
+	defer d.observe("lookup", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return nil, err
 	}
@@ -219,6 +256,7 @@ func (d *DispatchNode) Lookup(ctx context.Context, name string, out *fuse.EntryO
 		if !ok {
 			return nil, syscall.ENOTDIR
 		}
+		d.tracker.touch(d)
 		n, errno = dir.Lookup(ctx, name)
 	}
 	if errno != 0 {
@@ -241,13 +279,13 @@ func (d *DispatchNode) Lookup(ctx context.Context, name string, out *fuse.EntryO
 		out.Gid = d.gid
 	}
 
-	childNode := &DispatchNode{handler: d.handler, node: n, isRoot: false, uid: d.uid, gid: d.gid}
-	inode := d.NewInode(ctx, childNode, fs.StableAttr{Mode: mode})
+	_, inode := d.newChild(ctx, n, mode)
 	return inode, 0
 }
 
 // Create delegates to NodeCreator if the handler supports it.
 func (d *DispatchNode) Create(ctx context.Context, name string, flags uint32, mode uint32, _ *fuse.EntryOut) (inode *fs.Inode, fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
+	defer d.observe("create", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return nil, nil, 0, err
 	}
@@ -277,13 +315,14 @@ func (d *DispatchNode) Create(ctx context.Context, name string, flags uint32, mo
 		return nil, nil, 0, errno
 	}
 
-	childNode := &DispatchNode{handler: d.handler, node: n, isRoot: false, uid: d.uid, gid: d.gid}
-	child := d.NewInode(ctx, childNode, fs.StableAttr{Mode: syscall.S_IFREG})
+	childNode, child := d.newChild(ctx, n, syscall.S_IFREG)
+	d.tracker.opened(childNode, 1)
 	return child, &dispatchFileHandle{handle: handle}, 0, 0
 }
 
 // Mkdir delegates to NodeMkdirer if the handler supports it.
 func (d *DispatchNode) Mkdir(ctx context.Context, name string, mode uint32, _ *fuse.EntryOut) (inode *fs.Inode, errno syscall.Errno) {
+	defer d.observe("mkdir", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return nil, err
 	}
@@ -312,16 +351,195 @@ func (d *DispatchNode) Mkdir(ctx context.Context, name string, mode uint32, _ *f
 		return nil, errno
 	}
 
-	childNode := &DispatchNode{handler: d.handler, node: n, isRoot: false, uid: d.uid, gid: d.gid}
-	child := d.NewInode(ctx, childNode, fs.StableAttr{Mode: syscall.S_IFDIR})
+	_, child := d.newChild(ctx, n, syscall.S_IFDIR)
 	return child, 0
 }
 
+// Symlink delegates to NodeSymlinker if the handler supports it.
+func (d *DispatchNode) Symlink(ctx context.Context, target, name string, _ *fuse.EntryOut) (inode *fs.Inode, errno syscall.Errno) {
+	defer d.observe("symlink", time.Now())
+	if err := d.checkAccess(ctx); err != 0 {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in handler Symlink: %v\n%s", r, debug.Stack())
+			inode = nil
+			errno = syscall.EIO
+		}
+	}()
+
+	var parent interface{}
+	if d.isRoot {
+		parent = d.handler
+	} else {
+		parent = d.node
+	}
+
+	symlinker, ok := parent.(NodeSymlinker)
+	if !ok {
+		return nil, syscall.EPERM
+	}
+
+	n, errno := symlinker.Symlink(ctx, target, name)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	_, child := d.newChild(ctx, n, syscall.S_IFLNK)
+	return child, 0
+}
+
+// Readlink delegates to NodeReadlinker if the node is a symlink.
+func (d *DispatchNode) Readlink(ctx context.Context) (target []byte, errno syscall.Errno) {
+	defer d.observe("readlink", time.Now())
+	if err := d.checkAccess(ctx); err != 0 {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in handler Readlink: %v\n%s", r, debug.Stack())
+			target = nil
+			errno = syscall.EIO
+		}
+	}()
+
+	readlinker, ok := d.node.(NodeReadlinker)
+	if d.isRoot || !ok {
+		return nil, syscall.EINVAL
+	}
+
+	t, errno := readlinker.Readlink(ctx)
+	if errno != 0 {
+		return nil, errno
+	}
+	return []byte(t), 0
+}
+
+// Getxattr delegates to NodeXattrReader. A dest of length 0 queries
+// the value size; a short dest yields ERANGE and the required size.
+func (d *DispatchNode) Getxattr(ctx context.Context, attr string, dest []byte) (size uint32, errno syscall.Errno) {
+	defer d.observe("getxattr", time.Now())
+	if err := d.checkAccess(ctx); err != 0 {
+		return 0, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in handler Getxattr: %v\n%s", r, debug.Stack())
+			size = 0
+			errno = syscall.EIO
+		}
+	}()
+
+	reader, ok := d.node.(NodeXattrReader)
+	if d.isRoot || !ok {
+		return 0, syscall.ENODATA
+	}
+
+	val, errno := reader.Getxattr(ctx, attr)
+	if errno != 0 {
+		return 0, errno
+	}
+	return copyXattr(dest, val)
+}
+
+// Listxattr delegates to NodeXattrReader. Names are written
+// NUL-terminated; nodes without extended attributes list nothing.
+func (d *DispatchNode) Listxattr(ctx context.Context, dest []byte) (size uint32, errno syscall.Errno) {
+	defer d.observe("listxattr", time.Now())
+	if err := d.checkAccess(ctx); err != 0 {
+		return 0, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in handler Listxattr: %v\n%s", r, debug.Stack())
+			size = 0
+			errno = syscall.EIO
+		}
+	}()
+
+	reader, ok := d.node.(NodeXattrReader)
+	if d.isRoot || !ok {
+		return 0, 0
+	}
+
+	names, errno := reader.Listxattr(ctx)
+	if errno != 0 {
+		return 0, errno
+	}
+	var buf []byte
+	for _, name := range names {
+		buf = append(buf, name...)
+		buf = append(buf, 0)
+	}
+	return copyXattr(dest, buf)
+}
+
+// Setxattr delegates to NodeXattrWriter.
+func (d *DispatchNode) Setxattr(ctx context.Context, attr string, data []byte, flags uint32) (errno syscall.Errno) {
+	defer d.observe("setxattr", time.Now())
+	if err := d.checkAccess(ctx); err != 0 {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in handler Setxattr: %v\n%s", r, debug.Stack())
+			errno = syscall.EIO
+		}
+	}()
+
+	writer, ok := d.node.(NodeXattrWriter)
+	if d.isRoot || !ok {
+		return syscall.ENOTSUP
+	}
+	return writer.Setxattr(ctx, attr, data, flags)
+}
+
+// Removexattr delegates to NodeXattrWriter.
+func (d *DispatchNode) Removexattr(ctx context.Context, attr string) (errno syscall.Errno) {
+	defer d.observe("removexattr", time.Now())
+	if err := d.checkAccess(ctx); err != 0 {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in handler Removexattr: %v\n%s", r, debug.Stack())
+			errno = syscall.EIO
+		}
+	}()
+
+	writer, ok := d.node.(NodeXattrWriter)
+	if d.isRoot || !ok {
+		return syscall.ENOTSUP
+	}
+	return writer.Removexattr(ctx, attr)
+}
+
+// copyXattr implements the getxattr(2) size protocol: an empty dest
+// asks for the size, a short dest fails with ERANGE.
+func copyXattr(dest, val []byte) (uint32, syscall.Errno) {
+	size := uint32(len(val)) //nolint:gosec // xattr values are bounded by XATTR_SIZE_MAX
+	if len(dest) == 0 {
+		return size, 0
+	}
+	if len(dest) < len(val) {
+		return size, syscall.ERANGE
+	}
+	return uint32(copy(dest, val)), 0 //nolint:gosec // bounded by len(val)
+}
+
 // Open delegates to NodeOpener, NodeReader, or NodeWriter if the node supports it.
 func (d *DispatchNode) Open(ctx context.Context, flags uint32) (fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
+	defer d.observe("open", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return nil, 0, err
 	}
+	// Deferred first so it sees the errno set by the panic handler.
+	defer func() {
+		if errno == 0 {
+			d.tracker.opened(d, 1)
+		}
+	}()
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("panic in handler Open: %v\n%s", r, debug.Stack())
@@ -356,9 +574,11 @@ func (d *DispatchNode) Open(ctx context.Context, flags uint32) (fh fs.FileHandle
 
 // Release delegates to NodeReleaser if the node supports it.
 func (d *DispatchNode) Release(ctx context.Context, f fs.FileHandle) (errno syscall.Errno) {
+	defer d.observe("release", time.Now())
 	if d.isRoot || d.node == nil {
 		return 0
 	}
+	d.tracker.opened(d, -1)
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("panic in handler Release: %v\n%s", r, debug.Stack())
@@ -380,6 +600,7 @@ func (d *DispatchNode) Release(ctx context.Context, f fs.FileHandle) (errno sysc
 
 // Fsync delegates to NodeFsyncer if the node supports it.
 func (d *DispatchNode) Fsync(ctx context.Context, f fs.FileHandle, flags uint32) (errno syscall.Errno) {
+	defer d.observe("fsync", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return err
 	}
@@ -411,6 +632,7 @@ func (d *DispatchNode) Fsync(ctx context.Context, f fs.FileHandle, flags uint32)
 // close(2) — unlike Release, the calling process blocks until Flush returns.
 // This ensures write-mode FDs commit their revision before the caller proceeds.
 func (d *DispatchNode) Flush(ctx context.Context, f fs.FileHandle) (errno syscall.Errno) {
+	defer d.observe("flush", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return err
 	}
@@ -440,6 +662,7 @@ func (d *DispatchNode) Flush(ctx context.Context, f fs.FileHandle) (errno syscal
 
 // Read delegates to NodeReader if the node supports it.
 func (d *DispatchNode) Read(ctx context.Context, f fs.FileHandle, dest []byte, off int64) (res fuse.ReadResult, errno syscall.Errno) {
+	defer d.observe("read", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return nil, err
 	}
@@ -474,6 +697,7 @@ func (d *DispatchNode) Read(ctx context.Context, f fs.FileHandle, dest []byte, o
 
 // Write delegates to NodeWriter if the node supports it.
 func (d *DispatchNode) Write(ctx context.Context, f fs.FileHandle, data []byte, off int64) (written uint32, errno syscall.Errno) {
+	defer d.observe("write", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return 0, err
 	}
@@ -504,6 +728,7 @@ func (d *DispatchNode) Write(ctx context.Context, f fs.FileHandle, data []byte,
 
 // Unlink delegates to NodeRemover if the handler supports it.
 func (d *DispatchNode) Unlink(ctx context.Context, name string) (errno syscall.Errno) {
+	defer d.observe("unlink", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return err
 	}
@@ -531,6 +756,7 @@ func (d *DispatchNode) Unlink(ctx context.Context, name string) (errno syscall.E
 
 // Rmdir delegates to NodeRemover if the handler supports it.
 func (d *DispatchNode) Rmdir(ctx context.Context, name string) (errno syscall.Errno) {
+	defer d.observe("rmdir", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return err
 	}
@@ -558,6 +784,7 @@ func (d *DispatchNode) Rmdir(ctx context.Context, name string) (errno syscall.Er
 
 // Rename delegates to NodeRenamer if the handler supports it.
 func (d *DispatchNode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, _ uint32) (errno syscall.Errno) {
+	defer d.observe("rename", time.Now())
 	if err := d.checkAccess(ctx); err != 0 {
 		return err
 	}
@@ -593,3 +820,44 @@ func (d *DispatchNode) Rename(ctx context.Context, name string, newParent fs.Ino
 type dispatchFileHandle struct {
 	handle FileHandle
 }
+
+// statfsBlockSize is the block size statfs reports usage in.
+const statfsBlockSize = 4096
+
+// statfsNameLen is the maximum file name length statfs reports.
+const statfsNameLen = 255
+
+// Statfs reports the space usage of the node's namespace. Namespaces
+// whose handler does not implement NamespaceStatfser report no space.
+func (d *DispatchNode) Statfs(ctx context.Context, out *fuse.StatfsOut) (errno syscall.Errno) {
+	defer d.observe("statfs", time.Now())
+	if err := d.checkAccess(ctx); err != 0 {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in handler Statfs: %v\n%s", r, debug.Stack())
+			errno = syscall.EIO
+		}
+	}()
+
+	var usage Usage
+	if s, ok := d.handler.(NamespaceStatfser); ok {
+		if usage, errno = s.Statfs(ctx); errno != 0 {
+			return errno
+		}
+	}
+	fillStatfs(usage, out)
+	return 0
+}
+
+// fillStatfs converts usage to statfs blocks.
+func fillStatfs(usage Usage, out *fuse.StatfsOut) {
+	free := min(usage.Free, usage.Total)
+	out.Bsize = statfsBlockSize
+	out.Frsize = statfsBlockSize
+	out.Blocks = usage.Total / statfsBlockSize
+	out.Bfree = free / statfsBlockSize
+	out.Bavail = free / statfsBlockSize
+	out.NameLen = statfsNameLen
+}
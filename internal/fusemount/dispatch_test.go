@@ -4,8 +4,11 @@ package fusemount
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
 )
@@ -72,6 +75,75 @@ func (m *mockRemoverHandler) Rmdir(_ context.Context, _ string) syscall.Errno {
 	return 0
 }
 
+// mockSymlinkerHandler implements NamespaceHandler + NodeSymlinker.
+type mockSymlinkerHandler struct {
+	mockHandler
+}
+
+func (m *mockSymlinkerHandler) Symlink(_ context.Context, target, _ string) (Node, syscall.Errno) {
+	return &mockSymlinkNode{target: target}, 0
+}
+
+// mockSymlinkNode implements Node + NodeReadlinker.
+type mockSymlinkNode struct {
+	target string
+}
+
+func (m *mockSymlinkNode) Getattr(_ context.Context) (Attr, syscall.Errno) {
+	return Attr{Mode: syscall.S_IFLNK | 0777, Size: uint64(len(m.target))}, 0
+}
+
+func (m *mockSymlinkNode) Readlink(_ context.Context) (string, syscall.Errno) {
+	return m.target, 0
+}
+
+// mockXattrNode implements Node + NodeXattrReader + NodeXattrWriter.
+type mockXattrNode struct {
+	xattrs map[string][]byte
+}
+
+func (m *mockXattrNode) Getattr(_ context.Context) (Attr, syscall.Errno) {
+	return Attr{Mode: syscall.S_IFREG | 0644}, 0
+}
+
+func (m *mockXattrNode) Getxattr(_ context.Context, attr string) ([]byte, syscall.Errno) {
+	v, ok := m.xattrs[attr]
+	if !ok {
+		return nil, syscall.ENODATA
+	}
+	return v, 0
+}
+
+func (m *mockXattrNode) Listxattr(_ context.Context) ([]string, syscall.Errno) {
+	names := make([]string, 0, len(m.xattrs))
+	for k := range m.xattrs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names, 0
+}
+
+func (m *mockXattrNode) Setxattr(_ context.Context, attr string, data []byte, _ uint32) syscall.Errno {
+	m.xattrs[attr] = data
+	return 0
+}
+
+func (m *mockXattrNode) Removexattr(_ context.Context, attr string) syscall.Errno {
+	delete(m.xattrs, attr)
+	return 0
+}
+
+// mockStatfsHandler implements NamespaceHandler + NamespaceStatfser.
+type mockStatfsHandler struct {
+	mockHandler
+	usage Usage
+	errno syscall.Errno
+}
+
+func (m *mockStatfsHandler) Statfs(_ context.Context) (Usage, syscall.Errno) {
+	return m.usage, m.errno
+}
+
 // panicHandler panics on every method call.
 type panicHandler struct {
 	msg string
@@ -124,6 +196,35 @@ func TestDispatchNodeGetattr_ChildNode(t *testing.T) {
 	}
 }
 
+// recordingOps records the operations passed to ObserveSince.
+type recordingOps struct {
+	ops []string
+}
+
+func (r *recordingOps) ObserveSince(op string, _ time.Time) {
+	r.ops = append(r.ops, op)
+}
+
+func TestDispatchNode_ObservesOps(t *testing.T) {
+	ops := &recordingOps{}
+	h := &mockHandler{}
+	n := &mockDirNode{attr: Attr{Mode: syscall.S_IFDIR | 0755}}
+	d := &DispatchNode{handler: h, node: n, ops: ops}
+
+	var out fuse.AttrOut
+	_ = d.Getattr(context.Background(), nil, &out)
+	_, _ = d.Readdir(context.Background())
+
+	// A panicking handler is still observed.
+	p := &DispatchNode{handler: &panicHandler{msg: "test panic in getattr"}, isRoot: true, ops: ops}
+	_ = p.Getattr(context.Background(), nil, &out)
+
+	want := []string{"getattr", "readdir", "getattr"}
+	if !slices.Equal(ops.ops, want) {
+		t.Errorf("observed %v, want %v", ops.ops, want)
+	}
+}
+
 func TestDispatchNodeGetattr_TimestampPropagation(t *testing.T) {
 	h := &mockHandler{}
 	n := &mockNode{attr: Attr{
@@ -317,6 +418,151 @@ func TestDispatchNodeMkdir_Unsupported(t *testing.T) {
 	}
 }
 
+func TestDispatchNodeSymlink_Supported(t *testing.T) {
+	// Like Mkdir, Symlink needs a FUSE bridge for NewInode; the
+	// recovered panic yields EIO, which still proves detection.
+	h := &mockSymlinkerHandler{}
+	d := &DispatchNode{handler: h, isRoot: true}
+
+	_, errno := d.Symlink(context.Background(), "target", "link", &fuse.EntryOut{})
+	if errno == syscall.EPERM {
+		t.Fatal("Symlink should detect NodeSymlinker capability, got EPERM")
+	}
+}
+
+func TestDispatchNodeSymlink_Unsupported(t *testing.T) {
+	h := &mockHandler{}
+	d := &DispatchNode{handler: h, isRoot: true}
+
+	_, errno := d.Symlink(context.Background(), "target", "link", &fuse.EntryOut{})
+	if errno != syscall.EPERM {
+		t.Errorf("Symlink on handler without NodeSymlinker returned errno %d, want EPERM", errno)
+	}
+}
+
+func TestDispatchNodeReadlink(t *testing.T) {
+	d := &DispatchNode{handler: &mockHandler{}, node: &mockSymlinkNode{target: "../dest"}}
+
+	got, errno := d.Readlink(context.Background())
+	if errno != 0 {
+		t.Fatalf("Readlink returned errno %d", errno)
+	}
+	if string(got) != "../dest" {
+		t.Errorf("Readlink = %q, want %q", got, "../dest")
+	}
+}
+
+func TestDispatchNodeReadlink_NotSymlink(t *testing.T) {
+	d := &DispatchNode{handler: &mockHandler{}, node: &mockNode{}}
+
+	if _, errno := d.Readlink(context.Background()); errno != syscall.EINVAL {
+		t.Errorf("Readlink on regular node returned errno %d, want EINVAL", errno)
+	}
+}
+
+func TestDispatchNodeXattr(t *testing.T) {
+	n := &mockXattrNode{xattrs: map[string][]byte{"user.a": []byte("hello")}}
+	d := &DispatchNode{handler: &mockHandler{}, node: n}
+	ctx := context.Background()
+
+	if size, errno := d.Getxattr(ctx, "user.a", nil); errno != 0 || size != 5 {
+		t.Errorf("Getxattr size query = %d, %d; want 5, 0", size, errno)
+	}
+	if _, errno := d.Getxattr(ctx, "user.a", make([]byte, 2)); errno != syscall.ERANGE {
+		t.Errorf("Getxattr short buffer errno = %d, want ERANGE", errno)
+	}
+	buf := make([]byte, 16)
+	size, errno := d.Getxattr(ctx, "user.a", buf)
+	if errno != 0 || string(buf[:size]) != "hello" {
+		t.Errorf("Getxattr = %q, %d; want %q", buf[:size], errno, "hello")
+	}
+	if _, errno := d.Getxattr(ctx, "user.missing", buf); errno != syscall.ENODATA {
+		t.Errorf("Getxattr missing errno = %d, want ENODATA", errno)
+	}
+
+	if errno := d.Setxattr(ctx, "user.b", []byte("x"), 0); errno != 0 {
+		t.Fatalf("Setxattr errno = %d", errno)
+	}
+	size, errno = d.Listxattr(ctx, buf)
+	if errno != 0 || string(buf[:size]) != "user.a\x00user.b\x00" {
+		t.Errorf("Listxattr = %q, %d", buf[:size], errno)
+	}
+
+	if errno := d.Removexattr(ctx, "user.a"); errno != 0 {
+		t.Fatalf("Removexattr errno = %d", errno)
+	}
+	if _, ok := n.xattrs["user.a"]; ok {
+		t.Error("Removexattr did not delete user.a")
+	}
+}
+
+func TestDispatchNodeXattr_Unsupported(t *testing.T) {
+	d := &DispatchNode{handler: &mockHandler{}, node: &mockNode{}}
+	ctx := context.Background()
+
+	if _, errno := d.Getxattr(ctx, "user.a", nil); errno != syscall.ENODATA {
+		t.Errorf("Getxattr errno = %d, want ENODATA", errno)
+	}
+	if size, errno := d.Listxattr(ctx, nil); errno != 0 || size != 0 {
+		t.Errorf("Listxattr = %d, %d; want empty list", size, errno)
+	}
+	if errno := d.Setxattr(ctx, "user.a", nil, 0); errno != syscall.ENOTSUP {
+		t.Errorf("Setxattr errno = %d, want ENOTSUP", errno)
+	}
+	if errno := d.Removexattr(ctx, "user.a"); errno != syscall.ENOTSUP {
+		t.Errorf("Removexattr errno = %d, want ENOTSUP", errno)
+	}
+}
+
+func TestDispatchNodeStatfs(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   NamespaceHandler
+		node      Node
+		wantErrno syscall.Errno
+		want      fuse.StatfsOut
+	}{
+		{
+			name:    "namespace root",
+			handler: &mockStatfsHandler{usage: Usage{Total: 10 * statfsBlockSize, Free: 4 * statfsBlockSize}},
+			want:    fuse.StatfsOut{Blocks: 10, Bfree: 4, Bavail: 4, Bsize: statfsBlockSize, Frsize: statfsBlockSize, NameLen: statfsNameLen},
+		},
+		{
+			name:    "child node",
+			handler: &mockStatfsHandler{usage: Usage{Total: 10 * statfsBlockSize, Free: 4*statfsBlockSize + 100}},
+			node:    &mockNode{},
+			want:    fuse.StatfsOut{Blocks: 10, Bfree: 4, Bavail: 4, Bsize: statfsBlockSize, Frsize: statfsBlockSize, NameLen: statfsNameLen},
+		},
+		{
+			name:    "over quota",
+			handler: &mockStatfsHandler{usage: Usage{Total: 2 * statfsBlockSize, Free: 3 * statfsBlockSize}},
+			want:    fuse.StatfsOut{Blocks: 2, Bfree: 2, Bavail: 2, Bsize: statfsBlockSize, Frsize: statfsBlockSize, NameLen: statfsNameLen},
+		},
+		{
+			name:    "unsupported",
+			handler: &mockHandler{},
+			want:    fuse.StatfsOut{Bsize: statfsBlockSize, Frsize: statfsBlockSize, NameLen: statfsNameLen},
+		},
+		{
+			name:      "error",
+			handler:   &mockStatfsHandler{errno: syscall.ENETDOWN},
+			wantErrno: syscall.ENETDOWN,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DispatchNode{handler: tt.handler, node: tt.node, isRoot: tt.node == nil}
+			var out fuse.StatfsOut
+			if errno := d.Statfs(context.Background(), &out); errno != tt.wantErrno {
+				t.Fatalf("Statfs errno = %d, want %d", errno, tt.wantErrno)
+			}
+			if out != tt.want {
+				t.Errorf("Statfs = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
 func TestDispatchNodeUnlink_Supported(t *testing.T) {
 	h := &mockRemoverHandler{}
 	d := &DispatchNode{handler: h, isRoot: true}
@@ -0,0 +1,97 @@
+//go:build linux
+
+package drive
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/major0/proton-utils/api/drive"
+	"github.com/major0/proton-utils/internal/fusemount"
+)
+
+// Compile-time interface assertions.
+var _ fusemount.NamespaceChangeChecker = (*DriveHandler)(nil)
+var _ fusemount.NodeIdentifier = (*ShareDirNode)(nil)
+var _ fusemount.NodeIdentifier = (*LinkDirNode)(nil)
+var _ fusemount.NodeIdentifier = (*FileNode)(nil)
+var _ fusemount.NodeIdentifier = (*SymlinkNode)(nil)
+var _ fusemount.NodeInvalidator = (*ShareDirNode)(nil)
+var _ fusemount.NodeInvalidator = (*LinkDirNode)(nil)
+
+// NodeID returns the LinkID of the share's root link.
+func (n *ShareDirNode) NodeID() string { return n.share.Link.LinkID() }
+
+// NodeID returns the LinkID of the folder.
+func (n *LinkDirNode) NodeID() string { return n.link.LinkID() }
+
+// NodeID returns the LinkID of the file.
+func (n *FileNode) NodeID() string { return n.link.LinkID() }
+
+// NodeID returns the LinkID of the symlink.
+func (n *SymlinkNode) NodeID() string { return n.link.LinkID() }
+
+// Invalidate drops the children retained from the last Readdir.
+func (n *ShareDirNode) Invalidate() { n.children = nil }
+
+// Invalidate drops the children retained from the last Readdir.
+func (n *LinkDirNode) Invalidate() { n.children = nil }
+
+// current returns the link table's copy of l, or l if it is not in the
+// table. CheckLink replaces the table's copy when the link changes on
+// the server, so nodes read attributes and revisions through it.
+func current(client *drive.Client, l *drive.Link) *drive.Link {
+	if client == nil {
+		return l
+	}
+	if cur := client.GetLink(l.LinkID()); cur != nil {
+		return cur
+	}
+	return l
+}
+
+// nodeLink returns the link a node exposes, or nil for nodes with none.
+func nodeLink(node fusemount.Node) *drive.Link {
+	switch n := node.(type) {
+	case *ShareDirNode:
+		return n.share.Link
+	case *LinkDirNode:
+		return n.link
+	case *FileNode:
+		return n.link
+	case *SymlinkNode:
+		return n.link
+	}
+	return nil
+}
+
+// CheckChanges checks each node's link against the server. A removed
+// link also reports its parent, whose retained children still list it.
+// Checking stops while the API is unreachable.
+func (h *DriveHandler) CheckChanges(ctx context.Context, nodes map[string]fusemount.Node) []fusemount.Change {
+	var changes []fusemount.Change
+	for id, node := range nodes {
+		if h.client.Offline() || ctx.Err() != nil {
+			break
+		}
+		link := nodeLink(node)
+		if link == nil {
+			continue
+		}
+		change, err := h.client.CheckLink(ctx, link)
+		if err != nil {
+			slog.Debug("drive.CheckChanges: check failed", "linkID", id, "error", err)
+			continue
+		}
+		switch change {
+		case drive.LinkModified:
+			changes = append(changes, fusemount.Change{ID: id})
+		case drive.LinkRemoved:
+			changes = append(changes, fusemount.Change{ID: id, Removed: true})
+			if parent := link.ParentLink(); parent != nil {
+				changes = append(changes, fusemount.Change{ID: parent.LinkID()})
+			}
+		}
+	}
+	return changes
+}
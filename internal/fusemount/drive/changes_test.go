@@ -0,0 +1,51 @@
+//go:build linux
+
+package drive
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+)
+
+func TestNodeID(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	client := drive.NewTestClient(nil)
+	file := drive.NewTestLink(&proton.Link{LinkID: "file-id", Type: proton.LinkTypeFile}, share.Link, share, nil, "file.txt")
+
+	tests := []struct {
+		name string
+		node interface{ NodeID() string }
+		want string
+	}{
+		{"share", &ShareDirNode{share: share, client: client}, "root"},
+		{"folder", &LinkDirNode{link: share.Link, client: client}, "root"},
+		{"file", &FileNode{link: file, client: client}, "file-id"},
+		{"symlink", &SymlinkNode{link: file, client: client}, "file-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.node.NodeID(); got != tt.want {
+				t.Errorf("NodeID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidate_DropsChildren(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	children := map[string]*drive.Link{"a.txt": share.Link}
+
+	sn := &ShareDirNode{share: share, children: children}
+	sn.Invalidate()
+	if sn.children != nil {
+		t.Error("ShareDirNode children retained after Invalidate")
+	}
+
+	ln := &LinkDirNode{link: share.Link, children: children}
+	ln.Invalidate()
+	if ln.children != nil {
+		t.Error("LinkDirNode children retained after Invalidate")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/account"
 	"github.com/major0/proton-utils/api/drive"
 	"github.com/major0/proton-utils/internal/fusemount"
 )
@@ -28,11 +29,19 @@ type DriveHandler struct { //nolint:revive // name specified by design doc
 	// namespace directory and .linkid — avoids leaking internal Proton
 	// metadata (volume creation date) outside the encrypted boundary.
 	startTime uint64
+
+	// The account storage quota reported by Statfs, as of quotaTime.
+	// Refreshed by RefreshQuota.
+	quotaMu   sync.Mutex
+	maxSpace  int64
+	usedSpace int64
+	quotaTime time.Time
 }
 
 // Compile-time interface assertion.
 var _ fusemount.NamespaceHandler = (*DriveHandler)(nil)
 var _ fusemount.NodeRenamer = (*DriveHandler)(nil)
+var _ fusemount.NamespaceStatfser = (*DriveHandler)(nil)
 
 // NewDriveHandler constructs a DriveHandler with the given drive client.
 func NewDriveHandler(client *drive.Client) *DriveHandler {
@@ -186,6 +195,7 @@ func (h *DriveHandler) LoadShares(ctx context.Context) error {
 	h.shares = shares
 	h.sharesMu.Unlock()
 
+	h.refreshPublicURLs(ctx, shares)
 	return nil
 }
 
@@ -217,10 +227,83 @@ func (h *DriveHandler) RefreshShares(ctx context.Context) error {
 	h.shares = shares
 	h.sharesMu.Unlock()
 
+	h.refreshPublicURLs(ctx, shares)
 	slog.Debug("drive.RefreshShares: updated share map", "count", len(shares))
 	return nil
 }
 
+// refreshPublicURLs updates the cached public URLs of the standard
+// shares, which the user.proton.public_url attribute is served from.
+func (h *DriveHandler) refreshPublicURLs(ctx context.Context, shares map[string]*drive.Share) {
+	standard := make([]*drive.Share, 0, len(shares))
+	for _, share := range shares {
+		if share.ProtonShare().Type == proton.ShareTypeStandard {
+			standard = append(standard, share)
+		}
+	}
+	if err := h.client.RefreshPublicURLs(ctx, standard); err != nil {
+		slog.Warn("drive.refreshPublicURLs: lookup failed", "error", err)
+	}
+}
+
+// RefreshQuota fetches the account storage quota reported by Statfs.
+func (h *DriveHandler) RefreshQuota(ctx context.Context) error {
+	user, err := account.NewClient(h.client.Session).GetUser(ctx)
+	if err != nil {
+		return err
+	}
+	h.setQuota(user.MaxSpace(), user.UsedSpace())
+	slog.Debug("drive.RefreshQuota: updated quota",
+		"maxSpace", user.MaxSpace(), "usedSpace", user.UsedSpace())
+	return nil
+}
+
+// setQuota records the account storage quota.
+func (h *DriveHandler) setQuota(maxSpace, usedSpace int64) {
+	h.quotaMu.Lock()
+	h.maxSpace, h.usedSpace = maxSpace, usedSpace
+	h.quotaTime = time.Now()
+	h.quotaMu.Unlock()
+}
+
+// Statfs reports the account storage quota: the size of the filesystem
+// is MaxSpace and the free space what UsedSpace leaves of it. The quota
+// is fetched on first use if RefreshQuota has not run; while it cannot
+// be fetched, no space is reported.
+func (h *DriveHandler) Statfs(ctx context.Context) (fusemount.Usage, syscall.Errno) {
+	h.quotaMu.Lock()
+	loaded := !h.quotaTime.IsZero()
+	h.quotaMu.Unlock()
+	if !loaded {
+		if err := h.RefreshQuota(ctx); err != nil {
+			slog.Debug("drive.Statfs: quota unavailable", "error", err)
+			return fusemount.Usage{}, 0
+		}
+	}
+
+	h.quotaMu.Lock()
+	defer h.quotaMu.Unlock()
+	return quotaUsage(h.maxSpace, h.usedSpace), 0
+}
+
+// quotaUsage converts an account quota to namespace usage. Usage over
+// the quota leaves no free space.
+func quotaUsage(maxSpace, usedSpace int64) fusemount.Usage {
+	if maxSpace <= 0 {
+		return fusemount.Usage{}
+	}
+	free := max(maxSpace-usedSpace, 0)
+	return fusemount.Usage{Total: uint64(maxSpace), Free: uint64(free)} //nolint:gosec // both are non-negative
+}
+
+// ShareCount returns the number of loaded shares, including device
+// shares, which are not listed.
+func (h *DriveHandler) ShareCount() int {
+	h.sharesMu.RLock()
+	defer h.sharesMu.RUnlock()
+	return len(h.shares)
+}
+
 // SetShares replaces the internal share map under a write lock. This is
 // exported for testing (simulating refresh without a real API client).
 func (h *DriveHandler) SetShares(shares map[string]*drive.Share) {
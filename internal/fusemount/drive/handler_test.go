@@ -5,6 +5,8 @@ package drive
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"syscall"
 	"testing"
 
@@ -208,6 +210,47 @@ func TestApiErrno_OtherError(t *testing.T) {
 	}
 }
 
+func TestApiErrno_NetworkError(t *testing.T) {
+	err := fmt.Errorf("get link: %w", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})
+	errno := apiErrno(err)
+	if errno != syscall.ENETDOWN {
+		t.Errorf("apiErrno(network) = %d, want ENETDOWN (%d)", errno, syscall.ENETDOWN)
+	}
+}
+
+func TestQuotaUsage(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxSpace, used  int64
+		wantTotal, free uint64
+	}{
+		{"partly used", 1000, 400, 1000, 600},
+		{"full", 1000, 1000, 1000, 0},
+		{"over quota", 1000, 1500, 1000, 0},
+		{"no quota", 0, 100, 0, 0},
+	}
+	for _, tt := range tests {
+		got := quotaUsage(tt.maxSpace, tt.used)
+		if got.Total != tt.wantTotal || got.Free != tt.free {
+			t.Errorf("%s: quotaUsage(%d, %d) = %+v, want total %d free %d",
+				tt.name, tt.maxSpace, tt.used, got, tt.wantTotal, tt.free)
+		}
+	}
+}
+
+func TestDriveHandler_Statfs_ReportsQuota(t *testing.T) {
+	h := buildTestHandler(nil)
+	h.setQuota(5<<30, 2<<30)
+
+	usage, errno := h.Statfs(context.Background())
+	if errno != 0 {
+		t.Fatalf("Statfs errno = %d", errno)
+	}
+	if usage.Total != 5<<30 || usage.Free != 3<<30 {
+		t.Errorf("Statfs = %+v, want total %d free %d", usage, uint64(5<<30), uint64(3<<30))
+	}
+}
+
 func TestDriveHandler_SetShares_SwapsMap(t *testing.T) {
 	initial := map[string]*drive.Share{
 		"main-id": testShare("root", "main-id", proton.ShareTypeMain),
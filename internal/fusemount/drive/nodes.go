@@ -9,22 +9,36 @@ import (
 	"log/slog"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/ProtonMail/go-proton-api"
 	"github.com/major0/proton-utils/api/drive"
 	"github.com/major0/proton-utils/internal/fusemount"
+	"golang.org/x/sys/unix"
 )
 
 // apiErrno maps an API or context error to the appropriate FUSE errno.
-// Context cancellation and deadline exceeded map to EINTR; all other
-// errors map to EIO.
+// Context cancellation and deadline exceeded map to EINTR, an
+// unreachable API to ENETDOWN; all other errors map to EIO.
 func apiErrno(err error) syscall.Errno {
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return syscall.EINTR
 	}
+	if drive.IsNetworkError(err) {
+		return syscall.ENETDOWN
+	}
 	return syscall.EIO
 }
 
+// offlineErrno returns ENETDOWN while the client is offline, so that
+// mutations fail at once instead of waiting for a network timeout.
+func offlineErrno(client *drive.Client) syscall.Errno {
+	if client.Offline() {
+		return syscall.ENETDOWN
+	}
+	return 0
+}
+
 // extractParentLink resolves the destination parent *Link and *Share from
 // a fusemount.Node. Returns the fallback (self) if the node type is
 // unrecognized.
@@ -46,7 +60,7 @@ func extractParentLink(node fusemount.Node, fallbackLink *drive.Link, fallbackSh
 // Note: Client.Move wraps MoveLink errors with fmt.Errorf — errors.Is
 // traverses the chain. Name-collision detection depends on Client.Move
 // translating API 422 responses to drive.ErrFileNameExist (same pattern
-// as CreateFile). If the API returns an opaque error, it falls through to EIO.
+// as CreateFile). Other errors fall through to apiErrno.
 func renameErrno(err error) syscall.Errno {
 	if errors.Is(err, drive.ErrFileNameExist) {
 		return syscall.EEXIST
@@ -58,7 +72,7 @@ func renameErrno(err error) syscall.Errno {
 		return syscall.ENOTDIR
 	}
 	slog.Debug("rename: failed", "error", err)
-	return syscall.EIO
+	return apiErrno(err)
 }
 
 // ShareDirNode wraps a *drive.Share and implements fusemount.DirNode.
@@ -77,6 +91,8 @@ var _ fusemount.NodeCreator = (*ShareDirNode)(nil)
 var _ fusemount.NodeMkdirer = (*ShareDirNode)(nil)
 var _ fusemount.NodeRemover = (*ShareDirNode)(nil)
 var _ fusemount.NodeRenamer = (*ShareDirNode)(nil)
+var _ fusemount.NodeSymlinker = (*ShareDirNode)(nil)
+var _ fusemount.NodeXattrReader = (*ShareDirNode)(nil)
 
 // Getattr returns directory attributes for the share root.
 func (n *ShareDirNode) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
@@ -91,6 +107,8 @@ func (n *ShareDirNode) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno
 
 // Readdir lists children of the share's root link. Retains the name→Link
 // mapping so subsequent Lookup calls resolve locally without an API call.
+// The virtual .revisions and .Trash directories are listed when
+// configured.
 func (n *ShareDirNode) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall.Errno) {
 	// Use a detached context for the API call. The kernel FUSE timeout is
 	// too short for paginated ListChildren on large directories (the main
@@ -125,18 +143,39 @@ func (n *ShareDirNode) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall
 		})
 	}
 	n.children = children
-	return entries, 0
+	return append(entries, virtualDirEntries(n.client, true, children)...), 0
+}
+
+// Getxattr returns a read-only user.proton.* attribute of the share
+// root. Folders store no other attributes.
+func (n *ShareDirNode) Getxattr(_ context.Context, attr string) ([]byte, syscall.Errno) {
+	if !isProtonXattr(attr) {
+		return nil, syscall.ENODATA
+	}
+	return getProtonXattr(n.client, n.share, n.share.Link, attr)
+}
+
+// Listxattr returns the names of the user.proton.* attributes of the
+// share root, sorted.
+func (n *ShareDirNode) Listxattr(_ context.Context) ([]string, syscall.Errno) {
+	return listXattrs(n.client, n.share, n.share.Link, nil), 0
 }
 
 // Create creates a new file in the share's root directory.
 func (n *ShareDirNode) Create(_ context.Context, name string, _ uint32, _ uint32) (fusemount.Node, fusemount.FileHandle, syscall.Errno) {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return nil, nil, errno
+	}
 	fd, err := n.client.CreateFD(context.Background(), n.share, n.share.Link, name)
 	if err != nil {
 		if errors.Is(err, drive.ErrFileNameExist) {
 			return nil, nil, syscall.EEXIST
 		}
 		slog.Debug("ShareDirNode.Create: failed", "shareID", n.share.Metadata().ShareID, "error", err)
-		return nil, nil, syscall.EIO
+		return nil, nil, apiErrno(err)
+	}
+	if errno := writeBack(n.client, fd); errno != 0 {
+		return nil, nil, errno
 	}
 
 	// Invalidate children cache — directory listing is now stale.
@@ -150,6 +189,9 @@ func (n *ShareDirNode) Create(_ context.Context, name string, _ uint32, _ uint32
 
 // Mkdir creates a new subdirectory at the share root.
 func (n *ShareDirNode) Mkdir(_ context.Context, name string, _ uint32) (fusemount.Node, syscall.Errno) {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return nil, errno
+	}
 	newLink, err := n.client.MkDir(context.Background(), n.share, n.share.Link, name)
 	if err != nil {
 		if errors.Is(err, proton.ErrFolderNameExist) {
@@ -157,7 +199,7 @@ func (n *ShareDirNode) Mkdir(_ context.Context, name string, _ uint32) (fusemoun
 		}
 		slog.Debug("ShareDirNode.Mkdir: failed",
 			"shareID", n.share.Metadata().ShareID, "error", err)
-		return nil, syscall.EIO
+		return nil, apiErrno(err)
 	}
 
 	// Invalidate children cache.
@@ -166,8 +208,31 @@ func (n *ShareDirNode) Mkdir(_ context.Context, name string, _ uint32) (fusemoun
 	return &LinkDirNode{link: newLink, client: n.client}, 0
 }
 
+// Symlink creates a symbolic link at the share root.
+func (n *ShareDirNode) Symlink(_ context.Context, target, name string) (fusemount.Node, syscall.Errno) {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return nil, errno
+	}
+	newLink, err := n.client.Symlink(context.Background(), n.share, n.share.Link, name, target)
+	if err != nil {
+		if errors.Is(err, drive.ErrFileNameExist) {
+			return nil, syscall.EEXIST
+		}
+		slog.Debug("ShareDirNode.Symlink: failed",
+			"shareID", n.share.Metadata().ShareID, "error", err)
+		return nil, apiErrno(err)
+	}
+
+	// Invalidate children cache.
+	n.children = nil
+
+	return &SymlinkNode{link: newLink, client: n.client}, 0
+}
+
 // Lookup finds a child by name. Uses the retained children map from the
 // last Readdir to avoid a redundant ListLinkChildren API call.
+// .revisions and .Trash resolve to the virtual directories unless a
+// real child has that name.
 func (n *ShareDirNode) Lookup(_ context.Context, name string) (fusemount.Node, syscall.Errno) {
 	// Fast path: child retained from last Readdir.
 	if n.children != nil {
@@ -176,6 +241,9 @@ func (n *ShareDirNode) Lookup(_ context.Context, name string) (fusemount.Node, s
 				"shareID", n.share.Metadata().ShareID)
 			return linkNode(child, n.client), 0
 		}
+		if node := n.virtualChild(name); node != nil {
+			return node, 0
+		}
 	}
 
 	// Slow path: no retained children (first Lookup before Readdir).
@@ -188,6 +256,9 @@ func (n *ShareDirNode) Lookup(_ context.Context, name string) (fusemount.Node, s
 		return nil, apiErrno(err)
 	}
 	if child == nil {
+		if node := n.virtualChild(name); node != nil {
+			return node, 0
+		}
 		return nil, syscall.ENOENT
 	}
 	return linkNode(child, n.client), 0
@@ -205,7 +276,7 @@ func (n *ShareDirNode) resolveShareChild(name string) (*drive.Link, syscall.Errn
 	if err != nil {
 		slog.Debug("resolveShareChild: lookup failed",
 			"shareID", n.share.Metadata().ShareID, "error", err)
-		return nil, syscall.EIO
+		return nil, apiErrno(err)
 	}
 	if child == nil {
 		return nil, syscall.ENOENT
@@ -215,6 +286,9 @@ func (n *ShareDirNode) resolveShareChild(name string) (*drive.Link, syscall.Errn
 
 // Unlink removes a file from the share root (moves to trash).
 func (n *ShareDirNode) Unlink(_ context.Context, name string) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
 	child, errno := n.resolveShareChild(name)
 	if errno != 0 {
 		return errno
@@ -229,7 +303,7 @@ func (n *ShareDirNode) Unlink(_ context.Context, name string) syscall.Errno {
 		}
 		slog.Debug("ShareDirNode.Unlink: failed",
 			"shareID", n.share.Metadata().ShareID, "error", err)
-		return syscall.EIO
+		return apiErrno(err)
 	}
 
 	n.children = nil
@@ -238,6 +312,9 @@ func (n *ShareDirNode) Unlink(_ context.Context, name string) syscall.Errno {
 
 // Rmdir removes an empty directory from the share root (moves to trash).
 func (n *ShareDirNode) Rmdir(_ context.Context, name string) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
 	child, errno := n.resolveShareChild(name)
 	if errno != 0 {
 		return errno
@@ -252,7 +329,7 @@ func (n *ShareDirNode) Rmdir(_ context.Context, name string) syscall.Errno {
 		}
 		slog.Debug("ShareDirNode.Rmdir: failed",
 			"shareID", n.share.Metadata().ShareID, "error", err)
-		return syscall.EIO
+		return apiErrno(err)
 	}
 
 	n.children = nil
@@ -261,6 +338,12 @@ func (n *ShareDirNode) Rmdir(_ context.Context, name string) syscall.Errno {
 
 // Rename moves or renames a child of the share root.
 func (n *ShareDirNode) Rename(_ context.Context, oldName string, newParent fusemount.Node, newName string) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
+	if isVirtualNode(newParent) {
+		return syscall.EPERM
+	}
 	child, errno := n.resolveShareChild(oldName)
 	if errno != 0 {
 		return errno
@@ -310,20 +393,24 @@ var _ fusemount.NodeCreator = (*LinkDirNode)(nil)
 var _ fusemount.NodeMkdirer = (*LinkDirNode)(nil)
 var _ fusemount.NodeRemover = (*LinkDirNode)(nil)
 var _ fusemount.NodeRenamer = (*LinkDirNode)(nil)
+var _ fusemount.NodeSymlinker = (*LinkDirNode)(nil)
+var _ fusemount.NodeXattrReader = (*LinkDirNode)(nil)
 
 // Getattr returns directory attributes for the folder.
 func (n *LinkDirNode) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	link := current(n.client, n.link)
 	//nolint:gosec // ModifyTime/CreateTime are non-negative from API
 	return fusemount.Attr{
 		Mode:  syscall.S_IFDIR | 0700,
 		Nlink: 2,
-		Mtime: uint64(n.link.ModifyTime()),
-		Ctime: uint64(n.link.CreateTime()),
+		Mtime: uint64(link.ModifyTime()),
+		Ctime: uint64(link.CreateTime()),
 	}, 0
 }
 
 // Readdir lists children of the folder link. Retains the name→Link
 // mapping so subsequent Lookup calls resolve locally without an API call.
+// The virtual .revisions directory is listed when configured.
 func (n *LinkDirNode) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall.Errno) {
 	ctx := context.Background()
 	var entries []fusemount.DirEntry
@@ -355,11 +442,13 @@ func (n *LinkDirNode) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall.
 		})
 	}
 	n.children = children
-	return entries, 0
+	return append(entries, virtualDirEntries(n.client, false, children)...), 0
 }
 
 // Lookup finds a child by name. Uses the retained children map from the
 // last Readdir to avoid a redundant ListLinkChildren API call.
+// .revisions resolves to the virtual directory unless a real child has
+// that name.
 func (n *LinkDirNode) Lookup(_ context.Context, name string) (fusemount.Node, syscall.Errno) {
 	// Fast path: child retained from last Readdir.
 	if n.children != nil {
@@ -368,6 +457,9 @@ func (n *LinkDirNode) Lookup(_ context.Context, name string) (fusemount.Node, sy
 				"linkID", n.link.LinkID())
 			return linkNode(child, n.client), 0
 		}
+		if node := n.virtualChild(name); node != nil {
+			return node, 0
+		}
 	}
 
 	// Slow path: no retained children (first Lookup before Readdir).
@@ -380,6 +472,9 @@ func (n *LinkDirNode) Lookup(_ context.Context, name string) (fusemount.Node, sy
 		return nil, apiErrno(err)
 	}
 	if child == nil {
+		if node := n.virtualChild(name); node != nil {
+			return node, 0
+		}
 		return nil, syscall.ENOENT
 	}
 	return linkNode(child, n.client), 0
@@ -400,7 +495,7 @@ func (n *LinkDirNode) resolveChild(name string) (*drive.Link, syscall.Errno) {
 	if err != nil {
 		slog.Debug("resolveChild: lookup failed",
 			"linkID", n.link.LinkID(), "error", err)
-		return nil, syscall.EIO
+		return nil, apiErrno(err)
 	}
 	if child == nil {
 		return nil, syscall.ENOENT
@@ -410,6 +505,9 @@ func (n *LinkDirNode) resolveChild(name string) (*drive.Link, syscall.Errno) {
 
 // Unlink removes a file from this directory (moves to trash).
 func (n *LinkDirNode) Unlink(_ context.Context, name string) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
 	child, errno := n.resolveChild(name)
 	if errno != 0 {
 		return errno
@@ -425,7 +523,7 @@ func (n *LinkDirNode) Unlink(_ context.Context, name string) syscall.Errno {
 		}
 		slog.Debug("LinkDirNode.Unlink: failed",
 			"linkID", n.link.LinkID(), "error", err)
-		return syscall.EIO
+		return apiErrno(err)
 	}
 
 	n.children = nil
@@ -434,6 +532,9 @@ func (n *LinkDirNode) Unlink(_ context.Context, name string) syscall.Errno {
 
 // Rmdir removes an empty directory from this directory (moves to trash).
 func (n *LinkDirNode) Rmdir(_ context.Context, name string) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
 	child, errno := n.resolveChild(name)
 	if errno != 0 {
 		return errno
@@ -449,7 +550,7 @@ func (n *LinkDirNode) Rmdir(_ context.Context, name string) syscall.Errno {
 		}
 		slog.Debug("LinkDirNode.Rmdir: failed",
 			"linkID", n.link.LinkID(), "error", err)
-		return syscall.EIO
+		return apiErrno(err)
 	}
 
 	n.children = nil
@@ -458,6 +559,12 @@ func (n *LinkDirNode) Rmdir(_ context.Context, name string) syscall.Errno {
 
 // Rename moves or renames a child of this directory.
 func (n *LinkDirNode) Rename(_ context.Context, oldName string, newParent fusemount.Node, newName string) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
+	if isVirtualNode(newParent) {
+		return syscall.EPERM
+	}
 	child, errno := n.resolveChild(oldName)
 	if errno != 0 {
 		return errno
@@ -496,8 +603,26 @@ func (n *LinkDirNode) Rename(_ context.Context, oldName string, newParent fusemo
 	return 0
 }
 
+// Getxattr returns a read-only user.proton.* attribute of the folder.
+// Folders store no other attributes.
+func (n *LinkDirNode) Getxattr(_ context.Context, attr string) ([]byte, syscall.Errno) {
+	if !isProtonXattr(attr) {
+		return nil, syscall.ENODATA
+	}
+	return getProtonXattr(n.client, n.link.Share(), n.link, attr)
+}
+
+// Listxattr returns the names of the user.proton.* attributes of the
+// folder, sorted.
+func (n *LinkDirNode) Listxattr(_ context.Context) ([]string, syscall.Errno) {
+	return listXattrs(n.client, n.link.Share(), n.link, nil), 0
+}
+
 // Create creates a new file in this directory.
 func (n *LinkDirNode) Create(_ context.Context, name string, _ uint32, _ uint32) (fusemount.Node, fusemount.FileHandle, syscall.Errno) {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return nil, nil, errno
+	}
 	share := n.link.Share()
 	fd, err := n.client.CreateFD(context.Background(), share, n.link, name)
 	if err != nil {
@@ -505,7 +630,10 @@ func (n *LinkDirNode) Create(_ context.Context, name string, _ uint32, _ uint32)
 			return nil, nil, syscall.EEXIST
 		}
 		slog.Debug("LinkDirNode.Create: failed", "linkID", n.link.LinkID(), "error", err)
-		return nil, nil, syscall.EIO
+		return nil, nil, apiErrno(err)
+	}
+	if errno := writeBack(n.client, fd); errno != 0 {
+		return nil, nil, errno
 	}
 
 	// Invalidate children cache — directory listing is now stale.
@@ -521,6 +649,9 @@ func (n *LinkDirNode) Create(_ context.Context, name string, _ uint32, _ uint32)
 
 // Mkdir creates a new subdirectory in this folder.
 func (n *LinkDirNode) Mkdir(_ context.Context, name string, _ uint32) (fusemount.Node, syscall.Errno) {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return nil, errno
+	}
 	share := n.link.Share()
 	newLink, err := n.client.MkDir(context.Background(), share, n.link, name)
 	if err != nil {
@@ -532,7 +663,7 @@ func (n *LinkDirNode) Mkdir(_ context.Context, name string, _ uint32) (fusemount
 		}
 		slog.Debug("LinkDirNode.Mkdir: failed",
 			"linkID", n.link.LinkID(), "error", err)
-		return nil, syscall.EIO
+		return nil, apiErrno(err)
 	}
 
 	// Invalidate children cache — directory listing is now stale.
@@ -541,14 +672,40 @@ func (n *LinkDirNode) Mkdir(_ context.Context, name string, _ uint32) (fusemount
 	return &LinkDirNode{link: newLink, client: n.client}, 0
 }
 
+// Symlink creates a symbolic link in this directory.
+func (n *LinkDirNode) Symlink(_ context.Context, target, name string) (fusemount.Node, syscall.Errno) {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return nil, errno
+	}
+	share := n.link.Share()
+	newLink, err := n.client.Symlink(context.Background(), share, n.link, name, target)
+	if err != nil {
+		if errors.Is(err, drive.ErrFileNameExist) {
+			return nil, syscall.EEXIST
+		}
+		slog.Debug("LinkDirNode.Symlink: failed",
+			"linkID", n.link.LinkID(), "error", err)
+		return nil, apiErrno(err)
+	}
+
+	// Invalidate children cache — directory listing is now stale.
+	n.children = nil
+
+	return &SymlinkNode{link: newLink, client: n.client}, 0
+}
+
 // linkMode returns the FUSE mode for a link based on its type.
 // Directories use 0700 (owner rwx). Files use 0600 (owner rw).
+// Symlinks use 0777, as on Linux.
 // Group/other bits are cosmetic — DispatchNode.checkAccess enforces
 // UID-gated access at the FUSE layer regardless of permission bits.
 func linkMode(l *drive.Link) uint32 {
 	if l.Type() == proton.LinkTypeFolder {
 		return syscall.S_IFDIR | 0700
 	}
+	if l.IsSymlink() {
+		return syscall.S_IFLNK | 0777
+	}
 	return syscall.S_IFREG | 0600
 }
 
@@ -557,11 +714,77 @@ func linkNode(l *drive.Link, client *drive.Client) fusemount.Node {
 	if l.Type() == proton.LinkTypeFolder {
 		return &LinkDirNode{link: l, client: client}
 	}
+	if l.IsSymlink() {
+		return &SymlinkNode{link: l, client: client}
+	}
 	return &FileNode{link: l, client: client}
 }
 
+// SymlinkNode wraps a *drive.Link for a Drive symlink file and
+// implements fusemount.NodeReadlinker. The target is read from the
+// revision XAttr.
+type SymlinkNode struct {
+	link   *drive.Link
+	client *drive.Client
+}
+
+// Compile-time interface assertions.
+var _ fusemount.Node = (*SymlinkNode)(nil)
+var _ fusemount.NodeReadlinker = (*SymlinkNode)(nil)
+var _ fusemount.NodeXattrReader = (*SymlinkNode)(nil)
+
+// Getattr returns symlink attributes. The file content mirrors the
+// target, so the link size is the target length.
+func (n *SymlinkNode) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	link := current(n.client, n.link)
+	//nolint:gosec // Size/ModifyTime/CreateTime are non-negative from API
+	return fusemount.Attr{
+		Mode:  syscall.S_IFLNK | 0777,
+		Size:  uint64(link.Size()),
+		Nlink: 1,
+		Mtime: uint64(link.ModifyTime()),
+		Ctime: uint64(link.CreateTime()),
+	}, 0
+}
+
+// Readlink returns the symlink target.
+func (n *SymlinkNode) Readlink(_ context.Context) (string, syscall.Errno) {
+	link := current(n.client, n.link)
+	target, err := n.client.Readlink(context.Background(), link)
+	if err != nil {
+		slog.Debug("SymlinkNode.Readlink: failed",
+			"linkID", link.LinkID(), "error", err)
+		return "", apiErrno(err)
+	}
+	return target, 0
+}
+
+// Getxattr returns an extended attribute stored in the revision XAttr,
+// or a read-only user.proton.* attribute.
+func (n *SymlinkNode) Getxattr(_ context.Context, attr string) ([]byte, syscall.Errno) {
+	ctx := context.Background()
+	n.client.FetchRevisionXAttr(ctx, n.link)
+	if isProtonXattr(attr) {
+		return getProtonXattr(n.client, n.link.Share(), n.link, attr)
+	}
+	val, ok := n.link.XAttrs()[attr]
+	if !ok {
+		return nil, syscall.ENODATA
+	}
+	return val, 0
+}
+
+// Listxattr returns the names of the extended attributes stored in the
+// revision XAttr and of the user.proton.* attributes, sorted.
+func (n *SymlinkNode) Listxattr(_ context.Context) ([]string, syscall.Errno) {
+	ctx := context.Background()
+	n.client.FetchRevisionXAttr(ctx, n.link)
+	return listXattrs(n.client, n.link.Share(), n.link, n.link.XAttrs()), 0
+}
+
 // FileNode wraps a *drive.Link (file) and implements fusemount.Node,
-// NodeOpener, NodeReader, and NodeReleaser for read-only file access.
+// NodeOpener, NodeReader, NodeWriter and NodeReleaser for read-write
+// file access.
 type FileNode struct {
 	link   *drive.Link
 	client *drive.Client
@@ -576,6 +799,8 @@ var _ fusemount.NodeFsyncer = (*FileNode)(nil)
 var _ fusemount.NodeFlusher = (*FileNode)(nil)
 var _ fusemount.NodeReleaser = (*FileNode)(nil)
 var _ fusemount.NodeSetattrer = (*FileNode)(nil)
+var _ fusemount.NodeXattrReader = (*FileNode)(nil)
+var _ fusemount.NodeXattrWriter = (*FileNode)(nil)
 
 // fdHandle wraps a *drive.FileDescriptor as a fusemount.FileHandle.
 type fdHandle struct {
@@ -587,36 +812,122 @@ var _ fusemount.FileHandle = (*fdHandle)(nil)
 
 // Getattr returns file attributes including size and timestamps.
 func (n *FileNode) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	link := current(n.client, n.link)
 	mode := uint32(0600) // default
-	if m := n.link.Mode(); m != 0 {
+	if m := link.Mode(); m != 0 {
 		mode = m & 0o7777 // mask to permission bits only
 	}
 
+	size := link.Size()
+	if n.client.Spool != nil {
+		// A file with queued write-back jobs has the size they give it.
+		if pending, ok := n.client.Spool.Pending(link.LinkID()); ok {
+			size = pending
+		}
+	}
+
 	//nolint:gosec // Size/ModifyTime/CreateTime are non-negative from API
 	return fusemount.Attr{
 		Mode:  syscall.S_IFREG | mode,
-		Size:  uint64(n.link.Size()),
+		Size:  uint64(size),
 		Nlink: 1,
-		Mtime: uint64(n.link.ModifyTime()),
-		Ctime: uint64(n.link.CreateTime()),
+		Mtime: uint64(link.ModifyTime()),
+		Ctime: uint64(link.CreateTime()),
 	}, 0
 }
 
-// Setattr handles truncate (via open write FD). Chmod and utimes are
-// silent no-ops — mode persistence is deferred to a future spec.
-func (n *FileNode) Setattr(_ context.Context, fh fusemount.FileHandle, in *fusemount.SetattrIn) syscall.Errno {
-	// Truncate: only works with an open write-mode FD.
+// Getxattr returns an extended attribute stored in the revision XAttr,
+// or a read-only user.proton.* attribute.
+func (n *FileNode) Getxattr(_ context.Context, attr string) ([]byte, syscall.Errno) {
+	link := current(n.client, n.link)
+	ctx := context.Background()
+	n.client.FetchRevisionXAttr(ctx, link)
+	if isProtonXattr(attr) {
+		return getProtonXattr(n.client, link.Share(), link, attr)
+	}
+	val, ok := link.XAttrs()[attr]
+	if !ok {
+		return nil, syscall.ENODATA
+	}
+	return val, 0
+}
+
+// Listxattr returns the names of the extended attributes stored in the
+// revision XAttr and of the user.proton.* attributes, sorted.
+func (n *FileNode) Listxattr(_ context.Context) ([]string, syscall.Errno) {
+	link := current(n.client, n.link)
+	ctx := context.Background()
+	n.client.FetchRevisionXAttr(ctx, link)
+	return listXattrs(n.client, link.Share(), link, link.XAttrs()), 0
+}
+
+// Setxattr stores an extended attribute. Drive only accepts XAttr on
+// a new revision, so the file content is re-uploaded (as for chmod).
+// The user.proton.* attributes are read-only.
+func (n *FileNode) Setxattr(_ context.Context, attr string, data []byte, flags uint32) syscall.Errno {
+	if isProtonXattr(attr) {
+		return syscall.EPERM
+	}
+	ctx := context.Background()
+	n.client.FetchRevisionXAttr(ctx, n.link)
+	xattrs := n.link.XAttrs()
+	_, exists := xattrs[attr]
+	switch {
+	case flags&unix.XATTR_CREATE != 0 && exists:
+		return syscall.EEXIST
+	case flags&unix.XATTR_REPLACE != 0 && !exists:
+		return syscall.ENODATA
+	}
+	if xattrs == nil {
+		xattrs = make(map[string][]byte)
+	}
+	xattrs[attr] = append([]byte(nil), data...)
+	return n.setXAttrs(ctx, xattrs)
+}
+
+// Removexattr deletes an extended attribute, committing a new revision.
+func (n *FileNode) Removexattr(_ context.Context, attr string) syscall.Errno {
+	if isProtonXattr(attr) {
+		return syscall.EPERM
+	}
+	ctx := context.Background()
+	n.client.FetchRevisionXAttr(ctx, n.link)
+	xattrs := n.link.XAttrs()
+	if _, ok := xattrs[attr]; !ok {
+		return syscall.ENODATA
+	}
+	delete(xattrs, attr)
+	return n.setXAttrs(ctx, xattrs)
+}
+
+// setXAttrs commits xattrs as the file's extended attributes.
+func (n *FileNode) setXAttrs(ctx context.Context, xattrs map[string][]byte) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
+	if err := n.client.SetXAttrs(ctx, n.link.Share(), n.link, xattrs); err != nil {
+		slog.Debug("FileNode.setXAttrs: failed",
+			"linkID", n.link.LinkID(), "error", err)
+		return apiErrno(err)
+	}
+	return 0
+}
+
+// Setattr handles truncate. With an open write handle the handle's FD
+// truncates; otherwise the file is opened with ModifyFD, truncated and
+// committed. Chmod and utimes are silent no-ops — mode persistence is
+// deferred to a future spec.
+func (n *FileNode) Setattr(ctx context.Context, fh fusemount.FileHandle, in *fusemount.SetattrIn) syscall.Errno {
 	if in.Valid&fusemount.SetattrSize != 0 {
-		h, ok := fh.(*fdHandle)
-		if !ok || h == nil {
-			// No write FD — path-based truncate is not supported.
-			// Return success (no-op) to avoid breaking tools.
-			return 0
-		}
-		if err := h.fd.Truncate(int64(in.Size)); err != nil { //nolint:gosec // size from kernel setattr is non-negative
-			slog.Debug("FileNode.Setattr: truncate failed",
-				"linkID", n.link.LinkID(), "error", err)
-			return syscall.EIO
+		size := int64(in.Size) //nolint:gosec // size from kernel setattr is non-negative
+		if h, ok := fh.(*fdHandle); ok && h != nil {
+			if err := h.fd.Truncate(size); err != nil {
+				slog.Debug("FileNode.Setattr: truncate failed",
+					"linkID", n.link.LinkID(), "error", err)
+				return writeErrno(err)
+			}
+		} else if errno := n.truncate(ctx, size); errno != 0 {
+			return errno
 		}
 	}
 
@@ -626,41 +937,172 @@ func (n *FileNode) Setattr(_ context.Context, fh fusemount.FileHandle, in *fusem
 	return 0
 }
 
+// truncate sets the size of the file through a FD of its own, for a
+// truncate(2) without an open file.
+func (n *FileNode) truncate(ctx context.Context, size int64) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
+	if errno := n.waitWriteBack(ctx); errno != 0 {
+		return errno
+	}
+	if size == n.link.Size() {
+		return 0
+	}
+	fd, err := n.client.ModifyFD(context.Background(), n.link.Share(), n.link)
+	if err != nil {
+		slog.Debug("FileNode.Setattr: open failed", "linkID", n.link.LinkID(), "error", err)
+		return writeErrno(err)
+	}
+	if errno := writeBack(n.client, fd); errno != 0 {
+		_ = fd.Close()
+		return errno
+	}
+	if err := fd.Truncate(size); err != nil {
+		_ = fd.Close()
+		slog.Debug("FileNode.Setattr: truncate failed", "linkID", n.link.LinkID(), "error", err)
+		return writeErrno(err)
+	}
+	if err := fd.Close(); err != nil {
+		slog.Debug("FileNode.Setattr: commit failed", "linkID", n.link.LinkID(), "error", err)
+		return writeErrno(err)
+	}
+	return 0
+}
+
+// writeBack attaches fd to the client's write-back spool, if any, so
+// that its writes are spooled and uploaded after close.
+func writeBack(client *drive.Client, fd *drive.FileDescriptor) syscall.Errno {
+	if client.Spool == nil {
+		return 0
+	}
+	if err := client.Spool.Attach(fd); err != nil {
+		_ = fd.Close()
+		slog.Warn("writeBack: attach failed", "error", err)
+		return syscall.EIO
+	}
+	return 0
+}
+
+// writeBackWait bounds how long modifying a file waits for its queued
+// write-back jobs.
+const writeBackWait = 30 * time.Second
+
+// waitWriteBack waits for the queued write-back jobs of the file, so
+// that modifying it starts from the content last written through the
+// mount. It gives up with EBUSY after writeBackWait, as the uploader
+// retries failed jobs for as long as it takes, and with EINTR when the
+// FUSE request is interrupted.
+func (n *FileNode) waitWriteBack(ctx context.Context) syscall.Errno {
+	spool := n.client.Spool
+	if spool == nil {
+		return 0
+	}
+	if _, ok := spool.Pending(n.link.LinkID()); !ok {
+		return 0
+	}
+	wctx, cancel := context.WithTimeout(ctx, writeBackWait)
+	defer cancel()
+	if err := spool.Wait(wctx, n.link.LinkID()); err != nil {
+		if ctx.Err() != nil {
+			return syscall.EINTR
+		}
+		slog.Debug("FileNode.waitWriteBack: upload still queued", "linkID", n.link.LinkID())
+		return syscall.EBUSY
+	}
+
+	// The upload committed a revision; fetch the link again.
+	link, err := n.client.StatLink(ctx, n.link.Share(), n.link.ParentLink(), n.link.LinkID())
+	if err != nil {
+		slog.Debug("FileNode.waitWriteBack: stat failed", "linkID", n.link.LinkID(), "error", err)
+		return apiErrno(err)
+	}
+	n.link = link
+	return 0
+}
+
+// writeErrno maps an error from a write-mode FD to a FUSE errno: EBUSY
+// while another client holds a draft of the file, ENETDOWN while the
+// API is unreachable, EIO otherwise.
+func writeErrno(err error) syscall.Errno {
+	if errors.Is(err, drive.ErrDraftExist) {
+		return syscall.EBUSY
+	}
+	if drive.IsNetworkError(err) {
+		return syscall.ENETDOWN
+	}
+	return syscall.EIO
+}
+
 // Open creates a FileDescriptor for reading or writing depending on flags.
-// The context passed to OpenFD/OverwriteFD is context.Background() — the FD
-// context must outlive the FUSE request.
-func (n *FileNode) Open(_ context.Context, flags uint32) (fusemount.FileHandle, syscall.Errno) {
+// A write-mode FD starts from the current content, so writes at any
+// offset patch the file; O_TRUNC truncates it first. The context passed
+// to OpenFD/ModifyFD is context.Background() — the FD context must
+// outlive the FUSE request. With write-back enabled, writes are spooled;
+// opening a file with queued uploads for reading reads the queued
+// content, and for writing waits for the uploads (see waitWriteBack).
+// While the API is unreachable, opening for writing fails with
+// ENETDOWN; pinned files open for reading.
+func (n *FileNode) Open(ctx context.Context, flags uint32) (fusemount.FileHandle, syscall.Errno) {
 	// Determine mode from flags.
 	isWrite := flags&(syscall.O_WRONLY|syscall.O_RDWR) != 0
 
 	if isWrite {
-		share := n.link.Share()
-		fd, err := n.client.OverwriteFD(context.Background(), share, n.link)
+		if errno := offlineErrno(n.client); errno != 0 {
+			return nil, errno
+		}
+		if errno := n.waitWriteBack(ctx); errno != 0 {
+			return nil, errno
+		}
+		link := current(n.client, n.link)
+		share := link.Share()
+		fd, err := n.client.ModifyFD(context.Background(), share, link)
 		if err != nil {
-			if errors.Is(err, drive.ErrDraftExist) {
-				return nil, syscall.EBUSY
-			}
-			slog.Debug("FileNode.Open: write failed", "linkID", n.link.LinkID(), "error", err)
-			return nil, syscall.EIO
+			slog.Debug("FileNode.Open: write failed", "linkID", link.LinkID(), "error", err)
+			return nil, writeErrno(err)
+		}
+		if errno := writeBack(n.client, fd); errno != 0 {
+			return nil, errno
 		}
-		// Handle O_TRUNC: reset file size to 0.
 		if flags&syscall.O_TRUNC != 0 {
-			_ = fd.Truncate(0)
+			if err := fd.Truncate(0); err != nil {
+				_ = fd.Close()
+				slog.Debug("FileNode.Open: truncate failed", "linkID", link.LinkID(), "error", err)
+				return nil, writeErrno(err)
+			}
 		}
 		return &fdHandle{fd: fd}, 0
 	}
 
-	// Read mode — existing behavior.
-	fd, err := n.client.OpenFD(context.Background(), n.link)
+	// Read mode. A file with queued write-back jobs reads as they
+	// leave it.
+	link := current(n.client, n.link)
+	if n.client.Spool != nil {
+		fd, err := n.client.Spool.OpenFD(context.Background(), link)
+		if err != nil {
+			slog.Debug("FileNode.Open: read queued failed", "linkID", link.LinkID(), "error", err)
+			return nil, apiErrno(err)
+		}
+		if fd != nil {
+			return &fdHandle{fd: fd}, 0
+		}
+	}
+	fd, err := n.client.OpenFD(context.Background(), link)
 	if err != nil {
-		slog.Debug("FileNode.Open: read failed", "linkID", n.link.LinkID(), "error", err)
-		return nil, syscall.EIO
+		slog.Debug("FileNode.Open: read failed", "linkID", link.LinkID(), "error", err)
+		return nil, apiErrno(err)
 	}
 	return &fdHandle{fd: fd}, 0
 }
 
 // Read delegates to fd.ReadAt and maps errors to FUSE errnos.
 func (n *FileNode) Read(_ context.Context, fh fusemount.FileHandle, dest []byte, off int64) (int, syscall.Errno) {
+	return readHandle("FileNode.Read", n.link, fh, dest, off)
+}
+
+// readHandle reads from the FD of a read handle at off. A short read
+// returns what was read; op names the caller in logs.
+func readHandle(op string, link *drive.Link, fh fusemount.FileHandle, dest []byte, off int64) (int, syscall.Errno) {
 	h, ok := fh.(*fdHandle)
 	if !ok || h == nil {
 		return 0, syscall.EBADF
@@ -676,8 +1118,8 @@ func (n *FileNode) Read(_ context.Context, fh fusemount.FileHandle, dest []byte,
 		if errors.Is(err, os.ErrClosed) {
 			return 0, syscall.EBADF
 		}
-		slog.Debug("FileNode.Read: EIO", "linkID", n.link.LinkID(), "offset", off, "error", err)
-		return 0, syscall.EIO
+		slog.Debug(op+": EIO", "linkID", link.LinkID(), "offset", off, "error", err)
+		return 0, apiErrno(err)
 	}
 	return bytesRead, 0
 }
@@ -696,8 +1138,8 @@ func (n *FileNode) Write(_ context.Context, fh fusemount.FileHandle, data []byte
 		if errors.Is(err, syscall.EBADF) {
 			return 0, syscall.EBADF
 		}
-		slog.Debug("FileNode.Write: EIO", "linkID", n.link.LinkID(), "offset", off, "error", err)
-		return 0, syscall.EIO
+		slog.Debug("FileNode.Write: failed", "linkID", n.link.LinkID(), "offset", off, "error", err)
+		return 0, writeErrno(err)
 	}
 	return uint32(written), 0 //nolint:gosec // written is bounded by len(data) which fits uint32
 }
@@ -214,3 +214,34 @@ func TestFileNode_Release_CloseError(t *testing.T) {
 		t.Fatalf("Release (second/idempotent): got errno %d, want 0", errno)
 	}
 }
+
+// TestLinkNode_Symlink verifies that a file with the symlink MIME type
+// is listed and looked up as a SymlinkNode, while other files remain
+// FileNodes.
+func TestLinkNode_Symlink(t *testing.T) {
+	client := drive.NewTestClient(nil)
+
+	symLink := drive.NewTestLink(&proton.Link{
+		LinkID:   "sym",
+		Type:     proton.LinkTypeFile,
+		MIMEType: drive.SymlinkMIMEType,
+	}, nil, nil, nil, "link")
+	if got := linkMode(symLink); got != syscall.S_IFLNK|0777 {
+		t.Errorf("linkMode(symlink) = %o, want %o", got, syscall.S_IFLNK|0777)
+	}
+	if _, ok := linkNode(symLink, client).(*SymlinkNode); !ok {
+		t.Errorf("linkNode(symlink) = %T, want *SymlinkNode", linkNode(symLink, client))
+	}
+
+	fileLink := drive.NewTestLink(&proton.Link{
+		LinkID:   "file",
+		Type:     proton.LinkTypeFile,
+		MIMEType: "text/plain",
+	}, nil, nil, nil, "file.txt")
+	if got := linkMode(fileLink); got != syscall.S_IFREG|0600 {
+		t.Errorf("linkMode(file) = %o, want %o", got, syscall.S_IFREG|0600)
+	}
+	if _, ok := linkNode(fileLink, client).(*FileNode); !ok {
+		t.Errorf("linkNode(file) = %T, want *FileNode", linkNode(fileLink, client))
+	}
+}
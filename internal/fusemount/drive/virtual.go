@@ -0,0 +1,536 @@
+//go:build linux
+
+package drive
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+	"github.com/major0/proton-utils/internal/fusemount"
+)
+
+// Names of the virtual directories. .revisions is present in every
+// folder, .Trash in every share root. They are listed by Readdir only
+// when the client's ShowVirtualDirs is set; Lookup finds them either
+// way. A real child of the same name hides the virtual directory.
+const (
+	revisionsDirName = ".revisions"
+	trashDirName     = ".Trash"
+)
+
+// revisionTimeFormat names a revision by its creation time, in UTC.
+const revisionTimeFormat = "2006-01-02T15:04:05Z"
+
+// virtualDirEntries returns the virtual directory entries Readdir
+// appends to a folder listing. Share roots also get .Trash. Names taken
+// by a real child in children are left out.
+func virtualDirEntries(client *drive.Client, shareRoot bool, children map[string]*drive.Link) []fusemount.DirEntry {
+	if !client.ShowVirtualDirs {
+		return nil
+	}
+	names := []string{revisionsDirName}
+	if shareRoot {
+		names = append(names, trashDirName)
+	}
+	var entries []fusemount.DirEntry
+	for _, name := range names {
+		if _, ok := children[name]; !ok {
+			entries = append(entries, fusemount.DirEntry{Name: name, Mode: syscall.S_IFDIR})
+		}
+	}
+	return entries
+}
+
+// virtualChild returns the virtual directory name refers to in the
+// share root, or nil. Callers check the real children first.
+func (n *ShareDirNode) virtualChild(name string) fusemount.Node {
+	switch name {
+	case revisionsDirName:
+		return &RevisionsDir{dir: n.share.Link, client: n.client}
+	case trashDirName:
+		return &TrashDir{share: n.share, client: n.client}
+	}
+	return nil
+}
+
+// virtualChild returns the virtual directory name refers to in the
+// folder, or nil. Callers check the real children first.
+func (n *LinkDirNode) virtualChild(name string) fusemount.Node {
+	if name == revisionsDirName {
+		return &RevisionsDir{dir: n.link, client: n.client}
+	}
+	return nil
+}
+
+// isVirtualNode reports whether a node is one of the read-only virtual
+// directories, which nothing can be moved into.
+func isVirtualNode(node fusemount.Node) bool {
+	switch node.(type) {
+	case *RevisionsDir, *FileRevisionsDir, *TrashDir, *TrashedDirNode:
+		return true
+	}
+	return false
+}
+
+// revisionNames names revisions by their creation time. Revisions
+// created within the same second get a ~2, ~3, ... suffix in list
+// order. The result is parallel to revs.
+func revisionNames(revs []proton.RevisionMetadata) []string {
+	names := make([]string, len(revs))
+	seen := make(map[string]int, len(revs))
+	for i, rev := range revs {
+		name := time.Unix(rev.CreateTime, 0).UTC().Format(revisionTimeFormat)
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name += "~" + strconv.Itoa(n)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// trashNames names trashed links by their decrypted names. Links that
+// share a name are told apart by a ~<LinkID> suffix; links whose name
+// cannot be decrypted get an empty name and are skipped by callers.
+// The result is parallel to links.
+func trashNames(links []*drive.Link) []string {
+	names := make([]string, len(links))
+	count := make(map[string]int, len(links))
+	for i, l := range links {
+		name, err := l.Name()
+		if err != nil {
+			slog.Debug("trashNames: skipping link with decryption error",
+				"linkID", l.LinkID(), "error", err)
+			continue
+		}
+		names[i] = name
+		count[name]++
+	}
+	for i, name := range names {
+		if name != "" && count[name] > 1 {
+			names[i] = name + "~" + drive.SanitizeLinkID(links[i].LinkID())
+		}
+	}
+	return names
+}
+
+// activeRevision returns the metadata of the active revision of a file.
+func activeRevision(l *drive.Link) proton.RevisionMetadata {
+	if fp := l.ProtonLink().FileProperties; fp != nil {
+		return fp.ActiveRevision
+	}
+	return proton.RevisionMetadata{}
+}
+
+// isRegularFile reports whether a link is a file other than a symlink.
+func isRegularFile(l *drive.Link) bool {
+	return l.IsFile() && !l.IsSymlink()
+}
+
+// RevisionsDir is the virtual .revisions/ directory of a folder. It
+// lists the regular files of the folder, each as a directory of its
+// revisions. Implements fusemount.DirNode.
+type RevisionsDir struct {
+	dir    *drive.Link
+	client *drive.Client
+}
+
+// Compile-time interface assertions.
+var _ fusemount.Node = (*RevisionsDir)(nil)
+var _ fusemount.DirNode = (*RevisionsDir)(nil)
+
+// Getattr returns read-only directory attributes with the folder's
+// timestamps.
+func (n *RevisionsDir) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	//nolint:gosec // ModifyTime/CreateTime are non-negative from API
+	return fusemount.Attr{
+		Mode:  syscall.S_IFDIR | 0500,
+		Nlink: 2,
+		Mtime: uint64(n.dir.ModifyTime()),
+		Ctime: uint64(n.dir.CreateTime()),
+	}, 0
+}
+
+// Readdir lists the regular files of the folder as directories.
+func (n *RevisionsDir) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall.Errno) {
+	var entries []fusemount.DirEntry
+	for de := range n.dir.Readdir(context.Background()) {
+		if de.Err != nil {
+			slog.Debug("RevisionsDir.Readdir: error from Readdir stream",
+				"linkID", n.dir.LinkID(), "error", de.Err)
+			return nil, apiErrno(de.Err)
+		}
+		name, err := de.EntryName()
+		if err != nil || name == "." || name == ".." {
+			continue
+		}
+		if de.Link.IsTrashed() || de.Link.IsDraft() || !isRegularFile(de.Link) {
+			continue
+		}
+		entries = append(entries, fusemount.DirEntry{Name: name, Mode: syscall.S_IFDIR})
+	}
+	return entries, 0
+}
+
+// Lookup resolves a regular file of the folder to the directory of its
+// revisions.
+func (n *RevisionsDir) Lookup(_ context.Context, name string) (fusemount.Node, syscall.Errno) {
+	child, err := n.dir.Lookup(context.Background(), name)
+	if err != nil {
+		slog.Debug("RevisionsDir.Lookup: failed",
+			"linkID", n.dir.LinkID(), "error", err)
+		return nil, apiErrno(err)
+	}
+	if child == nil || !isRegularFile(child) {
+		return nil, syscall.ENOENT
+	}
+	return &FileRevisionsDir{link: child, client: n.client}, 0
+}
+
+// FileRevisionsDir is the virtual .revisions/<file>/ directory that
+// lists the committed revisions of a file by creation time (see
+// revisionNames). Retains the revisions from the last Readdir so
+// Lookup can resolve locally. Implements fusemount.DirNode.
+type FileRevisionsDir struct {
+	link      *drive.Link
+	client    *drive.Client
+	revisions map[string]proton.RevisionMetadata // name → revision, populated by Readdir
+}
+
+// Compile-time interface assertions.
+var _ fusemount.Node = (*FileRevisionsDir)(nil)
+var _ fusemount.DirNode = (*FileRevisionsDir)(nil)
+
+// Getattr returns read-only directory attributes with the file's
+// timestamps.
+func (n *FileRevisionsDir) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	//nolint:gosec // ModifyTime/CreateTime are non-negative from API
+	return fusemount.Attr{
+		Mode:  syscall.S_IFDIR | 0500,
+		Nlink: 2,
+		Mtime: uint64(n.link.ModifyTime()),
+		Ctime: uint64(n.link.CreateTime()),
+	}, 0
+}
+
+// listRevisions fetches the revisions of the file and retains them by
+// name.
+func (n *FileRevisionsDir) listRevisions() (map[string]proton.RevisionMetadata, []string, syscall.Errno) {
+	revs, err := n.client.Revisions(context.Background(), n.link)
+	if err != nil {
+		slog.Debug("FileRevisionsDir: list revisions failed",
+			"linkID", n.link.LinkID(), "error", err)
+		return nil, nil, apiErrno(err)
+	}
+	names := revisionNames(revs)
+	revisions := make(map[string]proton.RevisionMetadata, len(revs))
+	for i, rev := range revs {
+		revisions[names[i]] = rev
+	}
+	n.revisions = revisions
+	return revisions, names, 0
+}
+
+// Readdir lists the revisions of the file as read-only files.
+func (n *FileRevisionsDir) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall.Errno) {
+	_, names, errno := n.listRevisions()
+	if errno != 0 {
+		return nil, errno
+	}
+	entries := make([]fusemount.DirEntry, len(names))
+	for i, name := range names {
+		entries[i] = fusemount.DirEntry{Name: name, Mode: syscall.S_IFREG}
+	}
+	return entries, 0
+}
+
+// Lookup resolves a revision name to a read-only file node.
+func (n *FileRevisionsDir) Lookup(_ context.Context, name string) (fusemount.Node, syscall.Errno) {
+	revisions := n.revisions
+	if revisions == nil {
+		var errno syscall.Errno
+		if revisions, _, errno = n.listRevisions(); errno != 0 {
+			return nil, errno
+		}
+	}
+	rev, ok := revisions[name]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	return &RevisionFileNode{link: n.link, rev: rev, client: n.client}, 0
+}
+
+// RevisionFileNode is a read-only view of one revision of a file: a
+// historic revision under .revisions/, or the active revision of a
+// trashed file under .Trash/.
+type RevisionFileNode struct {
+	link   *drive.Link
+	rev    proton.RevisionMetadata
+	client *drive.Client
+}
+
+// Compile-time interface assertions.
+var _ fusemount.Node = (*RevisionFileNode)(nil)
+var _ fusemount.NodeOpener = (*RevisionFileNode)(nil)
+var _ fusemount.NodeReader = (*RevisionFileNode)(nil)
+var _ fusemount.NodeReleaser = (*RevisionFileNode)(nil)
+
+// Getattr returns read-only file attributes. Both timestamps are the
+// time the revision was created.
+func (n *RevisionFileNode) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	//nolint:gosec // Size/CreateTime are non-negative from API
+	return fusemount.Attr{
+		Mode:  syscall.S_IFREG | 0400,
+		Size:  uint64(n.rev.Size),
+		Nlink: 1,
+		Mtime: uint64(n.rev.CreateTime),
+		Ctime: uint64(n.rev.CreateTime),
+	}, 0
+}
+
+// Open opens the revision for reading. Opening for writing fails with
+// EROFS.
+func (n *RevisionFileNode) Open(_ context.Context, flags uint32) (fusemount.FileHandle, syscall.Errno) {
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_TRUNC) != 0 {
+		return nil, syscall.EROFS
+	}
+	fd, err := n.client.OpenRevisionFD(context.Background(), n.link, n.rev)
+	if err != nil {
+		slog.Debug("RevisionFileNode.Open: failed",
+			"linkID", n.link.LinkID(), "revisionID", n.rev.ID, "error", err)
+		return nil, apiErrno(err)
+	}
+	return &fdHandle{fd: fd}, 0
+}
+
+// Read delegates to fd.ReadAt and maps errors to FUSE errnos.
+func (n *RevisionFileNode) Read(_ context.Context, fh fusemount.FileHandle, dest []byte, off int64) (int, syscall.Errno) {
+	return readHandle("RevisionFileNode.Read", n.link, fh, dest, off)
+}
+
+// Release closes the FileDescriptor.
+func (n *RevisionFileNode) Release(_ context.Context, fh fusemount.FileHandle) syscall.Errno {
+	h, ok := fh.(*fdHandle)
+	if !ok || h == nil {
+		return 0
+	}
+	if err := h.fd.Close(); err != nil {
+		slog.Warn("RevisionFileNode.Release: close error", "linkID", n.link.LinkID(), "error", err)
+	}
+	return 0
+}
+
+// trashedNode returns the read-only node for a trashed link or a child
+// of a trashed folder.
+func trashedNode(l *drive.Link, client *drive.Client) fusemount.Node {
+	if l.IsDir() {
+		return &TrashedDirNode{link: l, client: client}
+	}
+	if l.IsSymlink() {
+		return &SymlinkNode{link: l, client: client}
+	}
+	return &RevisionFileNode{link: l, rev: activeRevision(l), client: client}
+}
+
+// TrashDir is the virtual .Trash/ directory of a share. It lists the
+// trashed links of the share (see trashNames) as read-only nodes;
+// renaming an entry out of it restores the link. Retains the entries
+// from the last Readdir so Lookup can resolve locally. Implements
+// fusemount.DirNode and fusemount.NodeRenamer.
+type TrashDir struct {
+	share   *drive.Share
+	client  *drive.Client
+	entries map[string]*drive.Link // name → Link, populated by Readdir
+}
+
+// Compile-time interface assertions.
+var _ fusemount.Node = (*TrashDir)(nil)
+var _ fusemount.DirNode = (*TrashDir)(nil)
+var _ fusemount.NodeRenamer = (*TrashDir)(nil)
+
+// Getattr returns directory attributes with the share root's
+// timestamps.
+func (n *TrashDir) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	//nolint:gosec // ModifyTime/CreateTime are non-negative from API
+	return fusemount.Attr{
+		Mode:  syscall.S_IFDIR | 0700,
+		Nlink: 2,
+		Mtime: uint64(n.share.Link.ModifyTime()),
+		Ctime: uint64(n.share.Link.CreateTime()),
+	}, 0
+}
+
+// listTrash fetches the trashed links of the share and retains them by
+// name.
+func (n *TrashDir) listTrash() (map[string]*drive.Link, syscall.Errno) {
+	links, err := n.client.ListTrash(context.Background(), n.share)
+	if err != nil {
+		slog.Debug("TrashDir: list trash failed",
+			"shareID", n.share.Metadata().ShareID, "error", err)
+		return nil, apiErrno(err)
+	}
+	entries := make(map[string]*drive.Link, len(links))
+	for i, name := range trashNames(links) {
+		if name != "" {
+			entries[name] = links[i]
+		}
+	}
+	n.entries = entries
+	return entries, 0
+}
+
+// Readdir lists the trashed links of the share.
+func (n *TrashDir) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall.Errno) {
+	entries, errno := n.listTrash()
+	if errno != 0 {
+		return nil, errno
+	}
+	out := make([]fusemount.DirEntry, 0, len(entries))
+	for name, l := range entries {
+		out = append(out, fusemount.DirEntry{Name: name, Mode: linkMode(l)})
+	}
+	return out, 0
+}
+
+// resolveEntry looks up a trashed link by name, listing the trash when
+// no Readdir has retained it.
+func (n *TrashDir) resolveEntry(name string) (*drive.Link, syscall.Errno) {
+	if l, ok := n.entries[name]; ok {
+		return l, 0
+	}
+	entries, errno := n.listTrash()
+	if errno != 0 {
+		return nil, errno
+	}
+	l, ok := entries[name]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	return l, 0
+}
+
+// Lookup resolves a trashed link by name to a read-only node.
+func (n *TrashDir) Lookup(_ context.Context, name string) (fusemount.Node, syscall.Errno) {
+	l, errno := n.resolveEntry(name)
+	if errno != 0 {
+		return nil, errno
+	}
+	return trashedNode(l, n.client), 0
+}
+
+// Rename restores a trashed link to the folder it was trashed from,
+// then moves it to newParent/newName if that is elsewhere. The
+// destination must be a folder of the same share.
+func (n *TrashDir) Rename(_ context.Context, oldName string, newParent fusemount.Node, newName string) syscall.Errno {
+	if errno := offlineErrno(n.client); errno != 0 {
+		return errno
+	}
+
+	var dst *drive.Link
+	var dstShare *drive.Share
+	switch p := newParent.(type) {
+	case *LinkDirNode:
+		dst, dstShare = p.link, p.link.Share()
+	case *ShareDirNode:
+		dst, dstShare = p.share.Link, p.share
+	default:
+		return syscall.EPERM
+	}
+	if dstShare.ProtonShare().ShareID != n.share.ProtonShare().ShareID {
+		return syscall.EXDEV
+	}
+
+	link, errno := n.resolveEntry(oldName)
+	if errno != 0 {
+		return errno
+	}
+	name, err := link.Name()
+	if err != nil {
+		slog.Debug("TrashDir.Rename: name", "linkID", link.LinkID(), "error", err)
+		return apiErrno(err)
+	}
+
+	ctx := context.Background()
+	if err := n.client.RestoreTrash(ctx, n.share, link); err != nil {
+		slog.Debug("TrashDir.Rename: restore failed",
+			"linkID", link.LinkID(), "error", err)
+		return apiErrno(err)
+	}
+	n.entries = nil
+
+	if dst.LinkID() != link.ParentLink().LinkID() || newName != name {
+		if err := n.client.Move(ctx, n.share, link, dst, newName); err != nil {
+			return renameErrno(err)
+		}
+	}
+
+	if dn, ok := newParent.(*LinkDirNode); ok {
+		dn.children = nil
+	}
+	if sn, ok := newParent.(*ShareDirNode); ok {
+		sn.children = nil
+	}
+	return 0
+}
+
+// TrashedDirNode is a read-only view of a trashed folder, or of a
+// folder inside one. Implements fusemount.DirNode.
+type TrashedDirNode struct {
+	link   *drive.Link
+	client *drive.Client
+}
+
+// Compile-time interface assertions.
+var _ fusemount.Node = (*TrashedDirNode)(nil)
+var _ fusemount.DirNode = (*TrashedDirNode)(nil)
+
+// Getattr returns read-only directory attributes for the folder.
+func (n *TrashedDirNode) Getattr(_ context.Context) (fusemount.Attr, syscall.Errno) {
+	//nolint:gosec // ModifyTime/CreateTime are non-negative from API
+	return fusemount.Attr{
+		Mode:  syscall.S_IFDIR | 0500,
+		Nlink: 2,
+		Mtime: uint64(n.link.ModifyTime()),
+		Ctime: uint64(n.link.CreateTime()),
+	}, 0
+}
+
+// Readdir lists the children of the folder.
+func (n *TrashedDirNode) Readdir(_ context.Context) ([]fusemount.DirEntry, syscall.Errno) {
+	var entries []fusemount.DirEntry
+	for de := range n.link.Readdir(context.Background()) {
+		if de.Err != nil {
+			slog.Debug("TrashedDirNode.Readdir: error from Readdir stream",
+				"linkID", n.link.LinkID(), "error", de.Err)
+			return nil, apiErrno(de.Err)
+		}
+		name, err := de.EntryName()
+		if err != nil || name == "." || name == ".." {
+			continue
+		}
+		if de.Link.IsDraft() {
+			continue
+		}
+		entries = append(entries, fusemount.DirEntry{Name: name, Mode: linkMode(de.Link)})
+	}
+	return entries, 0
+}
+
+// Lookup resolves a child of the folder to a read-only node.
+func (n *TrashedDirNode) Lookup(_ context.Context, name string) (fusemount.Node, syscall.Errno) {
+	child, err := n.link.Lookup(context.Background(), name)
+	if err != nil {
+		slog.Debug("TrashedDirNode.Lookup: failed",
+			"linkID", n.link.LinkID(), "error", err)
+		return nil, apiErrno(err)
+	}
+	if child == nil {
+		return nil, syscall.ENOENT
+	}
+	return trashedNode(child, n.client), 0
+}
@@ -0,0 +1,178 @@
+//go:build linux
+
+package drive
+
+import (
+	"context"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+	"github.com/major0/proton-utils/internal/fusemount"
+)
+
+func TestRevisionNames(t *testing.T) {
+	// 1700000000 is 2023-11-14T22:13:20Z.
+	revs := []proton.RevisionMetadata{
+		{ID: "r1", CreateTime: 1700000000},
+		{ID: "r2", CreateTime: 1700000060},
+		{ID: "r3", CreateTime: 1700000000},
+		{ID: "r4", CreateTime: 1700000000},
+	}
+	want := []string{
+		"2023-11-14T22:13:20Z",
+		"2023-11-14T22:14:20Z",
+		"2023-11-14T22:13:20Z~2",
+		"2023-11-14T22:13:20Z~3",
+	}
+	if got := revisionNames(revs); !slices.Equal(got, want) {
+		t.Fatalf("revisionNames = %q, want %q", got, want)
+	}
+}
+
+func TestTrashNames(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	link := func(id, name string) *drive.Link {
+		return drive.NewTestLink(&proton.Link{LinkID: id, Type: proton.LinkTypeFile}, share.Link, share, nil, name)
+	}
+	links := []*drive.Link{link("id-1==", "a.txt"), link("id-2", "b.txt"), link("id-3=", "a.txt")}
+	want := []string{"a.txt~id-1", "b.txt", "a.txt~id-3"}
+	if got := trashNames(links); !slices.Equal(got, want) {
+		t.Fatalf("trashNames = %q, want %q", got, want)
+	}
+}
+
+func TestVirtualDirEntries(t *testing.T) {
+	client := drive.NewTestClient(nil)
+	if got := virtualDirEntries(client, true, nil); got != nil {
+		t.Fatalf("unconfigured: got %v, want none", got)
+	}
+
+	client.ShowVirtualDirs = true
+	names := func(entries []fusemount.DirEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+	if got := names(virtualDirEntries(client, true, nil)); !slices.Equal(got, []string{".revisions", ".Trash"}) {
+		t.Errorf("share root: got %q", got)
+	}
+	if got := names(virtualDirEntries(client, false, nil)); !slices.Equal(got, []string{".revisions"}) {
+		t.Errorf("folder: got %q", got)
+	}
+}
+
+func TestVirtualDirs_Lookup(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	client := drive.NewTestClient(nil)
+	// Empty children, as after a Readdir: no real child has the name.
+	sn := &ShareDirNode{share: share, client: client, children: map[string]*drive.Link{}}
+	ln := &LinkDirNode{link: share.Link, client: client, children: map[string]*drive.Link{}}
+
+	tests := []struct {
+		name   string
+		lookup func(context.Context, string) (fusemount.Node, syscall.Errno)
+		child  string
+		check  func(fusemount.Node) bool
+	}{
+		{"share .revisions", sn.Lookup, ".revisions", func(n fusemount.Node) bool { _, ok := n.(*RevisionsDir); return ok }},
+		{"share .Trash", sn.Lookup, ".Trash", func(n fusemount.Node) bool { _, ok := n.(*TrashDir); return ok }},
+		{"folder .revisions", ln.Lookup, ".revisions", func(n fusemount.Node) bool { _, ok := n.(*RevisionsDir); return ok }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, errno := tt.lookup(context.Background(), tt.child)
+			if errno != 0 || !tt.check(node) {
+				t.Fatalf("Lookup(%s) = %T, %d", tt.child, node, errno)
+			}
+		})
+	}
+}
+
+func TestVirtualDirs_RealChildWins(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	client := drive.NewTestClient(nil)
+	client.ShowVirtualDirs = true
+	folder := func(id, name string) *drive.Link {
+		return drive.NewTestLink(&proton.Link{LinkID: id, Type: proton.LinkTypeFolder}, share.Link, share, nil, name)
+	}
+	children := map[string]*drive.Link{
+		".Trash":     folder("trash-id", ".Trash"),
+		".revisions": folder("revs-id", ".revisions"),
+	}
+	sn := &ShareDirNode{share: share, client: client, children: children}
+	ln := &LinkDirNode{link: share.Link, client: client, children: children}
+
+	for _, name := range []string{".Trash", ".revisions"} {
+		node, errno := sn.Lookup(context.Background(), name)
+		if dn, ok := node.(*LinkDirNode); errno != 0 || !ok || dn.link != children[name] {
+			t.Errorf("share Lookup(%s) = %T, %d; want the real folder", name, node, errno)
+		}
+	}
+	node, errno := ln.Lookup(context.Background(), ".revisions")
+	if dn, ok := node.(*LinkDirNode); errno != 0 || !ok || dn.link != children[".revisions"] {
+		t.Errorf("folder Lookup(.revisions) = %T, %d; want the real folder", node, errno)
+	}
+
+	if got := virtualDirEntries(client, true, children); len(got) != 0 {
+		t.Errorf("share root entries = %v, want none beside the real children", got)
+	}
+	delete(children, ".Trash")
+	if got := virtualDirEntries(client, true, children); len(got) != 1 || got[0].Name != ".Trash" {
+		t.Errorf("share root entries = %v, want only .Trash", got)
+	}
+}
+
+func TestRevisionFileNode_ReadOnly(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	link := drive.NewTestLink(&proton.Link{LinkID: "file-id", Type: proton.LinkTypeFile}, share.Link, share, nil, "file.txt")
+	n := &RevisionFileNode{
+		link:   link,
+		rev:    proton.RevisionMetadata{ID: "rev-id", CreateTime: 1700000000, Size: 42},
+		client: drive.NewTestClient(nil),
+	}
+
+	attr, errno := n.Getattr(context.Background())
+	if errno != 0 {
+		t.Fatalf("Getattr errno = %d", errno)
+	}
+	if attr.Mode != syscall.S_IFREG|0400 || attr.Size != 42 || attr.Mtime != 1700000000 {
+		t.Fatalf("Getattr = %+v", attr)
+	}
+
+	for _, flags := range []uint32{syscall.O_WRONLY, syscall.O_RDWR, syscall.O_TRUNC} {
+		if _, errno := n.Open(context.Background(), flags); errno != syscall.EROFS {
+			t.Errorf("Open(%#x) errno = %d, want EROFS", flags, errno)
+		}
+	}
+}
+
+func TestRename_VirtualDirs(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	client := drive.NewTestClient(nil)
+	sn := &ShareDirNode{share: share, client: client}
+	trash := &TrashDir{share: share, client: client}
+	revs := &RevisionsDir{dir: share.Link, client: client}
+
+	// Nothing can be moved into a virtual directory.
+	for _, dst := range []fusemount.Node{trash, revs} {
+		if errno := sn.Rename(context.Background(), "a.txt", dst, "a.txt"); errno != syscall.EPERM {
+			t.Errorf("Rename into %T errno = %d, want EPERM", dst, errno)
+		}
+	}
+
+	// Trashed links can only be restored into a folder.
+	if errno := trash.Rename(context.Background(), "a.txt", revs, "a.txt"); errno != syscall.EPERM {
+		t.Errorf("Rename out of .Trash into .revisions errno = %d, want EPERM", errno)
+	}
+
+	// Restoring into another share is a cross-device move.
+	other := testShare("other", "other-id", proton.ShareTypeStandard)
+	if errno := trash.Rename(context.Background(), "a.txt", &ShareDirNode{share: other, client: client}, "a.txt"); errno != syscall.EXDEV {
+		t.Errorf("Rename out of .Trash into another share errno = %d, want EXDEV", errno)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build linux
+
+package drive
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// protonXattrPrefix is the read-only namespace of extended attributes
+// that expose Proton metadata: link_id, share_id, and for files
+// revision_id, mime_type and digest.<algorithm>. Share roots with a
+// public URL also carry public_url, without the password.
+const protonXattrPrefix = "user.proton."
+
+// isProtonXattr reports whether attr is in the read-only Proton namespace.
+func isProtonXattr(attr string) bool {
+	return strings.HasPrefix(attr, protonXattrPrefix)
+}
+
+// protonXattrs returns the Proton metadata attributes of link in share.
+// It never calls the API: the public URL of a standard share root comes
+// from the client's cache, which RefreshShares keeps current.
+func protonXattrs(client *drive.Client, share *drive.Share, link *drive.Link) map[string][]byte {
+	attrs := map[string][]byte{
+		protonXattrPrefix + "link_id": []byte(link.LinkID()),
+	}
+	if link.IsFile() {
+		if id := link.RevisionID(); id != "" {
+			attrs[protonXattrPrefix+"revision_id"] = []byte(id)
+		}
+		if mimeType := link.MIMEType(); mimeType != "" {
+			attrs[protonXattrPrefix+"mime_type"] = []byte(mimeType)
+		}
+		for alg, digest := range link.Digests() {
+			attrs[protonXattrPrefix+"digest."+strings.ToLower(alg)] = []byte(digest)
+		}
+	}
+
+	if share == nil {
+		return attrs
+	}
+	attrs[protonXattrPrefix+"share_id"] = []byte(share.ProtonShare().ShareID)
+	if share.ProtonShare().Type == proton.ShareTypeStandard && share.Link != nil && share.Link.LinkID() == link.LinkID() {
+		if url := client.PublicURL(share.ProtonShare().ShareID); url != "" {
+			attrs[protonXattrPrefix+"public_url"] = []byte(url)
+		}
+	}
+	return attrs
+}
+
+// getProtonXattr returns the Proton metadata attribute attr of link.
+func getProtonXattr(client *drive.Client, share *drive.Share, link *drive.Link, attr string) ([]byte, syscall.Errno) {
+	val, ok := protonXattrs(client, share, link)[attr]
+	if !ok {
+		return nil, syscall.ENODATA
+	}
+	return val, 0
+}
+
+// listXattrs returns the names of the Proton metadata attributes of
+// link and of stored, sorted. Stored attributes in the Proton namespace
+// are hidden by the metadata.
+func listXattrs(client *drive.Client, share *drive.Share, link *drive.Link, stored map[string][]byte) []string {
+	names := slices.Collect(maps.Keys(protonXattrs(client, share, link)))
+	for name := range stored {
+		if !isProtonXattr(name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,130 @@
+//go:build linux
+
+package drive
+
+import (
+	"context"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/ProtonMail/go-proton-api"
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// testXattrFile returns a FileNode for a file with an active revision
+// in the main share.
+func testXattrFile() *FileNode {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	pLink := &proton.Link{
+		LinkID:   "file-id",
+		Type:     proton.LinkTypeFile,
+		MIMEType: "text/plain",
+		FileProperties: &proton.FileProperties{
+			ActiveRevision: proton.RevisionMetadata{ID: "rev-id"},
+		},
+	}
+	link := drive.NewTestLink(pLink, share.Link, share, nil, "file.txt")
+	return &FileNode{link: link, client: drive.NewTestClient(nil)}
+}
+
+func TestFileNode_Getxattr_Proton(t *testing.T) {
+	n := testXattrFile()
+	tests := []struct {
+		attr      string
+		want      string
+		wantErrno syscall.Errno
+	}{
+		{"user.proton.link_id", "file-id", 0},
+		{"user.proton.share_id", "main-id", 0},
+		{"user.proton.revision_id", "rev-id", 0},
+		{"user.proton.mime_type", "text/plain", 0},
+		{"user.proton.public_url", "", syscall.ENODATA},
+		{"user.proton.unknown", "", syscall.ENODATA},
+	}
+	for _, tt := range tests {
+		got, errno := n.Getxattr(context.Background(), tt.attr)
+		if errno != tt.wantErrno || string(got) != tt.want {
+			t.Errorf("Getxattr(%s) = %q, %d; want %q, %d", tt.attr, got, errno, tt.want, tt.wantErrno)
+		}
+	}
+}
+
+func TestFileNode_Listxattr_Proton(t *testing.T) {
+	names, errno := testXattrFile().Listxattr(context.Background())
+	if errno != 0 {
+		t.Fatalf("Listxattr errno = %d", errno)
+	}
+	want := []string{
+		"user.proton.link_id",
+		"user.proton.mime_type",
+		"user.proton.revision_id",
+		"user.proton.share_id",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("Listxattr = %v, want %v", names, want)
+	}
+}
+
+func TestFileNode_Setxattr_ProtonReadOnly(t *testing.T) {
+	n := testXattrFile()
+	if errno := n.Setxattr(context.Background(), "user.proton.link_id", []byte("x"), 0); errno != syscall.EPERM {
+		t.Errorf("Setxattr errno = %d, want EPERM", errno)
+	}
+	if errno := n.Removexattr(context.Background(), "user.proton.link_id"); errno != syscall.EPERM {
+		t.Errorf("Removexattr errno = %d, want EPERM", errno)
+	}
+}
+
+func TestDirNodes_Xattr_Proton(t *testing.T) {
+	share := testShare("root", "main-id", proton.ShareTypeMain)
+	folder := drive.NewTestLink(&proton.Link{LinkID: "dir-id", Type: proton.LinkTypeFolder}, share.Link, share, nil, "dir")
+	client := drive.NewTestClient(nil)
+
+	tests := []struct {
+		name string
+		node interface {
+			Getxattr(context.Context, string) ([]byte, syscall.Errno)
+			Listxattr(context.Context) ([]string, syscall.Errno)
+		}
+		linkID string
+	}{
+		{"share root", &ShareDirNode{share: share, client: client}, "link-main-id"},
+		{"folder", &LinkDirNode{link: folder, client: client}, "dir-id"},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if got, errno := tt.node.Getxattr(ctx, "user.proton.link_id"); errno != 0 || string(got) != tt.linkID {
+			t.Errorf("%s: Getxattr(link_id) = %q, %d; want %q", tt.name, got, errno, tt.linkID)
+		}
+		if _, errno := tt.node.Getxattr(ctx, "user.other"); errno != syscall.ENODATA {
+			t.Errorf("%s: Getxattr(user.other) errno = %d, want ENODATA", tt.name, errno)
+		}
+		names, _ := tt.node.Listxattr(ctx)
+		if want := []string{"user.proton.link_id", "user.proton.share_id"}; !slices.Equal(names, want) {
+			t.Errorf("%s: Listxattr = %v, want %v", tt.name, names, want)
+		}
+	}
+}
+
+func TestShareDirNode_Xattr_PublicURL(t *testing.T) {
+	share := testShare("shared", "std-id", proton.ShareTypeStandard)
+	client := drive.NewTestClient(nil)
+	n := &ShareDirNode{share: share, client: client}
+	ctx := context.Background()
+
+	// Without a cached URL the attribute is absent; nothing calls the API.
+	if _, errno := n.Getxattr(ctx, "user.proton.public_url"); errno != syscall.ENODATA {
+		t.Errorf("Getxattr(public_url) errno = %d, want ENODATA", errno)
+	}
+
+	client.SetPublicURLs(map[string]string{"std-id": "https://drive.proton.me/urls/ABC"})
+	got, errno := n.Getxattr(ctx, "user.proton.public_url")
+	if errno != 0 || string(got) != "https://drive.proton.me/urls/ABC" {
+		t.Errorf("Getxattr(public_url) = %q, %d; want the cached URL", got, errno)
+	}
+	names, _ := n.Listxattr(ctx)
+	if want := []string{"user.proton.link_id", "user.proton.public_url", "user.proton.share_id"}; !slices.Equal(names, want) {
+		t.Errorf("Listxattr = %v, want %v", names, want)
+	}
+}
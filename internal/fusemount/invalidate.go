@@ -0,0 +1,244 @@
+//go:build linux
+
+package fusemount
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxRecentChecks bounds the objects that are not open checked per
+// pass, the most recently used first, so that listing a large tree does
+// not turn into an API call per entry and pass. The others are left to
+// the kernel cache timeouts.
+const maxRecentChecks = 64
+
+// trackKey identifies a remote object within its namespace.
+type trackKey struct {
+	handler NamespaceHandler
+	id      string
+}
+
+// trackEntry holds the live inodes of one remote object and how
+// recently it was used. node is the most recently added Node for the
+// object, kept for checking while only open handles remain.
+type trackEntry struct {
+	node  Node
+	nodes map[*DispatchNode]struct{}
+	used  time.Time
+	open  int
+}
+
+// inodeTracker records the live inodes of nodes implementing
+// NodeIdentifier, so that a remote change invalidates exactly the
+// inodes exposing the changed object. Methods on a nil tracker are
+// no-ops.
+type inodeTracker struct {
+	mu      sync.Mutex
+	entries map[trackKey]*trackEntry
+	now     func() time.Time // injectable for tests
+}
+
+// newInodeTracker creates an empty inodeTracker.
+func newInodeTracker() *inodeTracker {
+	return &inodeTracker{
+		entries: make(map[trackKey]*trackEntry),
+		now:     time.Now,
+	}
+}
+
+// key returns the tracking key of d, or false if d's node has no NodeID.
+func (t *inodeTracker) key(d *DispatchNode) (trackKey, bool) {
+	if t == nil || d.node == nil {
+		return trackKey{}, false
+	}
+	ident, ok := d.node.(NodeIdentifier)
+	if !ok {
+		return trackKey{}, false
+	}
+	return trackKey{handler: d.handler, id: ident.NodeID()}, true
+}
+
+// add records a new inode and marks its object used.
+func (t *inodeTracker) add(d *DispatchNode) {
+	k, ok := t.key(d)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entries[k]
+	if e == nil {
+		e = &trackEntry{nodes: make(map[*DispatchNode]struct{})}
+		t.entries[k] = e
+	}
+	e.node = d.node
+	e.nodes[d] = struct{}{}
+	e.used = t.now()
+}
+
+// remove forgets an inode the kernel no longer references. The object
+// stays tracked while it has other inodes or open handles.
+func (t *inodeTracker) remove(d *DispatchNode) {
+	k, ok := t.key(d)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entries[k]
+	if e == nil {
+		return
+	}
+	delete(e.nodes, d)
+	if len(e.nodes) == 0 && e.open <= 0 {
+		delete(t.entries, k)
+	}
+}
+
+// touch marks the object of d used.
+func (t *inodeTracker) touch(d *DispatchNode) {
+	k, ok := t.key(d)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e := t.entries[k]; e != nil {
+		e.used = t.now()
+	}
+}
+
+// opened adjusts the open handle count of the object of d by delta.
+func (t *inodeTracker) opened(d *DispatchNode, delta int) {
+	k, ok := t.key(d)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entries[k]
+	if e == nil {
+		return
+	}
+	e.open += delta
+	e.used = t.now()
+	if len(e.nodes) == 0 && e.open <= 0 {
+		delete(t.entries, k)
+	}
+}
+
+// candidates returns, per handler, one node for each object that is
+// open, and for the maxRecentChecks most recently used others used
+// within window.
+func (t *inodeTracker) candidates(window time.Duration) map[NamespaceHandler]map[string]Node {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cutoff := t.now().Add(-window)
+	out := make(map[NamespaceHandler]map[string]Node)
+	add := func(k trackKey, e *trackEntry) {
+		if out[k.handler] == nil {
+			out[k.handler] = make(map[string]Node)
+		}
+		out[k.handler][k.id] = e.node
+	}
+
+	var recent []trackKey
+	for k, e := range t.entries {
+		switch {
+		case e.open > 0:
+			add(k, e)
+		case !e.used.Before(cutoff):
+			recent = append(recent, k)
+		}
+	}
+	slices.SortFunc(recent, func(a, b trackKey) int {
+		return t.entries[b].used.Compare(t.entries[a].used)
+	})
+	for _, k := range recent[:min(len(recent), maxRecentChecks)] {
+		add(k, t.entries[k])
+	}
+	return out
+}
+
+// changed returns the live inodes of a changed object. A removed object
+// is no longer tracked: its inodes are stale until the kernel forgets
+// them.
+func (t *inodeTracker) changed(handler NamespaceHandler, c Change) []*DispatchNode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	k := trackKey{handler: handler, id: c.ID}
+	e := t.entries[k]
+	if e == nil {
+		return nil
+	}
+	nodes := make([]*DispatchNode, 0, len(e.nodes))
+	for d := range e.nodes {
+		nodes = append(nodes, d)
+	}
+	if c.Removed {
+		delete(t.entries, k)
+	}
+	return nodes
+}
+
+// check asks every NamespaceChangeChecker about its candidate objects
+// and invalidates the inodes of those that changed.
+func (t *inodeTracker) check(ctx context.Context, window time.Duration) {
+	for handler, nodes := range t.candidates(window) {
+		checker, ok := handler.(NamespaceChangeChecker)
+		if !ok {
+			continue
+		}
+		for _, c := range checker.CheckChanges(ctx, nodes) {
+			for _, d := range t.changed(handler, c) {
+				d.invalidate(c.Removed)
+			}
+		}
+	}
+}
+
+// run checks for remote changes every interval until ctx is cancelled.
+func (t *inodeTracker) run(ctx context.Context, interval, window time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.check(ctx, window)
+		}
+	}
+}
+
+// invalidate drops the node's cached state and tells the kernel to
+// forget the entry and, unless removed, the attributes and pages of d.
+func (d *DispatchNode) invalidate(removed bool) {
+	if inv, ok := d.node.(NodeInvalidator); ok {
+		inv.Invalidate()
+	}
+
+	name, parent := d.Parent()
+	if parent != nil {
+		var errno syscall.Errno
+		if removed {
+			errno = parent.NotifyDelete(name, &d.Inode)
+		} else {
+			errno = parent.NotifyEntry(name)
+		}
+		if errno != 0 && errno != syscall.ENOENT {
+			slog.Debug("fusemount: entry invalidation failed", "name", name, "errno", errno)
+		}
+	}
+	if removed {
+		return
+	}
+	if errno := d.NotifyContent(0, 0); errno != 0 && errno != syscall.ENOENT {
+		slog.Debug("fusemount: inode invalidation failed", "name", name, "errno", errno)
+	}
+}
@@ -0,0 +1,144 @@
+//go:build linux
+
+package fusemount
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// mockIdentNode implements Node, NodeIdentifier and NodeInvalidator.
+type mockIdentNode struct {
+	mockNode
+	id          string
+	invalidated int
+}
+
+func (m *mockIdentNode) NodeID() string { return m.id }
+func (m *mockIdentNode) Invalidate()    { m.invalidated++ }
+
+// mockCheckerHandler implements NamespaceHandler + NamespaceChangeChecker.
+type mockCheckerHandler struct {
+	mockHandler
+	changes []Change
+	checked map[string]Node
+}
+
+func (m *mockCheckerHandler) CheckChanges(_ context.Context, nodes map[string]Node) []Change {
+	m.checked = nodes
+	return m.changes
+}
+
+// newTestTracker returns a tracker whose clock is controlled by *now.
+func newTestTracker(now *time.Time) *inodeTracker {
+	t := newInodeTracker()
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestInodeTracker_Candidates(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := newTestTracker(&now)
+	h := &mockHandler{}
+	track := func(id string) *DispatchNode {
+		d := &DispatchNode{handler: h, node: &mockIdentNode{id: id}, tracker: tr}
+		tr.add(d)
+		return d
+	}
+
+	recent := track("recent")
+	idle := track("idle")
+	open := track("open")
+	track("forgotten")
+	// A node without a NodeID is never tracked.
+	tr.add(&DispatchNode{handler: h, node: &mockNode{}, tracker: tr})
+
+	tr.opened(open, 1)
+	now = now.Add(time.Minute)
+	tr.touch(recent)
+	tr.remove(open) // open handles keep the object tracked
+	for d := range tr.entries[trackKey{h, "forgotten"}].nodes {
+		tr.remove(d)
+	}
+
+	got := tr.candidates(10 * time.Second)[h]
+	if len(got) != 2 || got["recent"] != recent.node || got["open"] != open.node {
+		t.Fatalf("candidates = %v, want recent and open", got)
+	}
+	if _, ok := tr.entries[trackKey{h, "idle"}]; !ok {
+		t.Error("idle object dropped while its inode is live")
+	}
+	if _, ok := tr.entries[trackKey{h, "forgotten"}]; ok {
+		t.Error("forgotten object still tracked")
+	}
+
+	tr.opened(open, -1)
+	if _, ok := tr.entries[trackKey{h, "open"}]; ok {
+		t.Error("closed object without inodes still tracked")
+	}
+	tr.remove(idle)
+	if len(tr.entries) != 1 {
+		t.Errorf("tracked %d objects, want 1", len(tr.entries))
+	}
+}
+
+func TestInodeTracker_CheckRemoved(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := newTestTracker(&now)
+	h := &mockCheckerHandler{changes: []Change{{ID: "gone", Removed: true}}}
+
+	// Two inodes for the same object are both invalidated.
+	a := &mockIdentNode{id: "gone"}
+	b := &mockIdentNode{id: "gone"}
+	tr.add(&DispatchNode{handler: h, node: a, tracker: tr})
+	tr.add(&DispatchNode{handler: h, node: b, tracker: tr})
+
+	tr.check(context.Background(), time.Minute)
+	if len(h.checked) != 1 {
+		t.Fatalf("checked %v, want one node for the object", h.checked)
+	}
+	if a.invalidated != 1 || b.invalidated != 1 {
+		t.Errorf("invalidated a=%d b=%d, want 1 each", a.invalidated, b.invalidated)
+	}
+	if len(tr.entries) != 0 {
+		t.Error("removed object still tracked")
+	}
+}
+
+func TestInodeTracker_Nil(t *testing.T) {
+	var tr *inodeTracker
+	d := &DispatchNode{handler: &mockHandler{}, node: &mockIdentNode{id: "x"}}
+	tr.add(d)
+	tr.touch(d)
+	tr.opened(d, 1)
+	tr.remove(d)
+}
+
+func TestInodeTracker_CandidatesCapped(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := newTestTracker(&now)
+	h := &mockHandler{}
+	open := &DispatchNode{handler: h, node: &mockIdentNode{id: "open"}, tracker: tr}
+	tr.add(open)
+	tr.opened(open, 1)
+	for i := range maxRecentChecks + 10 {
+		now = now.Add(time.Second)
+		tr.add(&DispatchNode{handler: h, node: &mockIdentNode{id: fmt.Sprint(i)}, tracker: tr})
+	}
+
+	got := tr.candidates(time.Hour)[h]
+	if len(got) != maxRecentChecks+1 {
+		t.Fatalf("%d candidates, want %d", len(got), maxRecentChecks+1)
+	}
+	if _, ok := got["open"]; !ok {
+		t.Error("open object not a candidate")
+	}
+	if _, ok := got[fmt.Sprint(maxRecentChecks+9)]; !ok {
+		t.Error("most recently used object not a candidate")
+	}
+	if _, ok := got["0"]; ok {
+		t.Error("least recently used object is a candidate past the cap")
+	}
+}
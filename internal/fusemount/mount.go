@@ -4,6 +4,7 @@ package fusemount
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"math"
@@ -24,6 +25,14 @@ type MountConfig struct {
 	EntryTimeout   time.Duration // default 1s; zero = kernel default
 	AttrTimeout    time.Duration // default 1s; zero = kernel default
 	PrefetchBlocks int           // kernel read-ahead in blocks (0 = kernel default, max 64)
+	CheckInterval  time.Duration // remote change check period; zero = disabled
+	Ops            OpObserver    // FUSE operation latencies; nil = not recorded
+}
+
+// OpObserver records the latency of FUSE operations by operation name,
+// e.g. a *metrics.HistogramVec.
+type OpObserver interface {
+	ObserveSince(op string, start time.Time)
 }
 
 // EnsureMountDir creates the mountpoint and its parent directory with mode 0700
@@ -143,7 +152,9 @@ func buildFSOptions(cfg MountConfig) *fs.Options {
 
 // Mount creates and starts the per-user FUSE server. It detects and cleans
 // any stale mount, ensures the mount directory exists, and starts the FUSE
-// server with the given registry as the root filesystem.
+// server with the given registry as the root filesystem. With a
+// CheckInterval, it also polls the namespace handlers for remote changes
+// until the server exits.
 func Mount(cfg MountConfig, registry *NamespaceRegistry) (*fuse.Server, error) {
 	// Clean stale mounts first — a dead FUSE mount at the path causes
 	// MkdirAll to fail with "file exists" because the kernel reports the
@@ -166,10 +177,23 @@ func Mount(cfg MountConfig, registry *NamespaceRegistry) (*fuse.Server, error) {
 
 	opts := buildFSOptions(cfg)
 
-	server, err := fs.Mount(cfg.Mountpoint, NewRoot(registry, mountInfo), opts)
+	root := NewRoot(registry, mountInfo)
+	root.ops = cfg.Ops
+	server, err := fs.Mount(cfg.Mountpoint, root, opts)
 	if err != nil {
 		return nil, fmt.Errorf("mounting FUSE filesystem: %w", err)
 	}
 
+	// Objects the kernel may still serve from its cache are those used
+	// within the longer of the two timeouts, plus any open file.
+	if cfg.CheckInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		go root.tracker.run(ctx, cfg.CheckInterval, max(cfg.EntryTimeout, cfg.AttrTimeout))
+		go func() {
+			server.Wait()
+			cancel()
+		}()
+	}
+
 	return server, nil
 }
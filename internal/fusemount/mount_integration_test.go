@@ -80,3 +80,31 @@ func TestIntegration_SignalHandling(t *testing.T) {
 		t.Error("mount still detected after SIGTERM unmount")
 	}
 }
+
+func TestIntegration_NestedDir(t *testing.T) {
+	skipIfNoFuse(t)
+
+	mountpoint := t.TempDir()
+	handler := &mockHandler{attr: Attr{Mode: syscall.S_IFDIR | 0700, Nlink: 2}}
+	work := NewRegistry()
+	work.Register("drive", handler)
+	registry := NewRegistry()
+	registry.Register("drive", handler)
+	registry.RegisterDir("work", work)
+
+	server, err := Mount(MountConfig{Mountpoint: mountpoint}, registry)
+	if err != nil {
+		t.Fatalf("Mount() error = %v", err)
+	}
+	defer server.Unmount()
+
+	for _, path := range []string{"work", "work/drive", "drive"} {
+		var stat syscall.Stat_t
+		if err := syscall.Stat(mountpoint+"/"+path, &stat); err != nil {
+			t.Fatalf("stat %s: %v", path, err)
+		}
+		if stat.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+			t.Errorf("%s mode = %#o, want a directory", path, stat.Mode)
+		}
+	}
+}
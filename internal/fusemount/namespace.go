@@ -58,6 +58,18 @@ type NamespaceHandler interface {
 	Getattr(ctx context.Context) (Attr, syscall.Errno)
 }
 
+// Usage holds the space usage of a namespace, in bytes.
+type Usage struct {
+	Total uint64
+	Free  uint64
+}
+
+// NamespaceStatfser indicates the handler reports the space usage of its
+// namespace, for statfs(2) on any node within it.
+type NamespaceStatfser interface {
+	Statfs(ctx context.Context) (Usage, syscall.Errno)
+}
+
 // NodeCreator indicates the handler supports file creation.
 type NodeCreator interface {
 	Create(ctx context.Context, name string, flags uint32, mode uint32) (Node, FileHandle, syscall.Errno)
@@ -68,6 +80,30 @@ type NodeMkdirer interface {
 	Mkdir(ctx context.Context, name string, mode uint32) (Node, syscall.Errno)
 }
 
+// NodeSymlinker indicates the handler supports creating symbolic links.
+type NodeSymlinker interface {
+	Symlink(ctx context.Context, target, name string) (Node, syscall.Errno)
+}
+
+// NodeReadlinker indicates the node is a symbolic link.
+type NodeReadlinker interface {
+	Readlink(ctx context.Context) (string, syscall.Errno)
+}
+
+// NodeXattrReader indicates the node exposes extended attributes.
+// Getxattr returns ENODATA for a missing attribute.
+type NodeXattrReader interface {
+	Getxattr(ctx context.Context, attr string) ([]byte, syscall.Errno)
+	Listxattr(ctx context.Context) ([]string, syscall.Errno)
+}
+
+// NodeXattrWriter indicates the node supports changing extended
+// attributes. flags are the setxattr(2) XATTR_CREATE/XATTR_REPLACE bits.
+type NodeXattrWriter interface {
+	Setxattr(ctx context.Context, attr string, data []byte, flags uint32) syscall.Errno
+	Removexattr(ctx context.Context, attr string) syscall.Errno
+}
+
 // NodeOpener indicates the node supports Open (creating per-open state).
 // Nodes implementing NodeOpener typically also implement NodeReader and/or
 // NodeWriter. NodeOpener creates per-open state; NodeReader/NodeWriter consume it.
@@ -134,36 +170,110 @@ type NodeSetattrer interface {
 	Setattr(ctx context.Context, fh FileHandle, in *SetattrIn) syscall.Errno
 }
 
-// NamespaceRegistry holds registered namespace handlers.
-// Populated at startup, immutable after mount.
+// NodeIdentifier indicates the node is a view of a remote object that
+// can change behind the kernel's back. NodeID returns an identifier that
+// is stable across the nodes the handler returns for the same object.
+type NodeIdentifier interface {
+	NodeID() string
+}
+
+// NodeInvalidator indicates the node caches state derived from its
+// remote object. Invalidate drops that state after the object changed.
+type NodeInvalidator interface {
+	Invalidate()
+}
+
+// Change reports a remote change to the object with the given NodeID.
+// Removed is set when the object is gone from its parent (deleted,
+// trashed, renamed or moved), otherwise its content or attributes
+// changed.
+type Change struct {
+	ID      string
+	Removed bool
+}
+
+// NamespaceChangeChecker indicates the handler can detect remote changes.
+// CheckChanges is passed one node per NodeID the kernel holds an inode
+// for and is recently used, and returns the IDs that changed.
+type NamespaceChangeChecker interface {
+	CheckChanges(ctx context.Context, nodes map[string]Node) []Change
+}
+
+// NamespaceRegistry holds registered namespace handlers and nested
+// registries, which appear as plain directories holding their own
+// namespaces (e.g. one per account). Populated at startup, immutable
+// after mount.
 type NamespaceRegistry struct {
 	handlers map[string]NamespaceHandler
+	dirs     map[string]*NamespaceRegistry
 }
 
 // NewRegistry creates a new empty NamespaceRegistry.
 func NewRegistry() *NamespaceRegistry {
 	return &NamespaceRegistry{
 		handlers: make(map[string]NamespaceHandler),
+		dirs:     make(map[string]*NamespaceRegistry),
 	}
 }
 
-// Register adds a namespace handler for the given prefix.
+// Register adds a namespace handler for the given prefix, replacing a
+// directory of the same name.
 func (r *NamespaceRegistry) Register(prefix string, h NamespaceHandler) {
+	delete(r.dirs, prefix)
 	r.handlers[prefix] = h
 }
 
+// RegisterDir adds a directory holding the namespaces of sub under the
+// given name, replacing a handler of the same name.
+func (r *NamespaceRegistry) RegisterDir(name string, sub *NamespaceRegistry) {
+	delete(r.handlers, name)
+	r.dirs[name] = sub
+}
+
 // Lookup returns the handler for the given prefix, or false if not found.
 func (r *NamespaceRegistry) Lookup(prefix string) (NamespaceHandler, bool) {
 	h, ok := r.handlers[prefix]
 	return h, ok
 }
 
-// List returns all registered prefixes in sorted order.
+// LookupDir returns the registry of the directory with the given name,
+// or false if not found.
+func (r *NamespaceRegistry) LookupDir(name string) (*NamespaceRegistry, bool) {
+	sub, ok := r.dirs[name]
+	return sub, ok
+}
+
+// List returns all registered prefixes and directory names in sorted
+// order.
 func (r *NamespaceRegistry) List() []string {
-	prefixes := make([]string, 0, len(r.handlers))
+	prefixes := make([]string, 0, len(r.handlers)+len(r.dirs))
 	for p := range r.handlers {
 		prefixes = append(prefixes, p)
 	}
+	for p := range r.dirs {
+		prefixes = append(prefixes, p)
+	}
 	sort.Strings(prefixes)
 	return prefixes
 }
+
+// statfsers returns the distinct handlers of r and its directories that
+// implement NamespaceStatfser, so that a handler registered under two
+// names is counted once.
+func (r *NamespaceRegistry) statfsers(seen map[NamespaceHandler]bool) []NamespaceStatfser {
+	var out []NamespaceStatfser
+	for _, prefix := range r.List() {
+		if sub, ok := r.dirs[prefix]; ok {
+			out = append(out, sub.statfsers(seen)...)
+			continue
+		}
+		h := r.handlers[prefix]
+		s, ok := h.(NamespaceStatfser)
+		if !ok || seen[h] {
+			continue
+		}
+		seen[h] = true
+		out = append(out, s)
+	}
+	return out
+}
@@ -111,3 +111,28 @@ func TestRegistryOverwrite(t *testing.T) {
 		t.Fatalf("expected 1 entry after overwrite, got %d", len(list))
 	}
 }
+
+func TestRegistryDirs(t *testing.T) {
+	r := NewRegistry()
+	sub := NewRegistry()
+	r.Register("drive", &mockHandler{})
+	r.RegisterDir("work", sub)
+
+	if got, ok := r.LookupDir("work"); !ok || got != sub {
+		t.Fatalf("LookupDir(work) = %v, %v", got, ok)
+	}
+	if _, ok := r.Lookup("work"); ok {
+		t.Error("Lookup(work) found a handler for a directory")
+	}
+	if _, ok := r.LookupDir("drive"); ok {
+		t.Error("LookupDir(drive) found a directory for a handler")
+	}
+	if list := r.List(); len(list) != 2 || list[0] != "drive" || list[1] != "work" {
+		t.Errorf("List = %v, want [drive work]", list)
+	}
+
+	r.Register("work", &mockHandler{})
+	if _, ok := r.LookupDir("work"); ok {
+		t.Error("Register did not replace the directory")
+	}
+}
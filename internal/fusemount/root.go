@@ -16,15 +16,21 @@ import (
 var _ = (fs.NodeGetattrer)((*RootNode)(nil))
 var _ = (fs.NodeLookuper)((*RootNode)(nil))
 var _ = (fs.NodeReaddirer)((*RootNode)(nil))
+var _ = (fs.NodeStatfser)((*RootNode)(nil))
 
 // RootNode implements the FUSE root directory for the per-user mount.
-// It dispatches Lookup to registered namespace handlers.
+// It dispatches Lookup to registered namespace handlers. Directories of
+// the registry are RootNodes too, listing the namespaces of their own
+// registry.
 type RootNode struct {
 	fs.Inode
 	registry *NamespaceRegistry
+	ino      uint64 // 1 for the mount root, 0 (assigned) for directories
 	mtime    time.Time
 	uid      uint32
 	gid      uint32
+	tracker  *inodeTracker
+	ops      OpObserver
 }
 
 // NewRoot creates a RootNode backed by the given registry.
@@ -33,9 +39,11 @@ type RootNode struct {
 func NewRoot(registry *NamespaceRegistry, info os.FileInfo) *RootNode {
 	return &RootNode{
 		registry: registry,
+		ino:      1,
 		mtime:    info.ModTime(),
 		uid:      uint32(os.Getuid()), //nolint:gosec // UID fits uint32 on Linux
 		gid:      uint32(os.Getgid()), //nolint:gosec // GID fits uint32 on Linux
+		tracker:  newInodeTracker(),
 	}
 }
 
@@ -45,7 +53,7 @@ func NewRoot(registry *NamespaceRegistry, info os.FileInfo) *RootNode {
 func (r *RootNode) Getattr(_ context.Context, _ fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
 	out.Mode = syscall.S_IFDIR | 0555
 	out.Nlink = 2
-	out.Ino = 1
+	out.Ino = r.ino
 	out.Uid = r.uid
 	out.Gid = r.gid
 	sec := uint64(r.mtime.Unix())        //nolint:gosec // G115: time values are always positive
@@ -65,7 +73,7 @@ func (r *RootNode) Readdir(_ context.Context) (fs.DirStream, syscall.Errno) {
 	prefixes := r.registry.List()
 	entries := make([]fuse.DirEntry, 0, 2+len(prefixes))
 	entries = append(entries,
-		fuse.DirEntry{Name: ".", Mode: syscall.S_IFDIR, Ino: 1},
+		fuse.DirEntry{Name: ".", Mode: syscall.S_IFDIR, Ino: r.ino},
 		fuse.DirEntry{Name: "..", Mode: syscall.S_IFDIR},
 	)
 	for _, p := range prefixes {
@@ -74,10 +82,19 @@ func (r *RootNode) Readdir(_ context.Context) (fs.DirStream, syscall.Errno) {
 	return fs.NewListDirStream(entries), 0
 }
 
-// Lookup returns a DispatchNode for a registered namespace prefix, or ENOENT.
-// Populates the EntryOut with the namespace's attributes so the kernel
-// caches the correct mode from the first response.
+// Lookup returns a DispatchNode for a registered namespace prefix, a
+// RootNode for a registered directory, or ENOENT. Populates the EntryOut
+// with the namespace's attributes so the kernel caches the correct mode
+// from the first response.
 func (r *RootNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if sub, ok := r.registry.LookupDir(name); ok {
+		dir := &RootNode{registry: sub, mtime: r.mtime, uid: r.uid, gid: r.gid, tracker: r.tracker, ops: r.ops}
+		var attr fuse.AttrOut
+		_ = dir.Getattr(ctx, nil, &attr)
+		out.Attr = attr.Attr
+		return r.NewInode(ctx, dir, fs.StableAttr{Mode: syscall.S_IFDIR}), 0
+	}
+
 	handler, ok := r.registry.Lookup(name)
 	if !ok {
 		return nil, syscall.ENOENT
@@ -97,7 +114,25 @@ func (r *RootNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut)
 	out.Uid = r.uid
 	out.Gid = r.gid
 
-	node := &DispatchNode{handler: handler, isRoot: true, uid: r.uid, gid: r.gid}
+	node := &DispatchNode{handler: handler, isRoot: true, uid: r.uid, gid: r.gid, tracker: r.tracker, ops: r.ops}
 	child := r.NewInode(ctx, node, fs.StableAttr{Mode: syscall.S_IFDIR})
 	return child, 0
 }
+
+// Statfs reports the combined space usage of the namespaces, including
+// those of nested directories, whose handler implements
+// NamespaceStatfser. A handler registered under two names is counted
+// once.
+func (r *RootNode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall.Errno {
+	var total Usage
+	for _, s := range r.registry.statfsers(make(map[NamespaceHandler]bool)) {
+		usage, errno := s.Statfs(ctx)
+		if errno != 0 {
+			return errno
+		}
+		total.Total += usage.Total
+		total.Free += usage.Free
+	}
+	fillStatfs(total, out)
+	return 0
+}
@@ -5,6 +5,7 @@ package fusemount
 import (
 	"context"
 	"os"
+	"slices"
 	"syscall"
 	"testing"
 	"time"
@@ -119,3 +120,72 @@ func TestRootNodeLookupNotFound(t *testing.T) {
 		t.Errorf("Lookup returned errno %d, want ENOENT (%d)", errno, syscall.ENOENT)
 	}
 }
+
+func TestRootNodeStatfs(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("drive", &mockStatfsHandler{usage: Usage{Total: 8 * statfsBlockSize, Free: 3 * statfsBlockSize}})
+	reg.Register("mail", &mockStatfsHandler{usage: Usage{Total: 2 * statfsBlockSize, Free: statfsBlockSize}})
+	reg.Register("other", &mockHandler{})
+	root := NewRoot(reg, testMountInfo{})
+
+	var out fuse.StatfsOut
+	if errno := root.Statfs(context.Background(), &out); errno != 0 {
+		t.Fatalf("Statfs returned errno %d", errno)
+	}
+	if out.Blocks != 10 || out.Bfree != 4 || out.Bavail != 4 {
+		t.Errorf("Statfs blocks = %d/%d/%d, want 10/4/4", out.Blocks, out.Bfree, out.Bavail)
+	}
+	if out.Bsize != statfsBlockSize {
+		t.Errorf("Bsize = %d, want %d", out.Bsize, statfsBlockSize)
+	}
+}
+
+func TestRootNodeReaddirDirs(t *testing.T) {
+	work := NewRegistry()
+	work.Register("drive", &mockHandler{})
+	reg := NewRegistry()
+	reg.Register("drive", &mockHandler{})
+	reg.RegisterDir("work", work)
+	root := NewRoot(reg, testMountInfo{})
+
+	stream, errno := root.Readdir(context.Background())
+	if errno != 0 {
+		t.Fatalf("Readdir returned errno %d", errno)
+	}
+	var names []string
+	for stream.HasNext() {
+		e, _ := stream.Next()
+		if e.Mode != fuse.S_IFDIR && e.Mode != syscall.S_IFDIR {
+			t.Errorf("%s: Mode = %o, want S_IFDIR", e.Name, e.Mode)
+		}
+		names = append(names, e.Name)
+	}
+	want := []string{".", "..", "drive", "work"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Readdir = %v, want %v", names, want)
+	}
+}
+
+func TestRootNodeStatfsDirs(t *testing.T) {
+	personal := &mockStatfsHandler{usage: Usage{Total: 8 * statfsBlockSize, Free: 3 * statfsBlockSize}}
+	work := &mockStatfsHandler{usage: Usage{Total: 2 * statfsBlockSize, Free: statfsBlockSize}}
+	personalDir := NewRegistry()
+	personalDir.Register("drive", personal)
+	workDir := NewRegistry()
+	workDir.Register("drive", work)
+
+	// The default account is reachable at drive and personal/drive.
+	reg := NewRegistry()
+	reg.Register("drive", personal)
+	reg.RegisterDir("personal", personalDir)
+	reg.RegisterDir("work", workDir)
+	root := NewRoot(reg, testMountInfo{})
+
+	var out fuse.StatfsOut
+	if errno := root.Statfs(context.Background(), &out); errno != 0 {
+		t.Fatalf("Statfs returned errno %d", errno)
+	}
+	if out.Blocks != 10 || out.Bfree != 4 {
+		t.Errorf("Statfs blocks = %d/%d, want 10/4", out.Blocks, out.Bfree)
+	}
+}
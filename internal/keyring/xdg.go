@@ -10,14 +10,32 @@ const appName = "proton-utils"
 // XDGConfigPath returns a path under $XDG_CONFIG_HOME/proton-utils/.
 // Defaults to ~/.config/proton-utils/ if XDG_CONFIG_HOME is unset.
 func XDGConfigPath(name string) string {
-	base := os.Getenv("XDG_CONFIG_HOME")
+	return xdgPath("XDG_CONFIG_HOME", ".config", name)
+}
+
+// XDGStatePath returns a path under $XDG_STATE_HOME/proton-utils/.
+// Defaults to ~/.local/state/proton-utils/ if XDG_STATE_HOME is unset.
+func XDGStatePath(name string) string {
+	return xdgPath("XDG_STATE_HOME", filepath.Join(".local", "state"), name)
+}
+
+// XDGCachePath returns a path under $XDG_CACHE_HOME/proton-utils/.
+// Defaults to ~/.cache/proton-utils/ if XDG_CACHE_HOME is unset.
+func XDGCachePath(name string) string {
+	return xdgPath("XDG_CACHE_HOME", ".cache", name)
+}
+
+// xdgPath joins name under the directory named by env, falling back to
+// home/fallback when env is unset.
+func xdgPath(env, fallback, name string) string {
+	base := os.Getenv(env)
 	if base == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
 			// Last resort: use current directory.
 			return filepath.Join(appName, name)
 		}
-		base = filepath.Join(home, ".config")
+		base = filepath.Join(home, fallback)
 	}
 	return filepath.Join(base, appName, name)
 }
@@ -84,3 +84,15 @@ func TestXDGConfigPath(t *testing.T) {
 func hasSuffix(path, suffix string) bool {
 	return len(path) >= len(suffix) && path[len(path)-len(suffix):] == suffix
 }
+
+func TestXDGStatePath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/custom/state")
+	if got, want := XDGStatePath("watch"), filepath.Join("/custom/state", appName, "watch"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	if got, want := XDGStatePath("watch"), filepath.Join(".local", "state", appName, "watch"); !hasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/major0/proton-utils/api/drive"
+)
+
+// RegisterDrive registers the cache and transfer counters of a Drive
+// client, labelled like RegisterSession. Counting the object cache walks
+// its directory, at most once per second.
+func RegisterDrive(r *Registry, c *drive.Client, labels ...Label) {
+	stats := Snapshot(time.Second, c.Stats)
+	usage := Snapshot(time.Second, c.CacheUsage)
+
+	r.CounterFunc("proton_drive_buffer_cache_hits", "Block reads answered by the buffer cache.",
+		func() float64 { return float64(stats().BufferHits) }, labels...)
+	r.CounterFunc("proton_drive_buffer_cache_misses", "Block reads that fetched into the buffer cache.",
+		func() float64 { return float64(stats().BufferMisses) }, labels...)
+	r.CounterFunc("proton_drive_buffer_cache_evictions", "Blocks evicted from the buffer cache for space.",
+		func() float64 { return float64(stats().BufferEvictions) }, labels...)
+	r.GaugeFunc("proton_drive_buffer_cache_blocks", "Blocks held by the buffer cache.",
+		func() float64 { return float64(usage().Blocks) }, labels...)
+	r.GaugeFunc("proton_drive_buffer_cache_bytes", "Bytes held by the buffer cache.",
+		func() float64 { return float64(usage().BlockBytes) }, labels...)
+	r.GaugeFunc("proton_drive_object_cache_objects", "API objects in the on-disk object cache.",
+		func() float64 { return float64(usage().Objects) }, labels...)
+	r.GaugeFunc("proton_drive_links", "Links in the link table.",
+		func() float64 { return float64(usage().Links) }, labels...)
+
+	r.CounterFunc("proton_drive_downloaded_bytes", "Block bytes downloaded.",
+		func() float64 { return float64(stats().Downloaded) }, labels...)
+	r.CounterFunc("proton_drive_uploaded_bytes", "Block bytes uploaded.",
+		func() float64 { return float64(stats().Uploaded) }, labels...)
+}
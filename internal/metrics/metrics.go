@@ -0,0 +1,295 @@
+// Package metrics exposes counters of long-running commands in the
+// Prometheus text format and in OpenMetrics. Most values are read at
+// scrape time from the snapshots the api packages already keep; only
+// latencies are recorded here, in histograms.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the buckets of a
+// latency histogram.
+var latencyBuckets = [...]float64{
+	0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05,
+	0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30,
+}
+
+// metric is one metric family of a Registry.
+type metric interface {
+	write(w *bufio.Writer, openMetrics bool)
+}
+
+// Registry holds the metric families exposed by a command. Families are
+// written in the order they were registered.
+type Registry struct {
+	mu      sync.Mutex
+	names   map[string]int // index into metrics
+	metrics []metric
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]int)}
+}
+
+// register adds m under name. Registering a name twice panics, like
+// registering an HTTP pattern twice.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.names[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = len(r.metrics)
+	r.metrics = append(r.metrics, m)
+}
+
+// Label is a label of the samples of a counter or gauge.
+type Label struct {
+	Name, Value string
+}
+
+// registerFunc adds a sample read from f to the counter or gauge name,
+// registering the family on first use. Samples of one family differ in
+// their labels; registering the same labels twice, or a name under two
+// types, panics.
+func (r *Registry) registerFunc(name, help, typ string, f func() float64, labels []Label) {
+	sample := funcSample{labels: formatLabels(labels), f: f}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.names[name]
+	if !ok {
+		r.names[name] = len(r.metrics)
+		r.metrics = append(r.metrics, &funcMetric{name: name, help: help, typ: typ, samples: []funcSample{sample}})
+		return
+	}
+
+	m, ok := r.metrics[i].(*funcMetric)
+	if !ok || m.typ != typ || slices.ContainsFunc(m.samples, func(s funcSample) bool { return s.labels == sample.labels }) {
+		panic(fmt.Sprintf("metrics: %s%s registered twice", name, sample.labels))
+	}
+	// Families are copied on write, so that Write can read them
+	// without holding the lock.
+	next := *m
+	next.samples = append(slices.Clip(m.samples), sample)
+	r.metrics[i] = &next
+}
+
+// CounterFunc registers a counter whose value is read from f at scrape
+// time. The name is given without the _total suffix.
+func (r *Registry) CounterFunc(name, help string, f func() float64, labels ...Label) {
+	r.registerFunc(name, help, "counter", f, labels)
+}
+
+// GaugeFunc registers a gauge whose value is read from f at scrape time.
+func (r *Registry) GaugeFunc(name, help string, f func() float64, labels ...Label) {
+	r.registerFunc(name, help, "gauge", f, labels)
+}
+
+// HistogramVec registers a latency histogram partitioned by one label.
+func (r *Registry) HistogramVec(name, help, label string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, label: label, hists: make(map[string]*histogram)}
+	r.register(name, h)
+	return h
+}
+
+// Write writes all families to w in the Prometheus text format, or in
+// OpenMetrics when openMetrics is set.
+func (r *Registry) Write(w io.Writer, openMetrics bool) error {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw, openMetrics)
+	}
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+// funcMetric is a counter or gauge read from functions, one per sample.
+type funcMetric struct {
+	name, help, typ string
+	samples         []funcSample
+}
+
+// funcSample is one sample of a funcMetric. labels is formatted for
+// output, empty when the sample has none.
+type funcSample struct {
+	labels string
+	f      func() float64
+}
+
+func (m *funcMetric) write(w *bufio.Writer, openMetrics bool) {
+	sample := m.name
+	if m.typ == "counter" {
+		sample += "_total"
+	}
+	family := sample
+	if openMetrics {
+		family = m.name
+	}
+	writeHeader(w, family, m.help, m.typ)
+	for _, s := range m.samples {
+		fmt.Fprintf(w, "%s%s %s\n", sample, s.labels, formatFloat(s.f()))
+	}
+}
+
+// HistogramVec is a latency histogram partitioned by one label. A nil
+// *HistogramVec discards observations.
+type HistogramVec struct {
+	name, help, label string
+
+	mu    sync.RWMutex
+	hists map[string]*histogram
+}
+
+// histogram holds the counts of one label value. Counts are per
+// bucket, not cumulative.
+type histogram struct {
+	counts [len(latencyBuckets) + 1]atomic.Uint64 // the last is the +Inf bucket
+	sum    atomic.Int64                           // nanoseconds
+}
+
+// Observe records a duration for the label value.
+func (h *HistogramVec) Observe(value string, d time.Duration) {
+	if h == nil {
+		return
+	}
+	hist := h.get(value)
+	s := d.Seconds()
+	i, _ := slices.BinarySearch(latencyBuckets[:], s)
+	hist.counts[i].Add(1)
+	hist.sum.Add(int64(d))
+}
+
+// ObserveSince records the time elapsed since start. It is meant to be
+// deferred: defer h.ObserveSince("read", time.Now()).
+func (h *HistogramVec) ObserveSince(value string, start time.Time) {
+	if h == nil {
+		return
+	}
+	h.Observe(value, time.Since(start))
+}
+
+// get returns the histogram of a label value, creating it on first use.
+func (h *HistogramVec) get(value string) *histogram {
+	h.mu.RLock()
+	hist := h.hists[value]
+	h.mu.RUnlock()
+	if hist != nil {
+		return hist
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hist = h.hists[value]; hist == nil {
+		hist = &histogram{}
+		h.hists[value] = hist
+	}
+	return hist
+}
+
+func (h *HistogramVec) write(w *bufio.Writer, _ bool) {
+	h.mu.RLock()
+	values := make([]string, 0, len(h.hists))
+	for v := range h.hists {
+		values = append(values, v)
+	}
+	h.mu.RUnlock()
+	slices.Sort(values)
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, v := range values {
+		hist := h.get(v)
+		label := fmt.Sprintf(`%s="%s"`, h.label, labelEscaper.Replace(v))
+		var n uint64
+		for i := range hist.counts {
+			n += hist.counts[i].Load()
+			le := math.Inf(1)
+			if i < len(latencyBuckets) {
+				le = latencyBuckets[i]
+			}
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", h.name, label, formatFloat(le), n)
+		}
+		sum := time.Duration(hist.sum.Load()).Seconds()
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", h.name, label, formatFloat(sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", h.name, label, n)
+	}
+}
+
+// helpEscaper escapes the text of a HELP line.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// writeHeader writes the HELP and TYPE lines of a family.
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	help = helpEscaper.Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// labelEscaper escapes a label value.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats labels as {name="value",...}, or returns "" for
+// none.
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, l.Name, labelEscaper.Replace(l.Value))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Snapshot returns a function that calls f at most once per ttl and
+// otherwise returns its last result, so that families reading fields of
+// one expensive snapshot share it within a scrape.
+func Snapshot[T any](ttl time.Duration, f func() T) func() T {
+	var (
+		mu   sync.Mutex
+		last T
+		at   time.Time
+	)
+	return func() T {
+		mu.Lock()
+		defer mu.Unlock()
+		if at.IsZero() || time.Since(at) >= ttl {
+			last, at = f(), time.Now()
+		}
+		return last
+	}
+}
@@ -0,0 +1,314 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// render returns the registry in the given format.
+func render(t *testing.T, r *Registry, openMetrics bool) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.Write(&b, openMetrics); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return b.String()
+}
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	r.CounterFunc("proton_bytes", "Bytes moved.", func() float64 { return 42 })
+	r.GaugeFunc("proton_active", "Active tasks.", func() float64 { return 1.5 })
+	h := r.HistogramVec("proton_op_duration_seconds", "Op latency.", "op")
+	h.Observe("read", 2*time.Millisecond)
+	h.Observe("read", time.Minute)
+	h.Observe(`a"b`, 0)
+
+	tests := []struct {
+		name        string
+		openMetrics bool
+		want        []string
+		notWant     []string
+	}{
+		{
+			name: "text",
+			want: []string{
+				"# HELP proton_bytes_total Bytes moved.\n# TYPE proton_bytes_total counter\nproton_bytes_total 42\n",
+				"# TYPE proton_active gauge\nproton_active 1.5\n",
+				"# TYPE proton_op_duration_seconds histogram\n",
+				`proton_op_duration_seconds_bucket{op="read",le="0.001"} 0` + "\n",
+				`proton_op_duration_seconds_bucket{op="read",le="0.0025"} 1` + "\n",
+				`proton_op_duration_seconds_bucket{op="read",le="30"} 1` + "\n",
+				`proton_op_duration_seconds_bucket{op="read",le="+Inf"} 2` + "\n",
+				`proton_op_duration_seconds_sum{op="read"} 60.002` + "\n",
+				`proton_op_duration_seconds_count{op="read"} 2` + "\n",
+				`proton_op_duration_seconds_count{op="a\"b"} 1` + "\n",
+			},
+			notWant: []string{"# EOF"},
+		},
+		{
+			name:        "openmetrics",
+			openMetrics: true,
+			want: []string{
+				"# TYPE proton_bytes counter\nproton_bytes_total 42\n",
+				"# EOF\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := render(t, r, tt.openMetrics)
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("output lacks %q:\n%s", w, out)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(out, w) {
+					t.Errorf("output contains %q", w)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_Order(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("b", "", func() float64 { return 0 })
+	r.GaugeFunc("a", "", func() float64 { return 0 })
+	out := render(t, r, false)
+	if strings.Index(out, "TYPE b") > strings.Index(out, "TYPE a") {
+		t.Errorf("families not in registration order:\n%s", out)
+	}
+}
+
+func TestRegistry_Duplicate(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("a", "", func() float64 { return 0 })
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a twice did not panic")
+		}
+	}()
+	r.CounterFunc("a", "", func() float64 { return 0 })
+}
+
+func TestRegistry_Labels(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("proton_active", "Active tasks.", func() float64 { return 1 }, Label{"account", "personal"})
+	r.GaugeFunc("proton_active", "Active tasks.", func() float64 { return 2 }, Label{"account", `w"rk`})
+	out := render(t, r, false)
+	want := "# HELP proton_active Active tasks.\n# TYPE proton_active gauge\n" +
+		`proton_active{account="personal"} 1` + "\n" +
+		`proton_active{account="w\"rk"} 2` + "\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering the same labels twice did not panic")
+		}
+	}()
+	r.GaugeFunc("proton_active", "", func() float64 { return 0 }, Label{"account", "personal"})
+}
+
+func TestHistogramVec_Nil(_ *testing.T) {
+	var h *HistogramVec
+	h.Observe("read", time.Second)
+	h.ObserveSince("read", time.Now())
+}
+
+// TestHistogramVec_Concurrent checks that concurrent observations are
+// all counted. Run with -race.
+func TestHistogramVec_Concurrent(t *testing.T) {
+	r := NewRegistry()
+	h := r.HistogramVec("ops", "", "op")
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				h.Observe("getattr", time.Millisecond)
+				_ = render(t, r, false)
+			}
+		})
+	}
+	wg.Wait()
+	if out := render(t, r, false); !strings.Contains(out, `ops_count{op="getattr"} 800`) {
+		t.Errorf("lost observations:\n%s", out)
+	}
+}
+
+// TestHistogramVec_Cumulative_Property checks that bucket counts never
+// decrease with le and that the +Inf bucket equals the count.
+func TestHistogramVec_Cumulative_Property(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		r := NewRegistry()
+		h := r.HistogramVec("ops", "", "op")
+		ds := rapid.SliceOf(rapid.Int64Range(0, int64(time.Minute))).Draw(t, "durations")
+		for _, d := range ds {
+			h.Observe("x", time.Duration(d))
+		}
+
+		var b strings.Builder
+		_ = r.Write(&b, false)
+		var prev uint64
+		var last string
+		for line := range strings.Lines(b.String()) {
+			if !strings.HasPrefix(line, "ops_bucket") {
+				continue
+			}
+			fields := strings.Fields(line)
+			n, err := strconv.ParseUint(fields[len(fields)-1], 10, 64)
+			if err != nil {
+				t.Fatalf("bad sample %q", line)
+			}
+			if n < prev {
+				t.Fatalf("bucket count decreased: %q after %d", line, prev)
+			}
+			prev, last = n, line
+		}
+		if len(ds) > 0 && (!strings.Contains(last, `le="+Inf"`) || prev != uint64(len(ds))) {
+			t.Fatalf("+Inf bucket %q, want %d", last, len(ds))
+		}
+	})
+}
+
+func TestHandler_Negotiation(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("up", "", func() float64 { return 1 })
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", textContentType},
+		{"text/plain", textContentType},
+		{"application/openmetrics-text;version=1.0.0,text/plain;q=0.5", openMetricsContentType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			r.Handler().ServeHTTP(rec, req)
+			if got := rec.Header().Get("Content-Type"); got != tt.want {
+				t.Errorf("Content-Type = %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(rec.Body.String(), "up 1\n") {
+				t.Errorf("body = %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServe_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.sock")
+	staleSocket(t, path)
+	l, err := Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %04o, want 0600", perm)
+	}
+
+	r := NewRegistry()
+	r.GaugeFunc("up", "", func() float64 { return 1 })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, l, r) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://proton/metrics")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if !strings.Contains(string(body), "up 1\n") {
+		t.Errorf("body = %q", body)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after cancel")
+	}
+}
+
+// staleSocket leaves a socket at path that nothing listens on, as a
+// process that exited without cleaning up does.
+func staleSocket(t *testing.T, path string) {
+	t.Helper()
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetUnlinkOnClose(false)
+	_ = l.Close()
+}
+
+func TestListen_KeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("keep me"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if l, err := Listen(notes); err == nil {
+		_ = l.Close()
+		t.Fatal("Listen over a regular file succeeded")
+	}
+	if data, err := os.ReadFile(notes); err != nil || string(data) != "keep me" {
+		t.Errorf("regular file = %q, %v; want it untouched", data, err)
+	}
+
+	live := filepath.Join(dir, "live.sock")
+	l, err := Listen("unix:" + live)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+	if l2, err := Listen("unix:" + live); err == nil {
+		_ = l2.Close()
+		t.Fatal("Listen on a live socket succeeded")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	calls := 0
+	get := Snapshot(time.Hour, func() int { calls++; return calls })
+	if a, b := get(), get(); a != 1 || b != 1 {
+		t.Errorf("get() = %d, %d; want 1, 1", a, b)
+	}
+
+	get = Snapshot(0, func() int { calls++; return calls })
+	if a, b := get(), get(); a == b {
+		t.Errorf("get() with ttl 0 = %d twice, want fresh values", a)
+	}
+}
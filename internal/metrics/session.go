@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"github.com/major0/proton-utils/api"
+)
+
+// RegisterSession registers the API concurrency and rate-limit counters
+// of a session. Sessions of several accounts are told apart by labels.
+func RegisterSession(r *Registry, s *api.Session, labels ...Label) {
+	stats := func() api.StatsSnapshot { return s.Sem.Stats() }
+	r.GaugeFunc("proton_api_workers", "Maximum number of concurrent API tasks.",
+		func() float64 { return float64(s.Sem.Limit()) }, labels...)
+	r.GaugeFunc("proton_api_tasks_active", "API tasks running now.",
+		func() float64 { return float64(stats().Active) }, labels...)
+	r.CounterFunc("proton_api_tasks_submitted", "API tasks submitted.",
+		func() float64 { return float64(stats().Submitted) }, labels...)
+	r.CounterFunc("proton_api_tasks_completed", "API tasks completed.",
+		func() float64 { return float64(stats().Completed) }, labels...)
+
+	r.CounterFunc("proton_api_throttle_backoffs", "Rate-limit responses that paused API tasks.",
+		func() float64 { return float64(s.Throttle.Stats().Backoffs) }, labels...)
+	r.CounterFunc("proton_api_throttle_backoff_seconds", "Pause requested by rate-limit responses.",
+		func() float64 { return s.Throttle.Stats().Delay.Seconds() }, labels...)
+}
@@ -24,7 +24,9 @@ var _ = (fs.NodeReadlinker)((*SymlinkNode)(nil))
 var _ = (fs.NodeGetattrer)((*SymlinkNode)(nil))
 
 // Root implements the /proton FUSE root directory.
-// Every Lookup returns a symlink to /run/user/<uid>/proton/fs/<name>.
+// Every Lookup returns a symlink to /run/user/<uid>/proton/fs/<name>,
+// where <name> is a namespace of the default account (e.g. drive) or an
+// account directory holding that account's namespaces.
 type Root struct {
 	fs.Inode
 	startTime time.Time // process start time, used for atime/mtime/ctime
@@ -49,8 +51,8 @@ func (r *Root) Getattr(_ context.Context, _ fs.FileHandle, out *fuse.AttrOut) sy
 
 // Readdir returns directory entries for the calling user. It reads the
 // user's per-user mount directory to discover registered namespaces and
-// returns them as symlink entries. Falls back to just . and .. if the
-// per-user mount is not available.
+// accounts and returns them as symlink entries. Falls back to just . and
+// .. if the per-user mount is not available.
 func (r *Root) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
 	dirEntries := []fuse.DirEntry{
 		{Name: ".", Mode: syscall.S_IFDIR, Ino: 1},
@@ -59,8 +61,7 @@ func (r *Root) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
 
 	caller, _ := fuse.FromContext(ctx)
 	if caller != nil && caller.Uid != 0 {
-		userDir := fmt.Sprintf("/run/user/%d/proton/fs", caller.Uid)
-		if entries, err := os.ReadDir(userDir); err == nil {
+		if entries, err := os.ReadDir(userMountDir(caller.Uid)); err == nil {
 			for _, e := range entries {
 				dirEntries = append(dirEntries, fuse.DirEntry{
 					Name: e.Name(),
@@ -113,7 +114,16 @@ func (s *SymlinkNode) Getattr(_ context.Context, _ fs.FileHandle, out *fuse.Attr
 	return 0
 }
 
+// userRuntimeDir holds the per-user runtime directories. Tests point it
+// elsewhere.
+var userRuntimeDir = "/run/user"
+
+// userMountDir returns the per-user mount of the given UID.
+func userMountDir(uid uint32) string {
+	return fmt.Sprintf("%s/%d/proton/fs", userRuntimeDir, uid)
+}
+
 // symlinkTarget constructs the per-user mount path for the given UID and name.
 func symlinkTarget(uid uint32, name string) string {
-	return fmt.Sprintf("/run/user/%d/proton/fs/%s", uid, name)
+	return userMountDir(uid) + "/" + name
 }
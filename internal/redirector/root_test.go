@@ -6,6 +6,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"syscall"
 	"testing"
 
@@ -72,6 +74,42 @@ func TestRedirectorRoot_Readdir(t *testing.T) {
 	}
 }
 
+func TestRedirectorRoot_ReaddirAccounts(t *testing.T) {
+	dir := t.TempDir()
+	prev := userRuntimeDir
+	userRuntimeDir = dir
+	t.Cleanup(func() { userRuntimeDir = prev })
+
+	// The per-user mount of a proton-fuse serving two accounts.
+	for _, name := range []string{"drive", "personal/drive", "work/drive"} {
+		if err := os.MkdirAll(filepath.Join(dir, "1000", "proton", "fs", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := fuse.NewContext(context.Background(), &fuse.Caller{Owner: fuse.Owner{Uid: 1000}})
+	stream, errno := (&Root{}).Readdir(ctx)
+	if errno != 0 {
+		t.Fatalf("Readdir returned errno %d", errno)
+	}
+	var names []string
+	for stream.HasNext() {
+		e, _ := stream.Next()
+		if e.Name != "." && e.Name != ".." && e.Mode != syscall.S_IFLNK {
+			t.Errorf("%s: Mode = %#o, want S_IFLNK", e.Name, e.Mode)
+		}
+		names = append(names, e.Name)
+	}
+	want := []string{".", "..", "drive", "personal", "work"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Readdir = %v, want %v", names, want)
+	}
+
+	if got, want := symlinkTarget(1000, "work"), filepath.Join(dir, "1000", "proton", "fs", "work"); got != want {
+		t.Errorf("symlinkTarget = %q, want %q", got, want)
+	}
+}
+
 func TestSymlinkNode_Readlink(t *testing.T) {
 	target := "/run/user/1000/proton/fs/drive"
 	node := &SymlinkNode{target: target}